@@ -0,0 +1,173 @@
+// Command server starts the 99minutos shipping tracking API.
+//
+// @title                       99minutos Shipping Tracking API
+// @version                     1.0
+// @description                 Event-driven shipment tracking system for 99minutos. Processes 10,000+ events/second with guaranteed per-shipment ordering.
+// @termsOfService              http://swagger.io/terms/
+// @contact.name                99minutos Engineering
+// @contact.url                 https://99minutos.com
+// @license.name                MIT
+// @host                        localhost:8080
+// @BasePath                    /
+// @securityDefinitions.apikey  BearerAuth
+// @in                          header
+// @name                        Authorization
+// @description                 Type "Bearer" followed by a space and JWT token.
+// @securityDefinitions.apikey  ApiKeyAuth
+// @in                          header
+// @name                        X-API-Key
+// @description                 API key of a client, for machine-to-machine integrations.
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	_ "time/tzdata" // resolve the calendar timezone even where the OS has no zoneinfo
+
+	_ "github.com/99minutos/shipping-system/docs" // register the generated Swagger spec
+	"github.com/99minutos/shipping-system/internal/api"
+	"github.com/99minutos/shipping-system/internal/core/domain"
+	mongoinfra "github.com/99minutos/shipping-system/internal/infrastructure/db/mongo"
+	redisinfra "github.com/99minutos/shipping-system/internal/infrastructure/db/redis"
+	"github.com/99minutos/shipping-system/internal/pkg/calendar"
+	"github.com/99minutos/shipping-system/internal/pkg/config"
+	"github.com/99minutos/shipping-system/internal/pkg/lifecycle"
+	"github.com/99minutos/shipping-system/internal/pkg/logger"
+)
+
+func main() {
+	cfg := config.Load()
+
+	log := logger.Init(logger.Options{
+		Level:  cfg.LogLevel,
+		Pretty: cfg.Env == "development",
+	})
+
+	// The shared secret only signs tokens when no key files are configured.
+	if cfg.JWTSecret == "" && len(cfg.JWTKeys) == 0 {
+		log.Fatal().Msg("JWT_SECRET must be set when JWT_KEYS is empty")
+	}
+
+	lifecycles := domain.BuiltinLifecycles()
+	if cfg.LifecycleFile != "" {
+		var err error
+		lifecycles, err = lifecycle.Load(cfg.LifecycleFile)
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to load shipment lifecycle")
+		}
+		log.Info().Str("file", cfg.LifecycleFile).Int("lifecycles", len(lifecycles.Lifecycles)).Msg("shipment lifecycle loaded")
+	}
+	cal := domain.BuiltinCalendar()
+	if cfg.CalendarFile != "" {
+		var err error
+		cal, err = calendar.Load(cfg.CalendarFile)
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to load delivery calendar")
+		}
+		log.Info().Str("file", cfg.CalendarFile).Str("timezone", cal.Timezone).Int("holidays", len(cal.Holidays)).Msg("delivery calendar loaded")
+	}
+
+	// rootCtx is cancelled on SIGINT/SIGTERM and triggers the shutdown sequence.
+	rootCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// --- Infrastructure ---
+	mongoClient, db, err := mongoinfra.Connect(rootCtx, mongoinfra.Config{
+		URI:      cfg.Mongo.URI,
+		Database: cfg.Mongo.Database,
+	})
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to connect to MongoDB")
+	}
+
+	rdb, err := redisinfra.Connect(rootCtx, redisinfra.Config{
+		Addr: cfg.Redis.Addr,
+		DB:   cfg.Redis.DB,
+	})
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to connect to Redis")
+	}
+
+	if err := mongoinfra.NewShipmentRepository(db, nil).EnsureIndexes(rootCtx); err != nil {
+		log.Fatal().Err(err).Msg("failed to ensure shipment indexes")
+	}
+	if err := mongoinfra.NewDeadLetterRepository(db).EnsureIndexes(rootCtx); err != nil {
+		log.Fatal().Err(err).Msg("failed to ensure dead letter indexes")
+	}
+	if err := mongoinfra.NewShipmentImportRepository(db).EnsureIndexes(rootCtx); err != nil {
+		log.Fatal().Err(err).Msg("failed to ensure shipment import indexes")
+	}
+	if err := mongoinfra.NewRefreshTokenRepository(db).EnsureIndexes(rootCtx); err != nil {
+		log.Fatal().Err(err).Msg("failed to ensure refresh token indexes")
+	}
+	if err := mongoinfra.NewAPIKeyRepository(db).EnsureIndexes(rootCtx); err != nil {
+		log.Fatal().Err(err).Msg("failed to ensure api key indexes")
+	}
+	if err := mongoinfra.NewInvitationRepository(db).EnsureIndexes(rootCtx); err != nil {
+		log.Fatal().Err(err).Msg("failed to ensure invitation indexes")
+	}
+
+	// workersCtx is independent from rootCtx so that workers keep running
+	// until the HTTP server has stopped accepting new events.
+	workersCtx, cancelWorkers := context.WithCancel(context.Background())
+	defer cancelWorkers()
+
+	e, eventQueue, err := api.NewRouter(workersCtx, db, rdb, cfg, lifecycles, cal)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to build router")
+	}
+
+	// --- HTTP server ---
+	serverErr := make(chan error, 1)
+	go func() {
+		log.Info().Str("port", cfg.Port).Str("env", cfg.Env).Msg("HTTP server listening")
+		if err := e.Start(":" + cfg.Port); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+	}()
+
+	select {
+	case <-rootCtx.Done():
+		log.Info().Msg("shutdown signal received")
+	case err := <-serverErr:
+		log.Error().Err(err).Msg("HTTP server failed")
+	}
+
+	// --- Graceful shutdown ---
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	// 1. Stop accepting HTTP requests and wait for in-flight handlers.
+	if err := e.Shutdown(shutdownCtx); err != nil {
+		log.Error().Err(err).Msg("HTTP server shutdown")
+	}
+
+	// 2. Stop admitting events and let the workers drain what was accepted;
+	//    whatever is left at the deadline goes to the dead-letter store.
+	result, err := eventQueue.Shutdown(shutdownCtx)
+	logEvent := log.Info()
+	if err != nil || result.Lost > 0 {
+		logEvent = log.Warn().Err(err)
+	}
+	logEvent.
+		Int("buffered", result.Buffered).
+		Int("flushed", result.Flushed).
+		Int("persisted", result.Persisted).
+		Int("lost", result.Lost).
+		Msg("event queue stopped")
+	cancelWorkers()
+
+	// 3. Release the storage clients, Mongo first then Redis.
+	if err := mongoClient.Disconnect(shutdownCtx); err != nil {
+		log.Error().Err(err).Msg("MongoDB disconnect")
+	}
+	if err := rdb.Close(); err != nil {
+		log.Error().Err(err).Msg("Redis close")
+	}
+
+	log.Info().Msg("server stopped")
+}
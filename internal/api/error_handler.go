@@ -42,16 +42,54 @@ func resolveError(err error, log zerolog.Logger, c echo.Context) (int, string) {
 	switch {
 	case errors.Is(err, domain.ErrShipmentNotFound):
 		return http.StatusNotFound, "shipment not found"
+	case errors.Is(err, domain.ErrPieceNotFound):
+		return http.StatusNotFound, "piece not found"
 	case errors.Is(err, domain.ErrForbidden):
 		return http.StatusForbidden, "access forbidden"
+	case errors.Is(err, domain.ErrShipmentCancelled):
+		return http.StatusConflict, "shipment already cancelled"
+	case errors.Is(err, domain.ErrShipmentLocked):
+		return http.StatusConflict, "shipment can no longer be amended once picked up"
+	case errors.Is(err, domain.ErrConcurrentUpdate):
+		return http.StatusConflict, "shipment was updated concurrently, retry"
 	case errors.Is(err, domain.ErrInvalidTransition):
 		return http.StatusUnprocessableEntity, err.Error()
+	case errors.Is(err, domain.ErrZoneNotServed),
+		errors.Is(err, domain.ErrServiceNotPriced),
+		errors.Is(err, domain.ErrCurrencyMismatch):
+		return http.StatusUnprocessableEntity, err.Error()
+	case errors.Is(err, domain.ErrNoRateCard):
+		return http.StatusServiceUnavailable, "pricing unavailable: no rate card in effect"
 	case errors.Is(err, domain.ErrInvalidCredentials):
 		return http.StatusUnauthorized, "invalid credentials"
 	case errors.Is(err, domain.ErrUserNotFound):
 		return http.StatusNotFound, "user not found"
 	case errors.Is(err, domain.ErrUserExists):
 		return http.StatusConflict, "user already exists"
+	case errors.Is(err, domain.ErrUserDisabled):
+		return http.StatusForbidden, "user is disabled"
+	case errors.Is(err, domain.ErrInvalidUser):
+		return http.StatusUnprocessableEntity, err.Error()
+	case errors.Is(err, domain.ErrCannotModifySelf):
+		return http.StatusConflict, err.Error()
+	case errors.Is(err, domain.ErrInvalidInvitation):
+		return http.StatusForbidden, "invalid invitation"
+	case errors.Is(err, domain.ErrEventQueueFull):
+		return http.StatusTooManyRequests, "event queue is full"
+	case errors.Is(err, domain.ErrEventQueueUnavailable):
+		return http.StatusServiceUnavailable, "event queue unavailable"
+	case errors.Is(err, domain.ErrDeadLetterNotFound):
+		return http.StatusNotFound, "dead letter event not found"
+	case errors.Is(err, domain.ErrEventNotFound):
+		return http.StatusNotFound, "event not found"
+	case errors.Is(err, domain.ErrEventBatchNotFound):
+		return http.StatusNotFound, "event batch not found"
+	case errors.Is(err, domain.ErrImportNotFound):
+		return http.StatusNotFound, "shipment import not found"
+	case errors.Is(err, domain.ErrAPIKeyNotFound):
+		return http.StatusNotFound, "api key not found"
+	case errors.Is(err, domain.ErrAPIKeyInactive):
+		return http.StatusConflict, "api key is revoked or expired"
 	}
 
 	// Unexpected error: log the real cause, return a generic message.
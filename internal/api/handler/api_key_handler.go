@@ -0,0 +1,217 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/99minutos/shipping-system/internal/core/domain"
+	"github.com/99minutos/shipping-system/internal/core/ports"
+)
+
+// APIKeyHandler handles HTTP requests for the API keys of clients.
+type APIKeyHandler struct {
+	service ports.APIKeyService
+}
+
+func NewAPIKeyHandler(service ports.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{service: service}
+}
+
+// Create handles POST /v1/api-keys.
+//
+// @Summary      Create an API key
+// @Description  Issues a key for a client's systems to call the API with, in the X-API-Key header, instead of a user's token. The key is returned only in this response; only its hash is stored. Users create keys for themselves, with their role; admins name the client and the role (client by default).
+// @Tags         api-keys
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        body  body      createAPIKeyRequest  true  "API key"
+// @Success      201   {object}  issuedAPIKeyResponse
+// @Failure      400   {object}  errorResponse
+// @Failure      401   {object}  errorResponse
+// @Failure      422   {object}  errorResponse
+// @Router       /v1/api-keys [post]
+func (h *APIKeyHandler) Create(c echo.Context) error {
+	role, clientID, err := ctxClaims(c)
+	if err != nil {
+		return err
+	}
+
+	var req createAPIKeyRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid payload")
+	}
+	if err := c.Validate(&req); err != nil {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+	}
+	keyRole := role
+	if role == domain.RoleAdmin {
+		if req.ClientID == "" {
+			return echo.NewHTTPError(http.StatusBadRequest, "client_id is required")
+		}
+		clientID = req.ClientID
+		keyRole = req.Role
+		if keyRole == "" {
+			keyRole = domain.RoleClient
+		}
+	}
+
+	input := ports.CreateAPIKeyInput{
+		ClientID:   clientID,
+		Role:       keyRole,
+		Name:       req.Name,
+		AllowedIPs: req.AllowedIPs,
+	}
+	if req.ExpiresAt != nil {
+		if !req.ExpiresAt.After(time.Now()) {
+			return echo.NewHTTPError(http.StatusUnprocessableEntity, "expires_at must be in the future")
+		}
+		input.ExpiresAt = req.ExpiresAt.UTC()
+	}
+	input.CreatedBy, _ = c.Get("username").(string)
+
+	issued, err := h.service.Create(c.Request().Context(), input)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusCreated, toIssuedAPIKeyResponse(issued))
+}
+
+// List handles GET /v1/api-keys.
+//
+// @Summary      List API keys
+// @Description  Lists the keys of a client, newest first, including revoked and expired ones. Keys themselves are never returned, only their prefix. Users list their own keys; admins name the client.
+// @Tags         api-keys
+// @Produce      json
+// @Security     BearerAuth
+// @Param        client_id  query     string  false  "Client whose keys to list (admins only)"
+// @Success      200        {object}  listAPIKeysResponse
+// @Failure      400        {object}  errorResponse
+// @Failure      401        {object}  errorResponse
+// @Router       /v1/api-keys [get]
+func (h *APIKeyHandler) List(c echo.Context) error {
+	role, clientID, err := ctxClaims(c)
+	if err != nil {
+		return err
+	}
+	if role == domain.RoleAdmin {
+		clientID = c.QueryParam("client_id")
+		if clientID == "" {
+			return echo.NewHTTPError(http.StatusBadRequest, "client_id is required")
+		}
+	}
+
+	keys, err := h.service.List(c.Request().Context(), clientID)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	data := make([]apiKeyResponse, len(keys))
+	for i := range keys {
+		data[i] = toAPIKeyResponse(&keys[i], now)
+	}
+	return c.JSON(http.StatusOK, listAPIKeysResponse{Data: data})
+}
+
+// Rotate handles POST /v1/api-keys/:id/rotate.
+//
+// @Summary      Rotate an API key
+// @Description  Issues a new key with the name, IP allowlist and expiry of an active key. The old key keeps working for the grace period, so integrations can switch without failed requests, and is then rejected. The new key is returned only in this response.
+// @Tags         api-keys
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id    path      string               true   "API key ID"
+// @Param        body  body      rotateAPIKeyRequest  false  "Grace period"
+// @Success      201   {object}  issuedAPIKeyResponse
+// @Failure      400   {object}  errorResponse
+// @Failure      401   {object}  errorResponse
+// @Failure      404   {object}  errorResponse
+// @Failure      409   {object}  errorResponse
+// @Failure      422   {object}  errorResponse
+// @Router       /v1/api-keys/{id}/rotate [post]
+func (h *APIKeyHandler) Rotate(c echo.Context) error {
+	role, clientID, err := ctxClaims(c)
+	if err != nil {
+		return err
+	}
+
+	var req rotateAPIKeyRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid payload")
+	}
+	if err := c.Validate(&req); err != nil {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+	}
+	createdBy, _ := c.Get("username").(string)
+
+	issued, err := h.service.Rotate(c.Request().Context(),
+		ports.APIKeyRef{ID: c.Param("id"), Role: role, ClientID: clientID},
+		time.Duration(req.GracePeriodSeconds)*time.Second,
+		createdBy,
+	)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusCreated, toIssuedAPIKeyResponse(issued))
+}
+
+// Revoke handles DELETE /v1/api-keys/:id.
+//
+// @Summary      Revoke an API key
+// @Description  Rejects the key from now on. Revoking a revoked key succeeds. Only admins reach the keys of other clients.
+// @Tags         api-keys
+// @Security     BearerAuth
+// @Param        id  path  string  true  "API key ID"
+// @Success      204
+// @Failure      401  {object}  errorResponse
+// @Failure      404  {object}  errorResponse
+// @Router       /v1/api-keys/{id} [delete]
+func (h *APIKeyHandler) Revoke(c echo.Context) error {
+	role, clientID, err := ctxClaims(c)
+	if err != nil {
+		return err
+	}
+	ref := ports.APIKeyRef{ID: c.Param("id"), Role: role, ClientID: clientID}
+	if err := h.service.Revoke(c.Request().Context(), ref); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func toIssuedAPIKeyResponse(issued *ports.IssuedAPIKey) issuedAPIKeyResponse {
+	return issuedAPIKeyResponse{
+		apiKeyResponse: toAPIKeyResponse(issued.APIKey, time.Now()),
+		Key:            issued.Key,
+	}
+}
+
+func toAPIKeyResponse(k *domain.APIKey, now time.Time) apiKeyResponse {
+	resp := apiKeyResponse{
+		ID:         k.ID,
+		ClientID:   k.ClientID,
+		Role:       k.KeyRole(),
+		Name:       k.Name,
+		Prefix:     k.Prefix,
+		Status:     k.Status(now),
+		AllowedIPs: k.AllowedIPs,
+		CreatedBy:  k.CreatedBy,
+		CreatedAt:  k.CreatedAt,
+		ReplacedBy: k.ReplacedBy,
+	}
+	if resp.AllowedIPs == nil {
+		resp.AllowedIPs = []string{}
+	}
+	if !k.ExpiresAt.IsZero() {
+		resp.ExpiresAt = &k.ExpiresAt
+	}
+	if !k.LastUsedAt.IsZero() {
+		resp.LastUsedAt = &k.LastUsedAt
+	}
+	if !k.RevokedAt.IsZero() {
+		resp.RevokedAt = &k.RevokedAt
+	}
+	return resp
+}
@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/99minutos/shipping-system/internal/core/domain"
+	"github.com/99minutos/shipping-system/internal/core/ports"
+)
+
+type stubAPIKeyService struct {
+	ports.APIKeyService
+	created *ports.CreateAPIKeyInput
+	rotated time.Duration
+}
+
+func (s *stubAPIKeyService) Create(_ context.Context, input ports.CreateAPIKeyInput) (*ports.IssuedAPIKey, error) {
+	s.created = &input
+	return &ports.IssuedAPIKey{
+		APIKey: &domain.APIKey{ID: "key_1", ClientID: input.ClientID, Name: input.Name, Prefix: "99mk_abcdefgh", ExpiresAt: input.ExpiresAt},
+		Key:    "99mk_abcdefghsecret",
+	}, nil
+}
+
+func (s *stubAPIKeyService) Rotate(_ context.Context, ref ports.APIKeyRef, gracePeriod time.Duration, _ string) (*ports.IssuedAPIKey, error) {
+	if ref.ID != "key_1" {
+		return nil, domain.ErrAPIKeyNotFound
+	}
+	s.rotated = gracePeriod
+	return &ports.IssuedAPIKey{APIKey: &domain.APIKey{ID: "key_2", ClientID: ref.ClientID}, Key: "99mk_next"}, nil
+}
+
+func newAPIKeyRequest(method, body, role, clientID string) (echo.Context, *httptest.ResponseRecorder) {
+	e := echo.New()
+	e.Validator = NewValidator(domain.BuiltinLifecycles())
+	req := httptest.NewRequest(method, "/v1/api-keys", strings.NewReader(body))
+	if body != "" {
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	}
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set("role", role)
+	c.Set("client_id", clientID)
+	c.Set("username", "alice")
+	return c, rec
+}
+
+func TestAPIKeyHandler_Create(t *testing.T) {
+	svc := &stubAPIKeyService{}
+	c, rec := newAPIKeyRequest(http.MethodPost, `{"name":"wms","client_id":"client_9","allowed_ips":["10.0.0.0/8","203.0.113.7"]}`, domain.RoleClient, "client_1")
+
+	if err := NewAPIKeyHandler(svc).Create(c); err != nil {
+		t.Fatalf("handler error: %v", err)
+	}
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", rec.Code)
+	}
+	// Clients always create keys for themselves.
+	if svc.created.ClientID != "client_1" || svc.created.CreatedBy != "alice" || len(svc.created.AllowedIPs) != 2 {
+		t.Fatalf("unexpected input %+v", svc.created)
+	}
+	var resp issuedAPIKeyResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Key != "99mk_abcdefghsecret" || resp.Prefix != "99mk_abcdefgh" || resp.Status != "active" {
+		t.Fatalf("unexpected response %s", rec.Body.String())
+	}
+}
+
+func TestAPIKeyHandler_Create_Role(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		role string
+		want string
+	}{
+		{"carriers create carrier keys", `{"name":"scanner","role":"client"}`, domain.RoleCarrier, domain.RoleCarrier},
+		{"admins choose the role", `{"name":"scanner","client_id":"carrier_1","role":"carrier"}`, domain.RoleAdmin, domain.RoleCarrier},
+		{"admins default to client keys", `{"name":"wms","client_id":"client_1"}`, domain.RoleAdmin, domain.RoleClient},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &stubAPIKeyService{}
+			c, _ := newAPIKeyRequest(http.MethodPost, tt.body, tt.role, "carrier_1")
+			if err := NewAPIKeyHandler(svc).Create(c); err != nil {
+				t.Fatalf("handler error: %v", err)
+			}
+			if svc.created.Role != tt.want {
+				t.Fatalf("expected a %s key, got %q", tt.want, svc.created.Role)
+			}
+		})
+	}
+}
+
+func TestAPIKeyHandler_Create_Rejects(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		role string
+		want int
+	}{
+		{"admin without client", `{"name":"wms"}`, domain.RoleAdmin, http.StatusBadRequest},
+		{"missing name", `{"client_id":"client_1"}`, domain.RoleAdmin, http.StatusUnprocessableEntity},
+		{"bad address", `{"name":"wms","allowed_ips":["10.0.0.0/33"]}`, domain.RoleClient, http.StatusUnprocessableEntity},
+		{"unknown role", `{"name":"wms","client_id":"client_1","role":"admin"}`, domain.RoleAdmin, http.StatusUnprocessableEntity},
+		{"past expiry", `{"name":"wms","expires_at":"2020-01-01T00:00:00Z"}`, domain.RoleClient, http.StatusUnprocessableEntity},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := newAPIKeyRequest(http.MethodPost, tt.body, tt.role, "client_1")
+			err := NewAPIKeyHandler(&stubAPIKeyService{}).Create(c)
+			var he *echo.HTTPError
+			if !errors.As(err, &he) || he.Code != tt.want {
+				t.Fatalf("expected %d, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestAPIKeyHandler_Rotate(t *testing.T) {
+	svc := &stubAPIKeyService{}
+	c, rec := newAPIKeyRequest(http.MethodPost, `{"grace_period_seconds":3600}`, domain.RoleClient, "client_1")
+	c.SetParamNames("id")
+	c.SetParamValues("key_1")
+
+	if err := NewAPIKeyHandler(svc).Rotate(c); err != nil {
+		t.Fatalf("handler error: %v", err)
+	}
+	if rec.Code != http.StatusCreated || svc.rotated != time.Hour {
+		t.Fatalf("expected 201 with a 1h grace period, got %d and %v", rec.Code, svc.rotated)
+	}
+
+	// Without a body the old key stops at once.
+	c, _ = newAPIKeyRequest(http.MethodPost, "", domain.RoleClient, "client_1")
+	c.SetParamNames("id")
+	c.SetParamValues("key_1")
+	if err := NewAPIKeyHandler(svc).Rotate(c); err != nil || svc.rotated != 0 {
+		t.Fatalf("expected rotation without grace period, got %v and %v", err, svc.rotated)
+	}
+}
@@ -0,0 +1,46 @@
+package handler
+
+import "time"
+
+type createAPIKeyRequest struct {
+	Name string `json:"name" validate:"required,max=100"`
+	// ClientID and Role are set by admins; others create keys for
+	// themselves, with their own role.
+	ClientID   string     `json:"client_id,omitempty"`
+	Role       string     `json:"role,omitempty" validate:"omitempty,oneof=client carrier" enums:"client,carrier"`
+	AllowedIPs []string   `json:"allowed_ips,omitempty" validate:"max=20,dive,cidr|ip"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+}
+
+type rotateAPIKeyRequest struct {
+	// GracePeriodSeconds is how long the old key keeps working, at most
+	// 7 days; 0 revokes it at once.
+	GracePeriodSeconds int `json:"grace_period_seconds" validate:"min=0,max=604800"`
+}
+
+type apiKeyResponse struct {
+	ID         string     `json:"id"`
+	ClientID   string     `json:"client_id"`
+	Role       string     `json:"role" enums:"client,carrier"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Status     string     `json:"status" enums:"active,revoked,expired"`
+	AllowedIPs []string   `json:"allowed_ips"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	CreatedBy  string     `json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	ReplacedBy string     `json:"replaced_by,omitempty"`
+}
+
+// issuedAPIKeyResponse is a key just created or rotated, the only time the
+// key itself is returned.
+type issuedAPIKeyResponse struct {
+	apiKeyResponse
+	Key string `json:"key"`
+}
+
+type listAPIKeysResponse struct {
+	Data []apiKeyResponse `json:"data"`
+}
@@ -1,7 +1,9 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
+	"time"
 
 	"github.com/labstack/echo/v4"
 
@@ -20,9 +22,9 @@ func NewAuthHandler(authService ports.AuthService) *AuthHandler {
 type registerRequest struct {
 	Username string `json:"username"`
 	Password string `json:"password"`
-	Email    string `json:"email,omitempty"`
-	Role     string `json:"role"`
-	ClientID string `json:"client_id"`
+	// Email must be the one the invitation was issued for.
+	Email           string `json:"email"`
+	InvitationToken string `json:"invitation_token"`
 }
 
 type loginRequest struct {
@@ -30,11 +32,22 @@ type loginRequest struct {
 	Password string `json:"password"`
 }
 
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type logoutRequest struct {
+	// RefreshToken, when given, is revoked with every token rotated from the
+	// same login.
+	RefreshToken string `json:"refresh_token,omitempty"`
+}
+
 type authResponse struct {
-	Token     string       `json:"token"`
-	TokenType string       `json:"token_type"`
-	ExpiresIn int          `json:"expires_in"`
-	User      *userPayload `json:"user,omitempty"`
+	Token        string       `json:"token"`
+	RefreshToken string       `json:"refresh_token"`
+	TokenType    string       `json:"token_type"`
+	ExpiresIn    int          `json:"expires_in"`
+	User         *userPayload `json:"user,omitempty"`
 }
 
 type userPayload struct {
@@ -43,15 +56,17 @@ type userPayload struct {
 	ClientID string `json:"client_id,omitempty"`
 }
 
-// Register creates a new user account.
+// Register creates a new user account from an invitation.
 //
 // @Summary      Register a new user
+// @Description  Creates a user with the role and client of an invitation issued by an admin for the same email. Each invitation registers one user.
 // @Tags         auth
 // @Accept       json
 // @Produce      json
 // @Param        body  body      registerRequest  true  "User registration details"
 // @Success      201   {object}  authResponse
 // @Failure      400   {object}  map[string]string
+// @Failure      403   {object}  map[string]string
 // @Failure      409   {object}  map[string]string
 // @Failure      500   {object}  map[string]string
 // @Router       /auth/register [post]
@@ -61,7 +76,12 @@ func (h *AuthHandler) Register(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid payload"})
 	}
 
-	user, err := h.authService.Register(c.Request().Context(), req.Username, req.Password, req.Email, req.Role, req.ClientID)
+	user, err := h.authService.Register(c.Request().Context(), ports.RegisterInput{
+		Username:        req.Username,
+		Password:        req.Password,
+		Email:           req.Email,
+		InvitationToken: req.InvitationToken,
+	})
 	if err != nil {
 		status := http.StatusInternalServerError
 		switch err {
@@ -69,6 +89,8 @@ func (h *AuthHandler) Register(c echo.Context) error {
 			status = http.StatusConflict
 		case domain.ErrInvalidCredentials:
 			status = http.StatusBadRequest
+		case domain.ErrInvalidInvitation:
+			status = http.StatusForbidden
 		}
 		return c.JSON(status, map[string]string{"error": err.Error()})
 	}
@@ -87,6 +109,7 @@ func (h *AuthHandler) Register(c echo.Context) error {
 // Login authenticates a user and returns a JWT token.
 //
 // @Summary      Login
+// @Description  Returns a short-lived access token and a refresh token to renew it at POST /auth/refresh.
 // @Tags         auth
 // @Accept       json
 // @Produce      json
@@ -94,6 +117,7 @@ func (h *AuthHandler) Register(c echo.Context) error {
 // @Success      200   {object}  authResponse
 // @Failure      400   {object}  map[string]string
 // @Failure      401   {object}  map[string]string
+// @Failure      403   {object}  map[string]string
 // @Failure      404   {object}  map[string]string
 // @Router       /auth/login [post]
 func (h *AuthHandler) Login(c echo.Context) error {
@@ -102,7 +126,7 @@ func (h *AuthHandler) Login(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid payload"})
 	}
 
-	token, user, err := h.authService.Login(c.Request().Context(), req.Email, req.Password)
+	tokens, user, err := h.authService.Login(c.Request().Context(), req.Email, req.Password)
 	if err != nil {
 		status := http.StatusUnauthorized
 		switch err {
@@ -110,14 +134,78 @@ func (h *AuthHandler) Login(c echo.Context) error {
 			status = http.StatusUnauthorized
 		case domain.ErrUserNotFound:
 			status = http.StatusNotFound
+		case domain.ErrUserDisabled:
+			status = http.StatusForbidden
 		}
 		return c.JSON(status, map[string]string{"error": err.Error()})
 	}
 
+	return c.JSON(http.StatusOK, toAuthResponse(tokens, user))
+}
+
+// Refresh exchanges a refresh token for new tokens.
+//
+// @Summary      Refresh tokens
+// @Description  Spends the refresh token and returns a new access token and a new refresh token. A refresh token can be used once: presenting it again revokes every token of the same login.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        body  body      refreshRequest  true  "Refresh token"
+// @Success      200   {object}  authResponse
+// @Failure      400   {object}  map[string]string
+// @Failure      401   {object}  map[string]string
+// @Router       /auth/refresh [post]
+func (h *AuthHandler) Refresh(c echo.Context) error {
+	var req refreshRequest
+	if err := c.Bind(&req); err != nil || req.RefreshToken == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid payload"})
+	}
+
+	tokens, user, err := h.authService.Refresh(c.Request().Context(), req.RefreshToken)
+	if errors.Is(err, domain.ErrInvalidRefreshToken) || errors.Is(err, domain.ErrRefreshTokenReused) {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+	}
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, toAuthResponse(tokens, user))
+}
+
+// Logout revokes the access token of the request.
+//
+// @Summary      Logout
+// @Description  Revokes the access token the request is made with and, when given, the refresh token issued with it.
+// @Tags         auth
+// @Accept       json
+// @Security     BearerAuth
+// @Param        body  body  logoutRequest  false  "Refresh token to revoke"
+// @Success      204
+// @Failure      400   {object}  map[string]string
+// @Failure      401   {object}  map[string]string
+// @Router       /auth/logout [post]
+func (h *AuthHandler) Logout(c echo.Context) error {
+	var req logoutRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid payload"})
+	}
+
+	input := ports.LogoutInput{RefreshToken: req.RefreshToken}
+	input.UserID, _ = c.Get("user_id").(string)
+	input.JTI, _ = c.Get("jti").(string)
+	input.TokenExpiresAt, _ = c.Get("token_expires_at").(time.Time)
+	if err := h.authService.Logout(c.Request().Context(), input); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func toAuthResponse(tokens *ports.AuthTokens, user *domain.User) authResponse {
 	resp := authResponse{
-		Token:     token,
-		TokenType: "Bearer",
-		ExpiresIn: 86400,
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(tokens.ExpiresIn.Seconds()),
 	}
 	if user != nil {
 		resp.User = &userPayload{
@@ -126,5 +214,5 @@ func (h *AuthHandler) Login(c echo.Context) error {
 			ClientID: user.ClientID,
 		}
 	}
-	return c.JSON(http.StatusOK, resp)
+	return resp
 }
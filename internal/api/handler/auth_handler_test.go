@@ -7,38 +7,50 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/labstack/echo/v4"
 
 	"github.com/99minutos/shipping-system/internal/core/domain"
+	"github.com/99minutos/shipping-system/internal/core/ports"
 )
 
 type stubAuthService struct {
-	registerFn func(ctx context.Context, username, password, email, role, clientID string) (*domain.User, error)
-	loginFn    func(ctx context.Context, email, password string) (string, *domain.User, error)
+	registerFn func(ctx context.Context, input ports.RegisterInput) (*domain.User, error)
+	loginFn    func(ctx context.Context, email, password string) (*ports.AuthTokens, *domain.User, error)
+	refreshFn  func(ctx context.Context, refreshToken string) (*ports.AuthTokens, *domain.User, error)
+	logoutFn   func(ctx context.Context, input ports.LogoutInput) error
 }
 
-func (s *stubAuthService) Register(ctx context.Context, username, password, email, role, clientID string) (*domain.User, error) {
-	return s.registerFn(ctx, username, password, email, role, clientID)
+func (s *stubAuthService) Register(ctx context.Context, input ports.RegisterInput) (*domain.User, error) {
+	return s.registerFn(ctx, input)
 }
 
-func (s *stubAuthService) Login(ctx context.Context, email, password string) (string, *domain.User, error) {
+func (s *stubAuthService) Login(ctx context.Context, email, password string) (*ports.AuthTokens, *domain.User, error) {
 	return s.loginFn(ctx, email, password)
 }
 
+func (s *stubAuthService) Refresh(ctx context.Context, refreshToken string) (*ports.AuthTokens, *domain.User, error) {
+	return s.refreshFn(ctx, refreshToken)
+}
+
+func (s *stubAuthService) Logout(ctx context.Context, input ports.LogoutInput) error {
+	return s.logoutFn(ctx, input)
+}
+
 func TestAuthHandler_Register_Success(t *testing.T) {
 	e := echo.New()
 	stub := &stubAuthService{
-		registerFn: func(ctx context.Context, username, password, email, role, clientID string) (*domain.User, error) {
-			if username != "alice" || role != "client" || clientID != "client_1" {
-				t.Fatalf("unexpected args: %s %s %s", username, role, clientID)
+		registerFn: func(ctx context.Context, input ports.RegisterInput) (*domain.User, error) {
+			if input.Username != "alice" || input.Email != "a@example.com" || input.InvitationToken != "inv-token" {
+				t.Fatalf("unexpected input: %+v", input)
 			}
-			return &domain.User{Username: username, Role: role, ClientID: clientID}, nil
+			return &domain.User{Username: input.Username, Role: "client", ClientID: "client_1"}, nil
 		},
 	}
 	handler := NewAuthHandler(stub)
 
-	body := strings.NewReader(`{"username":"alice","password":"secret","email":"a@example.com","role":"client","client_id":"client_1"}`)
+	body := strings.NewReader(`{"username":"alice","password":"secret","email":"a@example.com","invitation_token":"inv-token"}`)
 	req := httptest.NewRequest(http.MethodPost, "/auth/register", body)
 	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 	rec := httptest.NewRecorder()
@@ -69,7 +81,7 @@ func TestAuthHandler_Register_Success(t *testing.T) {
 func TestAuthHandler_Register_UserExists(t *testing.T) {
 	e := echo.New()
 	stub := &stubAuthService{
-		registerFn: func(ctx context.Context, username, password, email, role, clientID string) (*domain.User, error) {
+		registerFn: func(ctx context.Context, input ports.RegisterInput) (*domain.User, error) {
 			return nil, domain.ErrUserExists
 		},
 	}
@@ -87,10 +99,32 @@ func TestAuthHandler_Register_UserExists(t *testing.T) {
 	}
 }
 
+func TestAuthHandler_Register_InvalidInvitation(t *testing.T) {
+	e := echo.New()
+	stub := &stubAuthService{
+		registerFn: func(ctx context.Context, input ports.RegisterInput) (*domain.User, error) {
+			return nil, domain.ErrInvalidInvitation
+		},
+	}
+	handler := NewAuthHandler(stub)
+
+	body := strings.NewReader(`{"username":"mallory","password":"secret","email":"m@example.com","role":"admin"}`)
+	req := httptest.NewRequest(http.MethodPost, "/auth/register", body)
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	_ = handler.Register(c)
+
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", rec.Code)
+	}
+}
+
 func TestAuthHandler_Register_InvalidPayload(t *testing.T) {
 	e := echo.New()
 	stub := &stubAuthService{
-		registerFn: func(ctx context.Context, username, password, email, role, clientID string) (*domain.User, error) {
+		registerFn: func(ctx context.Context, input ports.RegisterInput) (*domain.User, error) {
 			t.Fatalf("should not be called")
 			return nil, nil
 		},
@@ -112,11 +146,11 @@ func TestAuthHandler_Register_InvalidPayload(t *testing.T) {
 func TestAuthHandler_Login_Success(t *testing.T) {
 	e := echo.New()
 	stub := &stubAuthService{
-		loginFn: func(ctx context.Context, email, password string) (string, *domain.User, error) {
+		loginFn: func(ctx context.Context, email, password string) (*ports.AuthTokens, *domain.User, error) {
 			if email != "alice@example.com" || password != "secret" {
 				t.Fatalf("unexpected args: %s %s", email, password)
 			}
-			return "token123", &domain.User{Username: "alice", Role: "admin", ClientID: ""}, nil
+			return &ports.AuthTokens{AccessToken: "token123", RefreshToken: "refresh123", ExpiresIn: 15 * time.Minute}, &domain.User{Username: "alice", Role: "admin", ClientID: ""}, nil
 		},
 	}
 	handler := NewAuthHandler(stub)
@@ -139,8 +173,8 @@ func TestAuthHandler_Login_Success(t *testing.T) {
 		t.Fatalf("invalid json: %v", err)
 	}
 
-	if resp["token"] != "token123" {
-		t.Fatalf("expected token, got %v", resp["token"])
+	if resp["token"] != "token123" || resp["refresh_token"] != "refresh123" || resp["expires_in"] != float64(900) {
+		t.Fatalf("unexpected tokens: %v", resp)
 	}
 	user, ok := resp["user"].(map[string]any)
 	if !ok || user["username"] != "alice" || user["role"] != "admin" {
@@ -151,8 +185,8 @@ func TestAuthHandler_Login_Success(t *testing.T) {
 func TestAuthHandler_Login_InvalidCredentials(t *testing.T) {
 	e := echo.New()
 	stub := &stubAuthService{
-		loginFn: func(ctx context.Context, email, password string) (string, *domain.User, error) {
-			return "", nil, domain.ErrInvalidCredentials
+		loginFn: func(ctx context.Context, email, password string) (*ports.AuthTokens, *domain.User, error) {
+			return nil, nil, domain.ErrInvalidCredentials
 		},
 	}
 	handler := NewAuthHandler(stub)
@@ -172,8 +206,8 @@ func TestAuthHandler_Login_InvalidCredentials(t *testing.T) {
 func TestAuthHandler_Login_UserNotFound(t *testing.T) {
 	e := echo.New()
 	stub := &stubAuthService{
-		loginFn: func(ctx context.Context, email, password string) (string, *domain.User, error) {
-			return "", nil, domain.ErrUserNotFound
+		loginFn: func(ctx context.Context, email, password string) (*ports.AuthTokens, *domain.User, error) {
+			return nil, nil, domain.ErrUserNotFound
 		},
 	}
 	handler := NewAuthHandler(stub)
@@ -193,9 +227,9 @@ func TestAuthHandler_Login_UserNotFound(t *testing.T) {
 func TestAuthHandler_Login_InvalidPayload(t *testing.T) {
 	e := echo.New()
 	stub := &stubAuthService{
-		loginFn: func(ctx context.Context, email, password string) (string, *domain.User, error) {
+		loginFn: func(ctx context.Context, email, password string) (*ports.AuthTokens, *domain.User, error) {
 			t.Fatalf("should not be called")
-			return "", nil, nil
+			return nil, nil, nil
 		},
 	}
 	handler := NewAuthHandler(stub)
@@ -211,3 +245,69 @@ func TestAuthHandler_Login_InvalidPayload(t *testing.T) {
 		t.Fatalf("expected 400, got %d", rec.Code)
 	}
 }
+
+func TestAuthHandler_Refresh(t *testing.T) {
+	e := echo.New()
+	stub := &stubAuthService{
+		refreshFn: func(ctx context.Context, refreshToken string) (*ports.AuthTokens, *domain.User, error) {
+			if refreshToken != "refresh123" {
+				return nil, nil, domain.ErrRefreshTokenReused
+			}
+			return &ports.AuthTokens{AccessToken: "token456", RefreshToken: "refresh456", ExpiresIn: 15 * time.Minute}, &domain.User{Username: "alice", Role: "admin"}, nil
+		},
+	}
+	handler := NewAuthHandler(stub)
+
+	tests := []struct {
+		body string
+		want int
+	}{
+		{`{"refresh_token":"refresh123"}`, http.StatusOK},
+		{`{"refresh_token":"spent"}`, http.StatusUnauthorized},
+		{`{}`, http.StatusBadRequest},
+	}
+	for _, tc := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/auth/refresh", strings.NewReader(tc.body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+
+		if err := handler.Refresh(e.NewContext(req, rec)); err != nil {
+			t.Fatalf("%s: handler error: %v", tc.body, err)
+		}
+		if rec.Code != tc.want {
+			t.Fatalf("%s: expected %d, got %d", tc.body, tc.want, rec.Code)
+		}
+	}
+}
+
+func TestAuthHandler_Logout(t *testing.T) {
+	e := echo.New()
+	expiresAt := time.Now().Add(10 * time.Minute)
+	var got ports.LogoutInput
+	stub := &stubAuthService{
+		logoutFn: func(ctx context.Context, input ports.LogoutInput) error {
+			got = input
+			return nil
+		},
+	}
+	handler := NewAuthHandler(stub)
+
+	req := httptest.NewRequest(http.MethodPost, "/auth/logout", strings.NewReader(`{"refresh_token":"refresh123"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set("user_id", "user_1")
+	c.Set("jti", "jti_1")
+	c.Set("token_expires_at", expiresAt)
+
+	if err := handler.Logout(c); err != nil {
+		t.Fatalf("handler error: %v", err)
+	}
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", rec.Code)
+	}
+	want := ports.LogoutInput{UserID: "user_1", JTI: "jti_1", TokenExpiresAt: expiresAt, RefreshToken: "refresh123"}
+	if got != want {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+}
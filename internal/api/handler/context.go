@@ -2,6 +2,7 @@ package handler
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/labstack/echo/v4"
 
@@ -11,8 +12,9 @@ import (
 // ctxClaims extracts the auth claims injected by the Auth middleware and
 // performs a fast-fail check before any service call:
 //   - role must be non-empty (presence proves the middleware ran).
-//   - client role requires a non-empty client_id; without it the JWT is
-//     structurally valid but operationally unusable — reject with 401.
+//   - every role but admin requires a non-empty client_id (the client or
+//     carrier account); without it the JWT is structurally valid but
+//     operationally unusable — reject with 401.
 func ctxClaims(c echo.Context) (role, clientID string, err error) {
 	role, _ = c.Get("role").(string)
 	if role == "" {
@@ -20,9 +22,20 @@ func ctxClaims(c echo.Context) (role, clientID string, err error) {
 	}
 
 	clientID, _ = c.Get("client_id").(string)
-	if role == domain.RoleClient && clientID == "" {
+	if role != domain.RoleAdmin && clientID == "" {
 		return "", "", echo.NewHTTPError(http.StatusUnauthorized, "token missing client identity")
 	}
 
 	return role, clientID, nil
 }
+
+// trackingNumberParam returns the :tracking_number path parameter, upper
+// cased, rejecting numbers whose check character does not match before any
+// lookup.
+func trackingNumberParam(c echo.Context) (string, error) {
+	tn := strings.ToUpper(c.Param("tracking_number"))
+	if !domain.ValidTrackingNumber(tn) {
+		return "", echo.NewHTTPError(http.StatusBadRequest, "invalid tracking number")
+	}
+	return tn, nil
+}
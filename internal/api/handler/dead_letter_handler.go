@@ -0,0 +1,182 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/99minutos/shipping-system/internal/core/ports"
+)
+
+// DeadLetterHandler exposes the admin operations on dead-lettered events.
+type DeadLetterHandler struct {
+	service ports.DeadLetterService
+}
+
+func NewDeadLetterHandler(service ports.DeadLetterService) *DeadLetterHandler {
+	return &DeadLetterHandler{service: service}
+}
+
+// List handles GET /v1/admin/dead-letters.
+//
+// @Summary      List dead-lettered events
+// @Tags         dead-letters
+// @Produce      json
+// @Security     BearerAuth
+// @Param        tracking_number  query     string  false  "Filter by tracking number"
+// @Param        page             query     int     false  "Page number (default 1)"
+// @Param        limit            query     int     false  "Items per page (default 20, max 100)"
+// @Success      200              {object}  listDeadLettersResponse
+// @Failure      401              {object}  errorResponse
+// @Failure      403              {object}  errorResponse
+// @Failure      500              {object}  errorResponse
+// @Router       /v1/admin/dead-letters [get]
+func (h *DeadLetterHandler) List(c echo.Context) error {
+	page, _ := strconv.Atoi(c.QueryParam("page"))
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+
+	result, err := h.service.List(c.Request().Context(), ports.ListDeadLettersInput{
+		TrackingNumber: c.QueryParam("tracking_number"),
+		Page:           page,
+		Limit:          limit,
+	})
+	if err != nil {
+		return err
+	}
+
+	items := make([]deadLetterResponse, len(result.Items))
+	for i, item := range result.Items {
+		items[i] = toDeadLetterResponse(item)
+	}
+	return c.JSON(http.StatusOK, listDeadLettersResponse{
+		Data: items,
+		Pagination: paginationResponse{
+			Total:      result.Total,
+			Page:       result.Page,
+			Limit:      result.Limit,
+			TotalPages: result.TotalPages,
+		},
+	})
+}
+
+// Get handles GET /v1/admin/dead-letters/{id}.
+//
+// @Summary      Inspect a dead-lettered event
+// @Tags         dead-letters
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Dead letter ID"
+// @Success      200  {object}  deadLetterResponse
+// @Failure      401  {object}  errorResponse
+// @Failure      403  {object}  errorResponse
+// @Failure      404  {object}  errorResponse
+// @Router       /v1/admin/dead-letters/{id} [get]
+func (h *DeadLetterHandler) Get(c echo.Context) error {
+	item, err := h.service.Get(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, toDeadLetterResponse(*item))
+}
+
+// Replay handles POST /v1/admin/dead-letters/{id}/replay.
+// The event is processed synchronously; on success the entry is removed.
+//
+// @Summary      Replay a dead-lettered event
+// @Tags         dead-letters
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Dead letter ID"
+// @Success      200  {object}  acceptedResponse
+// @Failure      401  {object}  errorResponse
+// @Failure      403  {object}  errorResponse
+// @Failure      404  {object}  errorResponse
+// @Failure      422  {object}  errorResponse
+// @Router       /v1/admin/dead-letters/{id}/replay [post]
+func (h *DeadLetterHandler) Replay(c echo.Context) error {
+	if err := h.service.Replay(c.Request().Context(), c.Param("id")); err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, acceptedResponse{Message: "event replayed"})
+}
+
+// Delete handles DELETE /v1/admin/dead-letters/{id}.
+//
+// @Summary      Discard a dead-lettered event
+// @Tags         dead-letters
+// @Security     BearerAuth
+// @Param        id   path  string  true  "Dead letter ID"
+// @Success      204
+// @Failure      401  {object}  errorResponse
+// @Failure      403  {object}  errorResponse
+// @Failure      404  {object}  errorResponse
+// @Router       /v1/admin/dead-letters/{id} [delete]
+func (h *DeadLetterHandler) Delete(c echo.Context) error {
+	if err := h.service.Delete(c.Request().Context(), c.Param("id")); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// Purge handles DELETE /v1/admin/dead-letters.
+// At least one filter is required so an empty request never wipes the store.
+//
+// @Summary      Purge dead-lettered events
+// @Tags         dead-letters
+// @Produce      json
+// @Security     BearerAuth
+// @Param        tracking_number  query     string  false  "Only entries for this tracking number"
+// @Param        before           query     string  false  "Only entries whose last failure is before this RFC 3339 time"
+// @Param        all              query     bool    false  "Purge every entry (required when no other filter is given)"
+// @Success      200              {object}  purgeDeadLettersResponse
+// @Failure      400              {object}  errorResponse
+// @Failure      401              {object}  errorResponse
+// @Failure      403              {object}  errorResponse
+// @Router       /v1/admin/dead-letters [delete]
+func (h *DeadLetterHandler) Purge(c echo.Context) error {
+	var before time.Time
+	if raw := c.QueryParam("before"); raw != "" {
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "before must be an RFC 3339 timestamp")
+		}
+		before = t
+	}
+
+	trackingNumber := c.QueryParam("tracking_number")
+	if trackingNumber == "" && before.IsZero() && c.QueryParam("all") != "true" {
+		return echo.NewHTTPError(http.StatusBadRequest, "specify tracking_number, before or all=true")
+	}
+
+	n, err := h.service.Purge(c.Request().Context(), ports.PurgeDeadLettersInput{
+		TrackingNumber: trackingNumber,
+		Before:         before,
+	})
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, purgeDeadLettersResponse{Deleted: n})
+}
+
+func toDeadLetterResponse(item ports.DeadLetterItem) deadLetterResponse {
+	ev := trackingEventResponse{
+		EventID:        item.Event.ID,
+		TrackingNumber: item.Event.TrackingNumber,
+		Status:         item.Event.Status,
+		Timestamp:      item.Event.Timestamp.UTC(),
+		Source:         item.Event.Source,
+	}
+	if item.Event.Location != nil {
+		ev.Location = &locationResponse{Lat: item.Event.Location.Lat, Lng: item.Event.Location.Lng}
+	}
+	return deadLetterResponse{
+		ID:            item.ID,
+		Event:         ev,
+		Reason:        item.Reason,
+		Attempts:      item.Attempts,
+		FirstFailedAt: item.FirstFailedAt.UTC(),
+		LastFailedAt:  item.LastFailedAt.UTC(),
+	}
+}
@@ -0,0 +1,35 @@
+package handler
+
+import "time"
+
+type locationResponse struct {
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+}
+
+type trackingEventResponse struct {
+	EventID        string            `json:"event_id,omitempty"`
+	TrackingNumber string            `json:"tracking_number"`
+	Status         string            `json:"status"`
+	Timestamp      time.Time         `json:"timestamp"`
+	Source         string            `json:"source"`
+	Location       *locationResponse `json:"location,omitempty"`
+}
+
+type deadLetterResponse struct {
+	ID            string                `json:"id"`
+	Event         trackingEventResponse `json:"event"`
+	Reason        string                `json:"reason"`
+	Attempts      int                   `json:"attempts"`
+	FirstFailedAt time.Time             `json:"first_failed_at"`
+	LastFailedAt  time.Time             `json:"last_failed_at"`
+}
+
+type listDeadLettersResponse struct {
+	Data       []deadLetterResponse `json:"data"`
+	Pagination paginationResponse   `json:"pagination"`
+}
+
+type purgeDeadLettersResponse struct {
+	Deleted int64 `json:"deleted"`
+}
@@ -1,31 +1,36 @@
 package handler
 
 import (
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/labstack/echo/v4"
 
+	"github.com/99minutos/shipping-system/internal/core/domain"
 	"github.com/99minutos/shipping-system/internal/core/ports"
 )
 
-// EventDispatcher is the interface the handler uses to enqueue events.
-type EventDispatcher interface {
-	Enqueue(event ports.TrackingEventInput)
-	EnqueueBatch(events []ports.TrackingEventInput)
-}
-
-// EventHandler handles tracking event ingestion.
+// EventHandler handles tracking event ingestion and ingestion status lookups.
 type EventHandler struct {
-	dispatcher EventDispatcher
+	service    ports.EventIngestService
+	retryAfter time.Duration
+	maxSync    int // most events accepted by a sync batch
 }
 
-// NewEventHandler creates an EventHandler backed by the given dispatcher.
-func NewEventHandler(dispatcher EventDispatcher) *EventHandler {
-	return &EventHandler{dispatcher: dispatcher}
+// NewEventHandler creates an EventHandler backed by the given ingest service.
+// retryAfter is advertised to clients in the Retry-After header when the
+// queue sheds load; maxSync caps the events of a batch applied inline.
+func NewEventHandler(service ports.EventIngestService, retryAfter time.Duration, maxSync int) *EventHandler {
+	return &EventHandler{service: service, retryAfter: retryAfter, maxSync: maxSync}
 }
 
-// Receive handles POST /v1/events — enqueues a single event, returns 202.
+// Receive handles POST /v1/events — enqueues a single event, returns 202
+// with the event ID and a Location header pointing at its status.
 //
 // @Summary      Ingest a single tracking event
 // @Tags         events
@@ -34,11 +39,19 @@ func NewEventHandler(dispatcher EventDispatcher) *EventHandler {
 // @Security     BearerAuth
 // @Param        body  body      trackingEventRequest  true  "Tracking event"
 // @Success      202   {object}  acceptedResponse
+// @Header       202   {string}  Location  "URL of the event status"
 // @Failure      400   {object}  errorResponse
 // @Failure      401   {object}  errorResponse
 // @Failure      422   {object}  errorResponse
+// @Failure      429   {object}  errorResponse
+// @Failure      503   {object}  errorResponse
 // @Router       /v1/events [post]
 func (h *EventHandler) Receive(c echo.Context) error {
+	role, clientID, err := ctxClaims(c)
+	if err != nil {
+		return err
+	}
+
 	var req trackingEventRequest
 	if err := c.Bind(&req); err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, "invalid payload")
@@ -47,24 +60,56 @@ func (h *EventHandler) Receive(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
 	}
 
-	h.dispatcher.Enqueue(toEventInput(req))
-	return c.JSON(http.StatusAccepted, acceptedResponse{Message: "event accepted"})
+	result, err := h.service.Ingest(c.Request().Context(), ports.IngestEventsInput{
+		Events:   []ports.TrackingEventInput{toEventInput(req, role)},
+		ClientID: clientID,
+	})
+	if err != nil {
+		return h.queueError(c, err)
+	}
+
+	id := result.EventIDs[0]
+	c.Response().Header().Set(echo.HeaderLocation, "/v1/events/"+id)
+	return c.JSON(http.StatusAccepted, acceptedResponse{Message: "event accepted", EventID: id})
 }
 
-// ReceiveBatch handles POST /v1/events/batch — enqueues a batch of events, returns 202.
+// ReceiveBatch handles POST /v1/events/batch — enqueues a batch of events,
+// returns 202 with the batch ID, the event IDs in request order and a
+// Location header pointing at the batch status.
+//
+// With ?mode=sync or "Prefer: return=representation" the batch is applied
+// inline instead and answered with 207 and one outcome per event; invalid
+// items are reported without stopping the valid ones. A sync batch holds at
+// most maxSync events.
 //
 // @Summary      Ingest a batch of tracking events
 // @Tags         events
 // @Accept       json
 // @Produce      json
 // @Security     BearerAuth
-// @Param        body  body      []trackingEventRequest  true  "Array of tracking events"
-// @Success      202   {object}  acceptedResponse
-// @Failure      400   {object}  errorResponse
-// @Failure      401   {object}  errorResponse
-// @Failure      422   {object}  errorResponse
+// @Param        body    body      []trackingEventRequest  true   "Array of tracking events"
+// @Param        mode    query     string                  false  "Processing mode (default async)"  Enums(async, sync)
+// @Param        Prefer  header    string                  false  "return=representation selects sync mode"
+// @Success      202     {object}  acceptedResponse
+// @Header       202     {string}  Location  "URL of the batch status"
+// @Success      207     {object}  multiStatusResponse
+// @Failure      400     {object}  errorResponse
+// @Failure      401     {object}  errorResponse
+// @Failure      413     {object}  errorResponse
+// @Failure      422     {object}  errorResponse
+// @Failure      429     {object}  errorResponse
+// @Failure      503     {object}  errorResponse
 // @Router       /v1/events/batch [post]
 func (h *EventHandler) ReceiveBatch(c echo.Context) error {
+	role, clientID, err := ctxClaims(c)
+	if err != nil {
+		return err
+	}
+	sync, err := syncRequested(c)
+	if err != nil {
+		return err
+	}
+
 	var reqs []trackingEventRequest
 	if err := c.Bind(&reqs); err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, "invalid payload")
@@ -72,6 +117,13 @@ func (h *EventHandler) ReceiveBatch(c echo.Context) error {
 	if len(reqs) == 0 {
 		return echo.NewHTTPError(http.StatusBadRequest, "batch cannot be empty")
 	}
+	if sync {
+		if len(reqs) > h.maxSync {
+			return echo.NewHTTPError(http.StatusRequestEntityTooLarge,
+				fmt.Sprintf("sync batch cannot hold more than %d events", h.maxSync))
+		}
+		return h.applyBatch(c, reqs, role)
+	}
 
 	inputs := make([]ports.TrackingEventInput, 0, len(reqs))
 	for i, req := range reqs {
@@ -79,26 +131,228 @@ func (h *EventHandler) ReceiveBatch(c echo.Context) error {
 			return echo.NewHTTPError(http.StatusUnprocessableEntity,
 				fmt.Sprintf("event[%d]: %s", i, err.Error()))
 		}
-		inputs = append(inputs, toEventInput(req))
+		inputs = append(inputs, toEventInput(req, role))
 	}
 
-	h.dispatcher.EnqueueBatch(inputs)
+	result, err := h.service.Ingest(c.Request().Context(), ports.IngestEventsInput{
+		Events:   inputs,
+		Batch:    true,
+		ClientID: clientID,
+	})
+	if err != nil {
+		return h.queueError(c, err)
+	}
+
+	c.Response().Header().Set(echo.HeaderLocation, "/v1/events/batches/"+result.BatchID)
 	return c.JSON(http.StatusAccepted, acceptedResponse{
-		Message: "events accepted",
-		Count:   len(inputs),
+		Message:  "events accepted",
+		Count:    len(inputs),
+		BatchID:  result.BatchID,
+		EventIDs: result.EventIDs,
 	})
 }
 
-// toEventInput maps the HTTP request to the service DTO.
-func toEventInput(r trackingEventRequest) ports.TrackingEventInput {
+// applyBatch processes a batch inline and answers 207 with the outcome of each
+// event in request order.
+func (h *EventHandler) applyBatch(c echo.Context, reqs []trackingEventRequest, role string) error {
+	resp := multiStatusResponse{
+		Total:   len(reqs),
+		Counts:  make(map[string]int),
+		Results: make([]syncEventResult, len(reqs)),
+	}
+
+	var (
+		inputs  []ports.TrackingEventInput
+		indexes []int
+	)
+	for i, req := range reqs {
+		resp.Results[i] = syncEventResult{Index: i, TrackingNumber: req.TrackingNumber, Status: req.Status}
+		if err := c.Validate(&req); err != nil {
+			resp.Results[i].Outcome = outcomeInvalid
+			resp.Results[i].Error = err.Error()
+			continue
+		}
+		inputs = append(inputs, toEventInput(req, role))
+		indexes = append(indexes, i)
+	}
+
+	if len(inputs) > 0 {
+		for j, applied := range h.service.Apply(c.Request().Context(), inputs) {
+			result := &resp.Results[indexes[j]]
+			result.Outcome, result.Error = appliedOutcome(applied)
+		}
+	}
+	for _, r := range resp.Results {
+		resp.Counts[r.Outcome]++
+	}
+	return c.JSON(http.StatusMultiStatus, resp)
+}
+
+// syncRequested reports whether the client asked for inline processing with
+// ?mode=sync or "Prefer: return=representation". The preference is echoed in
+// Preference-Applied when it is honoured.
+func syncRequested(c echo.Context) (bool, error) {
+	switch c.QueryParam("mode") {
+	case "sync":
+		return true, nil
+	case "async":
+		return false, nil
+	case "":
+	default:
+		return false, echo.NewHTTPError(http.StatusBadRequest, "mode must be async or sync")
+	}
+
+	for _, pref := range strings.FieldsFunc(c.Request().Header.Get("Prefer"), func(r rune) bool {
+		return r == ',' || r == ';'
+	}) {
+		if strings.EqualFold(strings.TrimSpace(pref), "return=representation") {
+			c.Response().Header().Set("Preference-Applied", "return=representation")
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// appliedOutcome maps the result of an inline event to its outcome and, for
+// failures, a message. Unexpected errors are not exposed to the client.
+func appliedOutcome(a ports.AppliedEvent) (outcome, message string) {
+	switch {
+	case a.Err == nil && a.State == domain.EventDuplicate:
+		return outcomeDuplicate, ""
+	case a.Err == nil && a.State == domain.EventLate:
+		return outcomeLate, ""
+	case a.Err == nil:
+		return outcomeApplied, ""
+	case errors.Is(a.Err, domain.ErrShipmentCancelled):
+		return outcomeCancelled, a.Err.Error()
+	case errors.Is(a.Err, domain.ErrInvalidTransition):
+		return outcomeInvalidTransition, a.Err.Error()
+	case errors.Is(a.Err, domain.ErrShipmentNotFound):
+		return outcomeNotFound, domain.ErrShipmentNotFound.Error()
+	case errors.Is(a.Err, domain.ErrPieceNotFound):
+		return outcomeNotFound, domain.ErrPieceNotFound.Error()
+	case errors.Is(a.Err, domain.ErrConcurrentUpdate):
+		return outcomeError, domain.ErrConcurrentUpdate.Error()
+	default:
+		return outcomeError, "internal server error"
+	}
+}
+
+// Get handles GET /v1/events/:id — ingestion status of an accepted event.
+//
+// @Summary      Get the ingestion status of an event
+// @Description  State is queued, processed, late (older than the last transition, kept in the history only), duplicate, rejected (business rule, with reason) or failed (moved to the dead-letter queue, with reason). Only admins see events sent under other client IDs. Statuses expire after EVENT_STATUS_TTL.
+// @Tags         events
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Event ID"
+// @Success      200  {object}  eventStatusResponse
+// @Failure      401  {object}  errorResponse
+// @Failure      404  {object}  errorResponse
+// @Router       /v1/events/{id} [get]
+func (h *EventHandler) Get(c echo.Context) error {
+	role, clientID, err := ctxClaims(c)
+	if err != nil {
+		return err
+	}
+
+	item, err := h.service.GetEvent(c.Request().Context(), ports.GetEventStatusInput{
+		ID:       c.Param("id"),
+		Role:     role,
+		ClientID: clientID,
+	})
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, toEventStatusResponse(*item))
+}
+
+// GetBatch handles GET /v1/events/batches/:id — per-event outcomes of a batch.
+//
+// @Summary      Get the ingestion status of a batch
+// @Tags         events
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Batch ID"
+// @Success      200  {object}  eventBatchStatusResponse
+// @Failure      401  {object}  errorResponse
+// @Failure      404  {object}  errorResponse
+// @Router       /v1/events/batches/{id} [get]
+func (h *EventHandler) GetBatch(c echo.Context) error {
+	role, clientID, err := ctxClaims(c)
+	if err != nil {
+		return err
+	}
+
+	batch, err := h.service.GetBatch(c.Request().Context(), ports.GetEventStatusInput{
+		ID:       c.Param("id"),
+		Role:     role,
+		ClientID: clientID,
+	})
+	if err != nil {
+		return err
+	}
+
+	resp := eventBatchStatusResponse{
+		BatchID: batch.BatchID,
+		Total:   len(batch.Items),
+		Counts:  batch.Counts,
+		Items:   make([]eventStatusResponse, len(batch.Items)),
+	}
+	for i, item := range batch.Items {
+		resp.Items[i] = toEventStatusResponse(item)
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// queueError turns an admission failure into 429 (saturated) or 503
+// (queue unavailable) with a Retry-After hint. Other errors pass through.
+func (h *EventHandler) queueError(c echo.Context, err error) error {
+	var code int
+	switch {
+	case errors.Is(err, domain.ErrEventQueueFull):
+		code = http.StatusTooManyRequests
+	case errors.Is(err, domain.ErrEventQueueUnavailable):
+		code = http.StatusServiceUnavailable
+	default:
+		return err
+	}
+
+	secs := int(math.Ceil(h.retryAfter.Seconds()))
+	if secs < 1 {
+		secs = 1
+	}
+	c.Response().Header().Set("Retry-After", strconv.Itoa(secs))
+	return echo.NewHTTPError(code, err.Error())
+}
+
+// toEventInput maps the HTTP request to the service DTO. role is the role of
+// the caller, checked against the transitions that restrict it.
+func toEventInput(r trackingEventRequest, role string) ports.TrackingEventInput {
 	in := ports.TrackingEventInput{
 		TrackingNumber: r.TrackingNumber,
 		Status:         r.Status,
 		Timestamp:      r.Timestamp,
 		Source:         r.Source,
+		Role:           role,
+		ReasonCode:     r.ReasonCode,
+		Piece:          r.Piece,
 	}
 	if r.Location != nil {
 		in.Location = &ports.LocationInput{Lat: r.Location.Lat, Lng: r.Location.Lng}
 	}
 	return in
-}
\ No newline at end of file
+}
+
+func toEventStatusResponse(item ports.EventStatusItem) eventStatusResponse {
+	return eventStatusResponse{
+		EventID:        item.ID,
+		BatchID:        item.BatchID,
+		TrackingNumber: item.TrackingNumber,
+		Status:         item.Status,
+		State:          item.State,
+		Reason:         item.Reason,
+		AcceptedAt:     item.AcceptedAt,
+		UpdatedAt:      item.UpdatedAt,
+	}
+}
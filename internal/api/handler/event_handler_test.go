@@ -0,0 +1,350 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/99minutos/shipping-system/internal/core/domain"
+	"github.com/99minutos/shipping-system/internal/core/ports"
+)
+
+type stubIngestService struct {
+	err      error
+	ingested []ports.IngestEventsInput
+	item     *ports.EventStatusItem
+	batch    *ports.EventBatchStatus
+	lookup   ports.GetEventStatusInput
+	outcomes []ports.AppliedEvent
+	applied  []ports.TrackingEventInput
+}
+
+func (s *stubIngestService) Ingest(_ context.Context, input ports.IngestEventsInput) (*ports.IngestEventsResult, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	s.ingested = append(s.ingested, input)
+	result := &ports.IngestEventsResult{}
+	if input.Batch {
+		result.BatchID = "bat_1"
+	}
+	for i := range input.Events {
+		result.EventIDs = append(result.EventIDs, fmt.Sprintf("evt_%d", i+1))
+	}
+	return result, nil
+}
+
+func (s *stubIngestService) Apply(_ context.Context, events []ports.TrackingEventInput) []ports.AppliedEvent {
+	s.applied = append(s.applied, events...)
+	return s.outcomes[:len(events)]
+}
+
+func (s *stubIngestService) GetEvent(_ context.Context, input ports.GetEventStatusInput) (*ports.EventStatusItem, error) {
+	s.lookup = input
+	if s.item == nil {
+		return nil, domain.ErrEventNotFound
+	}
+	return s.item, nil
+}
+
+func (s *stubIngestService) GetBatch(_ context.Context, input ports.GetEventStatusInput) (*ports.EventBatchStatus, error) {
+	s.lookup = input
+	if s.batch == nil {
+		return nil, domain.ErrEventBatchNotFound
+	}
+	return s.batch, nil
+}
+
+const validEventBody = `{"tracking_number":"99M-AABBCCDD","status":"picked_up","timestamp":"2026-02-19T10:00:00Z","source":"driver_app"}`
+
+func newEventRequest(method, body string) (echo.Context, *httptest.ResponseRecorder) {
+	e := echo.New()
+	e.Validator = NewValidator(domain.BuiltinLifecycles())
+	req := httptest.NewRequest(method, "/v1/events", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set("role", domain.RoleClient)
+	c.Set("client_id", "client_1")
+	return c, rec
+}
+
+func TestEventHandler_Receive_Accepted(t *testing.T) {
+	svc := &stubIngestService{}
+	h := NewEventHandler(svc, time.Second, 100)
+	c, rec := newEventRequest(http.MethodPost, validEventBody)
+
+	if err := h.Receive(c); err != nil {
+		t.Fatalf("handler error: %v", err)
+	}
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d", rec.Code)
+	}
+	if got := rec.Header().Get(echo.HeaderLocation); got != "/v1/events/evt_1" {
+		t.Errorf("unexpected Location header %q", got)
+	}
+	var body acceptedResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if body.EventID != "evt_1" {
+		t.Errorf("expected event_id evt_1, got %q", body.EventID)
+	}
+	if len(svc.ingested) != 1 || svc.ingested[0].Batch || svc.ingested[0].ClientID != "client_1" {
+		t.Fatalf("unexpected ingest input: %+v", svc.ingested)
+	}
+}
+
+func TestEventHandler_Receive_QueueFull(t *testing.T) {
+	h := NewEventHandler(&stubIngestService{err: domain.ErrEventQueueFull}, 1500*time.Millisecond, 100)
+	c, rec := newEventRequest(http.MethodPost, validEventBody)
+
+	err := h.Receive(c)
+	he, ok := err.(*echo.HTTPError)
+	if !ok || he.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 HTTPError, got %v", err)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "2" {
+		t.Errorf("expected Retry-After 2, got %q", got)
+	}
+}
+
+func TestEventHandler_ReceiveBatch_Accepted(t *testing.T) {
+	h := NewEventHandler(&stubIngestService{}, time.Second, 100)
+	c, rec := newEventRequest(http.MethodPost, "["+validEventBody+","+validEventBody+"]")
+
+	if err := h.ReceiveBatch(c); err != nil {
+		t.Fatalf("handler error: %v", err)
+	}
+	if got := rec.Header().Get(echo.HeaderLocation); got != "/v1/events/batches/bat_1" {
+		t.Errorf("unexpected Location header %q", got)
+	}
+	var body acceptedResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if body.BatchID != "bat_1" || body.Count != 2 || fmt.Sprint(body.EventIDs) != "[evt_1 evt_2]" {
+		t.Errorf("unexpected body: %+v", body)
+	}
+}
+
+func TestEventHandler_ReceiveBatch_QueueUnavailable(t *testing.T) {
+	h := NewEventHandler(&stubIngestService{err: fmt.Errorf("event[0]: %w", domain.ErrEventQueueUnavailable)}, time.Second, 100)
+	c, rec := newEventRequest(http.MethodPost, "["+validEventBody+"]")
+
+	err := h.ReceiveBatch(c)
+	he, ok := err.(*echo.HTTPError)
+	if !ok || he.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 HTTPError, got %v", err)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "1" {
+		t.Errorf("expected Retry-After 1, got %q", got)
+	}
+}
+
+func TestEventHandler_ReceiveBatch_SyncMode(t *testing.T) {
+	svc := &stubIngestService{outcomes: []ports.AppliedEvent{
+		{State: domain.EventProcessed},
+		{Err: fmt.Errorf("process event: %w", domain.ErrShipmentNotFound)},
+		{Err: fmt.Errorf("process event: %w (from created to delivered)", domain.ErrInvalidTransition)},
+		{State: domain.EventDuplicate},
+	}}
+	h := NewEventHandler(svc, time.Second, 100)
+	invalid := `{"tracking_number":"99M-AABBCCDD","status":"teleported","timestamp":"2026-02-19T10:00:00Z","source":"driver_app"}`
+	body := "[" + strings.Join([]string{validEventBody, validEventBody, invalid, validEventBody, validEventBody}, ",") + "]"
+	c, rec := newEventRequest(http.MethodPost, body)
+	c.QueryParams().Set("mode", "sync")
+
+	if err := h.ReceiveBatch(c); err != nil {
+		t.Fatalf("handler error: %v", err)
+	}
+	if rec.Code != http.StatusMultiStatus {
+		t.Fatalf("expected 207, got %d", rec.Code)
+	}
+	var resp multiStatusResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+
+	want := []string{"applied", "not_found", "invalid", "invalid_transition", "duplicate"}
+	if len(resp.Results) != len(want) {
+		t.Fatalf("expected %d results, got %+v", len(want), resp.Results)
+	}
+	for i, outcome := range want {
+		if r := resp.Results[i]; r.Index != i || r.Outcome != outcome {
+			t.Errorf("result %d = %+v, want outcome %s", i, r, outcome)
+		}
+	}
+	if resp.Results[2].Error == "" {
+		t.Error("expected a validation message for the invalid item")
+	}
+	if len(svc.applied) != 4 {
+		t.Errorf("expected the 4 valid events to be applied, got %d", len(svc.applied))
+	}
+	if resp.Total != 5 || resp.Counts["applied"] != 1 || resp.Counts["invalid"] != 1 {
+		t.Errorf("unexpected summary: total=%d counts=%v", resp.Total, resp.Counts)
+	}
+}
+
+func TestEventHandler_ReceiveBatch_PreferRepresentation(t *testing.T) {
+	svc := &stubIngestService{outcomes: []ports.AppliedEvent{{State: domain.EventProcessed}}}
+	h := NewEventHandler(svc, time.Second, 100)
+	c, rec := newEventRequest(http.MethodPost, "["+validEventBody+"]")
+	c.Request().Header.Set("Prefer", "respond-async, return=representation")
+
+	if err := h.ReceiveBatch(c); err != nil {
+		t.Fatalf("handler error: %v", err)
+	}
+	if rec.Code != http.StatusMultiStatus {
+		t.Fatalf("expected 207, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("Preference-Applied"); got != "return=representation" {
+		t.Errorf("unexpected Preference-Applied header %q", got)
+	}
+	if len(svc.ingested) != 0 {
+		t.Error("sync mode must not enqueue events")
+	}
+}
+
+func TestEventHandler_ReceiveBatch_SyncModeTooLarge(t *testing.T) {
+	svc := &stubIngestService{}
+	h := NewEventHandler(svc, time.Second, 2)
+	body := "[" + strings.Join([]string{validEventBody, validEventBody, validEventBody}, ",") + "]"
+	c, _ := newEventRequest(http.MethodPost, body)
+	c.QueryParams().Set("mode", "sync")
+
+	err := h.ReceiveBatch(c)
+	he, ok := err.(*echo.HTTPError)
+	if !ok || he.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413 HTTPError, got %v", err)
+	}
+	if len(svc.applied) != 0 {
+		t.Error("an oversized sync batch must not be applied")
+	}
+}
+
+func TestEventHandler_ReceiveBatch_InvalidMode(t *testing.T) {
+	h := NewEventHandler(&stubIngestService{}, time.Second, 100)
+	c, _ := newEventRequest(http.MethodPost, "["+validEventBody+"]")
+	c.QueryParams().Set("mode", "later")
+
+	err := h.ReceiveBatch(c)
+	he, ok := err.(*echo.HTTPError)
+	if !ok || he.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 HTTPError, got %v", err)
+	}
+}
+
+func TestEventHandler_Get(t *testing.T) {
+	svc := &stubIngestService{item: &ports.EventStatusItem{
+		ID:     "evt_1",
+		State:  string(domain.EventRejected),
+		Reason: "invalid state transition",
+	}}
+	h := NewEventHandler(svc, time.Second, 100)
+	c, rec := newEventRequest(http.MethodGet, "")
+	c.SetParamNames("id")
+	c.SetParamValues("evt_1")
+
+	if err := h.Get(c); err != nil {
+		t.Fatalf("handler error: %v", err)
+	}
+	var body eventStatusResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if body.State != "rejected" || body.Reason == "" {
+		t.Errorf("unexpected body: %+v", body)
+	}
+	if svc.lookup.ID != "evt_1" || svc.lookup.ClientID != "client_1" {
+		t.Errorf("unexpected lookup: %+v", svc.lookup)
+	}
+}
+
+func TestEventHandler_Get_NotFound(t *testing.T) {
+	h := NewEventHandler(&stubIngestService{}, time.Second, 100)
+	c, _ := newEventRequest(http.MethodGet, "")
+	c.SetParamNames("id")
+	c.SetParamValues("evt_missing")
+
+	if err := h.Get(c); !errors.Is(err, domain.ErrEventNotFound) {
+		t.Fatalf("expected ErrEventNotFound, got %v", err)
+	}
+}
+
+func TestEventHandler_Receive_ReasonCode(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"failed attempt with reason", `{"tracking_number":"99M-AABBCCDD","status":"delivery_attempt_failed","reason_code":"refused","timestamp":"2026-02-19T10:00:00Z","source":"driver_app"}`, http.StatusAccepted},
+		{"failed attempt without reason", `{"tracking_number":"99M-AABBCCDD","status":"delivery_attempt_failed","timestamp":"2026-02-19T10:00:00Z","source":"driver_app"}`, http.StatusUnprocessableEntity},
+		{"unknown reason", `{"tracking_number":"99M-AABBCCDD","status":"delivery_attempt_failed","reason_code":"dog","timestamp":"2026-02-19T10:00:00Z","source":"driver_app"}`, http.StatusUnprocessableEntity},
+		{"reason on another status", `{"tracking_number":"99M-AABBCCDD","status":"picked_up","reason_code":"refused","timestamp":"2026-02-19T10:00:00Z","source":"driver_app"}`, http.StatusUnprocessableEntity},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := &stubIngestService{}
+			c, rec := newEventRequest(http.MethodPost, tc.body)
+
+			err := NewEventHandler(svc, time.Second, 100).Receive(c)
+			code := rec.Code
+			if err != nil {
+				he, ok := err.(*echo.HTTPError)
+				if !ok {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				code = he.Code
+			}
+			if code != tc.want {
+				t.Fatalf("expected %d, got %d", tc.want, code)
+			}
+			if tc.want == http.StatusAccepted && svc.ingested[0].Events[0].ReasonCode != "refused" {
+				t.Errorf("reason code not passed on: %+v", svc.ingested[0].Events[0])
+			}
+		})
+	}
+}
+
+func TestEventHandler_Receive_MistypedTrackingNumber(t *testing.T) {
+	gen, _ := domain.NewTrackingNumbers(domain.DefaultTrackingPrefix, nil)
+	tn := gen.Generate("client_1")
+	typo := []byte(tn)
+	if typo[5] == 'A' {
+		typo[5] = 'B'
+	} else {
+		typo[5] = 'A'
+	}
+
+	for _, tc := range []struct {
+		trackingNumber string
+		want           int
+	}{
+		{tn, http.StatusAccepted},
+		{string(typo), http.StatusUnprocessableEntity},
+	} {
+		svc := &stubIngestService{}
+		body := strings.Replace(validEventBody, "99M-AABBCCDD", tc.trackingNumber, 1)
+		c, rec := newEventRequest(http.MethodPost, body)
+
+		err := NewEventHandler(svc, time.Second, 100).Receive(c)
+		code := rec.Code
+		if he, ok := err.(*echo.HTTPError); ok {
+			code = he.Code
+		} else if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if code != tc.want {
+			t.Errorf("%s: expected %d, got %d", tc.trackingNumber, tc.want, code)
+		}
+	}
+}
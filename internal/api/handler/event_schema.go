@@ -8,14 +8,66 @@ type locationRequest struct {
 }
 
 type trackingEventRequest struct {
-	TrackingNumber string           `json:"tracking_number" validate:"required"`
-	Status         string           `json:"status"          validate:"required,oneof=picked_up in_warehouse in_transit delivered cancelled"`
-	Timestamp      time.Time        `json:"timestamp"       validate:"required"`
-	Source         string           `json:"source"          validate:"required"`
-	Location       *locationRequest `json:"location"`
+	TrackingNumber string    `json:"tracking_number" validate:"required,tracking_number"`
+	Status         string    `json:"status"          validate:"required,shipment_status"`
+	Timestamp      time.Time `json:"timestamp"       validate:"required"`
+	Source         string    `json:"source"          validate:"required"`
+	// ReasonCode is required on delivery_attempt_failed events and not allowed on others.
+	ReasonCode string `json:"reason_code,omitempty" validate:"required_if=Status delivery_attempt_failed,excluded_unless=Status delivery_attempt_failed,omitempty,delivery_failure_reason"`
+	// Piece is the barcode of the piece scanned on a multi-piece shipment;
+	// omit it to move the whole shipment.
+	Piece    string           `json:"piece,omitempty" validate:"omitempty,max=64"`
+	Location *locationRequest `json:"location"`
 }
 
 type acceptedResponse struct {
-	Message string `json:"message"`
-	Count   int    `json:"count,omitempty"`
+	Message  string   `json:"message"`
+	EventID  string   `json:"event_id,omitempty"`
+	Count    int      `json:"count,omitempty"`
+	BatchID  string   `json:"batch_id,omitempty"`
+	EventIDs []string `json:"event_ids,omitempty"`
+}
+
+type eventStatusResponse struct {
+	EventID        string    `json:"event_id"`
+	BatchID        string    `json:"batch_id,omitempty"`
+	TrackingNumber string    `json:"tracking_number"`
+	Status         string    `json:"status"`
+	State          string    `json:"state" enums:"queued,processed,late,duplicate,rejected,failed"`
+	Reason         string    `json:"reason,omitempty"`
+	AcceptedAt     time.Time `json:"accepted_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+type eventBatchStatusResponse struct {
+	BatchID string                `json:"batch_id"`
+	Total   int                   `json:"total"`
+	Counts  map[string]int        `json:"counts"`
+	Items   []eventStatusResponse `json:"items"`
+}
+
+// Outcomes of events applied inline in sync mode.
+const (
+	outcomeApplied           = "applied"
+	outcomeLate              = "late"
+	outcomeDuplicate         = "duplicate"
+	outcomeInvalidTransition = "invalid_transition"
+	outcomeCancelled         = "cancelled"
+	outcomeNotFound          = "not_found"
+	outcomeInvalid           = "invalid"
+	outcomeError             = "error"
+)
+
+type syncEventResult struct {
+	Index          int    `json:"index"`
+	TrackingNumber string `json:"tracking_number"`
+	Status         string `json:"status"`
+	Outcome        string `json:"outcome" enums:"applied,late,duplicate,invalid_transition,cancelled,not_found,invalid,error"`
+	Error          string `json:"error,omitempty"`
+}
+
+type multiStatusResponse struct {
+	Total   int               `json:"total"`
+	Counts  map[string]int    `json:"counts"`
+	Results []syncEventResult `json:"results"`
 }
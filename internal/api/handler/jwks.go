@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/99minutos/shipping-system/internal/pkg/jwtkeys"
+)
+
+// JWKSHandler handles GET /.well-known/jwks.json — publishes the public keys
+// access tokens are verified with, so other services can verify them without
+// the signing keys.
+type JWKSHandler struct {
+	keys *jwtkeys.KeySet
+}
+
+func NewJWKSHandler(keys *jwtkeys.KeySet) *JWKSHandler {
+	return &JWKSHandler{keys: keys}
+}
+
+// Get returns the JSON Web Key Set.
+//
+// @Summary      JSON Web Key Set
+// @Description  Public keys access tokens are signed with, by kid. Keys being rotated in or out are listed too; cache the set for a few minutes and fetch it again on an unknown kid. Empty when tokens are signed with a shared secret.
+// @Tags         auth
+// @Produce      json
+// @Success      200  {object}  jwtkeys.JWKS
+// @Router       /.well-known/jwks.json [get]
+func (h *JWKSHandler) Get(c echo.Context) error {
+	c.Response().Header().Set("Cache-Control", "public, max-age=300")
+	return c.JSON(http.StatusOK, h.keys.JWKS())
+}
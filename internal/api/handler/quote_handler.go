@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/99minutos/shipping-system/internal/core/domain"
+	"github.com/99minutos/shipping-system/internal/core/ports"
+)
+
+// QuoteHandler handles HTTP requests for shipment quotes.
+type QuoteHandler struct {
+	service ports.PricingService
+}
+
+// NewQuoteHandler returns a quote handler. A nil service answers every quote
+// with 503, for deployments without rate cards.
+func NewQuoteHandler(service ports.PricingService) *QuoteHandler {
+	return &QuoteHandler{service: service}
+}
+
+// Create handles POST /v1/quotes.
+//
+// @Summary      Quote a shipment
+// @Description  Prices a shipment with the rate card in effect, without creating it. Each box is billed on the greater of its actual and volumetric weight; the destination zip code picks the zone and the coordinates give the distance. Insurance is added on declared_value when insured is true.
+// @Tags         quotes
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        body  body      quoteRequest   true  "Shipment to price"
+// @Success      200   {object}  priceResponse
+// @Failure      400   {object}  errorResponse
+// @Failure      401   {object}  errorResponse
+// @Failure      422   {object}  errorResponse
+// @Failure      503   {object}  errorResponse
+// @Router       /v1/quotes [post]
+func (h *QuoteHandler) Create(c echo.Context) error {
+	var req quoteRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid payload")
+	}
+	if err := c.Validate(&req); err != nil {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+	}
+	if h.service == nil {
+		return domain.ErrNoRateCard
+	}
+
+	quote, err := h.service.Quote(c.Request().Context(), toQuoteInput(req))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, toPriceResponse(quote))
+}
+
+func toQuoteInput(req quoteRequest) ports.QuoteInput {
+	in := ports.QuoteInput{
+		ServiceType: req.ServiceType,
+		Origin:      toQuoteAddressInput(req.Origin),
+		Destination: toQuoteAddressInput(req.Destination),
+		Package: ports.PackageInput{
+			DeclaredValue: req.DeclaredValue,
+			Currency:      req.Currency,
+		},
+		Pieces:  toPieceInputs(req.Pieces),
+		Insured: req.Insured,
+	}
+	if p := req.Package; p != nil {
+		in.Package.WeightKg = p.WeightKg
+		in.Package.Dimensions = ports.DimensionsInput{
+			LengthCm: p.Dimensions.LengthCm,
+			WidthCm:  p.Dimensions.WidthCm,
+			HeightCm: p.Dimensions.HeightCm,
+		}
+	}
+	return in
+}
+
+func toQuoteAddressInput(a quoteAddressRequest) ports.AddressInput {
+	return ports.AddressInput{
+		ZipCode: a.ZipCode,
+		Coordinates: ports.CoordinatesInput{
+			Lat: a.Coordinates.Lat,
+			Lng: a.Coordinates.Lng,
+		},
+	}
+}
+
+func toPriceResponse(q *ports.Quote) *priceResponse {
+	if q == nil {
+		return nil
+	}
+	return &priceResponse{
+		RateCardVersion:    q.RateCardVersion,
+		Currency:           q.Currency,
+		ServiceType:        q.ServiceType,
+		Zone:               q.Zone,
+		DistanceKm:         q.DistanceKm,
+		ActualWeightKg:     q.ActualWeightKg,
+		VolumetricWeightKg: q.VolumetricWeightKg,
+		BillableWeightKg:   q.BillableWeightKg,
+		Base:               q.Base,
+		WeightCharge:       q.WeightCharge,
+		DistanceCharge:     q.DistanceCharge,
+		ZoneSurcharge:      q.ZoneSurcharge,
+		MinimumAdjustment:  q.MinimumAdjustment,
+		Insured:            q.Insured,
+		Insurance:          q.Insurance,
+		Total:              q.Total,
+	}
+}
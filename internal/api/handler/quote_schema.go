@@ -0,0 +1,41 @@
+package handler
+
+// quoteAddressRequest is where a quoted shipment ships from or to: the zip
+// code picks the zone and the coordinates give the distance.
+type quoteAddressRequest struct {
+	ZipCode     string             `json:"zip_code"    validate:"required"`
+	Coordinates coordinatesRequest `json:"coordinates" validate:"required"`
+}
+
+// quoteRequest is priced like a shipment created with the same fields.
+// declared_value and currency are only needed to insure it.
+type quoteRequest struct {
+	ServiceType   string              `json:"service_type"   validate:"required,oneof=same_day next_day standard"`
+	Origin        quoteAddressRequest `json:"origin"         validate:"required"`
+	Destination   quoteAddressRequest `json:"destination"    validate:"required"`
+	Package       *pieceRequest       `json:"package"        validate:"required_without=Pieces"`
+	Pieces        []pieceRequest      `json:"pieces"         validate:"omitempty,max=99,dive"`
+	Insured       bool                `json:"insured"`
+	DeclaredValue float64             `json:"declared_value" validate:"required_if=Insured true,omitempty,gt=0"`
+	Currency      string              `json:"currency"       validate:"required_if=Insured true"`
+}
+
+// priceResponse is the price breakdown of a quote or of a shipment.
+type priceResponse struct {
+	RateCardVersion    string  `json:"rate_card_version"`
+	Currency           string  `json:"currency"`
+	ServiceType        string  `json:"service_type"`
+	Zone               string  `json:"zone"`
+	DistanceKm         float64 `json:"distance_km"`
+	ActualWeightKg     float64 `json:"actual_weight_kg"`
+	VolumetricWeightKg float64 `json:"volumetric_weight_kg"`
+	BillableWeightKg   float64 `json:"billable_weight_kg"`
+	Base               float64 `json:"base"`
+	WeightCharge       float64 `json:"weight_charge"`
+	DistanceCharge     float64 `json:"distance_charge"`
+	ZoneSurcharge      float64 `json:"zone_surcharge"`
+	MinimumAdjustment  float64 `json:"minimum_adjustment"`
+	Insured            bool    `json:"insured"`
+	Insurance          float64 `json:"insurance"`
+	Total              float64 `json:"total"`
+}
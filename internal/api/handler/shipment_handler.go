@@ -1,22 +1,33 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/labstack/echo/v4"
 
+	"github.com/99minutos/shipping-system/internal/core/domain"
 	"github.com/99minutos/shipping-system/internal/core/ports"
 )
 
 // ShipmentHandler handles HTTP requests for shipment operations.
 type ShipmentHandler struct {
-	service ports.ShipmentService
+	service  ports.ShipmentService
+	maxBatch int // most shipments accepted by CreateBatch
+
+	// known holds the statuses List filters on: those of the lifecycles and
+	// the derived partially_delivered.
+	known []domain.ShipmentStatus
 }
 
-func NewShipmentHandler(service ports.ShipmentService) *ShipmentHandler {
-	return &ShipmentHandler{service: service}
+func NewShipmentHandler(service ports.ShipmentService, maxBatch int, lifecycles *domain.Lifecycles) *ShipmentHandler {
+	known := append(lifecycles.States(), domain.StatusPartiallyDelivered)
+	return &ShipmentHandler{service: service, maxBatch: maxBatch, known: known}
 }
 
 // List handles GET /v1/shipments.
@@ -25,9 +36,10 @@ func NewShipmentHandler(service ports.ShipmentService) *ShipmentHandler {
 // @Tags         shipments
 // @Produce      json
 // @Security     BearerAuth
-// @Param        status        query     string  false  "Filter by status (created, picked_up, in_warehouse, in_transit, delivered, cancelled)"
+// @Param        status        query     string  false  "Filter by status; comma-separated for several (e.g. lost,damaged)"
+// @Param        min_attempts  query     int     false  "Shipments with at least this many failed delivery attempts"
 // @Param        service_type  query     string  false  "Filter by service type (same_day, next_day, standard)"
-// @Param        search        query     string  false  "Partial match on tracking_number or sender name"
+// @Param        search        query     string  false  "Partial match on tracking_number, sender name or recipient name"
 // @Param        date_from     query     string  false  "Created at >= date (YYYY-MM-DD)"
 // @Param        date_to       query     string  false  "Created at <= date (YYYY-MM-DD)"
 // @Param        page          query     int     false  "Page number (default 1)"
@@ -54,11 +66,20 @@ func (h *ShipmentHandler) List(c echo.Context) error {
 	if err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, "date_to must be YYYY-MM-DD")
 	}
+	statuses, err := parseStatuses(c.QueryParam("status"), h.known)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	minAttempts, err := strconv.Atoi(c.QueryParam("min_attempts"))
+	if c.QueryParam("min_attempts") != "" && (err != nil || minAttempts < 0) {
+		return echo.NewHTTPError(http.StatusBadRequest, "min_attempts must be a non-negative integer")
+	}
 
 	result, err := h.service.ListShipments(c.Request().Context(), ports.ListShipmentsInput{
 		Role:        role,
 		ClientID:    clientID,
-		Status:      c.QueryParam("status"),
+		Statuses:    statuses,
+		MinAttempts: minAttempts,
 		ServiceType: c.QueryParam("service_type"),
 		Search:      c.QueryParam("search"),
 		DateFrom:    dateFrom,
@@ -73,6 +94,22 @@ func (h *ShipmentHandler) List(c echo.Context) error {
 	return c.JSON(http.StatusOK, toListResponse(result))
 }
 
+// parseStatuses splits an optional comma-separated status filter, rejecting
+// statuses not in known.
+func parseStatuses(s string, known []domain.ShipmentStatus) ([]string, error) {
+	if s == "" {
+		return nil, nil
+	}
+	statuses := strings.Split(s, ",")
+	for i, status := range statuses {
+		statuses[i] = strings.TrimSpace(status)
+		if !slices.Contains(known, domain.ShipmentStatus(statuses[i])) {
+			return nil, fmt.Errorf("unknown status %q", statuses[i])
+		}
+	}
+	return statuses, nil
+}
+
 // parseDate parses an optional YYYY-MM-DD query param into time.Time (zero if empty).
 func parseDate(s string) (time.Time, error) {
 	if s == "" {
@@ -85,8 +122,9 @@ func parseDate(s string) (time.Time, error) {
 // @Tags         shipments
 // @Produce      json
 // @Security     BearerAuth
-// @Param        tracking_number  path      string  true  "Tracking number (e.g. 99M-7A8B9C2D)"
+// @Param        tracking_number  path      string  true  "Tracking number (e.g. 99M-K7Q2M9XC4TV)"
 // @Success      200              {object}  getShipmentResponse
+// @Failure      400              {object}  errorResponse
 // @Failure      403              {object}  errorResponse
 // @Failure      404              {object}  errorResponse
 // @Failure      500              {object}  errorResponse
@@ -96,9 +134,13 @@ func (h *ShipmentHandler) Get(c echo.Context) error {
 	if err != nil {
 		return err
 	}
+	trackingNumber, err := trackingNumberParam(c)
+	if err != nil {
+		return err
+	}
 
 	detail, err := h.service.GetShipment(c.Request().Context(), ports.GetShipmentInput{
-		TrackingNumber: c.Param("tracking_number"),
+		TrackingNumber: trackingNumber,
 		Role:           role,
 		ClientID:       clientID,
 	})
@@ -148,3 +190,191 @@ func (h *ShipmentHandler) Create(c echo.Context) error {
 
 	return c.JSON(http.StatusCreated, toCreateResponse(result))
 }
+
+// CreateBatch handles POST /v1/shipments/batch — creates up to maxBatch
+// shipments in one request and answers 207 with the outcome of each, in
+// request order. Invalid items are reported without stopping the valid ones.
+//
+// @Summary      Create a batch of shipments
+// @Description  Each item is a shipment as accepted by POST /v1/shipments plus its own idempotency_key; an item whose key was already used returns the shipment created with it (outcome existing). Items failing validation are reported as invalid and items the rate card does not cover as rejected; the rest are inserted together.
+// @Tags         shipments
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        body  body      []batchShipmentRequest  true  "Shipments to create"
+// @Success      207   {object}  batchShipmentsResponse
+// @Failure      400   {object}  errorResponse
+// @Failure      401   {object}  errorResponse
+// @Failure      413   {object}  errorResponse
+// @Router       /v1/shipments/batch [post]
+func (h *ShipmentHandler) CreateBatch(c echo.Context) error {
+	_, clientID, err := ctxClaims(c)
+	if err != nil {
+		return err
+	}
+
+	var reqs []batchShipmentRequest
+	if err := c.Bind(&reqs); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid payload")
+	}
+	if len(reqs) == 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "batch cannot be empty")
+	}
+	if len(reqs) > h.maxBatch {
+		return echo.NewHTTPError(http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("batch cannot hold more than %d shipments", h.maxBatch))
+	}
+
+	resp := batchShipmentsResponse{
+		Total:   len(reqs),
+		Counts:  make(map[string]int),
+		Results: make([]batchShipmentResult, len(reqs)),
+	}
+	var (
+		inputs  []ports.CreateShipmentInput
+		indexes []int
+	)
+	for i, req := range reqs {
+		resp.Results[i] = batchShipmentResult{Index: i, IdempotencyKey: req.IdempotencyKey}
+		if err := c.Validate(&req); err != nil {
+			resp.Results[i].Outcome = outcomeInvalid
+			resp.Results[i].Error = err.Error()
+			continue
+		}
+		inputs = append(inputs, toCreateInput(req.createShipmentRequest, clientID, req.IdempotencyKey))
+		indexes = append(indexes, i)
+	}
+
+	if len(inputs) > 0 {
+		for j, created := range h.service.CreateShipments(c.Request().Context(), inputs) {
+			result := &resp.Results[indexes[j]]
+			result.Outcome, result.Error = batchShipmentOutcome(created)
+			if created.Shipment != nil {
+				shipment := toCreateResponse(created.Shipment)
+				result.Shipment = &shipment
+			}
+		}
+	}
+	for _, r := range resp.Results {
+		resp.Counts[r.Outcome]++
+	}
+
+	return c.JSON(http.StatusMultiStatus, resp)
+}
+
+// batchShipmentOutcome maps the result of one shipment of a batch to its
+// outcome and, for failures, the message reported for it.
+func batchShipmentOutcome(r ports.BatchShipmentResult) (outcome, message string) {
+	switch {
+	case r.Err == nil && r.Shipment.AlreadyExisted:
+		return outcomeExisting, ""
+	case r.Err == nil:
+		return outcomeCreated, ""
+	case errors.Is(r.Err, domain.ErrZoneNotServed),
+		errors.Is(r.Err, domain.ErrServiceNotPriced),
+		errors.Is(r.Err, domain.ErrCurrencyMismatch),
+		errors.Is(r.Err, domain.ErrNoRateCard):
+		return outcomeRejected, r.Err.Error()
+	default:
+		return outcomeError, "internal server error"
+	}
+}
+
+// Amend handles PATCH /v1/shipments/:tracking_number.
+//
+// @Summary      Amend a shipment before pickup
+// @Description  Corrects the destination, sender contact, package description or service type while the shipment is still in its initial status. Omitted fields are left unchanged. Each amendment is recorded as a new version with the previous and new values; changing the service type or destination zip code recalculates estimated_delivery, and changing the destination zip code, coordinates or service type prices the shipment again.
+// @Tags         shipments
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        tracking_number  path      string                true  "Tracking number (e.g. 99M-K7Q2M9XC4TV)"
+// @Param        body             body      amendShipmentRequest  true  "Fields to correct"
+// @Success      200              {object}  getShipmentResponse
+// @Failure      400              {object}  errorResponse
+// @Failure      401              {object}  errorResponse
+// @Failure      404              {object}  errorResponse
+// @Failure      409              {object}  errorResponse
+// @Failure      422              {object}  errorResponse
+// @Failure      500              {object}  errorResponse
+// @Router       /v1/shipments/{tracking_number} [patch]
+func (h *ShipmentHandler) Amend(c echo.Context) error {
+	role, clientID, err := ctxClaims(c)
+	if err != nil {
+		return err
+	}
+	trackingNumber, err := trackingNumberParam(c)
+	if err != nil {
+		return err
+	}
+
+	var req amendShipmentRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid payload")
+	}
+	if err := c.Validate(&req); err != nil {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+	}
+
+	in := toAmendInput(req)
+	in.TrackingNumber = trackingNumber
+	in.Role, in.ClientID = role, clientID
+	in.Actor, _ = c.Get("username").(string)
+	detail, err := h.service.AmendShipment(c.Request().Context(), in)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, toGetResponse(detail))
+}
+
+// Cancel handles POST /v1/shipments/:tracking_number/cancel.
+//
+// @Summary      Cancel a shipment
+// @Description  Clients can cancel their own shipments and admins any shipment, while its lifecycle allows a transition to cancelled. The cancellation is recorded in status_history with the user and reason; later carrier events are rejected as cancelled.
+// @Tags         shipments
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        tracking_number  path      string                 true  "Tracking number (e.g. 99M-K7Q2M9XC4TV)"
+// @Param        body             body      cancelShipmentRequest  true  "Cancellation reason"
+// @Success      200              {object}  cancelShipmentResponse
+// @Failure      400              {object}  errorResponse
+// @Failure      401              {object}  errorResponse
+// @Failure      404              {object}  errorResponse
+// @Failure      409              {object}  errorResponse
+// @Failure      422              {object}  errorResponse
+// @Failure      500              {object}  errorResponse
+// @Router       /v1/shipments/{tracking_number}/cancel [post]
+func (h *ShipmentHandler) Cancel(c echo.Context) error {
+	role, clientID, err := ctxClaims(c)
+	if err != nil {
+		return err
+	}
+	trackingNumber, err := trackingNumberParam(c)
+	if err != nil {
+		return err
+	}
+
+	var req cancelShipmentRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid payload")
+	}
+	if err := c.Validate(&req); err != nil {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+	}
+
+	actor, _ := c.Get("username").(string)
+	result, err := h.service.CancelShipment(c.Request().Context(), ports.CancelShipmentInput{
+		TrackingNumber: trackingNumber,
+		Role:           role,
+		ClientID:       clientID,
+		Actor:          actor,
+		Reason:         req.Reason,
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, toCancelResponse(result))
+}
@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/99minutos/shipping-system/internal/core/domain"
+	"github.com/99minutos/shipping-system/internal/core/ports"
+)
+
+// stubShipmentService implements the batch create; other methods are not
+// called by these tests.
+type stubShipmentService struct {
+	ports.ShipmentService
+	inputs  []ports.CreateShipmentInput
+	results []ports.BatchShipmentResult
+}
+
+func (s *stubShipmentService) CreateShipments(_ context.Context, inputs []ports.CreateShipmentInput) []ports.BatchShipmentResult {
+	s.inputs = inputs
+	return s.results[:len(inputs)]
+}
+
+const batchShipmentItem = `{"idempotency_key":%q,"service_type":"next_day",
+	"sender":{"name":"Pedro","email":"pedro@example.com","phone":"+52"},
+	"recipient":{"name":"Ana","phone":"+52"},
+	"origin":{"address":"Av 1","city":"CDMX","zip_code":"06600","coordinates":{"lat":19.4326,"lng":-99.1332}},
+	"destination":{"address":"Calle 2","city":"Puebla","zip_code":"72000","coordinates":{"lat":19.0414,"lng":-98.2063}},
+	"package":{"weight_kg":2.5,"dimensions":{"length_cm":10,"width_cm":10,"height_cm":10},"description":"test","declared_value":100,"currency":"MXN"}}`
+
+func newBatchRequest(body string) (echo.Context, *httptest.ResponseRecorder) {
+	e := echo.New()
+	e.Validator = NewValidator(domain.BuiltinLifecycles())
+	req := httptest.NewRequest(http.MethodPost, "/v1/shipments/batch", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set("role", domain.RoleClient)
+	c.Set("client_id", "client_1")
+	return c, rec
+}
+
+func TestShipmentHandler_CreateBatch(t *testing.T) {
+	svc := &stubShipmentService{results: []ports.BatchShipmentResult{
+		{Shipment: &ports.ShipmentResult{TrackingNumber: "99M-AABBCCDD", Status: "created"}},
+		{Shipment: &ports.ShipmentResult{TrackingNumber: "99M-AABBCCEE", Status: "created", AlreadyExisted: true}},
+		{Err: domain.ErrZoneNotServed},
+		{Err: errors.New("connection reset")},
+	}}
+	items := []string{
+		fmt.Sprintf(batchShipmentItem, "order-1"),
+		fmt.Sprintf(batchShipmentItem, "order-2"),
+		fmt.Sprintf(batchShipmentItem, ""),
+		fmt.Sprintf(batchShipmentItem, "order-4"),
+		fmt.Sprintf(batchShipmentItem, "order-5"),
+	}
+	c, rec := newBatchRequest("[" + strings.Join(items, ",") + "]")
+
+	if err := NewShipmentHandler(svc, 10, domain.BuiltinLifecycles()).CreateBatch(c); err != nil {
+		t.Fatalf("handler error: %v", err)
+	}
+	if rec.Code != http.StatusMultiStatus {
+		t.Fatalf("expected 207, got %d", rec.Code)
+	}
+	var body batchShipmentsResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+
+	want := []string{outcomeCreated, outcomeExisting, outcomeInvalid, outcomeRejected, outcomeError}
+	if len(body.Results) != len(want) {
+		t.Fatalf("expected %d results, got %+v", len(want), body.Results)
+	}
+	for i, outcome := range want {
+		if r := body.Results[i]; r.Index != i || r.Outcome != outcome {
+			t.Errorf("result %d: expected %s, got %+v", i, outcome, r)
+		}
+	}
+	if s := body.Results[0].Shipment; s == nil || s.TrackingNumber != "99M-AABBCCDD" {
+		t.Errorf("created item must carry its shipment: %+v", body.Results[0])
+	}
+	if body.Results[4].Error != "internal server error" {
+		t.Errorf("unexpected errors must not leak: %q", body.Results[4].Error)
+	}
+	if body.Total != 5 || body.Counts[outcomeInvalid] != 1 {
+		t.Errorf("unexpected totals: %+v", body)
+	}
+	if len(svc.inputs) != 4 || svc.inputs[2].IdempotencyKey != "order-4" || svc.inputs[0].ClientID != "client_1" {
+		t.Errorf("only valid items must reach the service, under the caller's client: %+v", svc.inputs)
+	}
+}
+
+func TestShipmentHandler_CreateBatch_Size(t *testing.T) {
+	item := fmt.Sprintf(batchShipmentItem, "order-1")
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"empty", `[]`, http.StatusBadRequest},
+		{"too many", "[" + strings.Repeat(item+",", 2) + item + "]", http.StatusRequestEntityTooLarge},
+		{"not an array", `{}`, http.StatusBadRequest},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c, _ := newBatchRequest(tc.body)
+			err := NewShipmentHandler(&stubShipmentService{}, 2, domain.BuiltinLifecycles()).CreateBatch(c)
+			he, ok := err.(*echo.HTTPError)
+			if !ok || he.Code != tc.want {
+				t.Fatalf("expected %d HTTPError, got %v", tc.want, err)
+			}
+		})
+	}
+}
+
+func TestShipmentHandler_CreateBatch_RecipientOptional(t *testing.T) {
+	item := fmt.Sprintf(batchShipmentItem, "order-1")
+	without := strings.Replace(item, `"recipient":{"name":"Ana","phone":"+52"},`, "", 1)
+	incomplete := strings.Replace(fmt.Sprintf(batchShipmentItem, "order-2"), `"phone":"+52"},`+"\n\t\"origin\"", `"phone":""},`+"\n\t\"origin\"", 1)
+	svc := &stubShipmentService{results: []ports.BatchShipmentResult{
+		{Shipment: &ports.ShipmentResult{TrackingNumber: "99M-AABBCCDD", Status: "created"}},
+	}}
+	c, rec := newBatchRequest("[" + without + "," + incomplete + "]")
+
+	if err := NewShipmentHandler(svc, 10, domain.BuiltinLifecycles()).CreateBatch(c); err != nil {
+		t.Fatalf("handler error: %v", err)
+	}
+	var body batchShipmentsResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	// A shipment without recipient is created; a recipient sent without phone
+	// is still invalid.
+	if body.Results[0].Outcome != outcomeCreated || body.Results[1].Outcome != outcomeInvalid {
+		t.Fatalf("unexpected results: %+v", body.Results)
+	}
+	if len(svc.inputs) != 1 || svc.inputs[0].Recipient.Name != "" {
+		t.Errorf("expected an empty recipient, got %+v", svc.inputs)
+	}
+}
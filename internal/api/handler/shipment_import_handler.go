@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/99minutos/shipping-system/internal/core/ports"
+	"github.com/99minutos/shipping-system/internal/pkg/sheet"
+)
+
+// maxImportFileSize bounds the size of an uploaded import file.
+const maxImportFileSize = 10 << 20
+
+// ShipmentImportHandler handles HTTP requests for shipment imports.
+type ShipmentImportHandler struct {
+	service ports.ShipmentImportService
+	maxRows int // most rows accepted in a file
+}
+
+func NewShipmentImportHandler(service ports.ShipmentImportService, maxRows int) *ShipmentImportHandler {
+	return &ShipmentImportHandler{service: service, maxRows: maxRows}
+}
+
+// Create handles POST /v1/shipments/imports.
+//
+// @Summary      Import shipments from a CSV or XLSX file
+// @Description  Queues a background import of the file's rows, one single-piece shipment per row, with the documented column mapping; progress is read at the Location returned. The file is checked for the required columns before it is accepted; rows are validated when imported and reported by row number. Uploading the same file again returns its existing import with 200.
+// @Tags         shipments
+// @Accept       multipart/form-data
+// @Produce      json
+// @Security     BearerAuth
+// @Param        file  formData  file  true  "CSV (comma or semicolon separated) or XLSX file; the first row holds the column names"
+// @Success      202   {object}  shipmentImportResponse
+// @Success      200   {object}  shipmentImportResponse
+// @Header       202   {string}  Location  "URL of the import"
+// @Failure      400   {object}  errorResponse
+// @Failure      401   {object}  errorResponse
+// @Failure      413   {object}  errorResponse
+// @Failure      415   {object}  errorResponse
+// @Failure      422   {object}  errorResponse
+// @Router       /v1/shipments/imports [post]
+func (h *ShipmentImportHandler) Create(c echo.Context) error {
+	_, clientID, err := ctxClaims(c)
+	if err != nil {
+		return err
+	}
+
+	fh, err := c.FormFile("file")
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "file is required")
+	}
+	if fh.Size > maxImportFileSize {
+		return echo.NewHTTPError(http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("file cannot be larger than %d MB", maxImportFileSize>>20))
+	}
+	f, err := fh.Open()
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, maxImportFileSize))
+	if err != nil {
+		return err
+	}
+
+	cells, err := sheet.Read(fh.Filename, data)
+	if errors.Is(err, sheet.ErrUnsupported) {
+		return echo.NewHTTPError(http.StatusUnsupportedMediaType, "file must be .csv or .xlsx")
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+	}
+	rows, err := importRows(cells)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+	}
+	if len(rows) > h.maxRows {
+		return echo.NewHTTPError(http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("file cannot hold more than %d rows", h.maxRows))
+	}
+
+	sum := sha256.Sum256(data)
+	view, err := h.service.StartImport(c.Request().Context(), ports.StartImportInput{
+		ClientID: clientID,
+		FileName: fh.Filename,
+		FileHash: hex.EncodeToString(sum[:]),
+		Rows:     rows,
+	})
+	if err != nil {
+		return err
+	}
+
+	resp := toImportResponse(view)
+	c.Response().Header().Set(echo.HeaderLocation, resp.Links.Self)
+	if view.AlreadyExisted {
+		return c.JSON(http.StatusOK, resp)
+	}
+	return c.JSON(http.StatusAccepted, resp)
+}
+
+// Get handles GET /v1/shipments/imports/:id.
+//
+// @Summary      Get the progress of a shipment import
+// @Description  Lists the tracking number created by each row imported so far and the rows that failed. Clients only see their own imports.
+// @Tags         shipments
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Import ID"
+// @Success      200  {object}  shipmentImportResponse
+// @Failure      401  {object}  errorResponse
+// @Failure      404  {object}  errorResponse
+// @Router       /v1/shipments/imports/{id} [get]
+func (h *ShipmentImportHandler) Get(c echo.Context) error {
+	view, err := h.getImport(c)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, toImportResponse(view))
+}
+
+// Errors handles GET /v1/shipments/imports/:id/errors.
+//
+// @Summary      Download the error report of a shipment import
+// @Description  CSV with the row number and error of every row not imported so far.
+// @Tags         shipments
+// @Produce      text/csv
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Import ID"
+// @Success      200  {string}  string  "row,error"
+// @Failure      401  {object}  errorResponse
+// @Failure      404  {object}  errorResponse
+// @Router       /v1/shipments/imports/{id}/errors [get]
+func (h *ShipmentImportHandler) Errors(c echo.Context) error {
+	view, err := h.getImport(c)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	_ = w.Write([]string{"row", "error"})
+	for _, e := range view.Errors {
+		_ = w.Write([]string{strconv.Itoa(e.Line), e.Error})
+	}
+	w.Flush()
+
+	c.Response().Header().Set(echo.HeaderContentDisposition,
+		fmt.Sprintf(`attachment; filename="%s-errors.csv"`, view.ID))
+	return c.Blob(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+}
+
+func (h *ShipmentImportHandler) getImport(c echo.Context) (*ports.ShipmentImportView, error) {
+	role, clientID, err := ctxClaims(c)
+	if err != nil {
+		return nil, err
+	}
+	return h.service.GetImport(c.Request().Context(), ports.GetImportInput{
+		ID:       c.Param("id"),
+		Role:     role,
+		ClientID: clientID,
+	})
+}
@@ -0,0 +1,208 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/99minutos/shipping-system/internal/core/domain"
+	"github.com/99minutos/shipping-system/internal/core/ports"
+)
+
+type stubImportService struct {
+	started *ports.StartImportInput
+	view    *ports.ShipmentImportView
+}
+
+func (s *stubImportService) StartImport(_ context.Context, input ports.StartImportInput) (*ports.ShipmentImportView, error) {
+	s.started = &input
+	return s.view, nil
+}
+
+func (s *stubImportService) GetImport(_ context.Context, input ports.GetImportInput) (*ports.ShipmentImportView, error) {
+	if s.view == nil || input.ID != s.view.ID {
+		return nil, domain.ErrImportNotFound
+	}
+	return s.view, nil
+}
+
+const importHeader = "service_type,sender_name,sender_email,sender_phone,recipient_name,recipient_phone," +
+	"origin_address,origin_city,origin_zip_code,origin_lat,origin_lng," +
+	"destination_address,destination_city,destination_zip_code,destination_lat,destination_lng," +
+	"weight_kg,length_cm,width_cm,height_cm,description,declared_value,currency,Notes\n"
+
+const importRow = "next_day,Pedro,pedro@example.com,+52,Ana,+52," +
+	"Av 1,CDMX,06600,19.4326,-99.1332," +
+	"Calle 2,Puebla,72000,19.0414,-98.2063," +
+	"2.5,30,20,15,Shoes,100,mxn,fragile\n"
+
+func newImportRequest(t *testing.T, name, content string) (echo.Context, *httptest.ResponseRecorder) {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, err := mw.CreateFormFile("file", name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = fw.Write([]byte(content))
+	_ = mw.Close()
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/v1/shipments/imports", &body)
+	req.Header.Set(echo.HeaderContentType, mw.FormDataContentType())
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set("role", domain.RoleClient)
+	c.Set("client_id", "client_1")
+	return c, rec
+}
+
+func TestShipmentImportHandler_Create(t *testing.T) {
+	svc := &stubImportService{view: &ports.ShipmentImportView{ID: "imp_1", Status: "queued", TotalRows: 2}}
+	c, rec := newImportRequest(t, "orders.csv", importHeader+importRow+",,,\n"+importRow)
+
+	if err := NewShipmentImportHandler(svc, 10).Create(c); err != nil {
+		t.Fatalf("handler error: %v", err)
+	}
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d", rec.Code)
+	}
+	if got := rec.Header().Get(echo.HeaderLocation); got != "/v1/shipments/imports/imp_1" {
+		t.Errorf("unexpected Location header %q", got)
+	}
+
+	in := svc.started
+	if in == nil || in.ClientID != "client_1" || in.FileName != "orders.csv" || len(in.FileHash) != 64 {
+		t.Fatalf("unexpected import input: %+v", in)
+	}
+	if len(in.Rows) != 2 || in.Rows[0].Line != 2 || in.Rows[1].Line != 4 {
+		t.Fatalf("expected rows at lines 2 and 4, got %+v", in.Rows)
+	}
+	if in.Rows[0].Fields["notes"] != "fragile" || in.Rows[0].Fields["weight_kg"] != "2.5" {
+		t.Errorf("unexpected fields: %v", in.Rows[0].Fields)
+	}
+
+	// The same file hashes the same.
+	svc.view.AlreadyExisted = true
+	c, rec = newImportRequest(t, "orders-copy.csv", importHeader+importRow+",,,\n"+importRow)
+	hash := in.FileHash
+	if err := NewShipmentImportHandler(svc, 10).Create(c); err != nil {
+		t.Fatalf("handler error: %v", err)
+	}
+	if rec.Code != http.StatusOK || svc.started.FileHash != hash {
+		t.Fatalf("expected 200 with the same hash, got %d and %s", rec.Code, svc.started.FileHash)
+	}
+}
+
+func TestShipmentImportHandler_Create_RejectsFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    int
+	}{
+		{"missing columns", "orders.csv", "service_type,sender_name\nnext_day,Pedro\n", http.StatusUnprocessableEntity},
+		{"no rows", "orders.csv", importHeader, http.StatusUnprocessableEntity},
+		{"too many rows", "orders.csv", importHeader + importRow + importRow + importRow, http.StatusRequestEntityTooLarge},
+		{"unsupported type", "orders.pdf", "%PDF-1.7", http.StatusUnsupportedMediaType},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c, _ := newImportRequest(t, tc.file, tc.content)
+			err := NewShipmentImportHandler(&stubImportService{}, 2).Create(c)
+			he, ok := err.(*echo.HTTPError)
+			if !ok || he.Code != tc.want {
+				t.Fatalf("expected %d HTTPError, got %v", tc.want, err)
+			}
+		})
+	}
+}
+
+func TestShipmentImportHandler_Errors(t *testing.T) {
+	svc := &stubImportService{view: &ports.ShipmentImportView{
+		ID:     "imp_1",
+		Errors: []ports.ImportRowItem{{Line: 3, Error: "weight_kg is required; currency is required"}},
+	}}
+	e := echo.New()
+	rec := httptest.NewRecorder()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/v1/shipments/imports/imp_1/errors", nil), rec)
+	c.Set("role", domain.RoleClient)
+	c.Set("client_id", "client_1")
+	c.SetParamNames("id")
+	c.SetParamValues("imp_1")
+
+	if err := NewShipmentImportHandler(svc, 10).Errors(c); err != nil {
+		t.Fatalf("handler error: %v", err)
+	}
+	want := "row,error\n3,weight_kg is required; currency is required\n"
+	if rec.Body.String() != want {
+		t.Fatalf("expected %q, got %q", want, rec.Body.String())
+	}
+	if got := rec.Header().Get(echo.HeaderContentDisposition); !strings.Contains(got, "imp_1-errors.csv") {
+		t.Errorf("unexpected Content-Disposition %q", got)
+	}
+}
+
+func TestShipmentRowDecoder_Decode(t *testing.T) {
+	header := strings.Split(strings.TrimSpace(importHeader), ",")
+	cells := strings.Split(strings.TrimSpace(importRow), ",")
+	fields := make(map[string]string)
+	for i, col := range header {
+		fields[strings.ToLower(col)] = cells[i]
+	}
+
+	in, err := NewShipmentRowDecoder(domain.BuiltinLifecycles()).Decode(fields)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if in.ServiceType != "next_day" || in.Package.WeightKg != 2.5 || in.Package.Currency != "MXN" || in.Destination.Coordinates.Lat != 19.0414 {
+		t.Errorf("unexpected input: %+v", in)
+	}
+
+	fields["weight_kg"] = "heavy"
+	delete(fields, "currency")
+	fields["insured"] = "maybe"
+	_, err = NewShipmentRowDecoder(domain.BuiltinLifecycles()).Decode(fields)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, want := range []string{"weight_kg must be a number", "insured must be yes or no", "weight_kg is required", "currency is required"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in %q", want, err.Error())
+		}
+	}
+}
+
+func TestShipmentImportHandler_Get(t *testing.T) {
+	svc := &stubImportService{view: &ports.ShipmentImportView{
+		ID:        "imp_1",
+		Status:    "running",
+		Shipments: []ports.ImportRowItem{{Line: 2, TrackingNumber: "99M-K7Q2M9XC4TV"}},
+		Errors:    []ports.ImportRowItem{},
+	}}
+	e := echo.New()
+	rec := httptest.NewRecorder()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/v1/shipments/imports/imp_1", nil), rec)
+	c.Set("role", domain.RoleClient)
+	c.Set("client_id", "client_1")
+	c.SetParamNames("id")
+	c.SetParamValues("imp_1")
+
+	if err := NewShipmentImportHandler(svc, 10).Get(c); err != nil {
+		t.Fatalf("handler error: %v", err)
+	}
+	var body shipmentImportResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if len(body.Shipments) != 1 || body.Shipments[0].Row != 2 || body.Links.Errors != "/v1/shipments/imports/imp_1/errors" || body.FinishedAt != nil {
+		t.Fatalf("unexpected body: %+v", body)
+	}
+}
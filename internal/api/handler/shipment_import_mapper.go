@@ -0,0 +1,188 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+
+	"github.com/99minutos/shipping-system/internal/core/domain"
+	"github.com/99minutos/shipping-system/internal/core/ports"
+)
+
+// ShipmentRowDecoder implements ports.ImportRowDecoder with the column
+// mapping of importColumns. Rows are validated like POST /v1/shipments
+// bodies, and errors name the columns at fault.
+type ShipmentRowDecoder struct {
+	validator *echoValidator
+}
+
+func NewShipmentRowDecoder(lifecycles *domain.Lifecycles) *ShipmentRowDecoder {
+	return &ShipmentRowDecoder{validator: NewValidator(lifecycles)}
+}
+
+// Decode turns the cells of a row into the shipment it describes. The
+// client ID and idempotency key are left to the caller.
+func (d *ShipmentRowDecoder) Decode(fields map[string]string) (ports.CreateShipmentInput, error) {
+	var errs []string
+	number := func(column string) float64 {
+		s := strings.ReplaceAll(fields[column], ",", ".")
+		if s == "" {
+			return 0
+		}
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			errs = append(errs, column+" must be a number")
+		}
+		return f
+	}
+
+	req := createShipmentRequest{
+		Sender: senderRequest{
+			Name:  fields["sender_name"],
+			Email: fields["sender_email"],
+			Phone: fields["sender_phone"],
+		},
+		Recipient: &recipientRequest{
+			Name:         fields["recipient_name"],
+			Phone:        fields["recipient_phone"],
+			Email:        fields["recipient_email"],
+			Instructions: fields["recipient_instructions"],
+		},
+		Origin: addressRequest{
+			Address:     fields["origin_address"],
+			City:        fields["origin_city"],
+			ZipCode:     fields["origin_zip_code"],
+			Coordinates: coordinatesRequest{Lat: number("origin_lat"), Lng: number("origin_lng")},
+		},
+		Destination: addressRequest{
+			Address:     fields["destination_address"],
+			City:        fields["destination_city"],
+			ZipCode:     fields["destination_zip_code"],
+			Coordinates: coordinatesRequest{Lat: number("destination_lat"), Lng: number("destination_lng")},
+		},
+		Package: packageRequest{
+			WeightKg: number("weight_kg"),
+			Dimensions: dimensionsRequest{
+				LengthCm: number("length_cm"),
+				WidthCm:  number("width_cm"),
+				HeightCm: number("height_cm"),
+			},
+			Description:   fields["description"],
+			DeclaredValue: number("declared_value"),
+			Currency:      strings.ToUpper(fields["currency"]),
+		},
+		ServiceType: strings.ToLower(fields["service_type"]),
+	}
+	switch strings.ToLower(fields["insured"]) {
+	case "", "false", "no", "0":
+	case "true", "yes", "si", "sí", "1":
+		req.Insured = true
+	default:
+		errs = append(errs, "insured must be yes or no")
+	}
+
+	if err := d.validator.v.Struct(&req); err != nil {
+		var ve validator.ValidationErrors
+		if !errors.As(err, &ve) {
+			return ports.CreateShipmentInput{}, err
+		}
+		for _, fe := range ve {
+			errs = append(errs, d.columnError(fe))
+		}
+	}
+	if len(errs) > 0 {
+		return ports.CreateShipmentInput{}, errors.New(strings.Join(errs, "; "))
+	}
+	return toCreateInput(req, "", ""), nil
+}
+
+// columnError is fieldError with the field named after its column.
+func (d *ShipmentRowDecoder) columnError(fe validator.FieldError) string {
+	msg := d.validator.fieldError(fe)
+	_, field, _ := strings.Cut(fe.StructNamespace(), ".")
+	for _, col := range importColumns {
+		if col.Field == field {
+			return col.Name + strings.TrimPrefix(msg, strings.ToLower(fe.Field()))
+		}
+	}
+	return msg
+}
+
+// importRows maps the rows of an import file to cells by column name. The
+// first row is the header; empty rows are skipped and the others keep their
+// line number in the file.
+func importRows(cells [][]string) ([]domain.ImportRow, error) {
+	if len(cells) == 0 {
+		return nil, errors.New("file is empty")
+	}
+	header := make([]string, len(cells[0]))
+	present := make(map[string]bool, len(header))
+	for i, name := range cells[0] {
+		header[i] = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(name)), " ", "_")
+		present[header[i]] = true
+	}
+	var missing []string
+	for _, col := range importColumns {
+		if col.Required && !present[col.Name] {
+			missing = append(missing, col.Name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("missing columns: %s", strings.Join(missing, ", "))
+	}
+
+	var rows []domain.ImportRow
+	for i, row := range cells[1:] {
+		if len(row) == 0 {
+			continue
+		}
+		fields := make(map[string]string, len(row))
+		for j, value := range row {
+			if j < len(header) && header[j] != "" && value != "" {
+				fields[header[j]] = value
+			}
+		}
+		rows = append(rows, domain.ImportRow{Line: i + 2, Fields: fields})
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("file has no rows")
+	}
+	return rows, nil
+}
+
+func toImportResponse(v *ports.ShipmentImportView) shipmentImportResponse {
+	resp := shipmentImportResponse{
+		ID:             v.ID,
+		FileName:       v.FileName,
+		Status:         v.Status,
+		TotalRows:      v.TotalRows,
+		Processed:      v.Processed,
+		Created:        v.Created,
+		Failed:         v.Failed,
+		Shipments:      toImportRowResponses(v.Shipments),
+		Errors:         toImportRowResponses(v.Errors),
+		CreatedAt:      v.CreatedAt.UTC(),
+		UpdatedAt:      v.UpdatedAt.UTC(),
+		AlreadyExisted: v.AlreadyExisted,
+		Links: importLinks{
+			Self:   "/v1/shipments/imports/" + v.ID,
+			Errors: "/v1/shipments/imports/" + v.ID + "/errors",
+		},
+	}
+	if !v.FinishedAt.IsZero() {
+		finished := v.FinishedAt.UTC()
+		resp.FinishedAt = &finished
+	}
+	return resp
+}
+
+func toImportRowResponses(items []ports.ImportRowItem) []importRowResponse {
+	out := make([]importRowResponse, len(items))
+	for i, it := range items {
+		out[i] = importRowResponse{Row: it.Line, TrackingNumber: it.TrackingNumber, Error: it.Error}
+	}
+	return out
+}
@@ -0,0 +1,72 @@
+package handler
+
+import "time"
+
+// importColumn is a column of a shipment import file and the field of
+// createShipmentRequest it fills, as a validator struct namespace.
+type importColumn struct {
+	Name     string
+	Field    string
+	Required bool
+}
+
+// importColumns is the column mapping of shipment import files. Column names
+// are matched case-insensitively, with spaces read as underscores; other
+// columns are ignored. Each row is one single-piece shipment.
+var importColumns = []importColumn{
+	{"service_type", "ServiceType", true},
+	{"sender_name", "Sender.Name", true},
+	{"sender_email", "Sender.Email", true},
+	{"sender_phone", "Sender.Phone", true},
+	{"recipient_name", "Recipient.Name", true},
+	{"recipient_phone", "Recipient.Phone", true},
+	{"recipient_email", "Recipient.Email", false},
+	{"recipient_instructions", "Recipient.Instructions", false},
+	{"origin_address", "Origin.Address", true},
+	{"origin_city", "Origin.City", true},
+	{"origin_zip_code", "Origin.ZipCode", true},
+	{"origin_lat", "Origin.Coordinates.Lat", true},
+	{"origin_lng", "Origin.Coordinates.Lng", true},
+	{"destination_address", "Destination.Address", true},
+	{"destination_city", "Destination.City", true},
+	{"destination_zip_code", "Destination.ZipCode", true},
+	{"destination_lat", "Destination.Coordinates.Lat", true},
+	{"destination_lng", "Destination.Coordinates.Lng", true},
+	{"weight_kg", "Package.WeightKg", true},
+	{"length_cm", "Package.Dimensions.LengthCm", true},
+	{"width_cm", "Package.Dimensions.WidthCm", true},
+	{"height_cm", "Package.Dimensions.HeightCm", true},
+	{"description", "Package.Description", true},
+	{"declared_value", "Package.DeclaredValue", true},
+	{"currency", "Package.Currency", true},
+	{"insured", "Insured", false},
+}
+
+type importRowResponse struct {
+	Row            int    `json:"row"`
+	TrackingNumber string `json:"tracking_number,omitempty"`
+	Error          string `json:"error,omitempty"`
+}
+
+type importLinks struct {
+	Self   string `json:"self"`
+	Errors string `json:"errors"`
+}
+
+type shipmentImportResponse struct {
+	ID         string              `json:"id"`
+	FileName   string              `json:"file_name"`
+	Status     string              `json:"status" enums:"queued,running,completed"`
+	TotalRows  int                 `json:"total_rows"`
+	Processed  int                 `json:"processed"`
+	Created    int                 `json:"created"`
+	Failed     int                 `json:"failed"`
+	Shipments  []importRowResponse `json:"shipments"`
+	Errors     []importRowResponse `json:"errors"`
+	CreatedAt  time.Time           `json:"created_at"`
+	UpdatedAt  time.Time           `json:"updated_at"`
+	FinishedAt *time.Time          `json:"finished_at,omitempty"`
+	// AlreadyExisted is true when the same file had already been uploaded.
+	AlreadyExisted bool        `json:"already_existed"`
+	Links          importLinks `json:"_links"`
+}
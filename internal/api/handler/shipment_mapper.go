@@ -13,15 +13,35 @@ func toCreateInput(req createShipmentRequest, clientID, idempotencyKey string) p
 			Email: req.Sender.Email,
 			Phone: req.Sender.Phone,
 		},
+		Recipient:   toRecipientInput(req.Recipient),
 		Origin:      toAddressInput(req.Origin),
 		Destination: toAddressInput(req.Destination),
 		Package:     toPackageInput(req.Package),
+		Pieces:      toPieceInputs(req.Pieces),
 		ServiceType:    req.ServiceType,
+		Insured:        req.Insured,
 		ClientID:       clientID,
 		IdempotencyKey: idempotencyKey,
 	}
 }
 
+// toRecipientInput returns an empty recipient when none was sent.
+func toRecipientInput(r *recipientRequest) ports.RecipientInput {
+	if r == nil {
+		return ports.RecipientInput{}
+	}
+	in := ports.RecipientInput{
+		Name:         r.Name,
+		Phone:        r.Phone,
+		Email:        r.Email,
+		Instructions: r.Instructions,
+	}
+	if c := r.AlternativeContact; c != nil {
+		in.AlternativeContact = &ports.SenderInput{Name: c.Name, Email: c.Email, Phone: c.Phone}
+	}
+	return in
+}
+
 func toAddressInput(a addressRequest) ports.AddressInput {
 	return ports.AddressInput{
 		Address: a.Address,
@@ -34,6 +54,24 @@ func toAddressInput(a addressRequest) ports.AddressInput {
 	}
 }
 
+func toPieceInputs(pieces []pieceRequest) []ports.PieceInput {
+	if len(pieces) == 0 {
+		return nil
+	}
+	out := make([]ports.PieceInput, len(pieces))
+	for i, p := range pieces {
+		out[i] = ports.PieceInput{
+			WeightKg: p.WeightKg,
+			Dimensions: ports.DimensionsInput{
+				LengthCm: p.Dimensions.LengthCm,
+				WidthCm:  p.Dimensions.WidthCm,
+				HeightCm: p.Dimensions.HeightCm,
+			},
+		}
+	}
+	return out
+}
+
 func toPackageInput(p packageRequest) ports.PackageInput {
 	return ports.PackageInput{
 		WeightKg: p.WeightKg,
@@ -48,6 +86,26 @@ func toPackageInput(p packageRequest) ports.PackageInput {
 	}
 }
 
+func toAmendInput(req amendShipmentRequest) ports.AmendShipmentInput {
+	in := ports.AmendShipmentInput{ServiceType: req.ServiceType}
+	if s := req.Sender; s != nil {
+		in.Sender = ports.SenderPatch{Name: s.Name, Email: s.Email, Phone: s.Phone}
+	}
+	if r := req.Recipient; r != nil {
+		in.Recipient = ports.RecipientPatch{Name: r.Name, Phone: r.Phone, Email: r.Email, Instructions: r.Instructions}
+	}
+	if d := req.Destination; d != nil {
+		in.Destination = ports.AddressPatch{Address: d.Address, City: d.City, ZipCode: d.ZipCode}
+		if d.Coordinates != nil {
+			in.Destination.Coordinates = &ports.CoordinatesInput{Lat: d.Coordinates.Lat, Lng: d.Coordinates.Lng}
+		}
+	}
+	if req.Package != nil {
+		in.Description = req.Package.Description
+	}
+	return in
+}
+
 // --- Service result → HTTP response ---
 
 func toCreateResponse(r *ports.ShipmentResult) createShipmentResponse {
@@ -75,10 +133,17 @@ func toGetResponse(d *ports.ShipmentDetail) getShipmentResponse {
 			Email: d.Sender.Email,
 			Phone: d.Sender.Phone,
 		},
-		Origin:        toAddressResponse(d.Origin),
-		Destination:   toAddressResponse(d.Destination),
-		Package:       toPackageResponse(d.Package),
-		StatusHistory: toStatusHistoryResponse(d.StatusHistory),
+		Recipient:        toRecipientResponse(d.Recipient),
+		Origin:           toAddressResponse(d.Origin),
+		Destination:      toAddressResponse(d.Destination),
+		Package:          toPackageResponse(d.Package),
+		PieceCount:       pieceCount(d.Pieces),
+		Pieces:           toPiecesResponse(d.Pieces),
+		Price:            toPriceResponse(d.Price),
+		DeliveryAttempts: d.DeliveryAttempts,
+		Version:          d.Version,
+		Amendments:       toAmendmentsResponse(d.Amendments),
+		StatusHistory:    toStatusHistoryResponse(d.StatusHistory),
 		Links: shipmentLinks{
 			Self:   "/shipments/" + d.TrackingNumber,
 			Events: "/events/" + d.TrackingNumber,
@@ -86,6 +151,19 @@ func toGetResponse(d *ports.ShipmentDetail) getShipmentResponse {
 	}
 }
 
+func toRecipientResponse(r ports.RecipientInput) recipientResponse {
+	out := recipientResponse{
+		Name:         r.Name,
+		Phone:        r.Phone,
+		Email:        r.Email,
+		Instructions: r.Instructions,
+	}
+	if c := r.AlternativeContact; c != nil {
+		out.AlternativeContact = &senderResponse{Name: c.Name, Email: c.Email, Phone: c.Phone}
+	}
+	return out
+}
+
 func toAddressResponse(a ports.AddressInput) addressResponse {
 	return addressResponse{
 		Address: a.Address,
@@ -112,13 +190,73 @@ func toPackageResponse(p ports.PackageInput) packageResponse {
 	}
 }
 
+// pieceCount counts a shipment without pieces as a single piece.
+func pieceCount(pieces []ports.PieceItem) int {
+	return max(len(pieces), 1)
+}
+
+func toPiecesResponse(pieces []ports.PieceItem) []pieceResponse {
+	if len(pieces) == 0 {
+		return nil
+	}
+	out := make([]pieceResponse, len(pieces))
+	for i, p := range pieces {
+		out[i] = pieceResponse{
+			Barcode:  p.Barcode,
+			WeightKg: p.WeightKg,
+			Dimensions: dimensionsResponse{
+				LengthCm: p.Dimensions.LengthCm,
+				WidthCm:  p.Dimensions.WidthCm,
+				HeightCm: p.Dimensions.HeightCm,
+			},
+			Status:           p.Status,
+			DeliveryAttempts: p.DeliveryAttempts,
+		}
+	}
+	return out
+}
+
+func toPieceStatusesResponse(pieces []ports.PieceItem) []pieceStatusResponse {
+	if len(pieces) == 0 {
+		return nil
+	}
+	out := make([]pieceStatusResponse, len(pieces))
+	for i, p := range pieces {
+		out[i] = pieceStatusResponse{Barcode: p.Barcode, Status: p.Status}
+	}
+	return out
+}
+
 func toStatusHistoryResponse(items []ports.StatusHistoryItem) []statusHistoryItemResponse {
 	out := make([]statusHistoryItemResponse, len(items))
 	for i, item := range items {
 		out[i] = statusHistoryItemResponse{
-			Status:    item.Status,
-			Timestamp: item.Timestamp.UTC(),
-			Notes:     item.Notes,
+			Status:     item.Status,
+			Timestamp:  item.Timestamp.UTC(),
+			Notes:      item.Notes,
+			ReasonCode: item.ReasonCode,
+			Actor:      item.Actor,
+			Reason:     item.Reason,
+			Piece:      item.Piece,
+			Attempt:    item.Attempt,
+			Late:       item.Late,
+		}
+	}
+	return out
+}
+
+func toAmendmentsResponse(items []ports.AmendmentItem) []amendmentResponse {
+	out := make([]amendmentResponse, len(items))
+	for i, item := range items {
+		changes := make([]fieldChangeResponse, len(item.Changes))
+		for j, c := range item.Changes {
+			changes[j] = fieldChangeResponse{Field: c.Field, From: c.From, To: c.To}
+		}
+		out[i] = amendmentResponse{
+			Version: item.Version,
+			Actor:   item.Actor,
+			At:      item.At.UTC(),
+			Changes: changes,
 		}
 	}
 	return out
@@ -147,11 +285,15 @@ func toSummaryResponse(s ports.ShipmentSummary) shipmentSummaryResponse {
 		ServiceType:       s.ServiceType,
 		CreatedAt:         s.CreatedAt.UTC(),
 		EstimatedDelivery: s.EstimatedDelivery.UTC(),
+		DeliveryAttempts:  s.DeliveryAttempts,
+		PieceCount:        pieceCount(s.Pieces),
+		Pieces:            toPieceStatusesResponse(s.Pieces),
 		Sender: senderResponse{
 			Name:  s.Sender.Name,
 			Email: s.Sender.Email,
 			Phone: s.Sender.Phone,
 		},
+		Recipient:   toRecipientResponse(s.Recipient),
 		Origin:      toAddressResponse(s.Origin),
 		Destination: toAddressResponse(s.Destination),
 		Links: shipmentLinks{
@@ -160,3 +302,16 @@ func toSummaryResponse(s ports.ShipmentSummary) shipmentSummaryResponse {
 		},
 	}
 }
+
+func toCancelResponse(r *ports.CancelShipmentResult) cancelShipmentResponse {
+	return cancelShipmentResponse{
+		TrackingNumber: r.TrackingNumber,
+		Status:         r.Status,
+		CancelledAt:    r.CancelledAt.UTC(),
+		Reason:         r.Reason,
+		Links: shipmentLinks{
+			Self:   "/shipments/" + r.TrackingNumber,
+			Events: "/events/" + r.TrackingNumber,
+		},
+	}
+}
@@ -27,6 +27,20 @@ type senderRequest struct {
 	Phone string `json:"phone" validate:"required"`
 }
 
+type contactRequest struct {
+	Name  string `json:"name"  validate:"required"`
+	Phone string `json:"phone" validate:"required"`
+	Email string `json:"email" validate:"omitempty,email"`
+}
+
+type recipientRequest struct {
+	Name               string          `json:"name"                validate:"required"`
+	Phone              string          `json:"phone"               validate:"required"`
+	Email              string          `json:"email"               validate:"omitempty,email"`
+	Instructions       string          `json:"instructions"        validate:"max=500"`
+	AlternativeContact *contactRequest `json:"alternative_contact"`
+}
+
 type dimensionsRequest struct {
 	LengthCm float64 `json:"length_cm" validate:"required,gt=0"`
 	WidthCm  float64 `json:"width_cm"  validate:"required,gt=0"`
@@ -41,12 +55,68 @@ type packageRequest struct {
 	Currency      string            `json:"currency"       validate:"required"`
 }
 
+// pieceRequest is one box of a multi-piece shipment.
+type pieceRequest struct {
+	WeightKg   float64           `json:"weight_kg"  validate:"required,gt=0"`
+	Dimensions dimensionsRequest `json:"dimensions" validate:"required"`
+}
+
+// createShipmentRequest is the body of POST /v1/shipments. Recipient is
+// optional so that clients written before it was added keep working.
 type createShipmentRequest struct {
-	Sender      senderRequest  `json:"sender"       validate:"required"`
-	Origin      addressRequest `json:"origin"       validate:"required"`
-	Destination addressRequest `json:"destination"  validate:"required"`
-	Package     packageRequest `json:"package"      validate:"required"`
-	ServiceType string         `json:"service_type" validate:"required,oneof=same_day next_day standard"`
+	Sender      senderRequest     `json:"sender"       validate:"required"`
+	Recipient   *recipientRequest `json:"recipient"`
+	Origin      addressRequest    `json:"origin"       validate:"required"`
+	Destination addressRequest    `json:"destination"  validate:"required"`
+	Package     packageRequest    `json:"package"      validate:"required"`
+	Pieces      []pieceRequest    `json:"pieces"       validate:"omitempty,max=99,dive"`
+	ServiceType string            `json:"service_type" validate:"required,oneof=same_day next_day standard"`
+	Insured     bool              `json:"insured"`
+}
+
+// batchShipmentRequest is one shipment of POST /v1/shipments/batch. Its
+// idempotency key lets the batch be sent again without duplicating it.
+type batchShipmentRequest struct {
+	IdempotencyKey string `json:"idempotency_key" validate:"required,max=128"`
+	createShipmentRequest
+}
+
+type cancelShipmentRequest struct {
+	Reason string `json:"reason" validate:"required,max=500"`
+}
+
+// amendShipmentRequest carries corrections to a shipment not yet picked up.
+// Omitted fields are left unchanged.
+type amendShipmentRequest struct {
+	Sender      *amendSenderRequest    `json:"sender"`
+	Recipient   *amendRecipientRequest `json:"recipient"`
+	Destination *amendAddressRequest   `json:"destination"`
+	Package     *amendPackageRequest   `json:"package"`
+	ServiceType *string                `json:"service_type" validate:"omitnil,oneof=same_day next_day standard"`
+}
+
+type amendSenderRequest struct {
+	Name  *string `json:"name"  validate:"omitnil,min=1"`
+	Email *string `json:"email" validate:"omitnil,email"`
+	Phone *string `json:"phone" validate:"omitnil,min=1"`
+}
+
+type amendRecipientRequest struct {
+	Name         *string `json:"name"         validate:"omitnil,min=1"`
+	Phone        *string `json:"phone"        validate:"omitnil,min=1"`
+	Email        *string `json:"email"        validate:"omitnil,omitempty,email"`
+	Instructions *string `json:"instructions" validate:"omitnil,max=500"`
+}
+
+type amendAddressRequest struct {
+	Address     *string             `json:"address"     validate:"omitnil,min=1"`
+	City        *string             `json:"city"        validate:"omitnil,min=1"`
+	ZipCode     *string             `json:"zip_code"    validate:"omitnil,min=1"`
+	Coordinates *coordinatesRequest `json:"coordinates"`
+}
+
+type amendPackageRequest struct {
+	Description *string `json:"description" validate:"omitnil,min=1"`
 }
 
 type shipmentLinks struct {
@@ -62,6 +132,35 @@ type createShipmentResponse struct {
 	Links             shipmentLinks `json:"_links"`
 }
 
+// Outcomes of the shipments of a batch.
+const (
+	outcomeCreated  = "created"
+	outcomeExisting = "existing"
+	outcomeRejected = "rejected"
+)
+
+type batchShipmentResult struct {
+	Index          int                     `json:"index"`
+	IdempotencyKey string                  `json:"idempotency_key"`
+	Outcome        string                  `json:"outcome" enums:"created,existing,invalid,rejected,error"`
+	Shipment       *createShipmentResponse `json:"shipment,omitempty"`
+	Error          string                  `json:"error,omitempty"`
+}
+
+type batchShipmentsResponse struct {
+	Total   int                   `json:"total"`
+	Counts  map[string]int        `json:"counts"`
+	Results []batchShipmentResult `json:"results"`
+}
+
+type cancelShipmentResponse struct {
+	TrackingNumber string        `json:"tracking_number"`
+	Status         string        `json:"status"`
+	CancelledAt    time.Time     `json:"cancelled_at"`
+	Reason         string        `json:"reason"`
+	Links          shipmentLinks `json:"_links"`
+}
+
 // Response-only types owned by the transport layer.
 // These are intentionally separate from ports/domain types so the JSON
 // contract is not coupled to internal service changes.
@@ -72,6 +171,14 @@ type senderResponse struct {
 	Phone string `json:"phone"`
 }
 
+type recipientResponse struct {
+	Name               string          `json:"name"`
+	Phone              string          `json:"phone"`
+	Email              string          `json:"email,omitempty"`
+	Instructions       string          `json:"instructions,omitempty"`
+	AlternativeContact *senderResponse `json:"alternative_contact,omitempty"`
+}
+
 type coordinatesResponse struct {
 	Lat float64 `json:"lat"`
 	Lng float64 `json:"lng"`
@@ -98,10 +205,30 @@ type packageResponse struct {
 	Currency      string             `json:"currency"`
 }
 
+type pieceResponse struct {
+	Barcode          string             `json:"barcode"`
+	WeightKg         float64            `json:"weight_kg"`
+	Dimensions       dimensionsResponse `json:"dimensions"`
+	Status           string             `json:"status"`
+	DeliveryAttempts int                `json:"delivery_attempts"`
+}
+
+// pieceStatusResponse is the piece view used in list responses.
+type pieceStatusResponse struct {
+	Barcode string `json:"barcode"`
+	Status  string `json:"status"`
+}
+
 type statusHistoryItemResponse struct {
-	Status    string    `json:"status"`
-	Timestamp time.Time `json:"timestamp"`
-	Notes     string    `json:"notes,omitempty"`
+	Status     string    `json:"status"`
+	Timestamp  time.Time `json:"timestamp"`
+	Notes      string    `json:"notes,omitempty"`
+	ReasonCode string    `json:"reason_code,omitempty"`
+	Actor      string    `json:"actor,omitempty"`
+	Reason     string    `json:"reason,omitempty"`
+	Piece      string    `json:"piece,omitempty"`
+	Attempt    int       `json:"attempt,omitempty"`
+	Late       bool      `json:"late,omitempty"`
 }
 
 type getShipmentResponse struct {
@@ -111,25 +238,49 @@ type getShipmentResponse struct {
 	CreatedAt         time.Time                   `json:"created_at"`
 	EstimatedDelivery time.Time                   `json:"estimated_delivery"`
 	Sender            senderResponse              `json:"sender"`
+	Recipient         recipientResponse           `json:"recipient"`
 	Origin            addressResponse             `json:"origin"`
 	Destination       addressResponse             `json:"destination"`
 	Package           packageResponse             `json:"package"`
+	PieceCount        int                         `json:"piece_count"`
+	Pieces            []pieceResponse             `json:"pieces,omitempty"`
+	Price             *priceResponse              `json:"price,omitempty"`
+	DeliveryAttempts  int                         `json:"delivery_attempts"`
+	Version           int                         `json:"version"`
+	Amendments        []amendmentResponse         `json:"amendments"`
 	StatusHistory     []statusHistoryItemResponse `json:"status_history"`
 	Links             shipmentLinks               `json:"_links"`
 }
 
+type amendmentResponse struct {
+	Version int                   `json:"version"`
+	Actor   string                `json:"actor"`
+	At      time.Time             `json:"at"`
+	Changes []fieldChangeResponse `json:"changes"`
+}
+
+type fieldChangeResponse struct {
+	Field string `json:"field"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
 // shipmentSummaryResponse is the lightweight item used in list responses.
 // It intentionally omits status_history to keep payloads small.
 type shipmentSummaryResponse struct {
-	TrackingNumber    string          `json:"tracking_number"`
-	Status            string          `json:"status"`
-	ServiceType       string          `json:"service_type"`
-	CreatedAt         time.Time       `json:"created_at"`
-	EstimatedDelivery time.Time       `json:"estimated_delivery"`
-	Sender            senderResponse  `json:"sender"`
-	Origin            addressResponse `json:"origin"`
-	Destination       addressResponse `json:"destination"`
-	Links             shipmentLinks   `json:"_links"`
+	TrackingNumber    string                `json:"tracking_number"`
+	Status            string                `json:"status"`
+	ServiceType       string                `json:"service_type"`
+	CreatedAt         time.Time             `json:"created_at"`
+	EstimatedDelivery time.Time             `json:"estimated_delivery"`
+	DeliveryAttempts  int                   `json:"delivery_attempts"`
+	PieceCount        int                   `json:"piece_count"`
+	Pieces            []pieceStatusResponse `json:"pieces,omitempty"`
+	Sender            senderResponse        `json:"sender"`
+	Recipient         recipientResponse     `json:"recipient"`
+	Origin            addressResponse       `json:"origin"`
+	Destination       addressResponse       `json:"destination"`
+	Links             shipmentLinks         `json:"_links"`
 }
 
 type paginationResponse struct {
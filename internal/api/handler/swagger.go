@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/swaggo/swag"
+
+	"github.com/99minutos/shipping-system/internal/core/domain"
+)
+
+// SwaggerDoc returns the handler of GET /swagger/doc.json — it serves the
+// generated spec with the event status enum taken from lifecycles, which may
+// differ from the statuses known when the spec was generated.
+func SwaggerDoc(lifecycles *domain.Lifecycles) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		raw, err := swag.ReadDoc()
+		if err != nil {
+			return err
+		}
+
+		var doc map[string]any
+		if err := json.Unmarshal([]byte(raw), &doc); err != nil {
+			return err
+		}
+		if status, ok := dig(doc, "definitions", "handler.trackingEventRequest", "properties", "status"); ok {
+			status["enum"] = lifecycles.EventStatuses()
+		}
+		return c.JSON(http.StatusOK, doc)
+	}
+}
+
+// dig walks nested JSON objects along keys.
+func dig(doc map[string]any, keys ...string) (map[string]any, bool) {
+	for _, k := range keys {
+		next, ok := doc[k].(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		doc = next
+	}
+	return doc, true
+}
@@ -0,0 +1,282 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/99minutos/shipping-system/internal/core/domain"
+	"github.com/99minutos/shipping-system/internal/core/ports"
+)
+
+// UserHandler exposes the admin management of users and invitations.
+type UserHandler struct {
+	service ports.UserService
+}
+
+func NewUserHandler(service ports.UserService) *UserHandler {
+	return &UserHandler{service: service}
+}
+
+// Create handles POST /v1/admin/users.
+//
+// @Summary      Create a user
+// @Description  Creates a user of any role. This is the only way to create admins.
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        body  body      createUserRequest  true  "User"
+// @Success      201   {object}  userResponse
+// @Failure      400   {object}  errorResponse
+// @Failure      401   {object}  errorResponse
+// @Failure      403   {object}  errorResponse
+// @Failure      409   {object}  errorResponse
+// @Failure      422   {object}  errorResponse
+// @Router       /v1/admin/users [post]
+func (h *UserHandler) Create(c echo.Context) error {
+	var req createUserRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid payload")
+	}
+	if err := c.Validate(&req); err != nil {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+	}
+
+	user, err := h.service.Create(c.Request().Context(), ports.CreateUserInput{
+		Username: req.Username,
+		Password: req.Password,
+		Email:    req.Email,
+		Role:     req.Role,
+		ClientID: req.ClientID,
+	})
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusCreated, toUserResponse(user))
+}
+
+// List handles GET /v1/admin/users.
+//
+// @Summary      List users
+// @Description  Lists users, newest first, including disabled ones.
+// @Tags         users
+// @Produce      json
+// @Security     BearerAuth
+// @Param        role       query     string  false  "Filter by role"  Enums(admin, client, carrier)
+// @Param        client_id  query     string  false  "Filter by client"
+// @Param        page       query     int     false  "Page number (default 1)"
+// @Param        limit      query     int     false  "Items per page (default 20, max 100)"
+// @Success      200        {object}  listUsersResponse
+// @Failure      401        {object}  errorResponse
+// @Failure      403        {object}  errorResponse
+// @Router       /v1/admin/users [get]
+func (h *UserHandler) List(c echo.Context) error {
+	page, _ := strconv.Atoi(c.QueryParam("page"))
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+
+	result, err := h.service.List(c.Request().Context(), ports.ListUsersInput{
+		Role:     c.QueryParam("role"),
+		ClientID: c.QueryParam("client_id"),
+		Page:     page,
+		Limit:    limit,
+	})
+	if err != nil {
+		return err
+	}
+
+	items := make([]userResponse, len(result.Items))
+	for i, user := range result.Items {
+		items[i] = toUserResponse(user)
+	}
+	return c.JSON(http.StatusOK, listUsersResponse{
+		Data: items,
+		Pagination: paginationResponse{
+			Total:      result.Total,
+			Page:       result.Page,
+			Limit:      result.Limit,
+			TotalPages: result.TotalPages,
+		},
+	})
+}
+
+// Get handles GET /v1/admin/users/:id.
+//
+// @Summary      Get a user
+// @Tags         users
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "User ID"
+// @Success      200  {object}  userResponse
+// @Failure      401  {object}  errorResponse
+// @Failure      403  {object}  errorResponse
+// @Failure      404  {object}  errorResponse
+// @Router       /v1/admin/users/{id} [get]
+func (h *UserHandler) Get(c echo.Context) error {
+	user, err := h.service.Get(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, toUserResponse(user))
+}
+
+// Update handles PATCH /v1/admin/users/:id.
+//
+// @Summary      Change the role or client of a user
+// @Description  The new role and client apply from the user's next login or token refresh. Admins cannot change their own role.
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id    path      string             true  "User ID"
+// @Param        body  body      updateUserRequest  true  "Changes"
+// @Success      200   {object}  userResponse
+// @Failure      400   {object}  errorResponse
+// @Failure      401   {object}  errorResponse
+// @Failure      403   {object}  errorResponse
+// @Failure      404   {object}  errorResponse
+// @Failure      409   {object}  errorResponse
+// @Failure      422   {object}  errorResponse
+// @Router       /v1/admin/users/{id} [patch]
+func (h *UserHandler) Update(c echo.Context) error {
+	var req updateUserRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid payload")
+	}
+	if err := c.Validate(&req); err != nil {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+	}
+
+	input := ports.UpdateUserInput{ID: c.Param("id"), Role: req.Role, ClientID: req.ClientID}
+	input.ActorID, _ = c.Get("user_id").(string)
+	user, err := h.service.Update(c.Request().Context(), input)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, toUserResponse(user))
+}
+
+// Disable handles POST /v1/admin/users/:id/disable.
+//
+// @Summary      Disable a user
+// @Description  Refuses the user's logins and revokes its refresh tokens; access tokens already issued are valid until they expire. Admins cannot disable themselves.
+// @Tags         users
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "User ID"
+// @Success      200  {object}  userResponse
+// @Failure      401  {object}  errorResponse
+// @Failure      403  {object}  errorResponse
+// @Failure      404  {object}  errorResponse
+// @Failure      409  {object}  errorResponse
+// @Router       /v1/admin/users/{id}/disable [post]
+func (h *UserHandler) Disable(c echo.Context) error {
+	return h.setDisabled(c, true)
+}
+
+// Enable handles POST /v1/admin/users/:id/enable.
+//
+// @Summary      Enable a user
+// @Tags         users
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "User ID"
+// @Success      200  {object}  userResponse
+// @Failure      401  {object}  errorResponse
+// @Failure      403  {object}  errorResponse
+// @Failure      404  {object}  errorResponse
+// @Router       /v1/admin/users/{id}/enable [post]
+func (h *UserHandler) Enable(c echo.Context) error {
+	return h.setDisabled(c, false)
+}
+
+func (h *UserHandler) setDisabled(c echo.Context, disabled bool) error {
+	actorID, _ := c.Get("user_id").(string)
+	user, err := h.service.SetDisabled(c.Request().Context(), c.Param("id"), disabled, actorID)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, toUserResponse(user))
+}
+
+// Delete handles DELETE /v1/admin/users/:id.
+//
+// @Summary      Delete a user
+// @Description  Deletes the user and revokes its refresh tokens. Admins cannot delete themselves.
+// @Tags         users
+// @Security     BearerAuth
+// @Param        id  path  string  true  "User ID"
+// @Success      204
+// @Failure      401  {object}  errorResponse
+// @Failure      403  {object}  errorResponse
+// @Failure      404  {object}  errorResponse
+// @Failure      409  {object}  errorResponse
+// @Router       /v1/admin/users/{id} [delete]
+func (h *UserHandler) Delete(c echo.Context) error {
+	actorID, _ := c.Get("user_id").(string)
+	if err := h.service.Delete(c.Request().Context(), c.Param("id"), actorID); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// Invite handles POST /v1/admin/invitations.
+//
+// @Summary      Invite a user
+// @Description  Issues an invitation for one person to register at POST /auth/register, with the given email, as a user of a client or carrier account. The token is returned only in this response; only its hash is stored.
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        body  body      inviteRequest  true  "Invitation"
+// @Success      201   {object}  invitationResponse
+// @Failure      400   {object}  errorResponse
+// @Failure      401   {object}  errorResponse
+// @Failure      403   {object}  errorResponse
+// @Failure      422   {object}  errorResponse
+// @Router       /v1/admin/invitations [post]
+func (h *UserHandler) Invite(c echo.Context) error {
+	var req inviteRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid payload")
+	}
+	if err := c.Validate(&req); err != nil {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+	}
+
+	input := ports.InviteInput{Email: req.Email, Role: req.Role, ClientID: req.ClientID}
+	input.CreatedBy, _ = c.Get("username").(string)
+	issued, err := h.service.Invite(c.Request().Context(), input)
+	if err != nil {
+		return err
+	}
+	inv := issued.Invitation
+	return c.JSON(http.StatusCreated, invitationResponse{
+		ID:        inv.ID,
+		Email:     inv.Email,
+		Role:      inv.Role,
+		ClientID:  inv.ClientID,
+		CreatedBy: inv.CreatedBy,
+		CreatedAt: inv.CreatedAt,
+		ExpiresAt: inv.ExpiresAt,
+		Token:     issued.Token,
+	})
+}
+
+func toUserResponse(u *domain.User) userResponse {
+	resp := userResponse{
+		ID:        u.ID,
+		Username:  u.Username,
+		Email:     u.Email,
+		Role:      u.Role,
+		ClientID:  u.ClientID,
+		Disabled:  u.Disabled(),
+		CreatedAt: u.CreatedAt,
+		UpdatedAt: u.UpdatedAt,
+	}
+	if u.Disabled() {
+		resp.DisabledAt = &u.DisabledAt
+	}
+	return resp
+}
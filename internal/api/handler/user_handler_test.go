@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/99minutos/shipping-system/internal/core/domain"
+	"github.com/99minutos/shipping-system/internal/core/ports"
+)
+
+type stubUserService struct {
+	ports.UserService
+	updated *ports.UpdateUserInput
+	invited *ports.InviteInput
+}
+
+func (s *stubUserService) Create(_ context.Context, input ports.CreateUserInput) (*domain.User, error) {
+	return &domain.User{ID: "u1", Username: input.Username, Email: input.Email, Role: input.Role, ClientID: input.ClientID}, nil
+}
+
+func (s *stubUserService) Update(_ context.Context, input ports.UpdateUserInput) (*domain.User, error) {
+	s.updated = &input
+	return &domain.User{ID: input.ID, Role: *input.Role}, nil
+}
+
+func (s *stubUserService) SetDisabled(_ context.Context, id string, disabled bool, _ string) (*domain.User, error) {
+	user := &domain.User{ID: id}
+	if disabled {
+		user.DisabledAt = time.Now()
+	}
+	return user, nil
+}
+
+func (s *stubUserService) Invite(_ context.Context, input ports.InviteInput) (*ports.IssuedInvitation, error) {
+	s.invited = &input
+	return &ports.IssuedInvitation{
+		Invitation: &domain.Invitation{ID: "inv_1", Email: input.Email, Role: input.Role, ClientID: input.ClientID},
+		Token:      "inv-token",
+	}, nil
+}
+
+func newUserRequest(method, body string) (echo.Context, *httptest.ResponseRecorder) {
+	e := echo.New()
+	e.Validator = NewValidator(domain.BuiltinLifecycles())
+	req := httptest.NewRequest(method, "/v1/admin/users", strings.NewReader(body))
+	if body != "" {
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	}
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set("role", domain.RoleAdmin)
+	c.Set("user_id", "admin_1")
+	c.Set("username", "root")
+	return c, rec
+}
+
+func TestUserHandler_Create(t *testing.T) {
+	h := NewUserHandler(&stubUserService{})
+
+	c, rec := newUserRequest(http.MethodPost, `{"username":"ops","password":"longpassword","email":"ops@example.com","role":"admin"}`)
+	if err := h.Create(c); err != nil {
+		t.Fatalf("handler error: %v", err)
+	}
+	var resp userResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusCreated || resp.Role != domain.RoleAdmin || resp.Disabled {
+		t.Fatalf("unexpected response %d %s", rec.Code, rec.Body.String())
+	}
+
+	c, _ = newUserRequest(http.MethodPost, `{"username":"ops","password":"short","email":"ops@example.com","role":"root"}`)
+	var he *echo.HTTPError
+	if err := h.Create(c); !errors.As(err, &he) || he.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422, got %v", err)
+	}
+}
+
+func TestUserHandler_UpdateAndDisable(t *testing.T) {
+	svc := &stubUserService{}
+	h := NewUserHandler(svc)
+
+	c, rec := newUserRequest(http.MethodPatch, `{"role":"carrier"}`)
+	c.SetParamNames("id")
+	c.SetParamValues("u2")
+	if err := h.Update(c); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("update: %d %v", rec.Code, err)
+	}
+	// The acting admin comes from the token, not the payload.
+	if svc.updated.ID != "u2" || svc.updated.ActorID != "admin_1" || svc.updated.ClientID != nil {
+		t.Fatalf("unexpected input %+v", svc.updated)
+	}
+
+	c, rec = newUserRequest(http.MethodPost, "")
+	c.SetParamNames("id")
+	c.SetParamValues("u2")
+	if err := h.Disable(c); err != nil {
+		t.Fatalf("disable: %v", err)
+	}
+	var resp userResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Disabled || resp.DisabledAt == nil {
+		t.Fatalf("expected a disabled user, got %s", rec.Body.String())
+	}
+}
+
+func TestUserHandler_Invite(t *testing.T) {
+	svc := &stubUserService{}
+	h := NewUserHandler(svc)
+
+	c, rec := newUserRequest(http.MethodPost, `{"email":"hana@example.com","role":"carrier","client_id":"carrier_1"}`)
+	if err := h.Invite(c); err != nil {
+		t.Fatalf("handler error: %v", err)
+	}
+	var resp invitationResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusCreated || resp.Token != "inv-token" || svc.invited.CreatedBy != "root" {
+		t.Fatalf("unexpected response %d %s", rec.Code, rec.Body.String())
+	}
+
+	// Admins are created directly, never invited.
+	c, _ = newUserRequest(http.MethodPost, `{"email":"x@example.com","role":"admin"}`)
+	var he *echo.HTTPError
+	if err := h.Invite(c); !errors.As(err, &he) || he.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422, got %v", err)
+	}
+}
@@ -0,0 +1,55 @@
+package handler
+
+import "time"
+
+type createUserRequest struct {
+	Username string `json:"username" validate:"required,max=100"`
+	Password string `json:"password" validate:"required,min=8,max=72"`
+	Email    string `json:"email" validate:"required,email"`
+	Role     string `json:"role" validate:"required,oneof=admin client carrier" enums:"admin,client,carrier"`
+	// ClientID is the client or carrier account; admins have none.
+	ClientID string `json:"client_id,omitempty"`
+}
+
+// updateUserRequest changes the role or client of a user; omitted fields
+// are left unchanged.
+type updateUserRequest struct {
+	Role     *string `json:"role,omitempty" validate:"omitempty,oneof=admin client carrier" enums:"admin,client,carrier"`
+	ClientID *string `json:"client_id,omitempty"`
+}
+
+type inviteRequest struct {
+	Email    string `json:"email" validate:"required,email"`
+	Role     string `json:"role" validate:"required,oneof=client carrier" enums:"client,carrier"`
+	ClientID string `json:"client_id" validate:"required"`
+}
+
+type userResponse struct {
+	ID         string     `json:"id"`
+	Username   string     `json:"username"`
+	Email      string     `json:"email"`
+	Role       string     `json:"role" enums:"admin,client,carrier"`
+	ClientID   string     `json:"client_id,omitempty"`
+	Disabled   bool       `json:"disabled"`
+	DisabledAt *time.Time `json:"disabled_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+type listUsersResponse struct {
+	Data       []userResponse     `json:"data"`
+	Pagination paginationResponse `json:"pagination"`
+}
+
+// invitationResponse is an invitation just created, the only time its token
+// is returned.
+type invitationResponse struct {
+	ID        string    `json:"id"`
+	Email     string    `json:"email"`
+	Role      string    `json:"role" enums:"client,carrier"`
+	ClientID  string    `json:"client_id"`
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Token     string    `json:"token"`
+}
@@ -3,19 +3,38 @@ package handler
 import (
 	"errors"
 	"fmt"
+	"reflect"
+	"slices"
 	"strings"
 
 	"github.com/go-playground/validator/v10"
+
+	"github.com/99minutos/shipping-system/internal/core/domain"
 )
 
 // echoValidator wraps go-playground/validator so Echo can call c.Validate(req).
 type echoValidator struct {
-	v *validator.Validate
+	v             *validator.Validate
+	eventStatuses []domain.ShipmentStatus // accepted by shipment_status
 }
 
 // NewValidator returns an echoValidator ready to be assigned to echo.Echo.Validator.
-func NewValidator() *echoValidator {
-	return &echoValidator{v: validator.New()}
+func NewValidator(lifecycles *domain.Lifecycles) *echoValidator {
+	v := validator.New()
+	// shipment_status accepts any status a transition of lifecycles leads to.
+	eventStatuses := lifecycles.EventStatuses()
+	_ = v.RegisterValidation("shipment_status", func(fl validator.FieldLevel) bool {
+		return slices.Contains(eventStatuses, domain.ShipmentStatus(fl.Field().String()))
+	})
+	_ = v.RegisterValidation("delivery_failure_reason", func(fl validator.FieldLevel) bool {
+		return slices.Contains(domain.DeliveryFailureReasons, fl.Field().String())
+	})
+	// tracking_number checks the format and check character, so mistyped
+	// numbers are rejected before any lookup.
+	_ = v.RegisterValidation("tracking_number", func(fl validator.FieldLevel) bool {
+		return domain.ValidTrackingNumber(fl.Field().String())
+	})
+	return &echoValidator{v: v, eventStatuses: eventStatuses}
 }
 
 // Validate satisfies the echo.Validator interface.
@@ -25,7 +44,7 @@ func (ev *echoValidator) Validate(i any) error {
 		if errors.As(err, &ve) {
 			msgs := make([]string, 0, len(ve))
 			for _, fe := range ve {
-				msgs = append(msgs, fieldError(fe))
+				msgs = append(msgs, ev.fieldError(fe))
 			}
 			return fmt.Errorf("%s", strings.Join(msgs, "; "))
 		}
@@ -35,7 +54,7 @@ func (ev *echoValidator) Validate(i any) error {
 }
 
 // fieldError converts a single ValidationError into a human-readable message.
-func fieldError(fe validator.FieldError) string {
+func (ev *echoValidator) fieldError(fe validator.FieldError) string {
 	field := strings.ToLower(fe.Field())
 	switch fe.Tag() {
 	case "required":
@@ -45,10 +64,45 @@ func fieldError(fe validator.FieldError) string {
 	case "gt":
 		return fmt.Sprintf("%s must be greater than %s", field, fe.Param())
 	case "min":
+		if fe.Kind() == reflect.String && fe.Param() == "1" {
+			return field + " cannot be empty"
+		}
 		return fmt.Sprintf("%s must be at least %s", field, fe.Param())
+	case "max":
+		return fmt.Sprintf("%s must be at most %s", field, fe.Param())
 	case "oneof":
 		return fmt.Sprintf("%s must be one of: %s", field, fe.Param())
+	case "shipment_status":
+		return fmt.Sprintf("%s must be one of: %s", field, eventStatusList(ev.eventStatuses))
+	case "delivery_failure_reason":
+		return fmt.Sprintf("%s must be one of: %s", field, strings.Join(domain.DeliveryFailureReasons, " "))
+	case "tracking_number":
+		return field + " is not a valid tracking number"
+	case "required_if":
+		return fmt.Sprintf("%s is required when %s", field, conditionParam(fe.Param()))
+	case "required_without":
+		return fmt.Sprintf("%s is required without %s", field, strings.ToLower(fe.Param()))
+	case "cidr|ip":
+		return field + " must be an IP address or a CIDR range"
+	case "excluded_unless":
+		return fmt.Sprintf("%s is only allowed when %s", field, conditionParam(fe.Param()))
 	default:
 		return fmt.Sprintf("%s failed validation (%s)", field, fe.Tag())
 	}
 }
+
+// eventStatusList returns statuses space separated like a oneof parameter.
+func eventStatusList(statuses []domain.ShipmentStatus) string {
+	names := make([]string, len(statuses))
+	for i, s := range statuses {
+		names[i] = string(s)
+	}
+	return strings.Join(names, " ")
+}
+
+// conditionParam renders a "Field value" parameter of required_if and
+// excluded_unless as "field is value".
+func conditionParam(param string) string {
+	field, value, _ := strings.Cut(param, " ")
+	return strings.ToLower(field) + " is " + value
+}
@@ -30,7 +30,7 @@ var EventsProcessedTotal = promauto.NewCounterVec(
 
 // EventsErrorsTotal counts events that failed processing.
 // Label:
-//   - reason: short description of the failure (e.g. "invalid_transition", "shipment_not_found", "update_failed")
+//   - reason: short description of the failure (e.g. "invalid_transition", "shipment_not_found", "update_failed", "concurrent_update")
 var EventsErrorsTotal = promauto.NewCounterVec(
 	prometheus.CounterOpts{
 		Namespace: namespace,
@@ -64,6 +64,60 @@ var EventsQueueDepth = promauto.NewGaugeVec(
 	[]string{"worker_id"},
 )
 
+// EventsRejectedTotal counts events refused at admission because a shard was full.
+// Label:
+//   - policy: the admission policy that rejected the event ("block", "reject", "spill")
+var EventsRejectedTotal = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "events_rejected_total",
+		Help:      "Total number of tracking events rejected because the dispatcher queue was saturated.",
+	},
+	[]string{"policy"},
+)
+
+// EventsSpilledTotal counts events written to the overflow spill store.
+var EventsSpilledTotal = promauto.NewCounter(
+	prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "events_spilled_total",
+		Help:      "Total number of tracking events spilled to Redis because a dispatcher shard was full.",
+	},
+)
+
+// EventsRedeliveredTotal counts stream entries re-processed after being left
+// unacknowledged by a previous consumer (crash, restart or lost partition lease).
+var EventsRedeliveredTotal = promauto.NewCounter(
+	prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "events_redelivered_total",
+		Help:      "Total number of tracking events claimed from another consumer of the Redis event stream.",
+	},
+)
+
+// EventsReorderedTotal counts events the reorder window applied ahead of an
+// event that arrived before them but carries a later timestamp.
+var EventsReorderedTotal = promauto.NewCounter(
+	prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "events_reordered_total",
+		Help:      "Total number of tracking events applied out of arrival order to respect their timestamps.",
+	},
+)
+
+// EventsLateTotal counts events older than the shipment's last applied
+// transition, recorded in the history without changing the status.
+// Label:
+//   - status: the status carried by the late event
+var EventsLateTotal = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "events_late_total",
+		Help:      "Total number of tracking events that arrived after a newer transition had been applied.",
+	},
+	[]string{"status"},
+)
+
 // EventProcessingDuration measures how long a single event takes to process end-to-end.
 // Label:
 //   - status: the resulting shipment status, or "error" on failure
@@ -77,8 +131,77 @@ var EventProcessingDuration = promauto.NewHistogramVec(
 	[]string{"status"},
 )
 
+// EventRetriesTotal counts retries scheduled by the dispatcher after a transient failure.
+// Label:
+//   - reason: error kind of the failed attempt (e.g. "timeout", "other")
+var EventRetriesTotal = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "events_retries_total",
+		Help:      "Total number of tracking event processing retries.",
+	},
+	[]string{"reason"},
+)
+
+// EventGiveUpsTotal counts events the dispatcher stopped retrying.
+// Labels:
+//   - reason: error kind of the last attempt (e.g. "invalid_transition", "timeout")
+//   - cause:  "permanent" (not retryable), "exhausted" (max attempts reached) or "shutdown"
+var EventGiveUpsTotal = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "events_give_ups_total",
+		Help:      "Total number of tracking events abandoned by the dispatcher after failing.",
+	},
+	[]string{"reason", "cause"},
+)
+
+// DeadLettersTotal counts events moved to the dead-letter store after failing processing.
+var DeadLettersTotal = promauto.NewCounter(
+	prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "events_dead_lettered_total",
+		Help:      "Total number of tracking events stored in the dead-letter queue.",
+	},
+)
+
+// DeadLetterReplaysTotal counts manual replays of dead-lettered events.
+// Label:
+//   - result: "success" (event applied, entry removed) or "failure" (entry kept)
+var DeadLetterReplaysTotal = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "events_dead_letter_replays_total",
+		Help:      "Total number of dead-letter replays, labelled by result (success/failure).",
+	},
+	[]string{"result"},
+)
+
 // ── Shipment metrics ──────────────────────────────────────────────────────────
 
+// DeliveryAttemptsFailedTotal counts failed delivery attempts applied to
+// shipments.
+// Label:
+//   - reason_code: why the attempt failed (e.g. "recipient_absent")
+var DeliveryAttemptsFailedTotal = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "delivery_attempts_failed_total",
+		Help:      "Total number of failed delivery attempts, by reason code.",
+	},
+	[]string{"reason_code"},
+)
+
+// ShipmentsAutoReturnedTotal counts shipments sent back to their sender after
+// running out of delivery attempts.
+var ShipmentsAutoReturnedTotal = promauto.NewCounter(
+	prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "shipments_auto_returned_total",
+		Help:      "Total number of shipments returned to sender after reaching the maximum failed delivery attempts.",
+	},
+)
+
 // ShipmentsCreatedTotal counts newly created shipments.
 // Label:
 //   - service_type: "same_day", "next_day", or "standard"
@@ -90,3 +213,100 @@ var ShipmentsCreatedTotal = promauto.NewCounterVec(
 	},
 	[]string{"service_type"},
 )
+
+// ShipmentsCancelledTotal counts shipments cancelled through the API.
+// Label:
+//   - role: role of the user who cancelled ("client" or "admin")
+var ShipmentsCancelledTotal = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "shipments_cancelled_total",
+		Help:      "Total number of shipments cancelled through the API, by role of the requester.",
+	},
+	[]string{"role"},
+)
+
+// ShipmentsAmendedTotal counts amendments applied to shipments before pickup.
+var ShipmentsAmendedTotal = promauto.NewCounter(
+	prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "shipments_amended_total",
+		Help:      "Total number of amendments applied to shipments before pickup.",
+	},
+)
+
+// ETARevisedTotal counts estimated deliveries pushed back by tracking events.
+// Label:
+//   - reason: "failed_attempt" or "late"
+var ETARevisedTotal = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "eta_revised_total",
+		Help:      "Total number of estimated deliveries pushed back by tracking events, by reason.",
+	},
+	[]string{"reason"},
+)
+
+// QuotesTotal counts prices computed, for quotes and for new shipments.
+// Labels:
+//   - service_type: "same_day", "next_day", or "standard"
+//   - result:       "priced", or the reason no price was given
+var QuotesTotal = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "quotes_total",
+		Help:      "Total number of shipment prices computed, by service type and result.",
+	},
+	[]string{"service_type", "result"},
+)
+
+// ShipmentImportRowsTotal counts rows of shipment import files handled.
+// Label:
+//   - result: "created", or "failed" for rows reported in the error report
+var ShipmentImportRowsTotal = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "shipment_import_rows_total",
+		Help:      "Total number of rows of shipment import files handled, by result.",
+	},
+	[]string{"result"},
+)
+
+// TokenRefreshesTotal counts refresh tokens presented to POST /auth/refresh.
+// Label:
+//   - result: "rotated", "invalid" (unknown, expired or revoked), or "reused"
+//     when a spent token is presented again and its family is revoked
+var TokenRefreshesTotal = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "token_refreshes_total",
+		Help:      "Total number of refresh tokens presented, by result.",
+	},
+	[]string{"result"},
+)
+
+// APIKeyAuthTotal counts requests authenticated with an API key.
+// Label:
+//   - result: "authenticated", "invalid" (unknown, revoked or expired), or
+//     "ip_not_allowed"
+var APIKeyAuthTotal = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "api_key_auth_total",
+		Help:      "Total number of requests carrying an API key, by result.",
+	},
+	[]string{"result"},
+)
+
+// ScopeDenialsTotal counts requests denied for lacking a scope.
+// Labels:
+//   - scope: the scope the route requires
+//   - role:  the role of the token or API key
+var ScopeDenialsTotal = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "scope_denials_total",
+		Help:      "Total number of requests denied for lacking a scope, by scope and role.",
+	},
+	[]string{"scope", "role"},
+)
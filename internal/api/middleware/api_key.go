@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/99minutos/shipping-system/internal/core/domain"
+	"github.com/99minutos/shipping-system/internal/core/ports"
+)
+
+// APIKeyHeader is the header requests carry an API key in.
+const APIKeyHeader = "X-API-Key"
+
+// APIKey authenticates requests carrying an API key and hands the others to
+// fallback, the JWT middleware. A key acts as its client: the context gets
+// the claims a token of the key's role would, with the key's prefix as
+// username and its ID as api_key_id.
+func APIKey(keys ports.APIKeyService, fallback echo.MiddlewareFunc) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		withToken := fallback(next)
+		return func(c echo.Context) error {
+			raw := c.Request().Header.Get(APIKeyHeader)
+			if raw == "" {
+				return withToken(c)
+			}
+
+			key, err := keys.Authenticate(c.Request().Context(), raw, c.RealIP())
+			switch {
+			case errors.Is(err, domain.ErrInvalidAPIKey):
+				return echo.NewHTTPError(http.StatusUnauthorized, "invalid api key")
+			case errors.Is(err, domain.ErrAPIKeyIPNotAllowed):
+				return echo.NewHTTPError(http.StatusForbidden, "ip address not allowed for this api key")
+			case err != nil:
+				return fmt.Errorf("api key: %w", err)
+			}
+
+			c.Set("username", key.Prefix)
+			c.Set("role", key.KeyRole())
+			c.Set("client_id", key.ClientID)
+			c.Set("scopes", domain.RoleScopes(key.KeyRole()))
+			c.Set("api_key_id", key.ID)
+			return next(c)
+		}
+	}
+}
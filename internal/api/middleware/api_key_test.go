@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/99minutos/shipping-system/internal/core/domain"
+	"github.com/99minutos/shipping-system/internal/core/ports"
+)
+
+// stubAPIKeys accepts "99mk_good" from any address but 198.51.100.1.
+type stubAPIKeys struct {
+	ports.APIKeyService
+	ip string
+}
+
+func (s *stubAPIKeys) Authenticate(_ context.Context, key, ip string) (*domain.APIKey, error) {
+	s.ip = ip
+	switch {
+	case key == "99mk_fail":
+		return nil, errors.New("mongo down")
+	case key != "99mk_good":
+		return nil, domain.ErrInvalidAPIKey
+	case ip == "198.51.100.1":
+		return nil, domain.ErrAPIKeyIPNotAllowed
+	}
+	return &domain.APIKey{ID: "key_1", ClientID: "client_1", Prefix: "99mk_good"}, nil
+}
+
+func TestAPIKeyMiddleware(t *testing.T) {
+	fallbackCalled := false
+	fallback := func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			fallbackCalled = true
+			c.Set("role", domain.RoleAdmin)
+			return next(c)
+		}
+	}
+
+	tests := []struct {
+		name         string
+		key          string
+		ip           string
+		wantCode     int
+		wantRole     string
+		wantFallback bool
+	}{
+		{name: "valid key", key: "99mk_good", ip: "203.0.113.7", wantCode: http.StatusOK, wantRole: domain.RoleClient},
+		{name: "no key falls back to JWT", wantCode: http.StatusOK, wantRole: domain.RoleAdmin, wantFallback: true},
+		{name: "invalid key", key: "99mk_bad", wantCode: http.StatusUnauthorized},
+		{name: "address not allowed", key: "99mk_good", ip: "198.51.100.1", wantCode: http.StatusForbidden},
+		{name: "lookup error", key: "99mk_fail", wantCode: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fallbackCalled = false
+			keys := &stubAPIKeys{}
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.key != "" {
+				req.Header.Set(APIKeyHeader, tt.key)
+			}
+			if tt.ip != "" {
+				req.RemoteAddr = tt.ip + ":4242"
+			}
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			var role string
+			h := APIKey(keys, fallback)(func(c echo.Context) error {
+				role, _ = c.Get("role").(string)
+				if role == domain.RoleClient && (c.Get("client_id") != "client_1" || c.Get("api_key_id") != "key_1" || c.Get("username") != "99mk_good") {
+					t.Errorf("unexpected claims: %v %v %v", c.Get("client_id"), c.Get("api_key_id"), c.Get("username"))
+				}
+				if scopes, _ := c.Get("scopes").([]string); role == domain.RoleClient && !slices.Equal(scopes, domain.RoleScopes(domain.RoleClient)) {
+					t.Errorf("expected the scopes of the client role, got %v", scopes)
+				}
+				return c.NoContent(http.StatusOK)
+			})
+			if err := h(c); err != nil {
+				e.HTTPErrorHandler(err, c)
+			}
+
+			if rec.Code != tt.wantCode {
+				t.Fatalf("expected %d, got %d", tt.wantCode, rec.Code)
+			}
+			if role != tt.wantRole {
+				t.Errorf("expected role %q, got %q", tt.wantRole, role)
+			}
+			if fallbackCalled != tt.wantFallback {
+				t.Errorf("expected fallback called %v, got %v", tt.wantFallback, fallbackCalled)
+			}
+			if tt.ip != "" && keys.ip != tt.ip {
+				t.Errorf("expected the key checked from %s, got %s", tt.ip, keys.ip)
+			}
+		})
+	}
+}
@@ -1,15 +1,26 @@
 package middleware
 
 import (
+	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/labstack/echo/v4"
+
+	"github.com/99minutos/shipping-system/internal/core/domain"
+	"github.com/99minutos/shipping-system/internal/core/ports"
+	"github.com/99minutos/shipping-system/internal/pkg/jwtkeys"
 )
 
-// Auth validates the JWT and injects claims into context.
-func Auth(jwtSecret string) echo.MiddlewareFunc {
+// Auth validates the JWT against the keys of the key set and injects claims
+// into context. The scopes of tokens without a scope claim are those of
+// their role.
+//
+// With a denylist, tokens must carry a jti claim and are rejected once
+// revoked on logout. A nil denylist only checks the signature and expiry.
+func Auth(keys *jwtkeys.KeySet, denylist ports.TokenDenylist) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
 			authHeader := c.Request().Header.Get("Authorization")
@@ -23,21 +34,48 @@ func Auth(jwtSecret string) echo.MiddlewareFunc {
 			}
 
 			claims := jwt.MapClaims{}
-			tkn, err := jwt.ParseWithClaims(parts[1], claims, func(token *jwt.Token) (interface{}, error) {
-				if token.Method.Alg() != jwt.SigningMethodHS256.Alg() {
-					return nil, jwt.ErrTokenSignatureInvalid
-				}
-				return []byte(jwtSecret), nil
-			})
+			tkn, err := jwt.ParseWithClaims(parts[1], claims, keys.Keyfunc, jwt.WithValidMethods(keys.Methods()))
 			if err != nil || !tkn.Valid {
 				return echo.NewHTTPError(http.StatusUnauthorized, "invalid token")
 			}
 
+			jti, _ := claims["jti"].(string)
+			if denylist != nil {
+				if jti == "" {
+					return echo.NewHTTPError(http.StatusUnauthorized, "invalid token")
+				}
+				revoked, err := denylist.IsRevoked(c.Request().Context(), jti)
+				if err != nil {
+					return fmt.Errorf("auth: %w", err)
+				}
+				if revoked {
+					return echo.NewHTTPError(http.StatusUnauthorized, "token revoked")
+				}
+			}
+
 			c.Set("username", claims["username"])
 			c.Set("role", claims["role"])
 			c.Set("client_id", claims["client_id"])
+			c.Set("scopes", tokenScopes(claims))
+			c.Set("user_id", claims["sub"])
+			c.Set("jti", jti)
+			var expiresAt time.Time
+			if exp, _ := claims.GetExpirationTime(); exp != nil {
+				expiresAt = exp.Time
+			}
+			c.Set("token_expires_at", expiresAt)
 
 			return next(c)
 		}
 	}
 }
+
+// tokenScopes returns the space-separated scopes of the scope claim, or the
+// scopes of the role of tokens issued without one.
+func tokenScopes(claims jwt.MapClaims) []string {
+	if scope, ok := claims["scope"].(string); ok {
+		return strings.Fields(scope)
+	}
+	role, _ := claims["role"].(string)
+	return domain.RoleScopes(role)
+}
@@ -1,12 +1,25 @@
 package middleware
 
 import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
 	"testing"
+	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/labstack/echo/v4"
+
+	"github.com/99minutos/shipping-system/internal/core/domain"
+	"github.com/99minutos/shipping-system/internal/pkg/jwtkeys"
 )
 
 func TestAuthMiddleware_ValidToken(t *testing.T) {
@@ -27,7 +40,7 @@ func TestAuthMiddleware_ValidToken(t *testing.T) {
 	c := e.NewContext(req, rec)
 
 	called := false
-	mw := Auth("secret")
+	mw := Auth(jwtkeys.NewHMAC("secret"), nil)
 	handler := mw(func(c echo.Context) error {
 		called = true
 		if c.Get("username") != "alice" {
@@ -59,7 +72,7 @@ func TestAuthMiddleware_MissingHeader(t *testing.T) {
 	rec := httptest.NewRecorder()
 	c := e.NewContext(req, rec)
 
-	mw := Auth("secret")
+	mw := Auth(jwtkeys.NewHMAC("secret"), nil)
 	handler := mw(func(c echo.Context) error {
 		t.Fatalf("should not reach next")
 		return nil
@@ -81,7 +94,7 @@ func TestAuthMiddleware_InvalidHeaderFormat(t *testing.T) {
 	rec := httptest.NewRecorder()
 	c := e.NewContext(req, rec)
 
-	mw := Auth("secret")
+	mw := Auth(jwtkeys.NewHMAC("secret"), nil)
 	handler := mw(func(c echo.Context) error {
 		t.Fatalf("should not reach next")
 		return nil
@@ -103,7 +116,7 @@ func TestAuthMiddleware_InvalidToken(t *testing.T) {
 	rec := httptest.NewRecorder()
 	c := e.NewContext(req, rec)
 
-	mw := Auth("secret")
+	mw := Auth(jwtkeys.NewHMAC("secret"), nil)
 	handler := mw(func(c echo.Context) error {
 		t.Fatalf("should not reach next")
 		return nil
@@ -117,3 +130,136 @@ func TestAuthMiddleware_InvalidToken(t *testing.T) {
 		t.Fatalf("expected 401, got %d", rec.Code)
 	}
 }
+
+type stubDenylist map[string]bool
+
+func (d stubDenylist) Revoke(_ context.Context, jti string, _ time.Time) error {
+	d[jti] = true
+	return nil
+}
+
+func (d stubDenylist) IsRevoked(_ context.Context, jti string) (bool, error) {
+	return d[jti], nil
+}
+
+func TestAuthMiddleware_Denylist(t *testing.T) {
+	sign := func(claims jwt.MapClaims) string {
+		signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("secret"))
+		if err != nil {
+			t.Fatalf("sign token: %v", err)
+		}
+		return signed
+	}
+	exp := time.Now().Add(time.Minute).Truncate(time.Second)
+	denylist := stubDenylist{"revoked": true}
+
+	tests := []struct {
+		name  string
+		token string
+		want  int
+	}{
+		{"active", sign(jwt.MapClaims{"role": "admin", "sub": "user_1", "jti": "active", "exp": exp.Unix()}), http.StatusOK},
+		{"revoked", sign(jwt.MapClaims{"role": "admin", "jti": "revoked", "exp": exp.Unix()}), http.StatusUnauthorized},
+		{"without jti", sign(jwt.MapClaims{"role": "admin", "exp": exp.Unix()}), http.StatusUnauthorized},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Authorization", "Bearer "+tc.token)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			handler := Auth(jwtkeys.NewHMAC("secret"), denylist)(func(c echo.Context) error {
+				if c.Get("jti") != "active" || c.Get("user_id") != "user_1" || !c.Get("token_expires_at").(time.Time).Equal(exp) {
+					t.Fatalf("token claims not set")
+				}
+				return c.NoContent(http.StatusOK)
+			})
+			if err := handler(c); err != nil {
+				e.HTTPErrorHandler(err, c)
+			}
+			if rec.Code != tc.want {
+				t.Fatalf("expected %d, got %d", tc.want, rec.Code)
+			}
+		})
+	}
+}
+
+func TestAuthMiddleware_SigningKeys(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	der, _ := x509.MarshalPKCS8PrivateKey(key)
+	file := filepath.Join(t.TempDir(), "2026-07.pem")
+	if err := os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	keys, err := jwtkeys.Load([]string{file}, "")
+	if err != nil {
+		t.Fatalf("load keys: %v", err)
+	}
+
+	signed, _ := keys.Sign(map[string]any{"role": "admin"})
+	shared, _ := jwtkeys.NewHMAC("secret").Sign(map[string]any{"role": "admin"})
+
+	tests := []struct {
+		name  string
+		token string
+		want  int
+	}{
+		{"signed with the key", signed, http.StatusOK},
+		{"signed with the shared secret", shared, http.StatusUnauthorized},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Authorization", "Bearer "+tc.token)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			handler := Auth(keys, nil)(func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			})
+			if err := handler(c); err != nil {
+				e.HTTPErrorHandler(err, c)
+			}
+			if rec.Code != tc.want {
+				t.Fatalf("expected %d, got %d", tc.want, rec.Code)
+			}
+		})
+	}
+}
+
+func TestAuthMiddleware_Scopes(t *testing.T) {
+	tests := []struct {
+		name   string
+		claims map[string]any
+		want   []string
+	}{
+		{"scope claim", map[string]any{"role": "admin", "scope": "events:write shipments:read"}, []string{"events:write", "shipments:read"}},
+		{"empty scope claim", map[string]any{"role": "admin", "scope": ""}, []string{}},
+		{"role without scope claim", map[string]any{"role": "carrier"}, domain.RoleScopes(domain.RoleCarrier)},
+		{"unknown role", map[string]any{"role": "guest"}, nil},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			token, _ := jwtkeys.NewHMAC("secret").Sign(tc.claims)
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			c := e.NewContext(req, httptest.NewRecorder())
+
+			var got []string
+			handler := Auth(jwtkeys.NewHMAC("secret"), nil)(func(c echo.Context) error {
+				got, _ = c.Get("scopes").([]string)
+				return nil
+			})
+			if err := handler(c); err != nil {
+				t.Fatalf("handler error: %v", err)
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Fatalf("expected scopes %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
@@ -2,24 +2,47 @@ package middleware
 
 import (
 	"net/http"
+	"slices"
+	"strings"
 
 	"github.com/labstack/echo/v4"
-)
+	"github.com/rs/zerolog"
 
-// RBAC enforces role-based access control.
-func RBAC(allowedRoles ...string) echo.MiddlewareFunc {
-	allowed := make(map[string]struct{}, len(allowedRoles))
-	for _, r := range allowedRoles {
-		allowed[r] = struct{}{}
-	}
+	"github.com/99minutos/shipping-system/internal/api/metrics"
+)
 
+// RequireScopes lets through requests whose token or API key holds every
+// one of scopes, set in context by Auth or APIKey. Denials are logged and
+// counted.
+func RequireScopes(log zerolog.Logger, scopes ...string) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
+			granted, _ := c.Get("scopes").([]string)
+			var missing []string
+			for _, s := range scopes {
+				if !slices.Contains(granted, s) {
+					missing = append(missing, s)
+				}
+			}
+			if len(missing) == 0 {
+				return next(c)
+			}
+
 			role, _ := c.Get("role").(string)
-			if _, ok := allowed[role]; !ok {
-				return c.JSON(http.StatusForbidden, map[string]string{"error": "forbidden"})
+			clientID, _ := c.Get("client_id").(string)
+			username, _ := c.Get("username").(string)
+			for _, s := range missing {
+				metrics.ScopeDenialsTotal.WithLabelValues(s, role).Inc()
 			}
-			return next(c)
+			log.Warn().
+				Str("method", c.Request().Method).
+				Str("path", c.Path()).
+				Str("role", role).
+				Str("client_id", clientID).
+				Str("username", username).
+				Strs("missing_scopes", missing).
+				Msg("request denied")
+			return echo.NewHTTPError(http.StatusForbidden, "missing scope "+strings.Join(missing, " "))
 		}
 	}
 }
@@ -1,22 +1,27 @@
 package middleware
 
 import (
+	"bytes"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+
+	"github.com/99minutos/shipping-system/internal/core/domain"
 )
 
-func TestRBAC_Allows(t *testing.T) {
+func TestRequireScopes_Allows(t *testing.T) {
 	e := echo.New()
 	req := httptest.NewRequest(http.MethodGet, "/", nil)
 	rec := httptest.NewRecorder()
 	c := e.NewContext(req, rec)
-	c.Set("role", "admin")
+	c.Set("scopes", domain.RoleScopes(domain.RoleCarrier))
 
 	called := false
-	mw := RBAC("admin", "client")
+	mw := RequireScopes(zerolog.Nop(), domain.ScopeEventsWrite, domain.ScopeShipmentsRead)
 	handler := mw(func(c echo.Context) error {
 		called = true
 		return c.NoContent(http.StatusOK)
@@ -33,20 +38,52 @@ func TestRBAC_Allows(t *testing.T) {
 	}
 }
 
-func TestRBAC_Forbids(t *testing.T) {
+func TestRequireScopes_Forbids(t *testing.T) {
+	var logs bytes.Buffer
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/v1/events", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set("role", domain.RoleClient)
+	c.Set("client_id", "client_1")
+	c.Set("scopes", domain.RoleScopes(domain.RoleClient))
+
+	mw := RequireScopes(zerolog.New(&logs), domain.ScopeEventsWrite)
+	handler := mw(func(c echo.Context) error {
+		t.Fatalf("should not reach next handler")
+		return nil
+	})
+
+	if err := handler(c); err != nil {
+		e.HTTPErrorHandler(err, c)
+	}
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), "events:write") {
+		t.Errorf("expected the missing scope in the response, got %s", rec.Body.String())
+	}
+	if !strings.Contains(logs.String(), `"missing_scopes":["events:write"]`) || !strings.Contains(logs.String(), `"client_id":"client_1"`) {
+		t.Errorf("expected the denial to be logged, got %s", logs.String())
+	}
+}
+
+func TestRequireScopes_ForbidsWithoutScopes(t *testing.T) {
 	e := echo.New()
 	req := httptest.NewRequest(http.MethodGet, "/", nil)
 	rec := httptest.NewRecorder()
 	c := e.NewContext(req, rec)
 	c.Set("role", "guest")
 
-	mw := RBAC("admin", "client")
+	mw := RequireScopes(zerolog.Nop(), domain.ScopeShipmentsRead)
 	handler := mw(func(c echo.Context) error {
 		t.Fatalf("should not reach next handler")
 		return nil
 	})
 
-	_ = handler(c)
+	if err := handler(c); err != nil {
+		e.HTTPErrorHandler(err, c)
+	}
 	if rec.Code != http.StatusForbidden {
 		t.Fatalf("expected 403, got %d", rec.Code)
 	}
@@ -2,31 +2,50 @@ package api
 
 import (
 	"context"
-	"time"
+	"fmt"
+	"net"
+	"os"
+	"strings"
 
 	"github.com/labstack/echo-contrib/echoprometheus"
 	"github.com/labstack/echo/v4"
 	echomiddleware "github.com/labstack/echo/v4/middleware"
 	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
 	echoswagger "github.com/swaggo/echo-swagger"
 	"go.mongodb.org/mongo-driver/mongo"
 
 	"github.com/99minutos/shipping-system/internal/api/handler"
 	_ "github.com/99minutos/shipping-system/internal/api/metrics" // register custom metrics with Prometheus
 	"github.com/99minutos/shipping-system/internal/api/middleware"
+	"github.com/99minutos/shipping-system/internal/core/domain"
+	"github.com/99minutos/shipping-system/internal/core/ports"
 	"github.com/99minutos/shipping-system/internal/core/service"
 	mongoinfra "github.com/99minutos/shipping-system/internal/infrastructure/db/mongo"
 	redisinfra "github.com/99minutos/shipping-system/internal/infrastructure/db/redis"
 	"github.com/99minutos/shipping-system/internal/infrastructure/queue"
+	"github.com/99minutos/shipping-system/internal/pkg/config"
+	"github.com/99minutos/shipping-system/internal/pkg/jwtkeys"
 	"github.com/99minutos/shipping-system/internal/pkg/logger"
+	"github.com/99minutos/shipping-system/internal/pkg/ratecard"
 )
 
-// NewRouter builds and returns the Echo instance with all routes registered.
-// ctx is used to control the lifecycle of background event workers.
-func NewRouter(ctx context.Context, db *mongo.Database, rdb *redis.Client, jwtSecret string) *echo.Echo {
+// NewRouter builds and returns the Echo instance with all routes registered,
+// together with the event queue backing /v1/events. Shipments follow
+// lifecycles and are promised for delivery by calendar.
+// ctx is used to control the lifecycle of background event workers; callers
+// should call Shutdown on the returned queue to drain it before cancelling ctx.
+func NewRouter(ctx context.Context, db *mongo.Database, rdb *redis.Client, cfg *config.Config, lifecycles *domain.Lifecycles, calendar *domain.DeliveryCalendar) (*echo.Echo, queue.Runner, error) {
 	e := echo.New()
 	e.HideBanner = true
-	e.Validator = handler.NewValidator()
+	e.Validator = handler.NewValidator(lifecycles)
+	// API key allowlists are checked against the client address; only the
+	// configured proxies are trusted to report it in X-Forwarded-For.
+	ipExtractor, err := newIPExtractor(cfg.TrustedProxies)
+	if err != nil {
+		return nil, nil, err
+	}
+	e.IPExtractor = ipExtractor
 
 	// --- Global middleware ---
 	e.Use(echomiddleware.Recover())
@@ -44,26 +63,75 @@ func NewRouter(ctx context.Context, db *mongo.Database, rdb *redis.Client, jwtSe
 
 	e.HTTPErrorHandler = NewHTTPErrorHandler(log)
 
+	keys, err := newKeySet(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	jwksHandler := handler.NewJWKSHandler(keys)
+
 	authRepo := mongoinfra.NewAuthRepository(db)
-	authService := service.NewAuthService(authRepo, jwtSecret, 24*time.Hour)
+	refreshTokenRepo := mongoinfra.NewRefreshTokenRepository(db)
+	invitationRepo := mongoinfra.NewInvitationRepository(db)
+	tokenDenylist := redisinfra.NewTokenDenylist(rdb)
+	authService := service.NewAuthService(authRepo, refreshTokenRepo, invitationRepo, tokenDenylist, keys, cfg.AccessTokenTTL, cfg.RefreshTokenTTL)
 	authHandler := handler.NewAuthHandler(authService)
+	userService := service.NewUserService(authRepo, refreshTokenRepo, invitationRepo, cfg.InvitationTTL, log)
+	userHandler := handler.NewUserHandler(userService)
+
+	apiKeyRepo := mongoinfra.NewAPIKeyRepository(db)
+	apiKeyService := service.NewAPIKeyService(apiKeyRepo, log)
+	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyService)
+
+	// Shipments are priced only when rate cards are configured.
+	var rateCards ports.RateCardProvider
+	var pricingService ports.PricingService
+	if cfg.RateCardsDir != "" {
+		store, err := ratecard.Load(cfg.RateCardsDir)
+		if err != nil {
+			return nil, nil, err
+		}
+		go store.Watch(ctx, cfg.RateCardsReloadInterval, log)
+		rateCards = store
+		pricingService = service.NewPricingService(store, log)
+	}
+	quoteHandler := handler.NewQuoteHandler(pricingService)
 
-	shipmentRepo := mongoinfra.NewShipmentRepository(db)
-	shipmentService := service.NewShipmentService(shipmentRepo, log)
-	shipmentHandler := handler.NewShipmentHandler(shipmentService)
+	trackingNumbers, err := domain.NewTrackingNumbers(cfg.TrackingPrefix, cfg.TrackingClientPrefixes)
+	if err != nil {
+		return nil, nil, err
+	}
+	shipmentRepo := mongoinfra.NewShipmentRepository(db, trackingNumbers)
+	shipmentService := service.NewShipmentService(shipmentRepo, rateCards, lifecycles, calendar, log)
+	shipmentHandler := handler.NewShipmentHandler(shipmentService, cfg.ShipmentBatchMaxSize, lifecycles)
+
+	importRepo := mongoinfra.NewShipmentImportRepository(db)
+	importService := service.NewShipmentImportService(importRepo, shipmentService, handler.NewShipmentRowDecoder(lifecycles), cfg.ImportPollInterval, log)
+	go importService.Run(ctx)
+	importHandler := handler.NewShipmentImportHandler(importService, cfg.ImportMaxRows)
 
 	eventRepo := mongoinfra.NewEventRepository(db)
 	dedup := redisinfra.NewDedupChecker(rdb)
-	eventService := service.NewEventService(shipmentRepo, eventRepo, dedup, log)
-	dispatcher := queue.NewDispatcher(0, eventService, log)
-	dispatcher.Start(ctx)
-	eventHandler := handler.NewEventHandler(dispatcher)
+	receipts := redisinfra.NewEventReceiptStore(rdb, cfg.Queue.StatusTTL)
+	eventService := service.NewEventService(shipmentRepo, eventRepo, dedup, receipts, lifecycles, calendar, log)
+	deadLetterRepo := mongoinfra.NewDeadLetterRepository(db)
+	deadLetterService := service.NewDeadLetterService(deadLetterRepo, eventService, receipts, log)
+	deadLetterHandler := handler.NewDeadLetterHandler(deadLetterService)
+	eventQueue, err := newEventQueue(cfg.Queue, rdb, eventService, deadLetterService, log)
+	if err != nil {
+		return nil, nil, err
+	}
+	eventQueue.Start(ctx)
+	eventIngestService := service.NewEventIngestService(eventQueue, eventService, receipts, log)
+	eventHandler := handler.NewEventHandler(eventIngestService, cfg.Queue.RetryAfter, cfg.EventSyncBatchMaxSize)
 
-	authMiddleware := middleware.Auth(jwtSecret)
+	authMiddleware := middleware.Auth(keys, tokenDenylist)
 
-	// --- Auth routes (public) ---
+	// --- Auth routes (public, except logout) ---
 	e.POST("/auth/register", authHandler.Register)
 	e.POST("/auth/login", authHandler.Login)
+	e.POST("/auth/refresh", authHandler.Refresh)
+	e.POST("/auth/logout", authHandler.Logout, authMiddleware)
+	e.GET("/.well-known/jwks.json", jwksHandler.Get)
 
 	// --- Health probes (no auth required) ---
 	healthHandler := handler.NewHealthHandler()
@@ -76,15 +144,164 @@ func NewRouter(ctx context.Context, db *mongo.Database, rdb *redis.Client, jwtSe
 	e.GET("/metrics", echoprometheus.NewHandler())
 
 	// --- Swagger UI ---
+	e.GET("/swagger/doc.json", handler.SwaggerDoc(lifecycles))
 	e.GET("/swagger/*", echoswagger.WrapHandler)
 
-	// --- v1 API (JWT protected) ---
-	v1 := e.Group("/v1", authMiddleware)
-	v1.GET("/shipments", shipmentHandler.List)
-	v1.POST("/shipments", shipmentHandler.Create)
-	v1.GET("/shipments/:tracking_number", shipmentHandler.Get)
-	v1.POST("/events", eventHandler.Receive)
-	v1.POST("/events/batch", eventHandler.ReceiveBatch)
+	// scope declares the scopes a route requires; denials are logged and
+	// counted.
+	scope := func(scopes ...string) echo.MiddlewareFunc {
+		return middleware.RequireScopes(log, scopes...)
+	}
+
+	// --- API key management (JWT only: a key cannot manage keys) ---
+	apiKeys := e.Group("/v1/api-keys", authMiddleware, scope(domain.ScopeAPIKeysManage))
+	apiKeys.POST("", apiKeyHandler.Create)
+	apiKeys.GET("", apiKeyHandler.List)
+	apiKeys.POST("/:id/rotate", apiKeyHandler.Rotate)
+	apiKeys.DELETE("/:id", apiKeyHandler.Revoke)
+
+	// --- v1 API (API key or JWT protected) ---
+	v1 := e.Group("/v1", middleware.APIKey(apiKeyService, authMiddleware))
+	v1.GET("/shipments", shipmentHandler.List, scope(domain.ScopeShipmentsRead))
+	v1.POST("/shipments", shipmentHandler.Create, scope(domain.ScopeShipmentsCreate))
+	v1.POST("/shipments/batch", shipmentHandler.CreateBatch, scope(domain.ScopeShipmentsCreate))
+	v1.POST("/shipments/imports", importHandler.Create, scope(domain.ScopeShipmentsCreate))
+	v1.GET("/shipments/imports/:id", importHandler.Get, scope(domain.ScopeShipmentsRead))
+	v1.GET("/shipments/imports/:id/errors", importHandler.Errors, scope(domain.ScopeShipmentsRead))
+	v1.GET("/shipments/:tracking_number", shipmentHandler.Get, scope(domain.ScopeShipmentsRead))
+	v1.PATCH("/shipments/:tracking_number", shipmentHandler.Amend, scope(domain.ScopeShipmentsUpdate))
+	v1.POST("/shipments/:tracking_number/cancel", shipmentHandler.Cancel, scope(domain.ScopeShipmentsUpdate))
+	v1.POST("/quotes", quoteHandler.Create, scope(domain.ScopeShipmentsCreate))
+	v1.POST("/events", eventHandler.Receive, scope(domain.ScopeEventsWrite))
+	v1.POST("/events/batch", eventHandler.ReceiveBatch, scope(domain.ScopeEventsWrite))
+	v1.GET("/events/batches/:id", eventHandler.GetBatch, scope(domain.ScopeEventsRead))
+	v1.GET("/events/:id", eventHandler.Get, scope(domain.ScopeEventsRead))
+
+	// --- Admin API ---
+	admin := v1.Group("/admin")
+	admin.GET("/dead-letters", deadLetterHandler.List, scope(domain.ScopeDeadLettersManage))
+	admin.DELETE("/dead-letters", deadLetterHandler.Purge, scope(domain.ScopeDeadLettersManage))
+	admin.GET("/dead-letters/:id", deadLetterHandler.Get, scope(domain.ScopeDeadLettersManage))
+	admin.DELETE("/dead-letters/:id", deadLetterHandler.Delete, scope(domain.ScopeDeadLettersManage))
+	admin.POST("/dead-letters/:id/replay", deadLetterHandler.Replay, scope(domain.ScopeDeadLettersManage))
+	admin.POST("/users", userHandler.Create, scope(domain.ScopeUsersManage))
+	admin.GET("/users", userHandler.List, scope(domain.ScopeUsersManage))
+	admin.GET("/users/:id", userHandler.Get, scope(domain.ScopeUsersManage))
+	admin.PATCH("/users/:id", userHandler.Update, scope(domain.ScopeUsersManage))
+	admin.POST("/users/:id/disable", userHandler.Disable, scope(domain.ScopeUsersManage))
+	admin.POST("/users/:id/enable", userHandler.Enable, scope(domain.ScopeUsersManage))
+	admin.DELETE("/users/:id", userHandler.Delete, scope(domain.ScopeUsersManage))
+	admin.POST("/invitations", userHandler.Invite, scope(domain.ScopeUsersManage))
+
+	return e, eventQueue, nil
+}
+
+// newKeySet loads the keys access tokens are signed with, falling back to
+// the shared JWT secret when no key files are configured.
+func newKeySet(cfg *config.Config) (*jwtkeys.KeySet, error) {
+	if len(cfg.JWTKeys) == 0 {
+		return jwtkeys.NewHMAC(cfg.JWTSecret), nil
+	}
+	return jwtkeys.Load(cfg.JWTKeys, cfg.JWTSigningKey)
+}
+
+// newIPExtractor reads the client address from X-Forwarded-For when the
+// request comes through one of the trusted proxy CIDRs, and from the peer
+// address otherwise. Without trusted proxies the header is ignored.
+func newIPExtractor(trustedProxies []string) (echo.IPExtractor, error) {
+	if len(trustedProxies) == 0 {
+		return echo.ExtractIPDirect(), nil
+	}
+	options := []echo.TrustOption{
+		echo.TrustLoopback(false),
+		echo.TrustLinkLocal(false),
+		echo.TrustPrivateNet(false),
+	}
+	for _, cidr := range trustedProxies {
+		_, ipNet, err := net.ParseCIDR(strings.TrimSpace(cidr))
+		if err != nil {
+			return nil, fmt.Errorf("trusted proxies: %w", err)
+		}
+		options = append(options, echo.TrustIPRange(ipNet))
+	}
+	return echo.ExtractIPFromXFFHeader(options...), nil
+}
+
+// newEventQueue builds the event queue backend selected in configuration.
+func newEventQueue(
+	cfg config.QueueConfig,
+	rdb *redis.Client,
+	events ports.EventService,
+	dlq queue.DeadLetterRecorder,
+	log zerolog.Logger,
+) (queue.Runner, error) {
+	backend, err := queue.ParseBackend(cfg.Backend)
+	if err != nil {
+		return nil, fmt.Errorf("event queue: %w", err)
+	}
+
+	retryPolicy := queue.DefaultRetryPolicy()
+	retryPolicy.MaxAttempts = cfg.RetryMaxAttempts
+	retryPolicy.InitialBackoff = cfg.RetryInitialBackoff
+	retryPolicy.MaxBackoff = cfg.RetryMaxBackoff
+
+	if backend == queue.BackendRedis {
+		instance, err := queueInstance(cfg)
+		if err != nil {
+			return nil, err
+		}
+		opts := queue.StreamOptions{
+			Partitions:    cfg.StreamPartitions,
+			MaxPartitions: cfg.StreamMaxPartitions,
+			LeaseTTL:      cfg.StreamLeaseTTL,
+			ClaimIdle:     cfg.StreamClaimIdle,
+			ReorderWindow: cfg.ReorderWindow,
+		}
+		broker := redisinfra.NewEventStream(rdb, instance)
+		return queue.NewStreamQueue(broker, opts, events, dlq, retryPolicy, log), nil
+	}
+
+	admission, err := newAdmission(cfg, rdb)
+	if err != nil {
+		return nil, err
+	}
+	dispatcher, err := queue.NewDispatcher(cfg.Workers, events, dlq, retryPolicy, cfg.ReorderWindow, admission, log)
+	if err != nil {
+		return nil, fmt.Errorf("event queue: %w", err)
+	}
+	return dispatcher, nil
+}
+
+// newAdmission builds the dispatcher admission settings from configuration.
+func newAdmission(cfg config.QueueConfig, rdb *redis.Client) (queue.Admission, error) {
+	policy, err := queue.ParseAdmissionPolicy(cfg.AdmissionPolicy)
+	if err != nil {
+		return queue.Admission{}, fmt.Errorf("event queue: %w", err)
+	}
+
+	admission := queue.Admission{
+		BufferSize: cfg.BufferSize,
+		Policy:     policy,
+		Timeout:    cfg.EnqueueTimeout,
+	}
+	if policy == queue.AdmissionSpill {
+		instance, err := queueInstance(cfg)
+		if err != nil {
+			return queue.Admission{}, err
+		}
+		admission.Spill = redisinfra.NewSpillStore(rdb, instance)
+	}
+	return admission, nil
+}
 
-	return e
+// queueInstance returns this replica's identity in Redis, defaulting to the hostname.
+func queueInstance(cfg config.QueueConfig) (string, error) {
+	if cfg.Instance != "" {
+		return cfg.Instance, nil
+	}
+	instance, err := os.Hostname()
+	if err != nil {
+		return "", fmt.Errorf("event queue: resolve instance name: %w", err)
+	}
+	return instance, nil
 }
@@ -0,0 +1,38 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewIPExtractor_TrustsOnlyConfiguredProxies(t *testing.T) {
+	tests := []struct {
+		name    string
+		proxies []string
+		peer    string
+		want    string
+	}{
+		{"no proxies ignores the header", nil, "172.17.0.1", "172.17.0.1"},
+		{"private peer not configured", []string{"10.0.0.0/8"}, "172.17.0.1", "172.17.0.1"},
+		{"configured proxy", []string{"10.0.0.0/8"}, "10.1.2.3", "203.0.113.7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			extract, err := newIPExtractor(tt.proxies)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.peer + ":4242"
+			req.Header.Set("X-Forwarded-For", "203.0.113.7")
+			if got := extract(req); got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+
+	if _, err := newIPExtractor([]string{"10.0.0.1"}); err == nil {
+		t.Error("expected an address without a prefix length to be rejected")
+	}
+}
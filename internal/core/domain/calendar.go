@@ -0,0 +1,243 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DeliveryCalendar decides when shipments are promised for. Days are counted
+// in the operation's timezone and only working days that are not holidays
+// count: an order placed after its service's cutoff, or on a day off, is
+// handled from the next business day.
+type DeliveryCalendar struct {
+	// Timezone is the IANA name of the timezone the operation runs in.
+	Timezone string `json:"timezone"`
+	// DeliveryBy is the local time, HH:MM, deliveries are promised by.
+	DeliveryBy string `json:"delivery_by"`
+	// WorkingDays are lowercase English weekday names.
+	WorkingDays []string `json:"working_days"`
+	// Holidays are YYYY-MM-DD dates nothing is picked up or delivered on.
+	Holidays []string                   `json:"holidays,omitempty"`
+	Services map[string]ServiceSchedule `json:"services"`
+	// Default applies to service types not listed in Services.
+	Default ServiceSchedule `json:"default"`
+	Zones   []TransitZone   `json:"zones,omitempty"`
+
+	// Prepared by Validate.
+	loc        *time.Location
+	deliveryBy time.Duration
+	workdays   [7]bool
+	holidays   map[string]bool
+}
+
+// ServiceSchedule is when orders of a service type must be placed and how
+// many business days they take.
+type ServiceSchedule struct {
+	// Cutoff is the local time, HH:MM, after which an order counts as placed
+	// on the next business day.
+	Cutoff      string `json:"cutoff"`
+	TransitDays int    `json:"transit_days"`
+
+	cutoff time.Duration
+}
+
+// TransitZone adds business days to shipments whose destination zip code
+// starts with one of its prefixes. The longest matching prefix wins.
+type TransitZone struct {
+	Name        string   `json:"name"`
+	ZipPrefixes []string `json:"zip_prefixes"`
+	ExtraDays   int      `json:"extra_days"`
+}
+
+// BuiltinCalendar returns the calendar used when no calendar file is
+// configured: Mexico City time, Monday to Friday, no holidays.
+func BuiltinCalendar() *DeliveryCalendar {
+	c := &DeliveryCalendar{
+		Timezone:    "America/Mexico_City",
+		DeliveryBy:  "20:00",
+		WorkingDays: []string{"monday", "tuesday", "wednesday", "thursday", "friday"},
+		Services: map[string]ServiceSchedule{
+			"same_day": {Cutoff: "12:00", TransitDays: 0},
+			"next_day": {Cutoff: "18:00", TransitDays: 1},
+			"standard": {Cutoff: "18:00", TransitDays: 3},
+		},
+		Default: ServiceSchedule{Cutoff: "18:00", TransitDays: 3},
+	}
+	if err := c.Validate(); err != nil {
+		panic(fmt.Sprintf("builtin delivery calendar: %v", err))
+	}
+	return c
+}
+
+// EstimateDelivery returns when a shipment of serviceType to zipCode, placed
+// at the given time, is promised for.
+func (c *DeliveryCalendar) EstimateDelivery(serviceType, zipCode string, placedAt time.Time) time.Time {
+	schedule, ok := c.Services[serviceType]
+	if !ok {
+		schedule = c.Default
+	}
+
+	local := placedAt.In(c.loc)
+	day := midnight(local)
+	if !c.IsBusinessDay(day) || clock(local) >= schedule.cutoff {
+		day = c.nextBusinessDay(day)
+	}
+	for range schedule.TransitDays + c.extraDays(zipCode) {
+		day = c.nextBusinessDay(day)
+	}
+	return at(day, c.deliveryBy).UTC()
+}
+
+// NextDelivery returns the delivery time of the first business day after the
+// given time, the earliest a delayed shipment can be promised for again.
+func (c *DeliveryCalendar) NextDelivery(after time.Time) time.Time {
+	return at(c.nextBusinessDay(midnight(after.In(c.loc))), c.deliveryBy).UTC()
+}
+
+// IsBusinessDay reports whether the day of t, in the calendar's timezone, is
+// a working day and not a holiday.
+func (c *DeliveryCalendar) IsBusinessDay(t time.Time) bool {
+	t = t.In(c.loc)
+	return c.workdays[t.Weekday()] && !c.holidays[t.Format(time.DateOnly)]
+}
+
+func (c *DeliveryCalendar) nextBusinessDay(day time.Time) time.Time {
+	for {
+		day = midnight(day.AddDate(0, 0, 1))
+		if c.IsBusinessDay(day) {
+			return day
+		}
+	}
+}
+
+// extraDays returns the transit days the zone of zipCode adds.
+func (c *DeliveryCalendar) extraDays(zipCode string) int {
+	extra, best := 0, -1
+	for _, z := range c.Zones {
+		for _, prefix := range z.ZipPrefixes {
+			if strings.HasPrefix(zipCode, prefix) && len(prefix) > best {
+				extra, best = z.ExtraDays, len(prefix)
+			}
+		}
+	}
+	return extra
+}
+
+// Validate checks the calendar and prepares it for use; a calendar must be
+// validated before it is used.
+func (c *DeliveryCalendar) Validate() error {
+	var errs []error
+
+	loc, err := time.LoadLocation(c.Timezone)
+	switch {
+	case c.Timezone == "":
+		errs = append(errs, errors.New("timezone is required"))
+	case err != nil:
+		errs = append(errs, fmt.Errorf("timezone: %w", err))
+	}
+	c.loc = loc
+
+	if c.deliveryBy, err = parseClock(c.DeliveryBy); err != nil {
+		errs = append(errs, fmt.Errorf("delivery_by: %w", err))
+	}
+
+	c.workdays = [7]bool{}
+	for _, name := range c.WorkingDays {
+		i := slices.IndexFunc(weekdays(), func(d time.Weekday) bool { return strings.EqualFold(d.String(), name) })
+		if i < 0 {
+			errs = append(errs, fmt.Errorf("working day %q is not a weekday", name))
+			continue
+		}
+		c.workdays[i] = true
+	}
+	if !slices.Contains(c.workdays[:], true) {
+		errs = append(errs, errors.New("at least one working day is required"))
+	}
+
+	c.holidays = make(map[string]bool, len(c.Holidays))
+	for _, h := range c.Holidays {
+		if _, err := time.Parse(time.DateOnly, h); err != nil {
+			errs = append(errs, fmt.Errorf("holiday %q must be YYYY-MM-DD", h))
+		}
+		c.holidays[h] = true
+	}
+
+	names := make([]string, 0, len(c.Services))
+	for name := range c.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		s := c.Services[name]
+		if err := s.prepare(c.deliveryBy); err != nil {
+			errs = append(errs, fmt.Errorf("service %q: %w", name, err))
+		}
+		c.Services[name] = s
+	}
+	if err := c.Default.prepare(c.deliveryBy); err != nil {
+		errs = append(errs, fmt.Errorf("default: %w", err))
+	}
+
+	for _, z := range c.Zones {
+		if z.Name == "" {
+			errs = append(errs, errors.New("zone name is required"))
+		}
+		if len(z.ZipPrefixes) == 0 {
+			errs = append(errs, fmt.Errorf("zone %q has no zip prefixes", z.Name))
+		}
+		if z.ExtraDays < 0 {
+			errs = append(errs, fmt.Errorf("zone %q: extra_days cannot be negative", z.Name))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// prepare parses the cutoff. A service delivered the day it is placed must
+// be cut off no later than deliveries are promised by.
+func (s *ServiceSchedule) prepare(deliveryBy time.Duration) error {
+	var errs []error
+	cutoff, err := parseClock(s.Cutoff)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("cutoff: %w", err))
+	}
+	s.cutoff = cutoff
+	if s.TransitDays < 0 {
+		errs = append(errs, errors.New("transit_days cannot be negative"))
+	}
+	if err == nil && s.TransitDays == 0 && cutoff > deliveryBy {
+		errs = append(errs, errors.New("cutoff of a same-day service cannot be after delivery_by"))
+	}
+	return errors.Join(errs...)
+}
+
+// parseClock parses an HH:MM time of day into the time since midnight.
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("%q must be HH:MM", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// clock returns the time of day of t.
+func clock(t time.Time) time.Duration {
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+}
+
+// at returns the given time of day on day.
+func at(day time.Time, clock time.Duration) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location()).Add(clock)
+}
+
+// midnight returns the start of the day of t in its location.
+func midnight(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func weekdays() []time.Weekday {
+	return []time.Weekday{time.Sunday, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday}
+}
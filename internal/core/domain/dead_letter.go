@@ -0,0 +1,19 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrDeadLetterNotFound = errors.New("dead letter event not found")
+
+// DeadLetterEvent is a tracking event that could not be processed, kept for
+// inspection and manual replay.
+type DeadLetterEvent struct {
+	ID            string
+	Event         TrackingEvent
+	Reason        string // error message of the latest failure
+	Attempts      int
+	FirstFailedAt time.Time
+	LastFailedAt  time.Time
+}
@@ -6,4 +6,16 @@ var (
 	ErrUserExists         = errors.New("user already exists")
 	ErrUserNotFound       = errors.New("user not found")
 	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrUserDisabled       = errors.New("user is disabled")
+	// ErrInvalidUser means a role or client ID a user cannot have: an
+	// unknown role, or a role other than admin without a client ID.
+	ErrInvalidUser = errors.New("invalid role or client_id")
+	// ErrCannotModifySelf means an admin tried to disable, delete or change
+	// the role of their own account, which could lock every admin out.
+	ErrCannotModifySelf = errors.New("admins cannot disable, delete or change the role of their own account")
+
+	// ErrEventQueueFull means the event queue is saturated; the caller should retry later.
+	ErrEventQueueFull = errors.New("event queue is full")
+	// ErrEventQueueUnavailable means the event queue cannot accept events right now.
+	ErrEventQueueUnavailable = errors.New("event queue unavailable")
 )
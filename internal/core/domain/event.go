@@ -1,12 +1,57 @@
 package domain
 
-import "time"
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrEventNotFound      = errors.New("event not found")
+	ErrEventBatchNotFound = errors.New("event batch not found")
+)
 
 // TrackingEvent represents a status update received from an external source.
 type TrackingEvent struct {
+	EventID        string // ingestion ID assigned on acceptance; empty for untracked events
 	TrackingNumber string
 	Status         ShipmentStatus
 	Timestamp      time.Time
 	Source         string
+	Role           string       // role of the user who sent the event
+	ReasonCode     string       // why a delivery attempt failed
+	Piece          string       // barcode of the piece scanned; empty for the whole shipment
 	Location       *Coordinates // optional
+	Late           bool         // older than the shipment's last transition
+}
+
+// EventState is where an accepted event stands in asynchronous ingestion.
+type EventState string
+
+const (
+	EventQueued    EventState = "queued"
+	EventProcessed EventState = "processed"
+	// EventLate means it was older than the shipment's last transition: it
+	// was added to the history without changing the status.
+	EventLate EventState = "late"
+	// EventDuplicate means the same event had already been applied.
+	EventDuplicate EventState = "duplicate"
+	// EventRejected means a business rule refused it (unknown shipment,
+	// invalid transition); resending it will not help.
+	EventRejected EventState = "rejected"
+	// EventFailed means processing kept failing and the event was moved to
+	// the dead-letter queue.
+	EventFailed EventState = "failed"
+)
+
+// EventReceipt follows an accepted event from the queue to its outcome.
+type EventReceipt struct {
+	ID             string
+	BatchID        string // empty for single events
+	TrackingNumber string
+	Status         ShipmentStatus
+	ClientID       string // client of the submitter; empty when an admin submitted it
+	State          EventState
+	Reason         string // why it was rejected or failed
+	AcceptedAt     time.Time
+	UpdatedAt      time.Time
 }
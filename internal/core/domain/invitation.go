@@ -0,0 +1,32 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// ErrInvalidInvitation means a registration carried an unknown, expired or
+// already used invitation, or one issued for another email.
+var ErrInvalidInvitation = errors.New("invalid invitation")
+
+// Invitation lets one person register as a user of a client or carrier
+// account. Only a hash of its token is stored; the token itself is shown
+// once, when the invitation is created.
+type Invitation struct {
+	ID        string    `bson:"_id"`
+	TokenHash string    `bson:"token_hash"` // hex SHA-256 of the token
+	Email     string    `bson:"email"`
+	Role      string    `bson:"role"`
+	ClientID  string    `bson:"client_id"`
+	CreatedBy string    `bson:"created_by"`
+	CreatedAt time.Time `bson:"created_at"`
+	ExpiresAt time.Time `bson:"expires_at"`
+	// AcceptedAt is set once a user registers with the invitation.
+	AcceptedAt time.Time `bson:"accepted_at,omitempty"`
+}
+
+// Usable reports whether email can register with the invitation at now.
+func (i *Invitation) Usable(email string, now time.Time) bool {
+	return i.AcceptedAt.IsZero() && now.Before(i.ExpiresAt) && strings.EqualFold(i.Email, email)
+}
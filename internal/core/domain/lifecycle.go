@@ -0,0 +1,291 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+)
+
+// DefaultLifecycle is the name of the lifecycle used by shipments that no
+// other lifecycle is assigned to.
+const DefaultLifecycle = "default"
+
+// Transition allows moving a shipment from one status to another. Roles and
+// Sources restrict who may trigger it; empty means anyone.
+type Transition struct {
+	From    ShipmentStatus `json:"from"`
+	To      ShipmentStatus `json:"to"`
+	Roles   []string       `json:"roles,omitempty"`
+	Sources []string       `json:"sources,omitempty"`
+}
+
+// Lifecycle is a shipment state machine.
+type Lifecycle struct {
+	Initial     ShipmentStatus   `json:"initial"`
+	States      []ShipmentStatus `json:"states"`
+	Terminal    []ShipmentStatus `json:"terminal"`
+	Transitions []Transition     `json:"transitions"`
+	// MaxDeliveryAttempts sends a shipment back to its sender once it has
+	// failed delivery this many times. Zero disables the rule.
+	MaxDeliveryAttempts int `json:"max_delivery_attempts,omitempty"`
+}
+
+// Lifecycles holds the lifecycles in use and which shipments follow each one.
+// A shipment follows the lifecycle of its client if it has one, else that of
+// its service type, else the default lifecycle.
+type Lifecycles struct {
+	Lifecycles   map[string]*Lifecycle `json:"lifecycles"`
+	ServiceTypes map[string]string     `json:"service_types,omitempty"`
+	Clients      map[string]string     `json:"clients,omitempty"`
+}
+
+// BuiltinLifecycles returns the lifecycle used when no definition file is
+// configured.
+func BuiltinLifecycles() *Lifecycles {
+	return &Lifecycles{Lifecycles: map[string]*Lifecycle{
+		DefaultLifecycle: {
+			Initial: StatusCreated,
+			States: []ShipmentStatus{
+				StatusCreated, StatusPickedUp, StatusInWarehouse, StatusInTransit, StatusOutForDelivery,
+				StatusDeliveryAttemptFailed, StatusReturningToSender, StatusReturned, StatusDelivered,
+				StatusCancelled, StatusLost, StatusDamaged,
+			},
+			Terminal: []ShipmentStatus{StatusDelivered, StatusReturned, StatusCancelled, StatusLost, StatusDamaged},
+			Transitions: []Transition{
+				{From: StatusCreated, To: StatusPickedUp},
+				{From: StatusCreated, To: StatusCancelled},
+				{From: StatusPickedUp, To: StatusInWarehouse},
+				{From: StatusPickedUp, To: StatusCancelled},
+				{From: StatusPickedUp, To: StatusLost},
+				{From: StatusPickedUp, To: StatusDamaged},
+				{From: StatusInWarehouse, To: StatusInTransit},
+				{From: StatusInWarehouse, To: StatusCancelled},
+				{From: StatusInWarehouse, To: StatusLost},
+				{From: StatusInWarehouse, To: StatusDamaged},
+				{From: StatusInTransit, To: StatusOutForDelivery},
+				{From: StatusInTransit, To: StatusDelivered},
+				{From: StatusInTransit, To: StatusLost},
+				{From: StatusInTransit, To: StatusDamaged},
+				{From: StatusOutForDelivery, To: StatusDelivered},
+				{From: StatusOutForDelivery, To: StatusDeliveryAttemptFailed},
+				{From: StatusOutForDelivery, To: StatusLost},
+				{From: StatusOutForDelivery, To: StatusDamaged},
+				{From: StatusDeliveryAttemptFailed, To: StatusOutForDelivery},
+				{From: StatusDeliveryAttemptFailed, To: StatusInWarehouse},
+				{From: StatusDeliveryAttemptFailed, To: StatusReturningToSender},
+				{From: StatusDeliveryAttemptFailed, To: StatusLost},
+				{From: StatusDeliveryAttemptFailed, To: StatusDamaged},
+				{From: StatusReturningToSender, To: StatusReturned},
+				{From: StatusReturningToSender, To: StatusLost},
+				{From: StatusReturningToSender, To: StatusDamaged},
+			},
+			MaxDeliveryAttempts: 3,
+		},
+	}}
+}
+
+// For returns the lifecycle a shipment of the given service type and client
+// follows.
+func (ls *Lifecycles) For(serviceType, clientID string) *Lifecycle {
+	if name, ok := ls.Clients[clientID]; ok && clientID != "" {
+		return ls.Lifecycles[name]
+	}
+	if name, ok := ls.ServiceTypes[serviceType]; ok {
+		return ls.Lifecycles[name]
+	}
+	return ls.Lifecycles[DefaultLifecycle]
+}
+
+// Of returns the lifecycle s follows.
+func (ls *Lifecycles) Of(s *Shipment) *Lifecycle {
+	return ls.For(s.ServiceType, s.ClientID)
+}
+
+// States returns every status declared by any lifecycle, default lifecycle
+// first.
+func (ls *Lifecycles) States() []ShipmentStatus {
+	var states []ShipmentStatus
+	for _, name := range ls.names() {
+		for _, s := range ls.Lifecycles[name].States {
+			if !slices.Contains(states, s) {
+				states = append(states, s)
+			}
+		}
+	}
+	return states
+}
+
+// EventStatuses returns every status a tracking event may carry: the targets
+// of any transition, default lifecycle first.
+func (ls *Lifecycles) EventStatuses() []ShipmentStatus {
+	var statuses []ShipmentStatus
+	for _, name := range ls.names() {
+		for _, t := range ls.Lifecycles[name].Transitions {
+			if !slices.Contains(statuses, t.To) {
+				statuses = append(statuses, t.To)
+			}
+		}
+	}
+	return statuses
+}
+
+// names returns the names of the defined lifecycles, default first and the
+// rest sorted.
+func (ls *Lifecycles) names() []string {
+	names := make([]string, 0, len(ls.Lifecycles))
+	for name := range ls.Lifecycles {
+		if name != DefaultLifecycle {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	if _, ok := ls.Lifecycles[DefaultLifecycle]; ok {
+		names = append([]string{DefaultLifecycle}, names...)
+	}
+	return names
+}
+
+// Validate checks that a default lifecycle exists, that every assignment
+// names a defined lifecycle and that each lifecycle is well formed.
+func (ls *Lifecycles) Validate() error {
+	var errs []error
+	if _, ok := ls.Lifecycles[DefaultLifecycle]; !ok {
+		errs = append(errs, fmt.Errorf("lifecycle %q is required", DefaultLifecycle))
+	}
+	for _, kind := range []struct {
+		name string
+		m    map[string]string
+	}{{"service type", ls.ServiceTypes}, {"client", ls.Clients}} {
+		for key, name := range kind.m {
+			if _, ok := ls.Lifecycles[name]; !ok {
+				errs = append(errs, fmt.Errorf("%s %q: unknown lifecycle %q", kind.name, key, name))
+			}
+		}
+	}
+
+	names := make([]string, 0, len(ls.Lifecycles))
+	for name := range ls.Lifecycles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := ls.Lifecycles[name].validate(); err != nil {
+			errs = append(errs, fmt.Errorf("lifecycle %q: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Allows reports whether the lifecycle has a transition from one status to
+// another, regardless of who triggers it.
+func (l *Lifecycle) Allows(from, to ShipmentStatus) bool {
+	return l.transition(from, to) != nil
+}
+
+// Check reports whether an event from source, sent by a user with role, may
+// move a shipment from one status to another. The error wraps
+// ErrInvalidTransition.
+func (l *Lifecycle) Check(from, to ShipmentStatus, role, source string) error {
+	t := l.transition(from, to)
+	if t == nil {
+		return fmt.Errorf("%w (from %s to %s)", ErrInvalidTransition, from, to)
+	}
+	if len(t.Roles) > 0 && !slices.Contains(t.Roles, role) {
+		return fmt.Errorf("%w (from %s to %s): not allowed for role %q", ErrInvalidTransition, from, to, role)
+	}
+	if len(t.Sources) > 0 && !slices.Contains(t.Sources, source) {
+		return fmt.Errorf("%w (from %s to %s): not allowed for source %q", ErrInvalidTransition, from, to, source)
+	}
+	return nil
+}
+
+// ReturnsAfter reports whether a shipment that has failed delivery attempts
+// times must be sent back to its sender.
+func (l *Lifecycle) ReturnsAfter(attempts int) bool {
+	return l.MaxDeliveryAttempts > 0 && attempts >= l.MaxDeliveryAttempts
+}
+
+// IsTerminal reports whether no transition leaves status.
+func (l *Lifecycle) IsTerminal(status ShipmentStatus) bool {
+	return slices.Contains(l.Terminal, status)
+}
+
+func (l *Lifecycle) transition(from, to ShipmentStatus) *Transition {
+	for i := range l.Transitions {
+		if l.Transitions[i].From == from && l.Transitions[i].To == to {
+			return &l.Transitions[i]
+		}
+	}
+	return nil
+}
+
+// validate rejects unknown or duplicate states, transitions leaving terminal
+// states, non-terminal dead ends and states unreachable from the initial one.
+func (l *Lifecycle) validate() error {
+	if l == nil {
+		return errors.New("definition is empty")
+	}
+
+	var errs []error
+	known := make(map[ShipmentStatus]bool, len(l.States))
+	for _, s := range l.States {
+		if s == "" {
+			errs = append(errs, errors.New("empty state name"))
+		} else if known[s] {
+			errs = append(errs, fmt.Errorf("state %q declared twice", s))
+		}
+		known[s] = true
+	}
+	if !known[l.Initial] {
+		errs = append(errs, fmt.Errorf("initial state %q is not declared", l.Initial))
+	}
+	for _, s := range l.Terminal {
+		if !known[s] {
+			errs = append(errs, fmt.Errorf("terminal state %q is not declared", s))
+		}
+	}
+	if l.IsTerminal(l.Initial) {
+		errs = append(errs, fmt.Errorf("initial state %q cannot be terminal", l.Initial))
+	}
+
+	outgoing := make(map[ShipmentStatus][]ShipmentStatus)
+	for _, t := range l.Transitions {
+		switch {
+		case !known[t.From] || !known[t.To]:
+			errs = append(errs, fmt.Errorf("transition %s -> %s uses an undeclared state", t.From, t.To))
+		case l.IsTerminal(t.From):
+			errs = append(errs, fmt.Errorf("transition %s -> %s leaves terminal state %q", t.From, t.To, t.From))
+		case slices.Contains(outgoing[t.From], t.To):
+			errs = append(errs, fmt.Errorf("transition %s -> %s declared twice", t.From, t.To))
+		}
+		outgoing[t.From] = append(outgoing[t.From], t.To)
+	}
+
+	switch {
+	case l.MaxDeliveryAttempts < 0:
+		errs = append(errs, errors.New("max_delivery_attempts cannot be negative"))
+	case l.MaxDeliveryAttempts > 0 && !l.Allows(StatusDeliveryAttemptFailed, StatusReturningToSender):
+		errs = append(errs, fmt.Errorf("max_delivery_attempts requires a transition %s -> %s",
+			StatusDeliveryAttemptFailed, StatusReturningToSender))
+	}
+
+	reached := map[ShipmentStatus]bool{l.Initial: true}
+	for queue := []ShipmentStatus{l.Initial}; len(queue) > 0; queue = queue[1:] {
+		for _, next := range outgoing[queue[0]] {
+			if !reached[next] {
+				reached[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+	for _, s := range l.States {
+		if !reached[s] {
+			errs = append(errs, fmt.Errorf("state %q is unreachable from %q", s, l.Initial))
+		}
+		if !l.IsTerminal(s) && len(outgoing[s]) == 0 {
+			errs = append(errs, fmt.Errorf("state %q has no transitions and is not terminal", s))
+		}
+	}
+	return errors.Join(errs...)
+}
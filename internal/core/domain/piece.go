@@ -0,0 +1,87 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// StatusPartiallyDelivered is the status of a multi-piece shipment some but
+// not all of whose pieces have been delivered. It is derived from the pieces:
+// no lifecycle declares it and no event can target it.
+const StatusPartiallyDelivered ShipmentStatus = "partially_delivered"
+
+var ErrPieceNotFound = errors.New("piece not found")
+
+// Piece is one box of a multi-piece shipment, tracked on its own. Shipments
+// created with a single package have no pieces and are tracked as a whole.
+type Piece struct {
+	Barcode          string         `json:"barcode" bson:"barcode"`
+	WeightKg         float64        `json:"weight_kg" bson:"weight_kg"`
+	Dimensions       Dimensions     `json:"dimensions" bson:"dimensions"`
+	Status           ShipmentStatus `json:"status" bson:"status"`
+	DeliveryAttempts int            `json:"delivery_attempts" bson:"delivery_attempts"`
+	// LastEventAt is the timestamp of the last event that moved the piece.
+	LastEventAt time.Time `json:"last_event_at,omitempty" bson:"last_event_at,omitempty"`
+}
+
+// PieceBarcode returns the barcode of the n-th piece, counting from 1, of the
+// shipment with the given tracking number.
+func PieceBarcode(trackingNumber string, n int) string {
+	return fmt.Sprintf("%s-%02d", trackingNumber, n)
+}
+
+// Piece returns the piece with the given barcode, or nil if the shipment has
+// none.
+func (s *Shipment) Piece(barcode string) *Piece {
+	for i := range s.Pieces {
+		if s.Pieces[i].Barcode == barcode {
+			return &s.Pieces[i]
+		}
+	}
+	return nil
+}
+
+// PiecesStatus derives the shipment status from its pieces: the status they
+// all share, partially_delivered when only some were delivered, otherwise the
+// earliest status of its lifecycle l among the pieces still moving, or among
+// all of them once every piece has stopped. Without pieces it returns
+// s.Status.
+func (s *Shipment) PiecesStatus(l *Lifecycle) ShipmentStatus {
+	if len(s.Pieces) == 0 {
+		return s.Status
+	}
+
+	var delivered, moving, stopped []ShipmentStatus
+	for _, p := range s.Pieces {
+		switch {
+		case p.Status == StatusDelivered:
+			delivered = append(delivered, p.Status)
+		case l.IsTerminal(p.Status):
+			stopped = append(stopped, p.Status)
+		default:
+			moving = append(moving, p.Status)
+		}
+	}
+	switch {
+	case len(delivered) == len(s.Pieces):
+		return StatusDelivered
+	case len(delivered) > 0:
+		return StatusPartiallyDelivered
+	case len(moving) > 0:
+		return earliest(l, moving)
+	default:
+		return earliest(l, stopped)
+	}
+}
+
+// earliest returns the status of statuses declared first by l.
+func earliest(l *Lifecycle, statuses []ShipmentStatus) ShipmentStatus {
+	for _, st := range l.States {
+		if slices.Contains(statuses, st) {
+			return st
+		}
+	}
+	return statuses[0]
+}
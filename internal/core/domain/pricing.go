@@ -0,0 +1,244 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+)
+
+var ErrNoRateCard = errors.New("no rate card in effect")
+var ErrZoneNotServed = errors.New("zip code is not served")
+var ErrServiceNotPriced = errors.New("service type has no rate")
+var ErrCurrencyMismatch = errors.New("declared value currency does not match the rate card")
+
+// RateCard prices shipments. Cards are versioned: the one in effect at a
+// given time is the latest whose EffectiveFrom is not after it.
+type RateCard struct {
+	Version       string    `json:"version"`
+	EffectiveFrom time.Time `json:"effective_from"`
+	Currency      string    `json:"currency"`
+	// VolumetricDivisor converts a package volume in cm³ to kilograms.
+	VolumetricDivisor float64                `json:"volumetric_divisor"`
+	Services          map[string]ServiceRate `json:"services"`
+	Zones             []Zone                 `json:"zones"`
+	Insurance         InsuranceRate          `json:"insurance"`
+}
+
+// ServiceRate is the tariff of one service type.
+type ServiceRate struct {
+	Base     float64 `json:"base"`
+	PerKg    float64 `json:"per_kg"`
+	PerKm    float64 `json:"per_km"`
+	MinPrice float64 `json:"min_price,omitempty"`
+}
+
+// Zone groups destination zip codes by prefix. A zip code belongs to the zone
+// with the longest matching prefix; an empty prefix matches any zip code.
+type Zone struct {
+	Name        string   `json:"name"`
+	ZipPrefixes []string `json:"zip_prefixes"`
+	Surcharge   float64  `json:"surcharge"`
+}
+
+// InsuranceRate prices optional insurance as a fraction of the declared
+// value, with a minimum charge.
+type InsuranceRate struct {
+	Rate    float64 `json:"rate"`
+	Minimum float64 `json:"minimum"`
+}
+
+// PriceRequest holds what a shipment is priced on.
+type PriceRequest struct {
+	ServiceType    string
+	Origin         Coordinates
+	Destination    Coordinates
+	DestinationZip string
+	// Parcels are the boxes shipped: the package, or each piece of a
+	// multi-piece shipment.
+	Parcels       []Parcel
+	DeclaredValue float64
+	Currency      string
+	Insured       bool
+}
+
+// Parcel is the weight and size of one box.
+type Parcel struct {
+	WeightKg   float64
+	Dimensions Dimensions
+}
+
+// Price is the breakdown of what a shipment costs, stored on the shipment
+// when it is created.
+type Price struct {
+	RateCardVersion    string  `json:"rate_card_version" bson:"rate_card_version"`
+	Currency           string  `json:"currency" bson:"currency"`
+	ServiceType        string  `json:"service_type" bson:"service_type"`
+	Zone               string  `json:"zone" bson:"zone"`
+	DistanceKm         float64 `json:"distance_km" bson:"distance_km"`
+	ActualWeightKg     float64 `json:"actual_weight_kg" bson:"actual_weight_kg"`
+	VolumetricWeightKg float64 `json:"volumetric_weight_kg" bson:"volumetric_weight_kg"`
+	BillableWeightKg   float64 `json:"billable_weight_kg" bson:"billable_weight_kg"`
+	Base               float64 `json:"base" bson:"base"`
+	WeightCharge       float64 `json:"weight_charge" bson:"weight_charge"`
+	DistanceCharge     float64 `json:"distance_charge" bson:"distance_charge"`
+	ZoneSurcharge      float64 `json:"zone_surcharge" bson:"zone_surcharge"`
+	// MinimumAdjustment raises the shipping charges to the service minimum.
+	MinimumAdjustment float64 `json:"minimum_adjustment,omitempty" bson:"minimum_adjustment,omitempty"`
+	Insured           bool    `json:"insured" bson:"insured"`
+	Insurance         float64 `json:"insurance" bson:"insurance"`
+	Total             float64 `json:"total" bson:"total"`
+}
+
+// Price computes what a shipment costs under the card. Each parcel is billed
+// on the greater of its actual and volumetric weight; distance is the great
+// circle between origin and destination.
+func (c *RateCard) Price(req PriceRequest) (Price, error) {
+	rate, ok := c.Services[req.ServiceType]
+	if !ok {
+		return Price{}, fmt.Errorf("%w: %s", ErrServiceNotPriced, req.ServiceType)
+	}
+	zone := c.zone(req.DestinationZip)
+	if zone == nil {
+		return Price{}, fmt.Errorf("%w: %s", ErrZoneNotServed, req.DestinationZip)
+	}
+	if req.Insured && !strings.EqualFold(req.Currency, c.Currency) {
+		return Price{}, fmt.Errorf("%w: %s, rate card in %s", ErrCurrencyMismatch, req.Currency, c.Currency)
+	}
+
+	p := Price{
+		RateCardVersion: c.Version,
+		Currency:        c.Currency,
+		ServiceType:     req.ServiceType,
+		Zone:            zone.Name,
+		Insured:         req.Insured,
+		DistanceKm:      round(distanceKm(req.Origin, req.Destination)),
+	}
+	var billable float64
+	for _, parcel := range req.Parcels {
+		d := parcel.Dimensions
+		volumetric := d.LengthCm * d.WidthCm * d.HeightCm / c.VolumetricDivisor
+		p.ActualWeightKg += parcel.WeightKg
+		p.VolumetricWeightKg += volumetric
+		billable += math.Max(parcel.WeightKg, volumetric)
+	}
+	p.ActualWeightKg = round(p.ActualWeightKg)
+	p.VolumetricWeightKg = round(p.VolumetricWeightKg)
+	p.BillableWeightKg = round(billable)
+
+	p.Base = round(rate.Base)
+	p.WeightCharge = round(rate.PerKg * p.BillableWeightKg)
+	p.DistanceCharge = round(rate.PerKm * p.DistanceKm)
+	p.ZoneSurcharge = round(zone.Surcharge)
+	shipping := p.Base + p.WeightCharge + p.DistanceCharge + p.ZoneSurcharge
+	if shipping < rate.MinPrice {
+		p.MinimumAdjustment = round(rate.MinPrice - shipping)
+		shipping = rate.MinPrice
+	}
+	if req.Insured {
+		p.Insurance = round(math.Max(c.Insurance.Rate*req.DeclaredValue, c.Insurance.Minimum))
+	}
+	p.Total = round(shipping + p.Insurance)
+	return p, nil
+}
+
+// zone returns the zone of zip, or nil if no zone serves it.
+func (c *RateCard) zone(zip string) *Zone {
+	var best *Zone
+	bestLen := -1
+	for i := range c.Zones {
+		for _, prefix := range c.Zones[i].ZipPrefixes {
+			if strings.HasPrefix(zip, prefix) && len(prefix) > bestLen {
+				best, bestLen = &c.Zones[i], len(prefix)
+			}
+		}
+	}
+	return best
+}
+
+// Validate checks that the card is complete and that no zip code prefix is
+// claimed by two zones.
+func (c *RateCard) Validate() error {
+	var errs []error
+	if c.Version == "" {
+		errs = append(errs, errors.New("version is required"))
+	}
+	if c.EffectiveFrom.IsZero() {
+		errs = append(errs, errors.New("effective_from is required"))
+	}
+	if c.Currency == "" {
+		errs = append(errs, errors.New("currency is required"))
+	}
+	if c.VolumetricDivisor <= 0 {
+		errs = append(errs, errors.New("volumetric_divisor must be positive"))
+	}
+	if len(c.Services) == 0 {
+		errs = append(errs, errors.New("at least one service rate is required"))
+	}
+	services := make([]string, 0, len(c.Services))
+	for name := range c.Services {
+		services = append(services, name)
+	}
+	slices.Sort(services)
+	for _, name := range services {
+		r := c.Services[name]
+		if r.Base < 0 || r.PerKg < 0 || r.PerKm < 0 || r.MinPrice < 0 {
+			errs = append(errs, fmt.Errorf("service %q: rates cannot be negative", name))
+		}
+	}
+
+	if len(c.Zones) == 0 {
+		errs = append(errs, errors.New("at least one zone is required"))
+	}
+	zones := make(map[string]bool, len(c.Zones))
+	prefixes := make(map[string]string)
+	for _, z := range c.Zones {
+		switch {
+		case z.Name == "":
+			errs = append(errs, errors.New("zone name is required"))
+		case zones[z.Name]:
+			errs = append(errs, fmt.Errorf("zone %q declared twice", z.Name))
+		}
+		zones[z.Name] = true
+		if len(z.ZipPrefixes) == 0 {
+			errs = append(errs, fmt.Errorf("zone %q has no zip prefixes", z.Name))
+		}
+		if z.Surcharge < 0 {
+			errs = append(errs, fmt.Errorf("zone %q: surcharge cannot be negative", z.Name))
+		}
+		for _, prefix := range z.ZipPrefixes {
+			if other, ok := prefixes[prefix]; ok {
+				errs = append(errs, fmt.Errorf("zip prefix %q is in zones %q and %q", prefix, other, z.Name))
+			}
+			prefixes[prefix] = z.Name
+		}
+	}
+
+	if c.Insurance.Rate < 0 || c.Insurance.Rate > 1 {
+		errs = append(errs, errors.New("insurance rate must be between 0 and 1"))
+	}
+	if c.Insurance.Minimum < 0 {
+		errs = append(errs, errors.New("insurance minimum cannot be negative"))
+	}
+	return errors.Join(errs...)
+}
+
+// earthRadiusKm is the mean radius of the Earth.
+const earthRadiusKm = 6371.0
+
+// distanceKm returns the great circle distance between two points.
+func distanceKm(a, b Coordinates) float64 {
+	rad := func(deg float64) float64 { return deg * math.Pi / 180 }
+	dLat := rad(b.Lat - a.Lat)
+	dLng := rad(b.Lng - a.Lng)
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(rad(a.Lat))*math.Cos(rad(b.Lat))*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(h))
+}
+
+// round rounds to two decimals, the precision prices are quoted in.
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,38 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrInvalidRefreshToken means a refresh token is unknown, expired or revoked.
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	// ErrRefreshTokenReused means a refresh token was presented after it had
+	// already been exchanged; its whole family is revoked.
+	ErrRefreshTokenReused = errors.New("refresh token reused")
+)
+
+// RefreshToken is a single-use credential that is exchanged for a new access
+// token and a new refresh token. Only a hash of the token is stored.
+//
+// Tokens descending from the same login share a FamilyID. Presenting a token
+// that was already rotated means it was copied, so the family is revoked and
+// both the thief and the owner have to log in again.
+type RefreshToken struct {
+	ID        string    `bson:"_id"`
+	FamilyID  string    `bson:"family_id"`
+	UserID    string    `bson:"user_id"`
+	TokenHash string    `bson:"token_hash"` // hex SHA-256 of the token
+	ExpiresAt time.Time `bson:"expires_at"`
+	CreatedAt time.Time `bson:"created_at"`
+	// RotatedAt is set once the token has been exchanged.
+	RotatedAt time.Time `bson:"rotated_at,omitempty"`
+	// RevokedAt is set on logout or reuse of a token of the family.
+	RevokedAt time.Time `bson:"revoked_at,omitempty"`
+}
+
+// Usable reports whether the token can still be exchanged at now.
+func (t *RefreshToken) Usable(now time.Time) bool {
+	return t.RotatedAt.IsZero() && t.RevokedAt.IsZero() && now.Before(t.ExpiresAt)
+}
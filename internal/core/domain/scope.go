@@ -0,0 +1,44 @@
+package domain
+
+import "slices"
+
+// Scopes name what a request may do. Access tokens carry the scopes of their
+// user's role and every route declares the scope it requires.
+const (
+	ScopeShipmentsCreate   = "shipments:create"
+	ScopeShipmentsRead     = "shipments:read"
+	ScopeShipmentsUpdate   = "shipments:update" // amend and cancel
+	ScopeEventsWrite       = "events:write"
+	ScopeEventsRead        = "events:read"
+	ScopeAPIKeysManage     = "api_keys:manage"
+	ScopeDeadLettersManage = "dead_letters:manage"
+	ScopeUsersManage       = "users:manage"
+	ScopeReportsRead       = "reports:read"
+)
+
+// roleScopes groups scopes into the roles users and API keys are given.
+var roleScopes = map[string][]string{
+	RoleAdmin: {
+		ScopeShipmentsCreate, ScopeShipmentsRead, ScopeShipmentsUpdate,
+		ScopeEventsWrite, ScopeEventsRead,
+		ScopeAPIKeysManage, ScopeDeadLettersManage, ScopeUsersManage, ScopeReportsRead,
+	},
+	RoleClient: {
+		ScopeShipmentsCreate, ScopeShipmentsRead, ScopeShipmentsUpdate,
+		ScopeAPIKeysManage, ScopeReportsRead,
+	},
+	RoleCarrier: {
+		ScopeShipmentsRead, ScopeEventsWrite, ScopeEventsRead, ScopeAPIKeysManage,
+	},
+}
+
+// ValidRole reports whether role is one of the known roles.
+func ValidRole(role string) bool {
+	_, ok := roleScopes[role]
+	return ok
+}
+
+// RoleScopes returns the scopes of role, none for unknown roles.
+func RoleScopes(role string) []string {
+	return slices.Clone(roleScopes[role])
+}
@@ -9,35 +9,48 @@ import (
 type ShipmentStatus string
 
 const (
-	StatusCreated     ShipmentStatus = "created"
-	StatusPickedUp    ShipmentStatus = "picked_up"
-	StatusInWarehouse ShipmentStatus = "in_warehouse"
-	StatusInTransit   ShipmentStatus = "in_transit"
-	StatusDelivered   ShipmentStatus = "delivered"
-	StatusCancelled   ShipmentStatus = "cancelled"
+	StatusCreated               ShipmentStatus = "created"
+	StatusPickedUp              ShipmentStatus = "picked_up"
+	StatusInWarehouse           ShipmentStatus = "in_warehouse"
+	StatusInTransit             ShipmentStatus = "in_transit"
+	StatusOutForDelivery        ShipmentStatus = "out_for_delivery"
+	StatusDeliveryAttemptFailed ShipmentStatus = "delivery_attempt_failed"
+	StatusReturningToSender     ShipmentStatus = "returning_to_sender"
+	StatusReturned              ShipmentStatus = "returned"
+	StatusDelivered             ShipmentStatus = "delivered"
+	StatusCancelled             ShipmentStatus = "cancelled"
+	StatusLost                  ShipmentStatus = "lost"
+	StatusDamaged               ShipmentStatus = "damaged"
 )
 
-// validTransitions defines the allowed state machine transitions.
-var validTransitions = map[ShipmentStatus][]ShipmentStatus{
-	StatusCreated:     {StatusPickedUp, StatusCancelled},
-	StatusPickedUp:    {StatusInWarehouse, StatusCancelled},
-	StatusInWarehouse: {StatusInTransit, StatusCancelled},
-	StatusInTransit:   {StatusDelivered},
+// DeliveryFailureReasons are the reason codes a delivery_attempt_failed event
+// may carry.
+var DeliveryFailureReasons = []string{
+	"recipient_absent",
+	"address_not_found",
+	"refused",
+	"access_denied",
+	"business_closed",
+	"other",
 }
 
+// ReasonMaxAttemptsReached is the reason code of the returning_to_sender
+// entry added when a shipment runs out of delivery attempts.
+const ReasonMaxAttemptsReached = "max_attempts_reached"
+
 var ErrInvalidTransition = errors.New("invalid status transition")
 var ErrShipmentNotFound = errors.New("shipment not found")
 var ErrDuplicateShipment = errors.New("shipment already exists")
 var ErrForbidden = errors.New("access forbidden")
+var ErrShipmentCancelled = errors.New("shipment cancelled")
+var ErrConcurrentUpdate = errors.New("shipment was updated concurrently")
+var ErrShipmentLocked = errors.New("shipment can no longer be amended")
 
-// CanTransitionTo reports whether a transition from current status to next is valid.
+// CanTransitionTo reports whether the built-in default lifecycle allows a
+// transition from current status to next. Shipments following a configured
+// lifecycle are checked with Lifecycle.Check instead.
 func (s ShipmentStatus) CanTransitionTo(next ShipmentStatus) bool {
-	for _, allowed := range validTransitions[s] {
-		if allowed == next {
-			return true
-		}
-	}
-	return false
+	return BuiltinLifecycles().Lifecycles[DefaultLifecycle].Allows(s, next)
 }
 
 // Coordinates represents a geographic point.
@@ -61,6 +74,17 @@ type Person struct {
 	Phone string `json:"phone" bson:"phone"`
 }
 
+// Recipient is the person a shipment is delivered to. Shipments created
+// before recipients were recorded read back with a zero Recipient.
+type Recipient struct {
+	Name         string `json:"name" bson:"name"`
+	Phone        string `json:"phone" bson:"phone"`
+	Email        string `json:"email,omitempty" bson:"email,omitempty"`
+	Instructions string `json:"instructions,omitempty" bson:"instructions,omitempty"`
+	// AlternativeContact is someone else who may receive the package.
+	AlternativeContact *Person `json:"alternative_contact,omitempty" bson:"alternative_contact,omitempty"`
+}
+
 // Dimensions represents the physical size of a package.
 type Dimensions struct {
 	LengthCm float64 `json:"length_cm" bson:"length_cm"`
@@ -82,6 +106,36 @@ type StatusHistoryEntry struct {
 	Status    ShipmentStatus `json:"status" bson:"status"`
 	Timestamp time.Time      `json:"timestamp" bson:"timestamp"`
 	Notes     string         `json:"notes,omitempty" bson:"notes,omitempty"`
+	// ReasonCode explains failed delivery attempts and automatic returns.
+	ReasonCode string `json:"reason_code,omitempty" bson:"reason_code,omitempty"`
+	// Attempt numbers failed delivery attempts, starting at 1.
+	Attempt int `json:"attempt,omitempty" bson:"attempt,omitempty"`
+	// Piece is the barcode of the piece the entry applies to; empty when it
+	// applies to the whole shipment.
+	Piece string `json:"piece,omitempty" bson:"piece,omitempty"`
+	// Actor and Reason record who made a change through the API, such as a
+	// cancellation, and why.
+	Actor  string `json:"actor,omitempty" bson:"actor,omitempty"`
+	Reason string `json:"reason,omitempty" bson:"reason,omitempty"`
+	// Late marks an event that arrived after a newer transition had been
+	// applied; it is kept for the record but did not change the status.
+	Late bool `json:"late,omitempty" bson:"late,omitempty"`
+}
+
+// FieldChange records the previous and new value of an amended field.
+type FieldChange struct {
+	Field string `json:"field" bson:"field"`
+	From  string `json:"from" bson:"from"`
+	To    string `json:"to" bson:"to"`
+}
+
+// Amendment is a set of corrections applied to a shipment before pickup.
+// Versions start at 1 and increase by one with each amendment.
+type Amendment struct {
+	Version int           `json:"version" bson:"version"`
+	Actor   string        `json:"actor" bson:"actor"`
+	At      time.Time     `json:"at" bson:"at"`
+	Changes []FieldChange `json:"changes" bson:"changes"`
 }
 
 // Shipment is the core aggregate root.
@@ -90,13 +144,43 @@ type Shipment struct {
 	TrackingNumber    string         `json:"tracking_number" bson:"tracking_number"`
 	ClientID          string         `json:"client_id" bson:"client_id"`
 	Sender            Person         `json:"sender" bson:"sender"`
+	Recipient         Recipient      `json:"recipient" bson:"recipient"`
 	Origin            Address        `json:"origin" bson:"origin"`
 	Destination       Address        `json:"destination" bson:"destination"`
 	Package           Package        `json:"package" bson:"package"`
+	Pieces            []Piece        `json:"pieces,omitempty" bson:"pieces,omitempty"`
+	Price             *Price         `json:"price,omitempty" bson:"price,omitempty"` // set when created with a rate card loaded
 	ServiceType       string         `json:"service_type" bson:"service_type"`
 	Status            ShipmentStatus `json:"status" bson:"status"`
 	CreatedAt         time.Time      `json:"created_at" bson:"created_at"`
 	EstimatedDelivery time.Time      `json:"estimated_delivery" bson:"estimated_delivery"`
 	IdempotencyKey    string         `json:"idempotency_key,omitempty" bson:"idempotency_key,omitempty"`
+	DeliveryAttempts  int            `json:"delivery_attempts" bson:"delivery_attempts"` // failed delivery attempts so far
+	Version           int            `json:"version" bson:"version"`                     // number of amendments applied
+	Amendments        []Amendment    `json:"amendments,omitempty" bson:"amendments,omitempty"`
 	StatusHistory     []StatusHistoryEntry `json:"status_history" bson:"status_history"`
 }
+
+// Amendable reports whether the shipment can still be corrected: only while
+// it is in the initial status of its lifecycle l, before pickup.
+func (s *Shipment) Amendable(l *Lifecycle) bool {
+	return s.Status == l.Initial
+}
+
+// LastTransitionAt returns the timestamp of the most recent status change
+// applied from a tracking event, or the zero time if there is none. The
+// entry of the initial status of its lifecycle l does not count: it carries
+// the server's clock, not the scanner's.
+func (s *Shipment) LastTransitionAt(l *Lifecycle) time.Time {
+	initial := l.Initial
+	var last time.Time
+	for _, h := range s.StatusHistory {
+		if h.Late || h.Status == initial {
+			continue
+		}
+		if h.Timestamp.After(last) {
+			last = h.Timestamp
+		}
+	}
+	return last
+}
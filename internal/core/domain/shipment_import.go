@@ -0,0 +1,62 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrImportNotFound = errors.New("shipment import not found")
+	// ErrImportExists means the client already uploaded a file with the same
+	// content.
+	ErrImportExists = errors.New("shipment import already exists")
+)
+
+// ImportStatus is the progress of a shipment import.
+type ImportStatus string
+
+const (
+	ImportQueued    ImportStatus = "queued"
+	ImportRunning   ImportStatus = "running"
+	ImportCompleted ImportStatus = "completed"
+)
+
+// ShipmentImport is a spreadsheet of shipments created in the background,
+// one row at a time. A client's file is imported once: uploading the same
+// content again returns the existing import.
+type ShipmentImport struct {
+	ID       string       `bson:"_id"`
+	ClientID string       `bson:"client_id"`
+	FileName string       `bson:"file_name"`
+	FileHash string       `bson:"file_hash"` // hex SHA-256 of the file
+	Status   ImportStatus `bson:"status"`
+
+	TotalRows int `bson:"total_rows"`
+	// Processed is how many rows, in file order, have been handled.
+	Processed int               `bson:"processed"`
+	Created   int               `bson:"created"`
+	Failed    int               `bson:"failed"`
+	Results   []ImportRowResult `bson:"results"`
+
+	// LeaseUntil is when the worker running the import is considered gone
+	// and another one may resume it.
+	LeaseUntil time.Time `bson:"lease_until,omitempty"`
+	CreatedAt  time.Time `bson:"created_at"`
+	UpdatedAt  time.Time `bson:"updated_at"`
+	FinishedAt time.Time `bson:"finished_at,omitempty"`
+}
+
+// ImportRow is a row of an import file: its line number in the file and its
+// cells by column name.
+type ImportRow struct {
+	Line   int               `bson:"line"`
+	Fields map[string]string `bson:"fields"`
+}
+
+// ImportRowResult is the outcome of one row: the tracking number of the
+// shipment it created, or why it was not imported.
+type ImportRowResult struct {
+	Line           int    `bson:"line"`
+	TrackingNumber string `bson:"tracking_number,omitempty"`
+	Error          string `bson:"error,omitempty"`
+}
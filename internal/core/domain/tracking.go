@@ -0,0 +1,118 @@
+package domain
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// DefaultTrackingPrefix starts every tracking number without a client prefix.
+const DefaultTrackingPrefix = "99M"
+
+// trackingAlphabet holds the characters of a tracking number body and of its
+// check character.
+const trackingAlphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+// trackingBodyLen is the number of random characters in a tracking number,
+// about 51 bits.
+const trackingBodyLen = 10
+
+// TrackingNumbers generates tracking numbers of the form PREFIX-BBBBBBBBBBC:
+// a prefix, ten random alphanumerics and an ISO 7064 MOD 37,36 check
+// character computed over prefix and body. The check character catches any
+// single mistyped character and any swap of two adjacent ones.
+type TrackingNumbers struct {
+	prefix  string
+	clients map[string]string
+}
+
+// NewTrackingNumbers returns a generator that starts tracking numbers with
+// prefix, or with the prefix clients maps the shipment's client ID to.
+// Prefixes are 2 to 6 uppercase letters or digits.
+func NewTrackingNumbers(prefix string, clients map[string]string) (*TrackingNumbers, error) {
+	if !validTrackingPrefix(prefix) {
+		return nil, fmt.Errorf("tracking prefix %q must be 2 to 6 uppercase letters or digits", prefix)
+	}
+	for client, p := range clients {
+		if !validTrackingPrefix(p) {
+			return nil, fmt.Errorf("tracking prefix %q of client %q must be 2 to 6 uppercase letters or digits", p, client)
+		}
+	}
+	return &TrackingNumbers{prefix: prefix, clients: clients}, nil
+}
+
+// Generate returns a new random tracking number for a shipment of clientID.
+// Uniqueness is not guaranteed: the store must reject duplicates.
+func (g *TrackingNumbers) Generate(clientID string) string {
+	prefix := g.prefix
+	if p, ok := g.clients[clientID]; ok {
+		prefix = p
+	}
+
+	body := make([]byte, trackingBodyLen)
+	max := big.NewInt(int64(len(trackingAlphabet)))
+	for i := range body {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			// crypto/rand does not fail on supported platforms.
+			panic(fmt.Sprintf("tracking number: %v", err))
+		}
+		body[i] = trackingAlphabet[n.Int64()]
+	}
+	return prefix + "-" + string(body) + string(trackingCheckChar(prefix+string(body)))
+}
+
+// ValidTrackingNumber reports whether s is well formed and its check
+// character matches. Tracking numbers issued before check characters, 99M-
+// followed by eight hexadecimal digits, are accepted as they are.
+func ValidTrackingNumber(s string) bool {
+	prefix, body, ok := strings.Cut(s, "-")
+	if !ok || !validTrackingPrefix(prefix) {
+		return false
+	}
+	if prefix == DefaultTrackingPrefix && len(body) == 8 && onlyChars(body, "0123456789ABCDEF") {
+		return true
+	}
+	if len(body) != trackingBodyLen+1 || !onlyChars(body, trackingAlphabet) {
+		return false
+	}
+	return trackingCheckChar(prefix+body[:trackingBodyLen]) == body[trackingBodyLen]
+}
+
+// AssignTrackingNumber gives the shipment its tracking number and barcodes
+// its pieces after it.
+func (s *Shipment) AssignTrackingNumber(trackingNumber string) {
+	s.TrackingNumber = trackingNumber
+	for i := range s.Pieces {
+		s.Pieces[i].Barcode = PieceBarcode(trackingNumber, i+1)
+	}
+}
+
+// trackingCheckChar computes the ISO 7064 MOD 37,36 check character of s,
+// which must only hold trackingAlphabet characters.
+func trackingCheckChar(s string) byte {
+	const m = 36
+	p := m
+	for i := 0; i < len(s); i++ {
+		p = (p + strings.IndexByte(trackingAlphabet, s[i])) % m
+		if p == 0 {
+			p = m
+		}
+		p = (2 * p) % (m + 1)
+	}
+	return trackingAlphabet[(m+1-p)%m]
+}
+
+func validTrackingPrefix(p string) bool {
+	return len(p) >= 2 && len(p) <= 6 && onlyChars(p, trackingAlphabet)
+}
+
+func onlyChars(s, chars string) bool {
+	for i := 0; i < len(s); i++ {
+		if strings.IndexByte(chars, s[i]) < 0 {
+			return false
+		}
+	}
+	return true
+}
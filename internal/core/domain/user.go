@@ -5,16 +5,36 @@ import "time"
 const (
 	RoleAdmin  = "admin"
 	RoleClient = "client"
+	// RoleCarrier is a carrier's systems and couriers: they report tracking
+	// events on any shipment but cannot create or change shipments.
+	RoleCarrier = "carrier"
 )
 
 // User models an authenticated actor in the system.
 type User struct {
-	ID           string    `json:"id"`
-	Username     string    `json:"username"`
-	Email        string    `json:"email,omitempty"`
-	PasswordHash string    `json:"-"`
-	Role         string    `json:"role"`
-	ClientID     string    `json:"client_id,omitempty"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID           string `json:"id"`
+	Username     string `json:"username"`
+	Email        string `json:"email,omitempty"`
+	PasswordHash string `json:"-"`
+	Role         string `json:"role"`
+	ClientID     string `json:"client_id,omitempty"`
+	// DisabledAt is set while the account is disabled: it cannot log in or
+	// refresh its tokens.
+	DisabledAt time.Time `json:"disabled_at,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// Disabled reports whether the account is disabled.
+func (u *User) Disabled() bool {
+	return !u.DisabledAt.IsZero()
+}
+
+// CheckRole reports domain.ErrInvalidUser unless role is known and, for
+// every role but admin, clientID names the client or carrier account.
+func CheckRole(role, clientID string) error {
+	if !ValidRole(role) || (role != RoleAdmin && clientID == "") {
+		return ErrInvalidUser
+	}
+	return nil
 }
@@ -0,0 +1,25 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"github.com/99minutos/shipping-system/internal/core/domain"
+)
+
+// APIKeyRepository persists hashed API keys.
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *domain.APIKey) error
+	// FindByHash returns domain.ErrAPIKeyNotFound when no key has the hash.
+	FindByHash(ctx context.Context, keyHash string) (*domain.APIKey, error)
+	// FindByID finds a key of clientID, or of any client when clientID is empty.
+	FindByID(ctx context.Context, id, clientID string) (*domain.APIKey, error)
+	// ListByClient returns the keys of a client, newest first.
+	ListByClient(ctx context.Context, clientID string) ([]domain.APIKey, error)
+	Revoke(ctx context.Context, id string, at time.Time) error
+	// Replace records that the key was rotated into replacedBy and stops it
+	// working at expiresAt.
+	Replace(ctx context.Context, id, replacedBy string, expiresAt time.Time) error
+	// Touch records when the key was last used.
+	Touch(ctx context.Context, id string, at time.Time) error
+}
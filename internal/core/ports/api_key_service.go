@@ -0,0 +1,45 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"github.com/99minutos/shipping-system/internal/core/domain"
+)
+
+// CreateAPIKeyInput describes a new API key of a client.
+type CreateAPIKeyInput struct {
+	ClientID   string
+	Role       string // domain.RoleClient or domain.RoleCarrier
+	Name       string
+	AllowedIPs []string
+	ExpiresAt  time.Time // zero never expires
+	CreatedBy  string
+}
+
+// APIKeyRef identifies an API key. Only admins reach the keys of other
+// clients.
+type APIKeyRef struct {
+	ID       string
+	Role     string
+	ClientID string
+}
+
+// IssuedAPIKey is a key just created, with the key itself, which is not
+// stored and cannot be shown again.
+type IssuedAPIKey struct {
+	APIKey *domain.APIKey
+	Key    string
+}
+
+type APIKeyService interface {
+	Create(ctx context.Context, input CreateAPIKeyInput) (*IssuedAPIKey, error)
+	List(ctx context.Context, clientID string) ([]domain.APIKey, error)
+	// Rotate issues a key with the same settings; the old key keeps working
+	// for gracePeriod.
+	Rotate(ctx context.Context, ref APIKeyRef, gracePeriod time.Duration, createdBy string) (*IssuedAPIKey, error)
+	Revoke(ctx context.Context, ref APIKeyRef) error
+	// Authenticate returns the key of a request made from ip, or
+	// domain.ErrInvalidAPIKey or domain.ErrAPIKeyIPNotAllowed.
+	Authenticate(ctx context.Context, key, ip string) (*domain.APIKey, error)
+}
@@ -6,9 +6,25 @@ import (
 	"github.com/99minutos/shipping-system/internal/core/domain"
 )
 
+// ListUsersFilter selects a page of users; empty fields match any user.
+type ListUsersFilter struct {
+	Role     string
+	ClientID string
+	Page     int
+	Limit    int
+}
+
 // AuthRepository defines the interface for user authentication persistence.
 type AuthRepository interface {
 	FindByEmail(ctx context.Context, email string) (*domain.User, error)
+	FindByID(ctx context.Context, id string) (*domain.User, error)
 	Create(ctx context.Context, user *domain.User) (*domain.User, error)
+	// List returns a page of users, newest first, and the number of users
+	// matching the filter.
+	List(ctx context.Context, filter ListUsersFilter) ([]*domain.User, int64, error)
+	// Update stores the role, client ID and disabled state of the user. It
+	// returns domain.ErrUserNotFound when there is no user with its ID.
+	Update(ctx context.Context, user *domain.User) error
+	Delete(ctx context.Context, id string) error
 }
 
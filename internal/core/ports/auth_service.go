@@ -2,11 +2,42 @@ package ports
 
 import (
 	"context"
+	"time"
 
 	"github.com/99minutos/shipping-system/internal/core/domain"
 )
 
+// AuthTokens are the credentials issued on login and refresh.
+type AuthTokens struct {
+	AccessToken  string
+	RefreshToken string
+	// ExpiresIn is the lifetime of the access token.
+	ExpiresIn time.Duration
+}
+
+// LogoutInput identifies the session to end: the access token the request
+// was made with and, optionally, the refresh token issued with it.
+type LogoutInput struct {
+	UserID         string
+	JTI            string
+	TokenExpiresAt time.Time
+	RefreshToken   string
+}
+
+// RegisterInput describes a self-service registration. The role and client
+// of the user are those of the invitation, which must be for Email.
+type RegisterInput struct {
+	Username        string
+	Password        string
+	Email           string
+	InvitationToken string
+}
+
 type AuthService interface {
-	Register(ctx context.Context, username, password, email, role, clientID string) (*domain.User, error)
-	Login(ctx context.Context, email, password string) (string, *domain.User, error)
+	Register(ctx context.Context, input RegisterInput) (*domain.User, error)
+	Login(ctx context.Context, email, password string) (*AuthTokens, *domain.User, error)
+	// Refresh exchanges a refresh token for new tokens; the refresh token
+	// cannot be used again.
+	Refresh(ctx context.Context, refreshToken string) (*AuthTokens, *domain.User, error)
+	Logout(ctx context.Context, input LogoutInput) error
 }
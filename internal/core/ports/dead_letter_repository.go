@@ -0,0 +1,35 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"github.com/99minutos/shipping-system/internal/core/domain"
+)
+
+// ListDeadLettersFilter carries the query parameters for listing dead letters.
+type ListDeadLettersFilter struct {
+	TrackingNumber string // optional: exact match
+	Page           int    // 1-based
+	Limit          int    // max rows per page
+}
+
+// PurgeDeadLettersFilter selects the dead letters removed by Purge.
+// Zero-valued fields do not restrict the selection.
+type PurgeDeadLettersFilter struct {
+	TrackingNumber string
+	Before         time.Time // last_failed_at < Before
+}
+
+// DeadLetterRepository persists tracking events that failed processing.
+type DeadLetterRepository interface {
+	Save(ctx context.Context, dl *domain.DeadLetterEvent) error
+	FindByID(ctx context.Context, id string) (*domain.DeadLetterEvent, error)
+	// List returns a page of dead letters (most recent failure first) and the total count.
+	List(ctx context.Context, filter ListDeadLettersFilter) ([]*domain.DeadLetterEvent, int64, error)
+	// MarkFailed increments the attempt counter and records the latest failure.
+	MarkFailed(ctx context.Context, id, reason string, at time.Time) error
+	Delete(ctx context.Context, id string) error
+	// Purge deletes every dead letter matching filter and returns how many were removed.
+	Purge(ctx context.Context, filter PurgeDeadLettersFilter) (int64, error)
+}
@@ -0,0 +1,51 @@
+package ports
+
+import (
+	"context"
+	"time"
+)
+
+// DeadLetterItem is the service view of a dead-lettered tracking event.
+type DeadLetterItem struct {
+	ID            string
+	Event         TrackingEventInput
+	Reason        string
+	Attempts      int
+	FirstFailedAt time.Time
+	LastFailedAt  time.Time
+}
+
+// ListDeadLettersInput carries the parameters for the list endpoint.
+type ListDeadLettersInput struct {
+	TrackingNumber string
+	Page           int
+	Limit          int
+}
+
+// ListDeadLettersResult is returned by DeadLetterService.List.
+type ListDeadLettersResult struct {
+	Items      []DeadLetterItem
+	Total      int64
+	Page       int
+	Limit      int
+	TotalPages int
+}
+
+// PurgeDeadLettersInput selects the dead letters to delete.
+type PurgeDeadLettersInput struct {
+	TrackingNumber string
+	Before         time.Time
+}
+
+// DeadLetterService stores failed tracking events and exposes the admin operations on them.
+type DeadLetterService interface {
+	// Record stores an event that failed processing after the given number of attempts.
+	Record(ctx context.Context, event TrackingEventInput, reason error, attempts int) error
+	List(ctx context.Context, input ListDeadLettersInput) (*ListDeadLettersResult, error)
+	Get(ctx context.Context, id string) (*DeadLetterItem, error)
+	// Replay re-processes the event through EventService. On success the entry is
+	// removed; on failure its attempt counter and reason are updated.
+	Replay(ctx context.Context, id string) error
+	Delete(ctx context.Context, id string) error
+	Purge(ctx context.Context, input PurgeDeadLettersInput) (int64, error)
+}
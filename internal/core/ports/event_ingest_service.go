@@ -0,0 +1,67 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"github.com/99minutos/shipping-system/internal/core/domain"
+)
+
+// IngestEventsInput carries events accepted over HTTP.
+type IngestEventsInput struct {
+	Events []TrackingEventInput
+	// Batch groups the events under a batch ID.
+	Batch    bool
+	ClientID string // client of the submitter, used to scope status lookups
+}
+
+// IngestEventsResult holds the IDs assigned to the accepted events.
+type IngestEventsResult struct {
+	BatchID  string
+	EventIDs []string
+}
+
+// GetEventStatusInput identifies a receipt and who is asking for it.
+type GetEventStatusInput struct {
+	ID       string
+	Role     string
+	ClientID string
+}
+
+// EventStatusItem is the ingestion state of one event.
+type EventStatusItem struct {
+	ID             string
+	BatchID        string
+	TrackingNumber string
+	Status         string
+	State          string
+	Reason         string
+	AcceptedAt     time.Time
+	UpdatedAt      time.Time
+}
+
+// EventBatchStatus is the ingestion state of every event in a batch.
+type EventBatchStatus struct {
+	BatchID string
+	Items   []EventStatusItem
+	Counts  map[string]int // events per state
+}
+
+// AppliedEvent is the outcome of an event processed inline.
+type AppliedEvent struct {
+	State domain.EventState // processed, late or duplicate when Err is nil
+	Err   error
+}
+
+// EventIngestService accepts events for asynchronous processing and reports
+// what became of them. It can also apply events synchronously.
+type EventIngestService interface {
+	// Ingest assigns IDs to the events, enqueues them and records them as queued.
+	Ingest(ctx context.Context, input IngestEventsInput) (*IngestEventsResult, error)
+	// Apply processes the events inline in timestamp order, bypassing the
+	// queue. It returns one outcome per event in request order; a failed
+	// event does not stop the rest.
+	Apply(ctx context.Context, events []TrackingEventInput) []AppliedEvent
+	GetEvent(ctx context.Context, input GetEventStatusInput) (*EventStatusItem, error)
+	GetBatch(ctx context.Context, input GetEventStatusInput) (*EventBatchStatus, error)
+}
@@ -0,0 +1,34 @@
+package ports
+
+import (
+	"context"
+	"fmt"
+)
+
+// EventQueue accepts tracking events for asynchronous processing by
+// EventService. Implementations must apply the events of one tracking number
+// in the order they were enqueued.
+//
+// A saturated queue is reported as domain.ErrEventQueueFull and an
+// unreachable backend as domain.ErrEventQueueUnavailable.
+type EventQueue interface {
+	Enqueue(ctx context.Context, event TrackingEventInput) error
+	// EnqueueBatch stops at the first event that is not accepted and returns
+	// an *EnqueueBatchError; events accepted before it stay queued.
+	EnqueueBatch(ctx context.Context, events []TrackingEventInput) error
+}
+
+// EnqueueBatchError reports the first event of a batch that was not
+// accepted. The events before Index were queued.
+type EnqueueBatchError struct {
+	Index int
+	Err   error
+}
+
+func (e *EnqueueBatchError) Error() string {
+	return fmt.Sprintf("event[%d]: %v", e.Index, e.Err)
+}
+
+func (e *EnqueueBatchError) Unwrap() error {
+	return e.Err
+}
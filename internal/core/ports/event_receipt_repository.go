@@ -0,0 +1,22 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"github.com/99minutos/shipping-system/internal/core/domain"
+)
+
+// EventReceiptRepository stores the ingestion state of accepted events.
+type EventReceiptRepository interface {
+	// Create records receipts in the queued state, grouped under batchID when
+	// it is not empty. A worker can finish an event before Create runs, so a
+	// state already set for a receipt is kept.
+	Create(ctx context.Context, batchID string, receipts []domain.EventReceipt) error
+	// SetState records the outcome of an event. It never turns processed into
+	// duplicate: a redelivered event that was already applied stays processed.
+	SetState(ctx context.Context, id string, state domain.EventState, reason string, at time.Time) error
+	FindByID(ctx context.Context, id string) (*domain.EventReceipt, error)
+	// FindBatch returns the receipts of a batch in submission order.
+	FindBatch(ctx context.Context, batchID string) ([]domain.EventReceipt, error)
+}
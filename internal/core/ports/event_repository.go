@@ -7,18 +7,36 @@ import (
 	"github.com/99minutos/shipping-system/internal/core/domain"
 )
 
+// StatusUpdate is a status change applied to a shipment in one write.
+type StatusUpdate struct {
+	// From and FromPieces are the statuses of the shipment and of each of
+	// its pieces the change was decided on; the write only applies while
+	// they still hold.
+	From       domain.ShipmentStatus
+	FromPieces []domain.ShipmentStatus
+
+	Status  domain.ShipmentStatus       // the shipment's new status
+	Entries []domain.StatusHistoryEntry // appended to the history in order
+	// FailedAttempt increments the shipment's failed delivery attempt counter.
+	FailedAttempt bool
+	// Pieces replaces the shipment's pieces when non-nil.
+	Pieces []domain.Piece
+	// EstimatedDelivery replaces the shipment's estimated delivery when
+	// non-zero.
+	EstimatedDelivery time.Time
+}
+
 // EventRepository handles event persistence and atomic shipment status updates.
 type EventRepository interface {
-	// UpdateShipmentStatus atomically sets the shipment's new status and appends
-	// a history entry. The source string is stored as the entry notes.
-	UpdateShipmentStatus(
-		ctx context.Context,
-		trackingNumber string,
-		status domain.ShipmentStatus,
-		ts time.Time,
-		source string,
-		location *domain.Coordinates,
-	) error
+	// UpdateShipmentStatus atomically sets the shipment's new status, appends
+	// the history entries and updates the attempt counter. It returns
+	// domain.ErrConcurrentUpdate when the shipment or one of its pieces is no
+	// longer in the status the update was decided on.
+	UpdateShipmentStatus(ctx context.Context, trackingNumber string, update StatusUpdate) error
+
+	// AppendLateEvent adds a history entry flagged as late, in timestamp
+	// order, without changing the shipment's status.
+	AppendLateEvent(ctx context.Context, trackingNumber string, entry domain.StatusHistoryEntry) error
 
 	// InsertEvent persists an event to the status_events audit collection.
 	InsertEvent(ctx context.Context, event *domain.TrackingEvent) error
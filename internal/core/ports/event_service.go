@@ -3,6 +3,8 @@ package ports
 import (
 	"context"
 	"time"
+
+	"github.com/99minutos/shipping-system/internal/core/domain"
 )
 
 // LocationInput carries optional geographic coordinates for a tracking event.
@@ -13,14 +15,22 @@ type LocationInput struct {
 
 // TrackingEventInput is the DTO passed from the transport layer to EventService.
 type TrackingEventInput struct {
+	ID             string // ingestion ID; empty for events without a receipt
 	TrackingNumber string
 	Status         string
 	Timestamp      time.Time
 	Source         string
+	Role           string         // role of the user who sent the event
+	ReasonCode     string         // why a delivery attempt failed
+	Piece          string         // barcode of the piece scanned; empty for the whole shipment
 	Location       *LocationInput // optional
 }
 
 // EventService processes incoming tracking events.
 type EventService interface {
 	Process(ctx context.Context, event TrackingEventInput) error
+	// Apply is Process for callers that report the outcome inline: it returns
+	// domain.EventProcessed, domain.EventLate or domain.EventDuplicate when
+	// err is nil.
+	Apply(ctx context.Context, event TrackingEventInput) (domain.EventState, error)
 }
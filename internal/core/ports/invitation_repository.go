@@ -0,0 +1,22 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"github.com/99minutos/shipping-system/internal/core/domain"
+)
+
+// InvitationRepository persists hashed registration invitations.
+type InvitationRepository interface {
+	Create(ctx context.Context, invitation *domain.Invitation) error
+	// FindByHash returns domain.ErrInvalidInvitation when no invitation has
+	// the hash.
+	FindByHash(ctx context.Context, tokenHash string) (*domain.Invitation, error)
+	// Accept marks the invitation as used, returning
+	// domain.ErrInvalidInvitation when it already was.
+	Accept(ctx context.Context, id string, at time.Time) error
+	// Release makes an accepted invitation usable again, when the user it
+	// was accepted for could not be created.
+	Release(ctx context.Context, id string) error
+}
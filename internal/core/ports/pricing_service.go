@@ -0,0 +1,49 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"github.com/99minutos/shipping-system/internal/core/domain"
+)
+
+// QuoteInput carries what a shipment is priced on.
+type QuoteInput struct {
+	ServiceType string
+	Origin      AddressInput
+	Destination AddressInput
+	Package     PackageInput
+	Pieces      []PieceInput // priced per piece when present, else the package
+	Insured     bool         // add insurance on Package.DeclaredValue
+}
+
+// Quote is the price breakdown of a shipment.
+type Quote struct {
+	RateCardVersion    string
+	Currency           string
+	ServiceType        string
+	Zone               string
+	DistanceKm         float64
+	ActualWeightKg     float64
+	VolumetricWeightKg float64
+	BillableWeightKg   float64
+	Base               float64
+	WeightCharge       float64
+	DistanceCharge     float64
+	ZoneSurcharge      float64
+	MinimumAdjustment  float64
+	Insured            bool
+	Insurance          float64
+	Total              float64
+}
+
+// PricingService prices shipments with the rate card in effect.
+type PricingService interface {
+	Quote(ctx context.Context, input QuoteInput) (*Quote, error)
+}
+
+// RateCardProvider returns the rate card in effect at a given time, or
+// domain.ErrNoRateCard.
+type RateCardProvider interface {
+	RateCard(at time.Time) (*domain.RateCard, error)
+}
@@ -0,0 +1,23 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"github.com/99minutos/shipping-system/internal/core/domain"
+)
+
+// RefreshTokenRepository persists hashed refresh tokens.
+type RefreshTokenRepository interface {
+	Create(ctx context.Context, token *domain.RefreshToken) error
+	// FindByHash returns domain.ErrInvalidRefreshToken when no token has the hash.
+	FindByHash(ctx context.Context, tokenHash string) (*domain.RefreshToken, error)
+	// Rotate marks the token id as rotated at at and stores next, its
+	// successor. It returns domain.ErrRefreshTokenReused when the token was
+	// already rotated or revoked.
+	Rotate(ctx context.Context, id string, at time.Time, next *domain.RefreshToken) error
+	// RevokeFamily revokes every token descending from the same login.
+	RevokeFamily(ctx context.Context, familyID string, at time.Time) error
+	// RevokeUser revokes every token of a user.
+	RevokeUser(ctx context.Context, userID string, at time.Time) error
+}
@@ -0,0 +1,44 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"github.com/99minutos/shipping-system/internal/core/domain"
+)
+
+// ImportProgress is the outcome of a run of rows of an import, recorded
+// together.
+type ImportProgress struct {
+	Processed  int // rows handled so far, including these
+	Results    []domain.ImportRowResult
+	Created    int // shipments created among Results
+	Failed     int // rows rejected among Results
+	LeaseUntil time.Time
+	At         time.Time
+}
+
+// ShipmentImportRepository persists shipment imports and their rows.
+type ShipmentImportRepository interface {
+	// Create stores imp and its rows, in file order. It returns
+	// domain.ErrImportExists when imp.ClientID already uploaded a file with
+	// the same hash.
+	Create(ctx context.Context, imp *domain.ShipmentImport, rows []domain.ImportRow) error
+	// FindByID retrieves an import. When clientID is non-empty, imports of
+	// other clients are not found.
+	FindByID(ctx context.Context, id, clientID string) (*domain.ShipmentImport, error)
+	// FindByFileHash retrieves the import of a file a client already uploaded.
+	FindByFileHash(ctx context.Context, clientID, fileHash string) (*domain.ShipmentImport, error)
+	// Claim leases the oldest import that is queued, or running under an
+	// expired lease, until leaseUntil and marks it running. It returns
+	// domain.ErrImportNotFound when there is none.
+	Claim(ctx context.Context, now, leaseUntil time.Time) (*domain.ShipmentImport, error)
+	// Rows returns up to limit rows of an import, starting with the row at
+	// position from in file order.
+	Rows(ctx context.Context, id string, from, limit int) ([]domain.ImportRow, error)
+	// SaveProgress records the outcome of the rows handled since the last
+	// call and extends the lease.
+	SaveProgress(ctx context.Context, id string, p ImportProgress) error
+	// Complete marks an import completed.
+	Complete(ctx context.Context, id string, at time.Time) error
+}
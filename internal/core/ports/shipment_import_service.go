@@ -0,0 +1,64 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"github.com/99minutos/shipping-system/internal/core/domain"
+)
+
+// StartImportInput carries an uploaded shipment file, already split into
+// rows.
+type StartImportInput struct {
+	ClientID string
+	FileName string
+	FileHash string // hex SHA-256 of the file; identifies re-uploads
+	Rows     []domain.ImportRow
+}
+
+// GetImportInput identifies an import. Clients only see their own.
+type GetImportInput struct {
+	ID       string
+	Role     string
+	ClientID string
+}
+
+// ImportRowItem is the outcome of one row of an import.
+type ImportRowItem struct {
+	Line           int
+	TrackingNumber string
+	Error          string
+}
+
+// ShipmentImportView is the progress of an import.
+type ShipmentImportView struct {
+	ID         string
+	FileName   string
+	Status     string
+	TotalRows  int
+	Processed  int
+	Created    int
+	Failed     int
+	Shipments  []ImportRowItem // rows that created a shipment
+	Errors     []ImportRowItem // rows that did not
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	FinishedAt time.Time
+	// AlreadyExisted is true when the same file had already been uploaded.
+	AlreadyExisted bool
+}
+
+// ImportRowDecoder turns the cells of an import row into the shipment it
+// describes, validated as a shipment created through the API would be.
+type ImportRowDecoder interface {
+	Decode(fields map[string]string) (CreateShipmentInput, error)
+}
+
+// ShipmentImportService creates shipments from uploaded files in the
+// background.
+type ShipmentImportService interface {
+	// StartImport queues an import, or returns the existing one when the
+	// client already uploaded the same file.
+	StartImport(ctx context.Context, input StartImportInput) (*ShipmentImportView, error)
+	GetImport(ctx context.Context, input GetImportInput) (*ShipmentImportView, error)
+}
@@ -11,9 +11,10 @@ import (
 // ClientID is always enforced by the service layer (RBAC).
 type ListShipmentsFilter struct {
 	ClientID    string    // empty = no filter (admin); non-empty = scoped to client
-	Status      string    // optional: filter by shipment status
+	Statuses    []string  // optional: filter by any of these shipment statuses
+	MinAttempts int       // optional: delivery_attempts >= MinAttempts
 	ServiceType string    // optional: filter by service type
-	Search      string    // optional: partial match on tracking_number or sender.name
+	Search      string    // optional: partial match on tracking_number, sender.name or recipient.name
 	DateFrom    time.Time // optional: created_at >= DateFrom
 	DateTo      time.Time // optional: created_at <= DateTo
 	Page        int       // 1-based
@@ -22,11 +23,40 @@ type ListShipmentsFilter struct {
 
 // ShipmentRepository defines persistence operations for shipments.
 type ShipmentRepository interface {
+	// Create assigns s a new tracking number and inserts it, drawing another
+	// number while the one drawn is already taken. It returns
+	// domain.ErrDuplicateShipment when the client already created a shipment
+	// with s's idempotency key.
 	Create(ctx context.Context, s *domain.Shipment) error
+	// CreateMany assigns each shipment a tracking number and inserts them,
+	// carrying on past the ones that fail. The errors are in the order of
+	// shipments; a nil error means the shipment was inserted, and
+	// domain.ErrDuplicateShipment that its idempotency key was already used.
+	CreateMany(ctx context.Context, shipments []*domain.Shipment) []error
 	// FindByTrackingNumber retrieves a shipment by tracking number.
 	// When clientID is non-empty, the query is additionally filtered by client_id (for RBAC).
 	FindByTrackingNumber(ctx context.Context, trackingNumber string, clientID string) (*domain.Shipment, error)
-	FindByIdempotencyKey(ctx context.Context, key string) (*domain.Shipment, error)
+	// FindByIdempotencyKey retrieves the shipment the client created with key.
+	// Keys are scoped to the client: another client's key never matches.
+	FindByIdempotencyKey(ctx context.Context, clientID, key string) (*domain.Shipment, error)
+	// FindByIdempotencyKeys retrieves the shipments the client created with
+	// any of the given keys, indexed by key. Keys without a shipment are left
+	// out.
+	FindByIdempotencyKeys(ctx context.Context, clientID string, keys []string) (map[string]*domain.Shipment, error)
 	// List returns a page of shipments matching filter and the total count.
 	List(ctx context.Context, filter ListShipmentsFilter) ([]*domain.Shipment, int64, error)
+	// TransitionStatus moves a shipment still in status from to entry.Status
+	// and appends entry to its history; pieces, when not nil, replace the
+	// shipment's pieces. It returns domain.ErrConcurrentUpdate when the status
+	// is no longer from.
+	TransitionStatus(ctx context.Context, trackingNumber string, from domain.ShipmentStatus, entry domain.StatusHistoryEntry, pieces []domain.Piece) error
+	// Amend saves the amendable fields of s and its latest amendment, provided
+	// the stored shipment is still in s.Status at version from. It returns
+	// domain.ErrConcurrentUpdate otherwise.
+	Amend(ctx context.Context, s *domain.Shipment, from int) error
+}
+
+// TrackingNumberGenerator issues tracking numbers for new shipments.
+type TrackingNumberGenerator interface {
+	Generate(clientID string) string
 }
@@ -8,14 +8,32 @@ import (
 // CreateShipmentInput carries all data needed to create a new shipment.
 type CreateShipmentInput struct {
 	Sender         SenderInput
+	Recipient      RecipientInput
 	Origin         AddressInput
 	Destination    AddressInput
 	Package        PackageInput
+	Pieces         []PieceInput // optional; one per box of a multi-piece shipment
 	ServiceType    string
+	Insured        bool // price insurance on Package.DeclaredValue
 	ClientID       string
 	IdempotencyKey string
 }
 
+// PieceInput holds the size of one box of a multi-piece shipment.
+type PieceInput struct {
+	WeightKg   float64
+	Dimensions DimensionsInput
+}
+
+// PieceItem is the view of one piece of a shipment.
+type PieceItem struct {
+	Barcode          string
+	WeightKg         float64
+	Dimensions       DimensionsInput
+	Status           string
+	DeliveryAttempts int
+}
+
 // SenderInput holds sender contact details.
 type SenderInput struct {
 	Name  string
@@ -23,6 +41,15 @@ type SenderInput struct {
 	Phone string
 }
 
+// RecipientInput holds the details of the person receiving the shipment.
+type RecipientInput struct {
+	Name               string
+	Phone              string
+	Email              string
+	Instructions       string
+	AlternativeContact *SenderInput // optional second person who may receive it
+}
+
 // CoordinatesInput holds geographic coordinates.
 type CoordinatesInput struct {
 	Lat float64
@@ -63,6 +90,12 @@ type ShipmentResult struct {
 	AlreadyExisted bool
 }
 
+// BatchShipmentResult is the outcome of one shipment of a batch.
+type BatchShipmentResult struct {
+	Shipment *ShipmentResult // nil when Err is set
+	Err      error
+}
+
 // GetShipmentInput carries the parameters needed to retrieve a single shipment.
 type GetShipmentInput struct {
 	TrackingNumber string
@@ -73,9 +106,15 @@ type GetShipmentInput struct {
 
 // StatusHistoryItem is a single entry in the shipment's status history.
 type StatusHistoryItem struct {
-	Status    string
-	Timestamp time.Time
-	Notes     string
+	Status     string
+	Timestamp  time.Time
+	Notes      string
+	ReasonCode string
+	Actor      string
+	Reason     string
+	Piece      string // piece barcode; empty for the whole shipment
+	Attempt    int    // failed delivery attempt number; 0 for other entries
+	Late       bool   // arrived after a newer transition; did not change the status
 }
 
 // ShipmentDetail is the full shipment view returned by GetShipment.
@@ -86,24 +125,107 @@ type ShipmentDetail struct {
 	CreatedAt         time.Time
 	EstimatedDelivery time.Time
 	Sender            SenderInput
+	Recipient         RecipientInput
 	Origin            AddressInput
 	Destination       AddressInput
 	Package           PackageInput
+	Pieces            []PieceItem
+	Price             *Quote // nil for shipments created without a rate card
+	DeliveryAttempts  int
+	Version           int
+	Amendments        []AmendmentItem
 	StatusHistory     []StatusHistoryItem
 }
 
+// AmendmentItem is a single entry in the shipment's amendment log.
+type AmendmentItem struct {
+	Version int
+	Actor   string
+	At      time.Time
+	Changes []FieldChangeItem
+}
+
+// FieldChangeItem records the previous and new value of an amended field.
+type FieldChangeItem struct {
+	Field string
+	From  string
+	To    string
+}
+
+// AmendShipmentInput carries corrections to a shipment that has not been
+// picked up yet. Nil fields are left unchanged.
+type AmendShipmentInput struct {
+	TrackingNumber string
+	// Role and ClientID scope the lookup like GetShipmentInput.
+	Role        string
+	ClientID    string
+	Actor       string // username recorded in the amendment log
+	Sender      SenderPatch
+	Recipient   RecipientPatch
+	Destination AddressPatch
+	Description *string
+	ServiceType *string
+}
+
+// SenderPatch holds the sender contact fields to change.
+type SenderPatch struct {
+	Name  *string
+	Email *string
+	Phone *string
+}
+
+// RecipientPatch holds the recipient fields to change.
+type RecipientPatch struct {
+	Name         *string
+	Phone        *string
+	Email        *string
+	Instructions *string
+}
+
+// AddressPatch holds the address fields to change.
+type AddressPatch struct {
+	Address     *string
+	City        *string
+	ZipCode     *string
+	Coordinates *CoordinatesInput
+}
+
+// CancelShipmentInput carries a cancellation request.
+type CancelShipmentInput struct {
+	TrackingNumber string
+	// Role and ClientID scope the lookup like GetShipmentInput.
+	Role     string
+	ClientID string
+	Actor    string // username recorded in the history
+	Reason   string
+}
+
+// CancelShipmentResult is returned after a shipment is cancelled.
+type CancelShipmentResult struct {
+	TrackingNumber string
+	Status         string
+	CancelledAt    time.Time
+	Reason         string
+}
+
 // ShipmentService defines use-case operations for shipments.
 type ShipmentService interface {
 	CreateShipment(ctx context.Context, input CreateShipmentInput) (*ShipmentResult, error)
+	// CreateShipments creates a batch of shipments; the results are in the
+	// order of inputs.
+	CreateShipments(ctx context.Context, inputs []CreateShipmentInput) []BatchShipmentResult
 	GetShipment(ctx context.Context, input GetShipmentInput) (*ShipmentDetail, error)
 	ListShipments(ctx context.Context, input ListShipmentsInput) (*ListShipmentsResult, error)
+	CancelShipment(ctx context.Context, input CancelShipmentInput) (*CancelShipmentResult, error)
+	AmendShipment(ctx context.Context, input AmendShipmentInput) (*ShipmentDetail, error)
 }
 
 // ListShipmentsInput carries all parameters for the list endpoint.
 type ListShipmentsInput struct {
 	Role        string
 	ClientID    string
-	Status      string
+	Statuses    []string // any of these statuses; empty = all
+	MinAttempts int      // at least this many failed delivery attempts
 	ServiceType string
 	Search      string
 	DateFrom    time.Time
@@ -119,10 +241,13 @@ type ShipmentSummary struct {
 	ServiceType       string
 	ClientID          string
 	Sender            SenderInput
+	Recipient         RecipientInput
 	Origin            AddressInput
 	Destination       AddressInput
 	CreatedAt         time.Time
 	EstimatedDelivery time.Time
+	DeliveryAttempts  int
+	Pieces            []PieceItem
 }
 
 // ListShipmentsResult is returned by ListShipments.
@@ -0,0 +1,14 @@
+package ports
+
+import (
+	"context"
+	"time"
+)
+
+// TokenDenylist records access tokens revoked before they expire, by their
+// jti claim.
+type TokenDenylist interface {
+	// Revoke denies the token until it expires at until.
+	Revoke(ctx context.Context, jti string, until time.Time) error
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+}
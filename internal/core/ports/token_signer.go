@@ -0,0 +1,6 @@
+package ports
+
+// TokenSigner signs the claims of access tokens into a JWT.
+type TokenSigner interface {
+	Sign(claims map[string]any) (string, error)
+}
@@ -0,0 +1,71 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/99minutos/shipping-system/internal/core/domain"
+)
+
+// CreateUserInput describes a user created by an admin, of any role.
+type CreateUserInput struct {
+	Username string
+	Password string
+	Email    string
+	Role     string
+	ClientID string
+}
+
+// ListUsersInput carries the parameters for the list endpoint.
+type ListUsersInput struct {
+	Role     string
+	ClientID string
+	Page     int
+	Limit    int
+}
+
+// ListUsersResult is returned by UserService.List.
+type ListUsersResult struct {
+	Items      []*domain.User
+	Total      int64
+	Page       int
+	Limit      int
+	TotalPages int
+}
+
+// UpdateUserInput changes the role or client of a user; nil fields are left
+// unchanged. ActorID is the admin making the change.
+type UpdateUserInput struct {
+	ID       string
+	Role     *string
+	ClientID *string
+	ActorID  string
+}
+
+// InviteInput describes an invitation for one person to register as a user
+// of a client or carrier account.
+type InviteInput struct {
+	Email     string
+	Role      string // domain.RoleClient or domain.RoleCarrier
+	ClientID  string
+	CreatedBy string
+}
+
+// IssuedInvitation is an invitation just created, with its token, which is
+// not stored and cannot be shown again.
+type IssuedInvitation struct {
+	Invitation *domain.Invitation
+	Token      string
+}
+
+// UserService implements the admin management of users. Disabling or
+// deleting a user revokes its refresh tokens; its access tokens stay valid
+// until they expire.
+type UserService interface {
+	Create(ctx context.Context, input CreateUserInput) (*domain.User, error)
+	List(ctx context.Context, input ListUsersInput) (*ListUsersResult, error)
+	Get(ctx context.Context, id string) (*domain.User, error)
+	Update(ctx context.Context, input UpdateUserInput) (*domain.User, error)
+	SetDisabled(ctx context.Context, id string, disabled bool, actorID string) (*domain.User, error)
+	Delete(ctx context.Context, id, actorID string) error
+	Invite(ctx context.Context, input InviteInput) (*IssuedInvitation, error)
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/99minutos/shipping-system/internal/api/metrics"
+	"github.com/99minutos/shipping-system/internal/core/domain"
+	"github.com/99minutos/shipping-system/internal/core/ports"
+)
+
+// apiKeyTouchInterval is how often the last use of a key is recorded, so
+// that busy integrations do not write on every request.
+const apiKeyTouchInterval = time.Minute
+
+// APIKeyService implements ports.APIKeyService.
+type APIKeyService struct {
+	repo   ports.APIKeyRepository
+	logger zerolog.Logger
+}
+
+func NewAPIKeyService(repo ports.APIKeyRepository, logger zerolog.Logger) *APIKeyService {
+	return &APIKeyService{repo: repo, logger: logger}
+}
+
+func (s *APIKeyService) Create(ctx context.Context, input ports.CreateAPIKeyInput) (*ports.IssuedAPIKey, error) {
+	now := time.Now().UTC()
+	return s.issue(ctx, &domain.APIKey{
+		ClientID:   input.ClientID,
+		Role:       input.Role,
+		Name:       input.Name,
+		AllowedIPs: input.AllowedIPs,
+		ExpiresAt:  input.ExpiresAt,
+		CreatedBy:  input.CreatedBy,
+		CreatedAt:  now,
+	})
+}
+
+func (s *APIKeyService) List(ctx context.Context, clientID string) ([]domain.APIKey, error) {
+	return s.repo.ListByClient(ctx, clientID)
+}
+
+// Rotate replaces an active key with a new one of the same name, allowlist
+// and expiry. The old key stops working after gracePeriod, or at once when it
+// is zero, so integrations can switch keys without failed requests.
+func (s *APIKeyService) Rotate(ctx context.Context, ref ports.APIKeyRef, gracePeriod time.Duration, createdBy string) (*ports.IssuedAPIKey, error) {
+	old, err := s.find(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	if !old.Active(now) {
+		return nil, domain.ErrAPIKeyInactive
+	}
+
+	issued, err := s.issue(ctx, &domain.APIKey{
+		ClientID:   old.ClientID,
+		Role:       old.Role,
+		Name:       old.Name,
+		AllowedIPs: old.AllowedIPs,
+		ExpiresAt:  old.ExpiresAt,
+		CreatedBy:  createdBy,
+		CreatedAt:  now,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	retireAt := now.Add(gracePeriod)
+	if !old.ExpiresAt.IsZero() && old.ExpiresAt.Before(retireAt) {
+		retireAt = old.ExpiresAt
+	}
+	if err := s.repo.Replace(ctx, old.ID, issued.APIKey.ID, retireAt); err != nil {
+		return nil, err
+	}
+	return issued, nil
+}
+
+func (s *APIKeyService) Revoke(ctx context.Context, ref ports.APIKeyRef) error {
+	key, err := s.find(ctx, ref)
+	if err != nil {
+		return err
+	}
+	if !key.RevokedAt.IsZero() {
+		return nil
+	}
+	return s.repo.Revoke(ctx, key.ID, time.Now().UTC())
+}
+
+// Authenticate looks the key up by its hash and checks it is active and used
+// from an allowed address.
+func (s *APIKeyService) Authenticate(ctx context.Context, key, ip string) (*domain.APIKey, error) {
+	if !strings.HasPrefix(key, domain.APIKeyPrefix) {
+		metrics.APIKeyAuthTotal.WithLabelValues("invalid").Inc()
+		return nil, domain.ErrInvalidAPIKey
+	}
+	k, err := s.repo.FindByHash(ctx, hashToken(key))
+	if errors.Is(err, domain.ErrAPIKeyNotFound) {
+		metrics.APIKeyAuthTotal.WithLabelValues("invalid").Inc()
+		return nil, domain.ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	if !k.Active(now) {
+		metrics.APIKeyAuthTotal.WithLabelValues("invalid").Inc()
+		return nil, domain.ErrInvalidAPIKey
+	}
+	if !k.AllowsIP(ip) {
+		metrics.APIKeyAuthTotal.WithLabelValues("ip_not_allowed").Inc()
+		s.logger.Warn().Str("api_key", k.Prefix).Str("client_id", k.ClientID).Str("ip", ip).Msg("api key used from an address not allowed")
+		return nil, domain.ErrAPIKeyIPNotAllowed
+	}
+
+	if now.Sub(k.LastUsedAt) >= apiKeyTouchInterval {
+		if err := s.repo.Touch(ctx, k.ID, now); err != nil {
+			s.logger.Warn().Err(err).Str("api_key", k.Prefix).Msg("failed to record api key use")
+		}
+	}
+	metrics.APIKeyAuthTotal.WithLabelValues("authenticated").Inc()
+	return k, nil
+}
+
+func (s *APIKeyService) find(ctx context.Context, ref ports.APIKeyRef) (*domain.APIKey, error) {
+	filterClientID := ""
+	if ref.Role != domain.RoleAdmin {
+		filterClientID = ref.ClientID
+	}
+	return s.repo.FindByID(ctx, ref.ID, filterClientID)
+}
+
+// issue generates the secret of key and stores the key with its hash.
+func (s *APIKeyService) issue(ctx context.Context, key *domain.APIKey) (*ports.IssuedAPIKey, error) {
+	secret := make([]byte, 24)
+	_, _ = rand.Read(secret) // never fails since Go 1.24
+	plain := domain.APIKeyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	key.ID = newID("key_")
+	key.Prefix = plain[:len(domain.APIKeyPrefix)+8]
+	key.KeyHash = hashToken(plain)
+	if err := s.repo.Create(ctx, key); err != nil {
+		return nil, err
+	}
+	return &ports.IssuedAPIKey{APIKey: key, Key: plain}, nil
+}
@@ -0,0 +1,236 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/99minutos/shipping-system/internal/core/domain"
+	"github.com/99minutos/shipping-system/internal/core/ports"
+)
+
+// stubAPIKeyRepo keeps API keys in memory by ID.
+type stubAPIKeyRepo struct {
+	keys    map[string]*domain.APIKey
+	touches int
+}
+
+func newStubAPIKeyRepo() *stubAPIKeyRepo {
+	return &stubAPIKeyRepo{keys: make(map[string]*domain.APIKey)}
+}
+
+func (r *stubAPIKeyRepo) Create(_ context.Context, key *domain.APIKey) error {
+	clone := *key
+	r.keys[key.ID] = &clone
+	return nil
+}
+
+func (r *stubAPIKeyRepo) FindByHash(_ context.Context, keyHash string) (*domain.APIKey, error) {
+	for _, k := range r.keys {
+		if k.KeyHash == keyHash {
+			clone := *k
+			return &clone, nil
+		}
+	}
+	return nil, domain.ErrAPIKeyNotFound
+}
+
+func (r *stubAPIKeyRepo) FindByID(_ context.Context, id, clientID string) (*domain.APIKey, error) {
+	k, ok := r.keys[id]
+	if !ok || (clientID != "" && k.ClientID != clientID) {
+		return nil, domain.ErrAPIKeyNotFound
+	}
+	clone := *k
+	return &clone, nil
+}
+
+func (r *stubAPIKeyRepo) ListByClient(_ context.Context, clientID string) ([]domain.APIKey, error) {
+	var keys []domain.APIKey
+	for _, k := range r.keys {
+		if k.ClientID == clientID {
+			keys = append(keys, *k)
+		}
+	}
+	return keys, nil
+}
+
+func (r *stubAPIKeyRepo) Revoke(_ context.Context, id string, at time.Time) error {
+	r.keys[id].RevokedAt = at
+	return nil
+}
+
+func (r *stubAPIKeyRepo) Replace(_ context.Context, id, replacedBy string, expiresAt time.Time) error {
+	r.keys[id].ReplacedBy = replacedBy
+	r.keys[id].ExpiresAt = expiresAt
+	return nil
+}
+
+func (r *stubAPIKeyRepo) Touch(_ context.Context, id string, at time.Time) error {
+	r.touches++
+	r.keys[id].LastUsedAt = at
+	return nil
+}
+
+func createTestKey(t *testing.T, svc *APIKeyService, input ports.CreateAPIKeyInput) *ports.IssuedAPIKey {
+	t.Helper()
+	issued, err := svc.Create(context.Background(), input)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	return issued
+}
+
+func TestAPIKeyService_CreateAndAuthenticate(t *testing.T) {
+	repo := newStubAPIKeyRepo()
+	svc := NewAPIKeyService(repo, zerolog.Nop())
+	ctx := context.Background()
+
+	issued := createTestKey(t, svc, ports.CreateAPIKeyInput{ClientID: "client_1", Name: "wms", CreatedBy: "alice"})
+	if !strings.HasPrefix(issued.Key, domain.APIKeyPrefix) || !strings.HasPrefix(issued.Key, issued.APIKey.Prefix) {
+		t.Fatalf("unexpected key %q with prefix %q", issued.Key, issued.APIKey.Prefix)
+	}
+	stored := repo.keys[issued.APIKey.ID]
+	if stored.KeyHash == "" || strings.Contains(stored.KeyHash, issued.Key) {
+		t.Fatalf("expected only a hash of the key to be stored, got %q", stored.KeyHash)
+	}
+
+	key, err := svc.Authenticate(ctx, issued.Key, "203.0.113.7")
+	if err != nil {
+		t.Fatalf("authenticate: %v", err)
+	}
+	if key.ClientID != "client_1" || key.ID != issued.APIKey.ID {
+		t.Fatalf("unexpected key %+v", key)
+	}
+	if repo.keys[key.ID].LastUsedAt.IsZero() {
+		t.Fatal("expected the use of the key to be recorded")
+	}
+	// A second use within a minute is not written again.
+	if _, err := svc.Authenticate(ctx, issued.Key, "203.0.113.7"); err != nil {
+		t.Fatalf("authenticate again: %v", err)
+	}
+	if repo.touches != 1 {
+		t.Fatalf("expected 1 recorded use, got %d", repo.touches)
+	}
+
+	for _, bad := range []string{"", "Bearer x", domain.APIKeyPrefix + "unknown", issued.Key + "x"} {
+		if _, err := svc.Authenticate(ctx, bad, "203.0.113.7"); !errors.Is(err, domain.ErrInvalidAPIKey) {
+			t.Errorf("key %q: expected ErrInvalidAPIKey, got %v", bad, err)
+		}
+	}
+}
+
+func TestAPIKeyService_Authenticate_AllowedIPs(t *testing.T) {
+	svc := NewAPIKeyService(newStubAPIKeyRepo(), zerolog.Nop())
+	issued := createTestKey(t, svc, ports.CreateAPIKeyInput{
+		ClientID:   "client_1",
+		Name:       "wms",
+		AllowedIPs: []string{"10.0.0.0/8", "203.0.113.7", "2001:db8::/32"},
+	})
+
+	for ip, want := range map[string]error{
+		"10.1.2.3":           nil,
+		"203.0.113.7":        nil,
+		"::ffff:203.0.113.7": nil,
+		"2001:db8::1":        nil,
+		"203.0.113.8":        domain.ErrAPIKeyIPNotAllowed,
+		"not-an-ip":          domain.ErrAPIKeyIPNotAllowed,
+	} {
+		if _, err := svc.Authenticate(context.Background(), issued.Key, ip); !errors.Is(err, want) {
+			t.Errorf("ip %s: expected %v, got %v", ip, want, err)
+		}
+	}
+}
+
+func TestAPIKeyService_ExpiredAndRevokedKeysAreRejected(t *testing.T) {
+	repo := newStubAPIKeyRepo()
+	svc := NewAPIKeyService(repo, zerolog.Nop())
+	ctx := context.Background()
+
+	expired := createTestKey(t, svc, ports.CreateAPIKeyInput{ClientID: "client_1", Name: "old", ExpiresAt: time.Now().Add(time.Hour)})
+	repo.keys[expired.APIKey.ID].ExpiresAt = time.Now().Add(-time.Second)
+	if _, err := svc.Authenticate(ctx, expired.Key, "203.0.113.7"); !errors.Is(err, domain.ErrInvalidAPIKey) {
+		t.Fatalf("expected an expired key to be rejected, got %v", err)
+	}
+
+	revoked := createTestKey(t, svc, ports.CreateAPIKeyInput{ClientID: "client_1", Name: "wms"})
+	ref := ports.APIKeyRef{ID: revoked.APIKey.ID, Role: domain.RoleClient, ClientID: "client_1"}
+	if err := svc.Revoke(ctx, ref); err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+	if err := svc.Revoke(ctx, ref); err != nil {
+		t.Fatalf("revoking twice: %v", err)
+	}
+	if _, err := svc.Authenticate(ctx, revoked.Key, "203.0.113.7"); !errors.Is(err, domain.ErrInvalidAPIKey) {
+		t.Fatalf("expected a revoked key to be rejected, got %v", err)
+	}
+	if _, err := svc.Rotate(ctx, ref, time.Hour, "alice"); !errors.Is(err, domain.ErrAPIKeyInactive) {
+		t.Fatalf("expected a revoked key not to rotate, got %v", err)
+	}
+}
+
+func TestAPIKeyService_Rotate(t *testing.T) {
+	repo := newStubAPIKeyRepo()
+	svc := NewAPIKeyService(repo, zerolog.Nop())
+	ctx := context.Background()
+	expiresAt := time.Now().Add(30 * 24 * time.Hour).UTC()
+	old := createTestKey(t, svc, ports.CreateAPIKeyInput{
+		ClientID:   "client_1",
+		Role:       domain.RoleCarrier,
+		Name:       "wms",
+		AllowedIPs: []string{"10.0.0.0/8"},
+		ExpiresAt:  expiresAt,
+	})
+	ref := ports.APIKeyRef{ID: old.APIKey.ID, Role: domain.RoleClient, ClientID: "client_1"}
+
+	next, err := svc.Rotate(ctx, ref, time.Hour, "bob")
+	if err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+	if next.Key == old.Key || next.APIKey.Name != "wms" || next.APIKey.Role != domain.RoleCarrier || next.APIKey.AllowedIPs[0] != "10.0.0.0/8" ||
+		!next.APIKey.ExpiresAt.Equal(expiresAt) || next.APIKey.CreatedBy != "bob" {
+		t.Fatalf("unexpected rotated key %+v", next.APIKey)
+	}
+	replaced := repo.keys[old.APIKey.ID]
+	if replaced.ReplacedBy != next.APIKey.ID || time.Until(replaced.ExpiresAt) > time.Hour {
+		t.Fatalf("expected the old key to expire within the grace period, got %+v", replaced)
+	}
+	// Both keys work during the grace period.
+	for _, key := range []string{old.Key, next.Key} {
+		if _, err := svc.Authenticate(ctx, key, "10.0.0.1"); err != nil {
+			t.Fatalf("authenticate during the grace period: %v", err)
+		}
+	}
+
+	// Without a grace period the old key stops at once.
+	last, err := svc.Rotate(ctx, ports.APIKeyRef{ID: next.APIKey.ID, Role: domain.RoleAdmin}, 0, "root")
+	if err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+	if _, err := svc.Authenticate(ctx, next.Key, "10.0.0.1"); !errors.Is(err, domain.ErrInvalidAPIKey) {
+		t.Fatalf("expected the rotated key to be rejected, got %v", err)
+	}
+	if _, err := svc.Authenticate(ctx, last.Key, "10.0.0.1"); err != nil {
+		t.Fatalf("authenticate new key: %v", err)
+	}
+}
+
+func TestAPIKeyService_ClientsOnlyReachTheirKeys(t *testing.T) {
+	svc := NewAPIKeyService(newStubAPIKeyRepo(), zerolog.Nop())
+	ctx := context.Background()
+	issued := createTestKey(t, svc, ports.CreateAPIKeyInput{ClientID: "client_1", Name: "wms"})
+
+	other := ports.APIKeyRef{ID: issued.APIKey.ID, Role: domain.RoleClient, ClientID: "client_2"}
+	if err := svc.Revoke(ctx, other); !errors.Is(err, domain.ErrAPIKeyNotFound) {
+		t.Fatalf("expected another client's key not to be found, got %v", err)
+	}
+	if _, err := svc.Rotate(ctx, other, 0, "mallory"); !errors.Is(err, domain.ErrAPIKeyNotFound) {
+		t.Fatalf("expected another client's key not to be found, got %v", err)
+	}
+	if err := svc.Revoke(ctx, ports.APIKeyRef{ID: issued.APIKey.ID, Role: domain.RoleAdmin}); err != nil {
+		t.Fatalf("admin revoke: %v", err)
+	}
+}
@@ -2,93 +2,276 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"log"
+	"strings"
 	"time"
 
-	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
 
+	"github.com/99minutos/shipping-system/internal/api/metrics"
 	"github.com/99minutos/shipping-system/internal/core/domain"
 	"github.com/99minutos/shipping-system/internal/core/ports"
 )
 
-// AuthService implements registration and login.
+// AuthService implements registration, login and the rotation and revocation
+// of tokens.
 type AuthService struct {
-	repo      ports.AuthRepository
-	jwtSecret string
-	tokenTTL  time.Duration
+	repo        ports.AuthRepository
+	tokens      ports.RefreshTokenRepository
+	invitations ports.InvitationRepository
+	denylist    ports.TokenDenylist
+	signer      ports.TokenSigner
+	accessTTL   time.Duration
+	refreshTTL  time.Duration
 }
 
-func NewAuthService(repo ports.AuthRepository, jwtSecret string, tokenTTL time.Duration) *AuthService {
-	if tokenTTL <= 0 {
-		tokenTTL = 24 * time.Hour
+// NewAuthService creates an AuthService. A non-positive accessTTL uses 15
+// minutes and a non-positive refreshTTL 30 days.
+func NewAuthService(
+	repo ports.AuthRepository,
+	tokens ports.RefreshTokenRepository,
+	invitations ports.InvitationRepository,
+	denylist ports.TokenDenylist,
+	signer ports.TokenSigner,
+	accessTTL, refreshTTL time.Duration,
+) *AuthService {
+	if accessTTL <= 0 {
+		accessTTL = 15 * time.Minute
+	}
+	if refreshTTL <= 0 {
+		refreshTTL = 30 * 24 * time.Hour
+	}
+	return &AuthService{
+		repo:        repo,
+		tokens:      tokens,
+		invitations: invitations,
+		denylist:    denylist,
+		signer:      signer,
+		accessTTL:   accessTTL,
+		refreshTTL:  refreshTTL,
 	}
-	return &AuthService{repo: repo, jwtSecret: jwtSecret, tokenTTL: tokenTTL}
 }
 
-func (s *AuthService) Register(ctx context.Context, username, password, email, role, clientID string) (*domain.User, error) {
-	if username == "" || password == "" || role == "" || email == "" {
+// Register creates a user with the role and client of an invitation issued
+// for its email. The invitation cannot be used again.
+func (s *AuthService) Register(ctx context.Context, input ports.RegisterInput) (*domain.User, error) {
+	if input.Username == "" || input.Password == "" || input.Email == "" {
 		return nil, domain.ErrInvalidCredentials
 	}
-	if role != domain.RoleAdmin && role != domain.RoleClient {
-		return nil, domain.ErrInvalidCredentials
+	if input.InvitationToken == "" {
+		return nil, domain.ErrInvalidInvitation
 	}
 
-	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	invitation, err := s.invitations.FindByHash(ctx, hashToken(input.InvitationToken))
 	if err != nil {
 		return nil, err
 	}
-
 	now := time.Now().UTC()
-	user := &domain.User{
-		Username:     username,
-		Email:        email,
-		PasswordHash: string(hash),
-		Role:         role,
-		ClientID:     clientID,
-		CreatedAt:    now,
-		UpdatedAt:    now,
+	if !invitation.Usable(input.Email, now) {
+		return nil, domain.ErrInvalidInvitation
+	}
+	if err := s.invitations.Accept(ctx, invitation.ID, now); err != nil {
+		return nil, err
 	}
 
-	created, err := s.repo.Create(ctx, user)
+	user, err := createUser(ctx, s.repo, ports.CreateUserInput{
+		Username: input.Username,
+		Password: input.Password,
+		Email:    input.Email,
+		Role:     invitation.Role,
+		ClientID: invitation.ClientID,
+	})
 	if err != nil {
+		if err := s.invitations.Release(ctx, invitation.ID); err != nil {
+			log.Printf("Failed to release invitation %s: %v", invitation.ID, err)
+		}
 		return nil, err
 	}
-	return created, nil
+	return user, nil
 }
 
-func (s *AuthService) Login(ctx context.Context, email, password string) (string, *domain.User, error) {
+// Login checks the credentials and starts a new family of refresh tokens.
+func (s *AuthService) Login(ctx context.Context, email, password string) (*ports.AuthTokens, *domain.User, error) {
 	if email == "" || password == "" {
-		return "", nil, domain.ErrInvalidCredentials
+		return nil, nil, domain.ErrInvalidCredentials
 	}
 
 	user, err := s.repo.FindByEmail(ctx, email)
 	if err != nil {
 		log.Printf("Login failed for email %s: %v", email, err)
-		return "", nil, err
+		return nil, nil, err
 	}
 
 	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) != nil {
 		log.Printf("Invalid password for email %s", email)
-		return "", nil, domain.ErrInvalidCredentials
+		return nil, nil, domain.ErrInvalidCredentials
+	}
+	if user.Disabled() {
+		log.Printf("Login refused for disabled user %s", user.ID)
+		return nil, nil, domain.ErrUserDisabled
+	}
+
+	tokens, err := s.issue(ctx, user, newID("rtf_"), nil)
+	if err != nil {
+		return nil, nil, err
 	}
+	return tokens, user, nil
+}
 
-	token, err := s.generateToken(user)
+// Refresh rotates a refresh token: it is spent and replaced by a new one of
+// the same family, along with a new access token carrying the user's current
+// role and client. Presenting a spent token revokes its family.
+func (s *AuthService) Refresh(ctx context.Context, refreshToken string) (*ports.AuthTokens, *domain.User, error) {
+	if refreshToken == "" {
+		return nil, nil, domain.ErrInvalidRefreshToken
+	}
+	current, err := s.tokens.FindByHash(ctx, hashToken(refreshToken))
 	if err != nil {
-		return "", nil, err
+		if errors.Is(err, domain.ErrInvalidRefreshToken) {
+			metrics.TokenRefreshesTotal.WithLabelValues("invalid").Inc()
+		}
+		return nil, nil, err
 	}
 
-	return token, user, nil
+	now := time.Now().UTC()
+	if !current.RotatedAt.IsZero() {
+		return nil, nil, s.revokeReused(ctx, current, now)
+	}
+	if !current.Usable(now) {
+		metrics.TokenRefreshesTotal.WithLabelValues("invalid").Inc()
+		return nil, nil, domain.ErrInvalidRefreshToken
+	}
+
+	user, err := s.repo.FindByID(ctx, current.UserID)
+	if errors.Is(err, domain.ErrUserNotFound) || (err == nil && user.Disabled()) {
+		metrics.TokenRefreshesTotal.WithLabelValues("invalid").Inc()
+		return nil, nil, domain.ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tokens, err := s.issue(ctx, user, current.FamilyID, current)
+	if errors.Is(err, domain.ErrRefreshTokenReused) {
+		// Another request spent the token between the lookup and the rotation.
+		return nil, nil, s.revokeReused(ctx, current, now)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	metrics.TokenRefreshesTotal.WithLabelValues("rotated").Inc()
+	return tokens, user, nil
 }
 
-func (s *AuthService) generateToken(user *domain.User) (string, error) {
-	claims := jwt.MapClaims{
+// Logout revokes the access token of the request and, when given, the family
+// of the refresh token issued with it. Unknown refresh tokens, and those of
+// other users, are ignored.
+func (s *AuthService) Logout(ctx context.Context, input ports.LogoutInput) error {
+	if input.JTI != "" {
+		if err := s.denylist.Revoke(ctx, input.JTI, input.TokenExpiresAt); err != nil {
+			return err
+		}
+	}
+	if input.RefreshToken == "" {
+		return nil
+	}
+
+	current, err := s.tokens.FindByHash(ctx, hashToken(input.RefreshToken))
+	if errors.Is(err, domain.ErrInvalidRefreshToken) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if current.UserID != input.UserID {
+		return nil
+	}
+	return s.tokens.RevokeFamily(ctx, current.FamilyID, time.Now().UTC())
+}
+
+// revokeReused revokes the family of a refresh token presented after it was
+// spent and returns domain.ErrRefreshTokenReused.
+func (s *AuthService) revokeReused(ctx context.Context, token *domain.RefreshToken, now time.Time) error {
+	log.Printf("Refresh token reused for user %s, revoking family %s", token.UserID, token.FamilyID)
+	metrics.TokenRefreshesTotal.WithLabelValues("reused").Inc()
+	if err := s.tokens.RevokeFamily(ctx, token.FamilyID, now); err != nil {
+		return err
+	}
+	return domain.ErrRefreshTokenReused
+}
+
+// issue signs an access token for user and stores a new refresh token of the
+// family, as the successor of rotated when it is set.
+func (s *AuthService) issue(ctx context.Context, user *domain.User, familyID string, rotated *domain.RefreshToken) (*ports.AuthTokens, error) {
+	now := time.Now().UTC()
+	access, err := s.generateToken(user, now)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := make([]byte, 32)
+	_, _ = rand.Read(secret) // never fails since Go 1.24
+	refresh := base64.RawURLEncoding.EncodeToString(secret)
+	next := &domain.RefreshToken{
+		ID:        newID("rt_"),
+		FamilyID:  familyID,
+		UserID:    user.ID,
+		TokenHash: hashToken(refresh),
+		ExpiresAt: now.Add(s.refreshTTL),
+		CreatedAt: now,
+	}
+
+	if rotated == nil {
+		err = s.tokens.Create(ctx, next)
+	} else {
+		err = s.tokens.Rotate(ctx, rotated.ID, now, next)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &ports.AuthTokens{AccessToken: access, RefreshToken: refresh, ExpiresIn: s.accessTTL}, nil
+}
+
+func (s *AuthService) generateToken(user *domain.User, now time.Time) (string, error) {
+	return s.signer.Sign(map[string]any{
+		"sub":       user.ID,
+		"jti":       newID(""),
 		"username":  user.Username,
 		"role":      user.Role,
 		"client_id": user.ClientID,
-		"exp":       time.Now().Add(s.tokenTTL).Unix(),
+		"scope":     strings.Join(domain.RoleScopes(user.Role), " "),
+		"iat":       now.Unix(),
+		"exp":       now.Add(s.accessTTL).Unix(),
+	})
+}
+
+// createUser hashes the password of a new user and stores it.
+func createUser(ctx context.Context, repo ports.AuthRepository, input ports.CreateUserInput) (*domain.User, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
 	}
 
-	t := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return t.SignedString([]byte(s.jwtSecret))
+	now := time.Now().UTC()
+	return repo.Create(ctx, &domain.User{
+		Username:     input.Username,
+		Email:        input.Email,
+		PasswordHash: string(hash),
+		Role:         input.Role,
+		ClientID:     input.ClientID,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	})
+}
+
+// hashToken is the form refresh tokens, API keys and invitations are stored
+// and looked up in.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
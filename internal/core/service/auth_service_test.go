@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"strings"
 	"testing"
 	"time"
 
@@ -9,6 +10,8 @@ import (
 	"golang.org/x/crypto/bcrypt"
 
 	"github.com/99minutos/shipping-system/internal/core/domain"
+	"github.com/99minutos/shipping-system/internal/core/ports"
+	"github.com/99minutos/shipping-system/internal/pkg/jwtkeys"
 )
 
 type stubAuthRepo struct {
@@ -39,6 +42,15 @@ func (r *stubAuthRepo) Create(_ context.Context, user *domain.User) (*domain.Use
 	return cloneUser(copy), nil
 }
 
+func (r *stubAuthRepo) FindByID(_ context.Context, id string) (*domain.User, error) {
+	for _, u := range r.users {
+		if u.ID == id {
+			return cloneUser(u), nil
+		}
+	}
+	return nil, domain.ErrUserNotFound
+}
+
 func (r *stubAuthRepo) FindByEmail(_ context.Context, email string) (*domain.User, error) {
 	for _, u := range r.users {
 		if u.Email == email {
@@ -48,11 +60,194 @@ func (r *stubAuthRepo) FindByEmail(_ context.Context, email string) (*domain.Use
 	return nil, domain.ErrUserNotFound
 }
 
+func (r *stubAuthRepo) List(_ context.Context, filter ports.ListUsersFilter) ([]*domain.User, int64, error) {
+	var users []*domain.User
+	for _, u := range r.users {
+		if (filter.Role == "" || u.Role == filter.Role) && (filter.ClientID == "" || u.ClientID == filter.ClientID) {
+			users = append(users, cloneUser(u))
+		}
+	}
+	return users, int64(len(users)), nil
+}
+
+func (r *stubAuthRepo) Update(_ context.Context, user *domain.User) error {
+	for name, u := range r.users {
+		if u.ID == user.ID {
+			r.users[name] = cloneUser(user)
+			return nil
+		}
+	}
+	return domain.ErrUserNotFound
+}
+
+func (r *stubAuthRepo) Delete(_ context.Context, id string) error {
+	for name, u := range r.users {
+		if u.ID == id {
+			delete(r.users, name)
+			return nil
+		}
+	}
+	return domain.ErrUserNotFound
+}
+
+// stubRefreshTokenRepo keeps refresh tokens in memory by hash.
+type stubRefreshTokenRepo struct {
+	byHash map[string]*domain.RefreshToken
+}
+
+func (r *stubRefreshTokenRepo) Create(_ context.Context, token *domain.RefreshToken) error {
+	clone := *token
+	r.byHash[token.TokenHash] = &clone
+	return nil
+}
+
+func (r *stubRefreshTokenRepo) FindByHash(_ context.Context, tokenHash string) (*domain.RefreshToken, error) {
+	token, ok := r.byHash[tokenHash]
+	if !ok {
+		return nil, domain.ErrInvalidRefreshToken
+	}
+	clone := *token
+	return &clone, nil
+}
+
+func (r *stubRefreshTokenRepo) Rotate(ctx context.Context, id string, at time.Time, next *domain.RefreshToken) error {
+	for _, token := range r.byHash {
+		if token.ID == id {
+			if !token.RotatedAt.IsZero() || !token.RevokedAt.IsZero() {
+				return domain.ErrRefreshTokenReused
+			}
+			token.RotatedAt = at
+			return r.Create(ctx, next)
+		}
+	}
+	return domain.ErrInvalidRefreshToken
+}
+
+func (r *stubRefreshTokenRepo) RevokeFamily(_ context.Context, familyID string, at time.Time) error {
+	for _, token := range r.byHash {
+		if token.FamilyID == familyID && token.RevokedAt.IsZero() {
+			token.RevokedAt = at
+		}
+	}
+	return nil
+}
+
+func (r *stubRefreshTokenRepo) RevokeUser(_ context.Context, userID string, at time.Time) error {
+	for _, token := range r.byHash {
+		if token.UserID == userID && token.RevokedAt.IsZero() {
+			token.RevokedAt = at
+		}
+	}
+	return nil
+}
+
+// stubInvitationRepo keeps invitations in memory by hash.
+type stubInvitationRepo struct {
+	byHash map[string]*domain.Invitation
+}
+
+func newStubInvitationRepo() *stubInvitationRepo {
+	return &stubInvitationRepo{byHash: make(map[string]*domain.Invitation)}
+}
+
+func (r *stubInvitationRepo) Create(_ context.Context, invitation *domain.Invitation) error {
+	clone := *invitation
+	r.byHash[invitation.TokenHash] = &clone
+	return nil
+}
+
+func (r *stubInvitationRepo) FindByHash(_ context.Context, tokenHash string) (*domain.Invitation, error) {
+	invitation, ok := r.byHash[tokenHash]
+	if !ok {
+		return nil, domain.ErrInvalidInvitation
+	}
+	clone := *invitation
+	return &clone, nil
+}
+
+func (r *stubInvitationRepo) Accept(_ context.Context, id string, at time.Time) error {
+	for _, invitation := range r.byHash {
+		if invitation.ID == id && invitation.AcceptedAt.IsZero() {
+			invitation.AcceptedAt = at
+			return nil
+		}
+	}
+	return domain.ErrInvalidInvitation
+}
+
+func (r *stubInvitationRepo) Release(_ context.Context, id string) error {
+	for _, invitation := range r.byHash {
+		if invitation.ID == id {
+			invitation.AcceptedAt = time.Time{}
+		}
+	}
+	return nil
+}
+
+type stubDenylist map[string]time.Time
+
+func (d stubDenylist) Revoke(_ context.Context, jti string, until time.Time) error {
+	d[jti] = until
+	return nil
+}
+
+func (d stubDenylist) IsRevoked(_ context.Context, jti string) (bool, error) {
+	_, ok := d[jti]
+	return ok, nil
+}
+
+func newTestAuthService(repo *stubAuthRepo) *AuthService {
+	tokens := &stubRefreshTokenRepo{byHash: make(map[string]*domain.RefreshToken)}
+	return NewAuthService(repo, tokens, newStubInvitationRepo(), stubDenylist{}, jwtkeys.NewHMAC("secret"), time.Hour, 24*time.Hour)
+}
+
+// invite stores an invitation of svc for email and returns its token.
+func invite(t *testing.T, svc *AuthService, email, role, clientID string) string {
+	t.Helper()
+	token := newID("tok_")
+	now := time.Now().UTC()
+	err := svc.invitations.Create(context.Background(), &domain.Invitation{
+		ID:        newID("inv_"),
+		TokenHash: hashToken(token),
+		Email:     email,
+		Role:      role,
+		ClientID:  clientID,
+		CreatedAt: now,
+		ExpiresAt: now.Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+// addUser stores a user the way admins create them.
+func addUser(t *testing.T, repo *stubAuthRepo, username, password, role, clientID string) {
+	t.Helper()
+	_, err := createUser(context.Background(), repo, ports.CreateUserInput{
+		Username: username,
+		Password: password,
+		Email:    username + "@example.com",
+		Role:     role,
+		ClientID: clientID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestAuthService_Register_Success(t *testing.T) {
 	repo := newStubAuthRepo()
-	svc := NewAuthService(repo, "secret", time.Hour)
+	svc := newTestAuthService(repo)
 
-	user, err := svc.Register(context.Background(), "alice", "pass123", "alice@example.com", domain.RoleClient, "client_1")
+	token := invite(t, svc, "alice@example.com", domain.RoleClient, "client_1")
+
+	user, err := svc.Register(context.Background(), ports.RegisterInput{
+		Username:        "alice",
+		Password:        "pass123",
+		Email:           "Alice@example.com",
+		InvitationToken: token,
+	})
 	if err != nil {
 		t.Fatalf("Register returned error: %v", err)
 	}
@@ -65,55 +260,81 @@ func TestAuthService_Register_Success(t *testing.T) {
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte("pass123")); err != nil {
 		t.Fatalf("stored hash does not match password: %v", err)
 	}
-	if user.Role != domain.RoleClient {
-		t.Fatalf("unexpected role: %s", user.Role)
+	if user.Role != domain.RoleClient || user.ClientID != "client_1" {
+		t.Fatalf("expected the role and client of the invitation, got %s %s", user.Role, user.ClientID)
+	}
+
+	// An invitation registers one user.
+	_, err = svc.Register(context.Background(), ports.RegisterInput{
+		Username:        "alice2",
+		Password:        "pass123",
+		Email:           "alice@example.com",
+		InvitationToken: token,
+	})
+	if err != domain.ErrInvalidInvitation {
+		t.Fatalf("expected a used invitation to be rejected, got %v", err)
 	}
 }
 
 func TestAuthService_Register_Validation(t *testing.T) {
 	repo := newStubAuthRepo()
-	svc := NewAuthService(repo, "secret", time.Hour)
+	svc := newTestAuthService(repo)
 
-	if _, err := svc.Register(context.Background(), "", "pass", "", domain.RoleClient, ""); err != domain.ErrInvalidCredentials {
+	if _, err := svc.Register(context.Background(), ports.RegisterInput{Password: "pass"}); err != domain.ErrInvalidCredentials {
 		t.Fatalf("expected ErrInvalidCredentials, got %v", err)
 	}
 
-	if _, err := svc.Register(context.Background(), "bob", "pass", "bob@example.com", "wrong", ""); err != domain.ErrInvalidCredentials {
-		t.Fatalf("expected ErrInvalidCredentials for bad role, got %v", err)
+	// Without an invitation, or with one for another email, nobody registers.
+	input := ports.RegisterInput{Username: "bob", Password: "pass", Email: "bob@example.com"}
+	if _, err := svc.Register(context.Background(), input); err != domain.ErrInvalidInvitation {
+		t.Fatalf("expected ErrInvalidInvitation without an invitation, got %v", err)
+	}
+	input.InvitationToken = invite(t, svc, "carl@example.com", domain.RoleClient, "client_1")
+	if _, err := svc.Register(context.Background(), input); err != domain.ErrInvalidInvitation {
+		t.Fatalf("expected ErrInvalidInvitation for another email, got %v", err)
+	}
+	if len(repo.users) != 0 {
+		t.Fatalf("expected no user to be created, got %v", repo.users)
 	}
 }
 
 func TestAuthService_Register_Duplicate(t *testing.T) {
 	repo := newStubAuthRepo()
-	svc := NewAuthService(repo, "secret", time.Hour)
+	svc := newTestAuthService(repo)
 
-	_, _ = svc.Register(context.Background(), "bob", "pass", "bob@example.com", domain.RoleClient, "")
-	if _, err := svc.Register(context.Background(), "bob", "pass2", "bob@example.com", domain.RoleClient, ""); err != domain.ErrUserExists {
+	addUser(t, repo, "bob", "pass", domain.RoleClient, "client_1")
+	token := invite(t, svc, "bob2@example.com", domain.RoleClient, "client_1")
+	input := ports.RegisterInput{Username: "bob", Password: "pass2", Email: "bob2@example.com", InvitationToken: token}
+	if _, err := svc.Register(context.Background(), input); err != domain.ErrUserExists {
 		t.Fatalf("expected ErrUserExists, got %v", err)
 	}
+
+	// The invitation is released for another try.
+	input.Username = "bob2"
+	if _, err := svc.Register(context.Background(), input); err != nil {
+		t.Fatalf("expected the invitation to be usable again, got %v", err)
+	}
 }
 
 func TestAuthService_Login_Success(t *testing.T) {
 	repo := newStubAuthRepo()
-	svc := NewAuthService(repo, "secret", time.Hour)
+	svc := newTestAuthService(repo)
 
-	if _, err := svc.Register(context.Background(), "carol", "s3cret", "carol@example.com", domain.RoleAdmin, ""); err != nil {
-		t.Fatalf("register failed: %v", err)
-	}
+	addUser(t, repo, "carol", "s3cret", domain.RoleAdmin, "")
 
-	token, user, err := svc.Login(context.Background(), "carol@example.com", "s3cret")
+	tokens, user, err := svc.Login(context.Background(), "carol@example.com", "s3cret")
 	if err != nil {
 		t.Fatalf("login failed: %v", err)
 	}
-	if token == "" {
-		t.Fatalf("expected token, got empty")
+	if tokens == nil || tokens.AccessToken == "" || tokens.RefreshToken == "" || tokens.ExpiresIn != time.Hour {
+		t.Fatalf("unexpected tokens: %+v", tokens)
 	}
 	if user == nil || user.Username != "carol" {
 		t.Fatalf("unexpected user: %+v", user)
 	}
 
 	claims := jwt.MapClaims{}
-	parsed, err := jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (interface{}, error) {
+	parsed, err := jwt.ParseWithClaims(tokens.AccessToken, claims, func(token *jwt.Token) (interface{}, error) {
 		return []byte("secret"), nil
 	})
 	if err != nil || !parsed.Valid {
@@ -122,13 +343,51 @@ func TestAuthService_Login_Success(t *testing.T) {
 	if claims["role"] != domain.RoleAdmin {
 		t.Fatalf("expected role %s, got %v", domain.RoleAdmin, claims["role"])
 	}
+	if claims["sub"] != "carol" || claims["jti"] == "" {
+		t.Fatalf("expected sub and jti claims, got %v", claims)
+	}
+	if scope, _ := claims["scope"].(string); !strings.Contains(scope, domain.ScopeUsersManage) {
+		t.Fatalf("expected the scopes of the admin role, got %q", scope)
+	}
+}
+
+func TestAuthService_Register_ExpiredInvitation(t *testing.T) {
+	svc := newTestAuthService(newStubAuthRepo())
+	token := invite(t, svc, "dave@example.com", domain.RoleCarrier, "carrier_1")
+	for _, invitation := range svc.invitations.(*stubInvitationRepo).byHash {
+		invitation.ExpiresAt = time.Now().Add(-time.Minute)
+	}
+
+	input := ports.RegisterInput{Username: "dave", Password: "s3cret", Email: "dave@example.com", InvitationToken: token}
+	if _, err := svc.Register(context.Background(), input); err != domain.ErrInvalidInvitation {
+		t.Fatalf("expected an expired invitation to be rejected, got %v", err)
+	}
+}
+
+func TestAuthService_Login_Disabled(t *testing.T) {
+	repo := newStubAuthRepo()
+	svc := newTestAuthService(repo)
+	addUser(t, repo, "ivan", "s3cret", domain.RoleClient, "client_1")
+	login, _, _ := svc.Login(context.Background(), "ivan@example.com", "s3cret")
+	repo.users["ivan"].DisabledAt = time.Now()
+
+	if _, _, err := svc.Login(context.Background(), "ivan@example.com", "s3cret"); err != domain.ErrUserDisabled {
+		t.Fatalf("expected ErrUserDisabled, got %v", err)
+	}
+	// A wrong password does not reveal the account is disabled.
+	if _, _, err := svc.Login(context.Background(), "ivan@example.com", "wrong"); err != domain.ErrInvalidCredentials {
+		t.Fatalf("expected ErrInvalidCredentials, got %v", err)
+	}
+	if _, _, err := svc.Refresh(context.Background(), login.RefreshToken); err != domain.ErrInvalidRefreshToken {
+		t.Fatalf("expected a disabled user not to refresh, got %v", err)
+	}
 }
 
 func TestAuthService_Login_InvalidPassword(t *testing.T) {
 	repo := newStubAuthRepo()
-	svc := NewAuthService(repo, "secret", time.Hour)
+	svc := newTestAuthService(repo)
 
-	_, _ = svc.Register(context.Background(), "dave", "goodpass", "dave@example.com", domain.RoleClient, "")
+	addUser(t, repo, "dave", "goodpass", domain.RoleClient, "client_1")
 	if _, _, err := svc.Login(context.Background(), "dave@example.com", "badpass"); err != domain.ErrInvalidCredentials {
 		t.Fatalf("expected ErrInvalidCredentials, got %v", err)
 	}
@@ -136,9 +395,89 @@ func TestAuthService_Login_InvalidPassword(t *testing.T) {
 
 func TestAuthService_Login_UserNotFound(t *testing.T) {
 	repo := newStubAuthRepo()
-	svc := NewAuthService(repo, "secret", time.Hour)
+	svc := newTestAuthService(repo)
 
 	if _, _, err := svc.Login(context.Background(), "ghost@example.com", "pass"); err != domain.ErrUserNotFound {
 		t.Fatalf("expected ErrUserNotFound, got %v", err)
 	}
 }
+
+func TestAuthService_Refresh_Rotates(t *testing.T) {
+	repo := newStubAuthRepo()
+	svc := newTestAuthService(repo)
+	addUser(t, repo, "erin", "pass", domain.RoleClient, "client_1")
+	login, _, _ := svc.Login(context.Background(), "erin@example.com", "pass")
+
+	// A change of client is picked up by the next access token.
+	repo.users["erin"].ClientID = "client_2"
+
+	refreshed, user, err := svc.Refresh(context.Background(), login.RefreshToken)
+	if err != nil {
+		t.Fatalf("refresh failed: %v", err)
+	}
+	if user.ClientID != "client_2" || refreshed.RefreshToken == login.RefreshToken || refreshed.AccessToken == login.AccessToken {
+		t.Fatalf("expected new tokens for the current user, got %+v for %+v", refreshed, user)
+	}
+	claims := jwt.MapClaims{}
+	if _, err := jwt.ParseWithClaims(refreshed.AccessToken, claims, func(*jwt.Token) (interface{}, error) {
+		return []byte("secret"), nil
+	}); err != nil || claims["client_id"] != "client_2" {
+		t.Fatalf("unexpected access token: %v %v", claims, err)
+	}
+
+	if _, _, err := svc.Refresh(context.Background(), "unknown"); err != domain.ErrInvalidRefreshToken {
+		t.Fatalf("expected ErrInvalidRefreshToken, got %v", err)
+	}
+}
+
+func TestAuthService_Refresh_ReuseRevokesFamily(t *testing.T) {
+	repo := newStubAuthRepo()
+	svc := newTestAuthService(repo)
+	addUser(t, repo, "frank", "pass", domain.RoleClient, "client_1")
+	login, _, _ := svc.Login(context.Background(), "frank@example.com", "pass")
+	other, _, _ := svc.Login(context.Background(), "frank@example.com", "pass")
+
+	refreshed, _, err := svc.Refresh(context.Background(), login.RefreshToken)
+	if err != nil {
+		t.Fatalf("refresh failed: %v", err)
+	}
+
+	// The spent token is presented again: the thief and the owner are both logged out.
+	if _, _, err := svc.Refresh(context.Background(), login.RefreshToken); err != domain.ErrRefreshTokenReused {
+		t.Fatalf("expected ErrRefreshTokenReused, got %v", err)
+	}
+	if _, _, err := svc.Refresh(context.Background(), refreshed.RefreshToken); err != domain.ErrInvalidRefreshToken {
+		t.Fatalf("expected the family to be revoked, got %v", err)
+	}
+
+	// Other logins are not affected.
+	if _, _, err := svc.Refresh(context.Background(), other.RefreshToken); err != nil {
+		t.Fatalf("expected the other login to refresh, got %v", err)
+	}
+}
+
+func TestAuthService_Logout(t *testing.T) {
+	repo := newStubAuthRepo()
+	tokens := &stubRefreshTokenRepo{byHash: make(map[string]*domain.RefreshToken)}
+	denylist := stubDenylist{}
+	svc := NewAuthService(repo, tokens, newStubInvitationRepo(), denylist, jwtkeys.NewHMAC("secret"), time.Hour, 24*time.Hour)
+	addUser(t, repo, "gina", "pass", domain.RoleClient, "client_1")
+	login, _, _ := svc.Login(context.Background(), "gina@example.com", "pass")
+
+	expiresAt := time.Now().Add(time.Hour)
+	err := svc.Logout(context.Background(), ports.LogoutInput{
+		UserID:         "gina",
+		JTI:            "jti_1",
+		TokenExpiresAt: expiresAt,
+		RefreshToken:   login.RefreshToken,
+	})
+	if err != nil {
+		t.Fatalf("logout failed: %v", err)
+	}
+	if !denylist["jti_1"].Equal(expiresAt) {
+		t.Fatalf("expected the access token to be denied until it expires, got %v", denylist)
+	}
+	if _, _, err := svc.Refresh(context.Background(), login.RefreshToken); err != domain.ErrInvalidRefreshToken {
+		t.Fatalf("expected the refresh token to be revoked, got %v", err)
+	}
+}
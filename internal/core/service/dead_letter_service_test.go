@@ -0,0 +1,249 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/99minutos/shipping-system/internal/core/domain"
+	"github.com/99minutos/shipping-system/internal/core/ports"
+)
+
+// ---------------------------------------------------------------------------
+// Stubs
+// ---------------------------------------------------------------------------
+
+type stubDeadLetterRepo struct {
+	byID    map[string]*domain.DeadLetterEvent
+	nextID  int
+	saveErr error
+}
+
+func newStubDeadLetterRepo() *stubDeadLetterRepo {
+	return &stubDeadLetterRepo{byID: make(map[string]*domain.DeadLetterEvent)}
+}
+
+func (r *stubDeadLetterRepo) Save(_ context.Context, dl *domain.DeadLetterEvent) error {
+	if r.saveErr != nil {
+		return r.saveErr
+	}
+	r.nextID++
+	dl.ID = fmt.Sprintf("dl-%d", r.nextID)
+	clone := *dl
+	r.byID[dl.ID] = &clone
+	return nil
+}
+
+func (r *stubDeadLetterRepo) FindByID(_ context.Context, id string) (*domain.DeadLetterEvent, error) {
+	dl, ok := r.byID[id]
+	if !ok {
+		return nil, domain.ErrDeadLetterNotFound
+	}
+	clone := *dl
+	return &clone, nil
+}
+
+func (r *stubDeadLetterRepo) List(_ context.Context, f ports.ListDeadLettersFilter) ([]*domain.DeadLetterEvent, int64, error) {
+	var out []*domain.DeadLetterEvent
+	for _, dl := range r.byID {
+		if f.TrackingNumber != "" && dl.Event.TrackingNumber != f.TrackingNumber {
+			continue
+		}
+		clone := *dl
+		out = append(out, &clone)
+	}
+	return out, int64(len(out)), nil
+}
+
+func (r *stubDeadLetterRepo) MarkFailed(_ context.Context, id, reason string, at time.Time) error {
+	dl, ok := r.byID[id]
+	if !ok {
+		return domain.ErrDeadLetterNotFound
+	}
+	dl.Attempts++
+	dl.Reason = reason
+	dl.LastFailedAt = at
+	return nil
+}
+
+func (r *stubDeadLetterRepo) Delete(_ context.Context, id string) error {
+	if _, ok := r.byID[id]; !ok {
+		return domain.ErrDeadLetterNotFound
+	}
+	delete(r.byID, id)
+	return nil
+}
+
+func (r *stubDeadLetterRepo) Purge(_ context.Context, f ports.PurgeDeadLettersFilter) (int64, error) {
+	var n int64
+	for id, dl := range r.byID {
+		if f.TrackingNumber != "" && dl.Event.TrackingNumber != f.TrackingNumber {
+			continue
+		}
+		if !f.Before.IsZero() && !dl.LastFailedAt.Before(f.Before) {
+			continue
+		}
+		delete(r.byID, id)
+		n++
+	}
+	return n, nil
+}
+
+type stubEventService struct {
+	err       error
+	conflicts int // calls failing with ErrConcurrentUpdate before err applies
+	processed []ports.TrackingEventInput
+}
+
+func (s *stubEventService) Process(_ context.Context, in ports.TrackingEventInput) error {
+	if s.conflicts > 0 {
+		s.conflicts--
+		return fmt.Errorf("process event: %w", domain.ErrConcurrentUpdate)
+	}
+	if s.err != nil {
+		return s.err
+	}
+	s.processed = append(s.processed, in)
+	return nil
+}
+
+func (s *stubEventService) Apply(ctx context.Context, in ports.TrackingEventInput) (domain.EventState, error) {
+	return domain.EventProcessed, s.Process(ctx, in)
+}
+
+func sampleEvent() ports.TrackingEventInput {
+	return ports.TrackingEventInput{
+		TrackingNumber: "99M-AABBCCDD",
+		Status:         "picked_up",
+		Timestamp:      time.Date(2026, 2, 19, 10, 0, 0, 0, time.UTC),
+		Source:         "driver_app",
+		Location:       &ports.LocationInput{Lat: 19.4326, Lng: -99.1332},
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Tests
+// ---------------------------------------------------------------------------
+
+func TestDeadLetterService_Record_StoresEventAndReason(t *testing.T) {
+	repo := newStubDeadLetterRepo()
+	svc := NewDeadLetterService(repo, &stubEventService{}, nil, zerolog.Nop())
+
+	err := svc.Record(context.Background(), sampleEvent(), domain.ErrShipmentNotFound, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(repo.byID) != 1 {
+		t.Fatalf("expected 1 dead letter, got %d", len(repo.byID))
+	}
+
+	item, err := svc.Get(context.Background(), "dl-1")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if item.Reason != domain.ErrShipmentNotFound.Error() {
+		t.Errorf("reason: want %q, got %q", domain.ErrShipmentNotFound.Error(), item.Reason)
+	}
+	if item.Attempts != 1 {
+		t.Errorf("attempts: want 1, got %d", item.Attempts)
+	}
+	if item.Event.Location == nil || item.Event.Location.Lat != 19.4326 {
+		t.Errorf("location not preserved: %+v", item.Event.Location)
+	}
+	if item.Event.Status != "picked_up" {
+		t.Errorf("status: want picked_up, got %q", item.Event.Status)
+	}
+}
+
+func TestDeadLetterService_Record_RepoError(t *testing.T) {
+	repo := newStubDeadLetterRepo()
+	repo.saveErr = errors.New("mongo unavailable")
+	svc := NewDeadLetterService(repo, &stubEventService{}, nil, zerolog.Nop())
+
+	if err := svc.Record(context.Background(), sampleEvent(), errors.New("boom"), 1); err == nil {
+		t.Fatal("expected error when repo fails")
+	}
+}
+
+func TestDeadLetterService_Replay_SuccessRemovesEntry(t *testing.T) {
+	repo := newStubDeadLetterRepo()
+	events := &stubEventService{}
+	svc := NewDeadLetterService(repo, events, nil, zerolog.Nop())
+	_ = svc.Record(context.Background(), sampleEvent(), errors.New("timeout"), 1)
+
+	if err := svc.Replay(context.Background(), "dl-1"); err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	if len(events.processed) != 1 || events.processed[0].TrackingNumber != "99M-AABBCCDD" {
+		t.Errorf("expected event re-processed, got %+v", events.processed)
+	}
+	if len(repo.byID) != 0 {
+		t.Errorf("expected entry removed after successful replay")
+	}
+}
+
+func TestDeadLetterService_Replay_FailureKeepsEntry(t *testing.T) {
+	repo := newStubDeadLetterRepo()
+	events := &stubEventService{err: domain.ErrInvalidTransition}
+	svc := NewDeadLetterService(repo, events, nil, zerolog.Nop())
+	_ = svc.Record(context.Background(), sampleEvent(), errors.New("timeout"), 1)
+
+	err := svc.Replay(context.Background(), "dl-1")
+	if !errors.Is(err, domain.ErrInvalidTransition) {
+		t.Fatalf("expected ErrInvalidTransition, got %v", err)
+	}
+	dl := repo.byID["dl-1"]
+	if dl == nil {
+		t.Fatal("expected entry kept after failed replay")
+	}
+	if dl.Attempts != 2 {
+		t.Errorf("attempts: want 2, got %d", dl.Attempts)
+	}
+	if dl.Reason != domain.ErrInvalidTransition.Error() {
+		t.Errorf("reason not updated: %q", dl.Reason)
+	}
+}
+
+func TestDeadLetterService_Replay_NotFound(t *testing.T) {
+	svc := NewDeadLetterService(newStubDeadLetterRepo(), &stubEventService{}, nil, zerolog.Nop())
+
+	if err := svc.Replay(context.Background(), "missing"); !errors.Is(err, domain.ErrDeadLetterNotFound) {
+		t.Errorf("expected ErrDeadLetterNotFound, got %v", err)
+	}
+}
+
+func TestDeadLetterService_Purge_ByTrackingNumber(t *testing.T) {
+	repo := newStubDeadLetterRepo()
+	svc := NewDeadLetterService(repo, &stubEventService{}, nil, zerolog.Nop())
+
+	other := sampleEvent()
+	other.TrackingNumber = "99M-11223344"
+	_ = svc.Record(context.Background(), sampleEvent(), errors.New("x"), 1)
+	_ = svc.Record(context.Background(), other, errors.New("x"), 1)
+
+	n, err := svc.Purge(context.Background(), ports.PurgeDeadLettersInput{TrackingNumber: "99M-AABBCCDD"})
+	if err != nil {
+		t.Fatalf("purge: %v", err)
+	}
+	if n != 1 || len(repo.byID) != 1 {
+		t.Errorf("expected 1 purged and 1 left, got purged=%d left=%d", n, len(repo.byID))
+	}
+}
+
+func TestDeadLetterService_List_DefaultPagination(t *testing.T) {
+	repo := newStubDeadLetterRepo()
+	svc := NewDeadLetterService(repo, &stubEventService{}, nil, zerolog.Nop())
+	_ = svc.Record(context.Background(), sampleEvent(), errors.New("x"), 1)
+
+	res, err := svc.List(context.Background(), ports.ListDeadLettersInput{})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if res.Limit != 20 || res.Page != 1 || res.Total != 1 || res.TotalPages != 1 {
+		t.Errorf("unexpected pagination: %+v", res)
+	}
+}
@@ -0,0 +1,230 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/99minutos/shipping-system/internal/core/domain"
+	"github.com/99minutos/shipping-system/internal/core/ports"
+)
+
+const (
+	eventIDPrefix = "evt_"
+	batchIDPrefix = "bat_"
+
+	// applyConflictAttempts bounds how often an inline event whose write
+	// lost a race with a concurrent one is checked again.
+	applyConflictAttempts = 3
+)
+
+// EventIngestService implements ports.EventIngestService.
+type EventIngestService struct {
+	queue    ports.EventQueue
+	events   ports.EventService
+	receipts ports.EventReceiptRepository
+	logger   zerolog.Logger
+}
+
+func NewEventIngestService(
+	queue ports.EventQueue,
+	events ports.EventService,
+	receipts ports.EventReceiptRepository,
+	logger zerolog.Logger,
+) *EventIngestService {
+	return &EventIngestService{queue: queue, events: events, receipts: receipts, logger: logger}
+}
+
+// Ingest assigns IDs, enqueues the events and records them as queued.
+// Queue errors are returned unchanged so the caller can tell a saturated
+// queue from an unavailable one. Receipts are written after enqueueing, so a
+// failure to write them is logged but does not reject events that are
+// already queued. When the queue stops partway, the events queued before the
+// failure still get their receipts, since they will be processed.
+func (s *EventIngestService) Ingest(ctx context.Context, input ports.IngestEventsInput) (*ports.IngestEventsResult, error) {
+	result := &ports.IngestEventsResult{EventIDs: make([]string, len(input.Events))}
+	if input.Batch {
+		result.BatchID = newID(batchIDPrefix)
+	}
+
+	events := make([]ports.TrackingEventInput, len(input.Events))
+	for i, e := range input.Events {
+		e.ID = newID(eventIDPrefix)
+		events[i] = e
+		result.EventIDs[i] = e.ID
+	}
+
+	var err error
+	queued := len(events)
+	if input.Batch {
+		err = s.queue.EnqueueBatch(ctx, events)
+		var batchErr *ports.EnqueueBatchError
+		if errors.As(err, &batchErr) {
+			queued = batchErr.Index
+		} else if err != nil {
+			queued = 0
+		}
+	} else {
+		for i, e := range events {
+			if err = s.queue.Enqueue(ctx, e); err != nil {
+				queued = i
+				break
+			}
+		}
+	}
+	s.recordQueued(ctx, input.ClientID, result.BatchID, events[:queued])
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// recordQueued writes the receipts of queued events.
+func (s *EventIngestService) recordQueued(ctx context.Context, clientID, batchID string, events []ports.TrackingEventInput) {
+	if len(events) == 0 {
+		return
+	}
+	now := time.Now().UTC()
+	receipts := make([]domain.EventReceipt, len(events))
+	for i, e := range events {
+		receipts[i] = domain.EventReceipt{
+			ID:             e.ID,
+			BatchID:        batchID,
+			TrackingNumber: e.TrackingNumber,
+			Status:         domain.ShipmentStatus(e.Status),
+			ClientID:       clientID,
+			State:          domain.EventQueued,
+			AcceptedAt:     now,
+			UpdatedAt:      now,
+		}
+	}
+	if err := s.receipts.Create(ctx, batchID, receipts); err != nil {
+		s.logger.Error().Err(err).
+			Str("batch_id", batchID).
+			Int("count", len(receipts)).
+			Msg("failed to record event receipts")
+	}
+}
+
+// Apply runs the events through EventService one at a time in timestamp
+// order, falling back to request order for equal timestamps, so scans of the
+// same shipment sent out of order still apply. Results are returned in
+// request order. An event whose write lost a race with a queued event or a
+// cancellation of the same shipment is checked again against the new status;
+// nothing else is retried, the caller resends what failed. Inline events get
+// no ID or receipt since their outcome is returned directly.
+func (s *EventIngestService) Apply(ctx context.Context, events []ports.TrackingEventInput) []ports.AppliedEvent {
+	order := make([]int, len(events))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return events[order[a]].Timestamp.Before(events[order[b]].Timestamp)
+	})
+
+	results := make([]ports.AppliedEvent, len(events))
+	for _, i := range order {
+		e := events[i]
+		e.ID = ""
+		var state domain.EventState
+		var err error
+		for attempt := 1; ; attempt++ {
+			state, err = s.events.Apply(ctx, e)
+			if !errors.Is(err, domain.ErrConcurrentUpdate) || attempt == applyConflictAttempts {
+				break
+			}
+		}
+		results[i] = ports.AppliedEvent{State: state, Err: err}
+	}
+	return results
+}
+
+// GetEvent returns the ingestion state of one event. Clients only see events
+// submitted under their own client ID; anything else is reported as not found.
+func (s *EventIngestService) GetEvent(ctx context.Context, input ports.GetEventStatusInput) (*ports.EventStatusItem, error) {
+	r, err := s.receipts.FindByID(ctx, input.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !canSeeReceipt(input, r) {
+		return nil, domain.ErrEventNotFound
+	}
+	item := toEventStatusItem(r)
+	return &item, nil
+}
+
+// GetBatch returns the ingestion state of every event in a batch, with a
+// count per state.
+func (s *EventIngestService) GetBatch(ctx context.Context, input ports.GetEventStatusInput) (*ports.EventBatchStatus, error) {
+	receipts, err := s.receipts.FindBatch(ctx, input.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(receipts) == 0 {
+		return nil, domain.ErrEventBatchNotFound
+	}
+
+	result := &ports.EventBatchStatus{
+		BatchID: input.ID,
+		Items:   make([]ports.EventStatusItem, len(receipts)),
+		Counts:  make(map[string]int),
+	}
+	for i := range receipts {
+		if !canSeeReceipt(input, &receipts[i]) {
+			return nil, domain.ErrEventBatchNotFound
+		}
+		result.Items[i] = toEventStatusItem(&receipts[i])
+		result.Counts[string(receipts[i].State)]++
+	}
+	return result, nil
+}
+
+func canSeeReceipt(input ports.GetEventStatusInput, r *domain.EventReceipt) bool {
+	return input.Role == domain.RoleAdmin || (input.ClientID != "" && input.ClientID == r.ClientID)
+}
+
+func toEventStatusItem(r *domain.EventReceipt) ports.EventStatusItem {
+	return ports.EventStatusItem{
+		ID:             r.ID,
+		BatchID:        r.BatchID,
+		TrackingNumber: r.TrackingNumber,
+		Status:         string(r.Status),
+		State:          string(r.State),
+		Reason:         r.Reason,
+		AcceptedAt:     r.AcceptedAt,
+		UpdatedAt:      r.UpdatedAt,
+	}
+}
+
+// markReceipt records the outcome of an event on its receipt. Receipts are
+// informational, so a failure is logged and never fails the event itself.
+func markReceipt(ctx context.Context, repo ports.EventReceiptRepository, logger zerolog.Logger, id string, state domain.EventState, reason string) {
+	if repo == nil || id == "" {
+		return
+	}
+	if err := repo.SetState(ctx, id, state, reason, time.Now().UTC()); err != nil {
+		logger.Warn().Err(err).Str("event_id", id).Str("state", string(state)).Msg("failed to update event receipt")
+	}
+}
+
+// outcomeOf maps a processing error to the state of an event that will not be
+// retried any further.
+func outcomeOf(err error) domain.EventState {
+	if errors.Is(err, domain.ErrInvalidTransition) || errors.Is(err, domain.ErrShipmentNotFound) ||
+		errors.Is(err, domain.ErrPieceNotFound) {
+		return domain.EventRejected
+	}
+	return domain.EventFailed
+}
+
+// newID returns prefix followed by 24 random hex characters.
+func newID(prefix string) string {
+	b := make([]byte, 12)
+	_, _ = rand.Read(b) // never fails since Go 1.24
+	return prefix + hex.EncodeToString(b)
+}
@@ -0,0 +1,278 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/99minutos/shipping-system/internal/core/domain"
+	"github.com/99minutos/shipping-system/internal/core/ports"
+)
+
+// ---------------------------------------------------------------------------
+// Stubs
+// ---------------------------------------------------------------------------
+
+type stubEventQueue struct {
+	err      error
+	capacity int // when positive, events beyond it are rejected as full
+	queued   []ports.TrackingEventInput
+}
+
+func (q *stubEventQueue) Enqueue(_ context.Context, e ports.TrackingEventInput) error {
+	if q.err != nil {
+		return q.err
+	}
+	if q.capacity > 0 && len(q.queued) >= q.capacity {
+		return domain.ErrEventQueueFull
+	}
+	q.queued = append(q.queued, e)
+	return nil
+}
+
+func (q *stubEventQueue) EnqueueBatch(ctx context.Context, events []ports.TrackingEventInput) error {
+	for i, e := range events {
+		if err := q.Enqueue(ctx, e); err != nil {
+			return &ports.EnqueueBatchError{Index: i, Err: err}
+		}
+	}
+	return nil
+}
+
+type stubReceiptRepo struct {
+	byID    map[string]domain.EventReceipt
+	batches map[string][]string
+}
+
+func newStubReceiptRepo() *stubReceiptRepo {
+	return &stubReceiptRepo{byID: make(map[string]domain.EventReceipt), batches: make(map[string][]string)}
+}
+
+func (r *stubReceiptRepo) Create(_ context.Context, batchID string, receipts []domain.EventReceipt) error {
+	for _, rc := range receipts {
+		r.byID[rc.ID] = rc
+		if batchID != "" {
+			r.batches[batchID] = append(r.batches[batchID], rc.ID)
+		}
+	}
+	return nil
+}
+
+func (r *stubReceiptRepo) SetState(_ context.Context, id string, state domain.EventState, reason string, at time.Time) error {
+	rc := r.byID[id]
+	if state == domain.EventDuplicate && rc.State == domain.EventProcessed {
+		return nil
+	}
+	rc.ID, rc.State, rc.Reason, rc.UpdatedAt = id, state, reason, at
+	r.byID[id] = rc
+	return nil
+}
+
+func (r *stubReceiptRepo) FindByID(_ context.Context, id string) (*domain.EventReceipt, error) {
+	rc, ok := r.byID[id]
+	if !ok {
+		return nil, domain.ErrEventNotFound
+	}
+	return &rc, nil
+}
+
+func (r *stubReceiptRepo) FindBatch(_ context.Context, batchID string) ([]domain.EventReceipt, error) {
+	var out []domain.EventReceipt
+	for _, id := range r.batches[batchID] {
+		out = append(out, r.byID[id])
+	}
+	return out, nil
+}
+
+// ---------------------------------------------------------------------------
+// Tests
+// ---------------------------------------------------------------------------
+
+func TestEventIngestService_Ingest_AssignsIDsAndRecordsReceipts(t *testing.T) {
+	queue := &stubEventQueue{}
+	receipts := newStubReceiptRepo()
+	svc := NewEventIngestService(queue, &stubEventService{}, receipts, zerolog.Nop())
+
+	result, err := svc.Ingest(context.Background(), ports.IngestEventsInput{
+		Events:   []ports.TrackingEventInput{sampleEvent(), sampleEvent()},
+		Batch:    true,
+		ClientID: "client_1",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(result.BatchID, batchIDPrefix) {
+		t.Errorf("unexpected batch id %q", result.BatchID)
+	}
+	if len(result.EventIDs) != 2 || result.EventIDs[0] == result.EventIDs[1] {
+		t.Fatalf("expected 2 distinct event ids, got %v", result.EventIDs)
+	}
+	for i, id := range result.EventIDs {
+		if queue.queued[i].ID != id {
+			t.Errorf("event %d queued with id %q, want %q", i, queue.queued[i].ID, id)
+		}
+		rc := receipts.byID[id]
+		if rc.State != domain.EventQueued || rc.ClientID != "client_1" || rc.BatchID != result.BatchID {
+			t.Errorf("unexpected receipt: %+v", rc)
+		}
+	}
+}
+
+func TestEventIngestService_Ingest_QueueErrorSkipsReceipts(t *testing.T) {
+	receipts := newStubReceiptRepo()
+	svc := NewEventIngestService(&stubEventQueue{err: domain.ErrEventQueueFull}, &stubEventService{}, receipts, zerolog.Nop())
+
+	_, err := svc.Ingest(context.Background(), ports.IngestEventsInput{
+		Events: []ports.TrackingEventInput{sampleEvent()},
+	})
+	if !errors.Is(err, domain.ErrEventQueueFull) {
+		t.Fatalf("expected ErrEventQueueFull, got %v", err)
+	}
+	if len(receipts.byID) != 0 {
+		t.Errorf("expected no receipts, got %d", len(receipts.byID))
+	}
+}
+
+func TestEventIngestService_Ingest_PartialBatchRecordsQueued(t *testing.T) {
+	queue := &stubEventQueue{capacity: 2}
+	receipts := newStubReceiptRepo()
+	svc := NewEventIngestService(queue, &stubEventService{}, receipts, zerolog.Nop())
+
+	_, err := svc.Ingest(context.Background(), ports.IngestEventsInput{
+		Events: []ports.TrackingEventInput{sampleEvent(), sampleEvent(), sampleEvent()},
+		Batch:  true,
+	})
+	if !errors.Is(err, domain.ErrEventQueueFull) {
+		t.Fatalf("expected ErrEventQueueFull, got %v", err)
+	}
+	// The two queued events will be processed, so their receipts exist.
+	for _, e := range queue.queued {
+		if rc, ok := receipts.byID[e.ID]; !ok || rc.State != domain.EventQueued {
+			t.Errorf("expected a queued receipt for %s, got %+v", e.ID, rc)
+		}
+	}
+	if len(receipts.byID) != 2 {
+		t.Errorf("expected 2 receipts, got %d", len(receipts.byID))
+	}
+}
+
+func TestEventIngestService_GetEvent_ScopedToClient(t *testing.T) {
+	receipts := newStubReceiptRepo()
+	svc := NewEventIngestService(&stubEventQueue{}, &stubEventService{}, receipts, zerolog.Nop())
+	result, err := svc.Ingest(context.Background(), ports.IngestEventsInput{
+		Events:   []ports.TrackingEventInput{sampleEvent()},
+		ClientID: "client_1",
+	})
+	if err != nil {
+		t.Fatalf("ingest: %v", err)
+	}
+	id := result.EventIDs[0]
+
+	_, err = svc.GetEvent(context.Background(), ports.GetEventStatusInput{ID: id, Role: domain.RoleClient, ClientID: "client_2"})
+	if !errors.Is(err, domain.ErrEventNotFound) {
+		t.Errorf("other client: expected ErrEventNotFound, got %v", err)
+	}
+	if _, err := svc.GetEvent(context.Background(), ports.GetEventStatusInput{ID: id, Role: domain.RoleAdmin}); err != nil {
+		t.Errorf("admin: unexpected error %v", err)
+	}
+
+	markReceipt(context.Background(), receipts, zerolog.Nop(), id, domain.EventProcessed, "")
+	item, err := svc.GetEvent(context.Background(), ports.GetEventStatusInput{ID: id, Role: domain.RoleClient, ClientID: "client_1"})
+	if err != nil {
+		t.Fatalf("owner: unexpected error %v", err)
+	}
+	if item.State != string(domain.EventProcessed) {
+		t.Errorf("expected processed, got %q", item.State)
+	}
+}
+
+func TestEventIngestService_GetBatch_CountsStates(t *testing.T) {
+	receipts := newStubReceiptRepo()
+	svc := NewEventIngestService(&stubEventQueue{}, &stubEventService{}, receipts, zerolog.Nop())
+	result, err := svc.Ingest(context.Background(), ports.IngestEventsInput{
+		Events:   []ports.TrackingEventInput{sampleEvent(), sampleEvent()},
+		Batch:    true,
+		ClientID: "client_1",
+	})
+	if err != nil {
+		t.Fatalf("ingest: %v", err)
+	}
+	markReceipt(context.Background(), receipts, zerolog.Nop(), result.EventIDs[1], outcomeOf(domain.ErrInvalidTransition), "invalid")
+
+	batch, err := svc.GetBatch(context.Background(), ports.GetEventStatusInput{ID: result.BatchID, Role: domain.RoleClient, ClientID: "client_1"})
+	if err != nil {
+		t.Fatalf("get batch: %v", err)
+	}
+	if batch.Counts["queued"] != 1 || batch.Counts["rejected"] != 1 {
+		t.Errorf("unexpected counts: %v", batch.Counts)
+	}
+
+	_, err = svc.GetBatch(context.Background(), ports.GetEventStatusInput{ID: "bat_missing", Role: domain.RoleAdmin})
+	if !errors.Is(err, domain.ErrEventBatchNotFound) {
+		t.Errorf("expected ErrEventBatchNotFound, got %v", err)
+	}
+}
+
+func TestEventIngestService_Apply_ReportsEachEvent(t *testing.T) {
+	events := &stubEventService{}
+	svc := NewEventIngestService(&stubEventQueue{}, events, newStubReceiptRepo(), zerolog.Nop())
+	in := sampleEvent()
+	in.ID = "evt_client_supplied"
+
+	results := svc.Apply(context.Background(), []ports.TrackingEventInput{in, sampleEvent()})
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	for i, r := range results {
+		if r.Err != nil || r.State != domain.EventProcessed {
+			t.Errorf("result %d = %+v, want processed", i, r)
+		}
+	}
+	if events.processed[0].ID != "" {
+		t.Errorf("inline events must not carry an ingestion ID, got %q", events.processed[0].ID)
+	}
+
+	events.err = domain.ErrShipmentNotFound
+	results = svc.Apply(context.Background(), []ports.TrackingEventInput{sampleEvent(), sampleEvent()})
+	for i, r := range results {
+		if !errors.Is(r.Err, domain.ErrShipmentNotFound) {
+			t.Errorf("result %d: expected ErrShipmentNotFound, got %v", i, r.Err)
+		}
+	}
+}
+
+func TestEventIngestService_Apply_ChecksAgainAfterConcurrentWrite(t *testing.T) {
+	events := &stubEventService{conflicts: 1}
+	svc := NewEventIngestService(&stubEventQueue{}, events, newStubReceiptRepo(), zerolog.Nop())
+
+	results := svc.Apply(context.Background(), []ports.TrackingEventInput{sampleEvent()})
+	if results[0].Err != nil || len(events.processed) != 1 {
+		t.Errorf("expected the event applied on the second check, got %+v", results[0])
+	}
+
+	events.conflicts = applyConflictAttempts
+	results = svc.Apply(context.Background(), []ports.TrackingEventInput{sampleEvent()})
+	if !errors.Is(results[0].Err, domain.ErrConcurrentUpdate) {
+		t.Errorf("expected ErrConcurrentUpdate once the attempts run out, got %v", results[0].Err)
+	}
+}
+
+func TestEventIngestService_Apply_InTimestampOrder(t *testing.T) {
+	events := &stubEventService{}
+	svc := NewEventIngestService(&stubEventQueue{}, events, newStubReceiptRepo(), zerolog.Nop())
+	transit, warehouse := sampleEvent(), sampleEvent()
+	transit.Status = "in_transit"
+	warehouse.Status, warehouse.Timestamp = "in_warehouse", transit.Timestamp.Add(-time.Hour)
+
+	results := svc.Apply(context.Background(), []ports.TrackingEventInput{transit, warehouse})
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	if events.processed[0].Status != "in_warehouse" || events.processed[1].Status != "in_transit" {
+		t.Errorf("expected in_warehouse applied first, got %+v", events.processed)
+	}
+}
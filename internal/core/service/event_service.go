@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -22,84 +23,214 @@ type eventService struct {
 	shipmentRepo ports.ShipmentRepository
 	eventRepo    ports.EventRepository
 	dedup        DedupChecker
+	receipts     ports.EventReceiptRepository
+	lifecycles   *domain.Lifecycles
+	calendar     *domain.DeliveryCalendar
 	log          zerolog.Logger
 }
 
-// NewEventService returns an EventService implementation.
+// NewEventService returns an EventService implementation. receipts records
+// the outcome of events that carry an ingestion ID; it may be nil. Events are
+// checked against the lifecycle each shipment follows in lifecycles, and late
+// shipments are promised for the next business day of calendar.
 func NewEventService(
 	shipmentRepo ports.ShipmentRepository,
 	eventRepo ports.EventRepository,
 	dedup DedupChecker,
+	receipts ports.EventReceiptRepository,
+	lifecycles *domain.Lifecycles,
+	calendar *domain.DeliveryCalendar,
 	log zerolog.Logger,
 ) ports.EventService {
 	return &eventService{
 		shipmentRepo: shipmentRepo,
 		eventRepo:    eventRepo,
 		dedup:        dedup,
+		receipts:     receipts,
+		lifecycles:   lifecycles,
+		calendar:     calendar,
 		log:          log,
 	}
 }
 
 // Process validates, deduplicates, and persists a single tracking event.
 func (s *eventService) Process(ctx context.Context, in ports.TrackingEventInput) error {
+	_, err := s.Apply(ctx, in)
+	return err
+}
+
+// Apply is Process, reporting whether the event was applied or skipped as a
+// duplicate.
+func (s *eventService) Apply(ctx context.Context, in ports.TrackingEventInput) (domain.EventState, error) {
 	newStatus := domain.ShipmentStatus(in.Status)
 
 	// 1. Idempotency check — silently skip duplicates.
-	isDup, err := s.dedup.IsDuplicate(ctx, in.TrackingNumber, in.Status, in.Timestamp)
+	isDup, err := s.dedup.IsDuplicate(ctx, eventSubject(in), in.Status, in.Timestamp)
 	if err != nil {
 		s.log.Warn().Err(err).Str("tracking", in.TrackingNumber).Msg("dedup check failed, processing anyway")
 		apimetrics.EventsDedupTotal.WithLabelValues("error").Inc()
 	} else if isDup {
 		s.log.Debug().Str("tracking", in.TrackingNumber).Str("status", in.Status).Msg("duplicate event skipped")
 		apimetrics.EventsDedupTotal.WithLabelValues("hit").Inc()
-		return nil
+		markReceipt(ctx, s.receipts, s.log, in.ID, domain.EventDuplicate, "")
+		return domain.EventDuplicate, nil
 	} else {
 		apimetrics.EventsDedupTotal.WithLabelValues("miss").Inc()
 	}
 
-	// 2. Find shipment (no client filter — events come from external sources).
+	// 2. Find shipment (no client filter — events come from external sources)
+	// and, for a piece scan, the piece.
 	shipment, err := s.shipmentRepo.FindByTrackingNumber(ctx, in.TrackingNumber, "")
 	if err != nil {
 		apimetrics.EventsErrorsTotal.WithLabelValues("shipment_not_found").Inc()
-		return fmt.Errorf("process event: %w", err)
+		return "", fmt.Errorf("process event: %w", err)
 	}
-
-	// 3. Validate state machine transition.
-	if !shipment.Status.CanTransitionTo(newStatus) {
-		apimetrics.EventsErrorsTotal.WithLabelValues("invalid_transition").Inc()
-		return fmt.Errorf("process event: %w (from %s to %s)", domain.ErrInvalidTransition, shipment.Status, newStatus)
+	var piece *domain.Piece
+	if in.Piece != "" {
+		if piece = shipment.Piece(in.Piece); piece == nil {
+			apimetrics.EventsErrorsTotal.WithLabelValues("piece_not_found").Inc()
+			return "", fmt.Errorf("process event: %w", domain.ErrPieceNotFound)
+		}
 	}
 
-	// 4. Mark as processed before writing (prevents duplicate processing on retry).
-	if markErr := s.dedup.Mark(ctx, in.TrackingNumber, in.Status, in.Timestamp); markErr != nil {
-		s.log.Warn().Err(markErr).Str("tracking", in.TrackingNumber).Msg("failed to set dedup key")
+	// 3. Late arrival — a newer transition was already applied, so the event
+	// can only be recorded; applying it would move the status backwards.
+	lifecycle := s.lifecycles.Of(shipment)
+	last := shipment.LastTransitionAt(lifecycle)
+	if piece != nil {
+		last = piece.LastEventAt
+	}
+	if in.Timestamp.Before(last) {
+		return s.recordLate(ctx, in, last)
 	}
 
-	// 5. Build optional location.
-	var loc *domain.Coordinates
-	if in.Location != nil {
-		loc = &domain.Coordinates{Lat: in.Location.Lat, Lng: in.Location.Lng}
+	// 4. Validate the transition against the shipment's lifecycle. Unless the
+	// whole shipment was cancelled, a piece scan moves the piece from its own
+	// status and a shipment scan every piece whose own status allows it.
+	var moved []*domain.Piece
+	switch {
+	case shipment.Status == domain.StatusCancelled || len(shipment.Pieces) == 0:
+		err = lifecycle.Check(shipment.Status, newStatus, in.Role, in.Source)
+	case piece != nil:
+		err = lifecycle.Check(piece.Status, newStatus, in.Role, in.Source)
+		moved = []*domain.Piece{piece}
+	default:
+		moved, err = movablePieces(shipment, lifecycle, newStatus, in.Role, in.Source)
+	}
+	if err != nil {
+		if shipment.Status == domain.StatusCancelled {
+			// Tell carriers the order was cancelled upstream rather than
+			// just that the scan does not fit the lifecycle.
+			apimetrics.EventsErrorsTotal.WithLabelValues("shipment_cancelled").Inc()
+			return "", fmt.Errorf("process event: %w: %w", domain.ErrShipmentCancelled, err)
+		}
+		apimetrics.EventsErrorsTotal.WithLabelValues("invalid_transition").Inc()
+		return "", fmt.Errorf("process event: %w", err)
 	}
 
-	// 6. Atomically update shipment status + history.
-	if err := s.eventRepo.UpdateShipmentStatus(ctx, in.TrackingNumber, newStatus, in.Timestamp, in.Source, loc); err != nil {
+	// 5. Atomically update shipment status + history, counting failed
+	// delivery attempts and returning the shipment once they run out.
+	update := ports.StatusUpdate{
+		From:   shipment.Status,
+		Status: newStatus,
+		Entries: []domain.StatusHistoryEntry{{
+			Status:     newStatus,
+			Timestamp:  in.Timestamp,
+			Notes:      in.Source,
+			ReasonCode: in.ReasonCode,
+			Piece:      in.Piece,
+		}},
+	}
+	autoReturned := false
+	if newStatus == domain.StatusDeliveryAttemptFailed {
+		// A piece scan counts against the piece; the shipment counter keeps
+		// the total either way.
+		attempt := shipment.DeliveryAttempts + 1
+		if piece != nil {
+			piece.DeliveryAttempts++
+			attempt = piece.DeliveryAttempts
+		}
+		update.FailedAttempt = true
+		update.Entries[0].Attempt = attempt
+		if lifecycle.ReturnsAfter(attempt) {
+			autoReturned = true
+			update.Status = domain.StatusReturningToSender
+			update.Entries = append(update.Entries, domain.StatusHistoryEntry{
+				Status:     domain.StatusReturningToSender,
+				Timestamp:  in.Timestamp,
+				Notes:      "system",
+				ReasonCode: domain.ReasonMaxAttemptsReached,
+				Piece:      in.Piece,
+			})
+		}
+	}
+	if len(shipment.Pieces) > 0 {
+		update.FromPieces = make([]domain.ShipmentStatus, len(shipment.Pieces))
+		for i, p := range shipment.Pieces {
+			update.FromPieces[i] = p.Status
+		}
+		// The shipment status is derived from the pieces once they moved.
+		for _, p := range moved {
+			p.Status, p.LastEventAt = update.Status, in.Timestamp
+		}
+		update.Pieces = shipment.Pieces
+		update.Status = shipment.PiecesStatus(lifecycle)
+	}
+	next := s.calendar.NextDelivery(in.Timestamp)
+	revision := reviseETA(shipment, lifecycle, update, in.Timestamp, next)
+	if revision != "" {
+		update.EstimatedDelivery = next
+	}
+	if err := s.eventRepo.UpdateShipmentStatus(ctx, in.TrackingNumber, update); err != nil {
+		if errors.Is(err, domain.ErrConcurrentUpdate) {
+			// Retried, the event is checked again against the new status.
+			apimetrics.EventsErrorsTotal.WithLabelValues("concurrent_update").Inc()
+			return "", fmt.Errorf("process event: %w", err)
+		}
 		apimetrics.EventsErrorsTotal.WithLabelValues("update_failed").Inc()
-		return fmt.Errorf("process event: update status: %w", err)
+		return "", fmt.Errorf("process event: update status: %w", err)
+	}
+
+	// 6. Mark as processed only once written, so that a retry of a failed
+	// write applies the event instead of skipping it as a duplicate.
+	s.markDedup(ctx, in)
+	if update.FailedAttempt {
+		apimetrics.DeliveryAttemptsFailedTotal.WithLabelValues(in.ReasonCode).Inc()
+	}
+	if revision != "" {
+		apimetrics.ETARevisedTotal.WithLabelValues(revision).Inc()
+		s.log.Info().
+			Str("tracking", in.TrackingNumber).
+			Str("reason", revision).
+			Time("from", shipment.EstimatedDelivery).
+			Time("to", update.EstimatedDelivery).
+			Msg("estimated delivery revised")
+	}
+	if autoReturned {
+		apimetrics.ShipmentsAutoReturnedTotal.Inc()
+		s.log.Info().
+			Str("tracking", in.TrackingNumber).
+			Str("piece", in.Piece).
+			Int("attempts", update.Entries[0].Attempt).
+			Msg("shipment returning to sender after failed delivery attempts")
 	}
 
 	// 7. Insert into audit trail (non-fatal on failure).
 	auditEvent := &domain.TrackingEvent{
+		EventID:        in.ID,
 		TrackingNumber: in.TrackingNumber,
 		Status:         newStatus,
 		Timestamp:      in.Timestamp,
 		Source:         in.Source,
-		Location:       loc,
-	}
-	if err := s.eventRepo.InsertEvent(ctx, auditEvent); err != nil {
-		s.log.Warn().Err(err).Str("tracking", in.TrackingNumber).Msg("failed to insert audit event")
+		Role:           in.Role,
+		ReasonCode:     in.ReasonCode,
+		Piece:          in.Piece,
+		Location:       toCoordinates(in.Location),
 	}
+	s.insertAudit(ctx, auditEvent)
 
 	apimetrics.EventsProcessedTotal.WithLabelValues(in.Status, in.Source).Inc()
+	markReceipt(ctx, s.receipts, s.log, in.ID, domain.EventProcessed, "")
 
 	s.log.Info().
 		Str("tracking", in.TrackingNumber).
@@ -107,5 +238,128 @@ func (s *eventService) Process(ctx context.Context, in ports.TrackingEventInput)
 		Str("source", in.Source).
 		Msg("event processed")
 
-	return nil
-}
\ No newline at end of file
+	return domain.EventProcessed, nil
+}
+
+// movablePieces returns the pieces of a multi-piece shipment following
+// lifecycle that a shipment scan to status moves: those still moving whose
+// own status allows it. Pieces already further along are left where they are,
+// so the scan never sends them back. When no piece can move it returns the
+// first rejection.
+func movablePieces(shipment *domain.Shipment, lifecycle *domain.Lifecycle, to domain.ShipmentStatus, role, source string) ([]*domain.Piece, error) {
+	var movable []*domain.Piece
+	var rejected error
+	for i := range shipment.Pieces {
+		p := &shipment.Pieces[i]
+		if lifecycle.IsTerminal(p.Status) {
+			continue
+		}
+		if err := lifecycle.Check(p.Status, to, role, source); err != nil {
+			if rejected == nil {
+				rejected = err
+			}
+			continue
+		}
+		movable = append(movable, p)
+	}
+	if len(movable) > 0 {
+		return movable, nil
+	}
+	if rejected == nil {
+		// Every piece has stopped.
+		rejected = fmt.Errorf("%w (from %s to %s)", domain.ErrInvalidTransition, shipment.Status, to)
+	}
+	return nil, rejected
+}
+
+// reviseETA reports why the estimated delivery of a shipment must be pushed
+// back to next, the next business day after an event at the given time: a
+// failed delivery attempt, or a scan of a shipment still on its way once its
+// estimated delivery has passed. It returns "" when the promise stands.
+func reviseETA(shipment *domain.Shipment, lifecycle *domain.Lifecycle, update ports.StatusUpdate, at, next time.Time) string {
+	if lifecycle.IsTerminal(update.Status) || update.Status == domain.StatusReturningToSender {
+		return ""
+	}
+	if !next.After(shipment.EstimatedDelivery) {
+		return ""
+	}
+	switch {
+	case update.FailedAttempt:
+		return "failed_attempt"
+	case !at.Before(shipment.EstimatedDelivery):
+		return "late"
+	default:
+		return ""
+	}
+}
+
+// recordLate adds an event older than the shipment's last transition to the
+// history, flagged as late, without touching the current status.
+func (s *eventService) recordLate(ctx context.Context, in ports.TrackingEventInput, last time.Time) (domain.EventState, error) {
+	status := domain.ShipmentStatus(in.Status)
+	entry := domain.StatusHistoryEntry{
+		Status:     status,
+		Timestamp:  in.Timestamp,
+		Notes:      in.Source,
+		ReasonCode: in.ReasonCode,
+		Piece:      in.Piece,
+	}
+	if err := s.eventRepo.AppendLateEvent(ctx, in.TrackingNumber, entry); err != nil {
+		apimetrics.EventsErrorsTotal.WithLabelValues("update_failed").Inc()
+		return "", fmt.Errorf("process event: record late event: %w", err)
+	}
+	s.markDedup(ctx, in)
+	s.insertAudit(ctx, &domain.TrackingEvent{
+		EventID:        in.ID,
+		TrackingNumber: in.TrackingNumber,
+		Status:         status,
+		Timestamp:      in.Timestamp,
+		Source:         in.Source,
+		Role:           in.Role,
+		ReasonCode:     in.ReasonCode,
+		Piece:          in.Piece,
+		Location:       toCoordinates(in.Location),
+		Late:           true,
+	})
+
+	apimetrics.EventsLateTotal.WithLabelValues(in.Status).Inc()
+	markReceipt(ctx, s.receipts, s.log, in.ID, domain.EventLate, "")
+
+	s.log.Info().
+		Str("tracking", in.TrackingNumber).
+		Str("status", in.Status).
+		Time("timestamp", in.Timestamp).
+		Time("last_transition", last).
+		Msg("late event recorded")
+
+	return domain.EventLate, nil
+}
+
+func (s *eventService) markDedup(ctx context.Context, in ports.TrackingEventInput) {
+	if err := s.dedup.Mark(ctx, eventSubject(in), in.Status, in.Timestamp); err != nil {
+		s.log.Warn().Err(err).Str("tracking", in.TrackingNumber).Msg("failed to set dedup key")
+	}
+}
+
+// eventSubject identifies what an event scanned for deduplication: the piece
+// when it names one, the shipment otherwise. Pieces of one shipment may be
+// scanned with the same status and timestamp.
+func eventSubject(in ports.TrackingEventInput) string {
+	if in.Piece != "" {
+		return in.Piece
+	}
+	return in.TrackingNumber
+}
+
+func (s *eventService) insertAudit(ctx context.Context, e *domain.TrackingEvent) {
+	if err := s.eventRepo.InsertEvent(ctx, e); err != nil {
+		s.log.Warn().Err(err).Str("tracking", e.TrackingNumber).Msg("failed to insert audit event")
+	}
+}
+
+func toCoordinates(l *ports.LocationInput) *domain.Coordinates {
+	if l == nil {
+		return nil
+	}
+	return &domain.Coordinates{Lat: l.Lat, Lng: l.Lng}
+}
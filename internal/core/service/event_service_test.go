@@ -3,8 +3,10 @@ package service
 import (
 	"context"
 	"errors"
+	"slices"
 	"testing"
 	"time"
+	_ "time/tzdata" // the calendars' timezones, even where the OS has no zoneinfo
 
 	"github.com/rs/zerolog"
 
@@ -20,14 +22,33 @@ type stubEventRepo struct {
 	updateErr error
 	insertErr error
 	updated   []string // tracking numbers updated
+	updates   []ports.StatusUpdate
+	late      []string // tracking numbers with a late entry appended
 	inserted  []*domain.TrackingEvent
+
+	// failUpdates makes that many status updates fail before updateErr
+	// applies.
+	failUpdates int
 }
 
-func (r *stubEventRepo) UpdateShipmentStatus(_ context.Context, tracking string, _ domain.ShipmentStatus, _ time.Time, _ string, _ *domain.Coordinates) error {
+func (r *stubEventRepo) UpdateShipmentStatus(_ context.Context, tracking string, update ports.StatusUpdate) error {
+	if r.failUpdates > 0 {
+		r.failUpdates--
+		return errors.New("write failed")
+	}
 	if r.updateErr != nil {
 		return r.updateErr
 	}
 	r.updated = append(r.updated, tracking)
+	r.updates = append(r.updates, update)
+	return nil
+}
+
+func (r *stubEventRepo) AppendLateEvent(_ context.Context, tracking string, _ domain.StatusHistoryEntry) error {
+	if r.updateErr != nil {
+		return r.updateErr
+	}
+	r.late = append(r.late, tracking)
 	return nil
 }
 
@@ -44,9 +65,15 @@ type stubDedup struct {
 	dupErr    error
 	markErr   error
 	marked    []string
+
+	// remember reports events already marked as duplicates, like Redis.
+	remember bool
 }
 
 func (d *stubDedup) IsDuplicate(_ context.Context, tracking, status string, _ time.Time) (bool, error) {
+	if d.remember && slices.Contains(d.marked, tracking+":"+status) {
+		return true, d.dupErr
+	}
 	return d.dupResult, d.dupErr
 }
 
@@ -63,7 +90,7 @@ func (d *stubDedup) Mark(_ context.Context, tracking, status string, _ time.Time
 // ---------------------------------------------------------------------------
 
 func newEventSvc(shipRepo *stubShipmentRepo, eventRepo *stubEventRepo, dedup *stubDedup) ports.EventService {
-	return NewEventService(shipRepo, eventRepo, dedup, zerolog.Nop())
+	return NewEventService(shipRepo, eventRepo, dedup, nil, domain.BuiltinLifecycles(), domain.BuiltinCalendar(), zerolog.Nop())
 }
 
 func seededRepo(tracking, clientID string, status domain.ShipmentStatus) *stubShipmentRepo {
@@ -217,6 +244,91 @@ func TestEventService_Process_DedupCheckError_ProcessesAnyway(t *testing.T) {
 	}
 }
 
+func TestEventService_Process_RetryAfterFailedWriteIsApplied(t *testing.T) {
+	repo := seededRepo("99M-AABBCCDD", "client_1", domain.StatusCreated)
+	evRepo := &stubEventRepo{failUpdates: 1}
+	dedup := &stubDedup{remember: true}
+	svc := newEventSvc(repo, evRepo, dedup)
+
+	in := ports.TrackingEventInput{
+		TrackingNumber: "99M-AABBCCDD",
+		Status:         "picked_up",
+		Timestamp:      time.Now().Add(time.Minute),
+		Source:         "driver_app",
+	}
+	if err := svc.Process(context.Background(), in); err == nil {
+		t.Fatal("expected the failed write to be reported")
+	}
+	if len(dedup.marked) != 0 {
+		t.Fatalf("failed write must not be marked as processed, marked %v", dedup.marked)
+	}
+
+	state, err := svc.Apply(context.Background(), in)
+	if err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+	if state != domain.EventProcessed || len(evRepo.updates) != 1 || evRepo.updates[0].Status != domain.StatusPickedUp {
+		t.Errorf("expected the retry to apply the event, got %s with updates %+v", state, evRepo.updates)
+	}
+}
+
+func TestEventService_Process_ConcurrentCancelIsReevaluated(t *testing.T) {
+	repo := seededRepo("99M-AABBCCDD", "client_1", domain.StatusPickedUp)
+	repo.byTracking["99M-AABBCCDD"].Pieces = []domain.Piece{
+		{Barcode: "99M-AABBCCDD-01", Status: domain.StatusPickedUp},
+	}
+	// The shipment was cancelled between the read and the write.
+	evRepo := &stubEventRepo{updateErr: domain.ErrConcurrentUpdate}
+	dedup := &stubDedup{}
+	svc := newEventSvc(repo, evRepo, dedup)
+
+	in := ports.TrackingEventInput{
+		TrackingNumber: "99M-AABBCCDD",
+		Status:         "in_warehouse",
+		Timestamp:      time.Now().Add(time.Minute),
+		Source:         "driver_app",
+	}
+	if err := svc.Process(context.Background(), in); !errors.Is(err, domain.ErrConcurrentUpdate) {
+		t.Fatalf("expected ErrConcurrentUpdate, got %v", err)
+	}
+	if len(dedup.marked) != 0 {
+		t.Errorf("a lost write must not be marked as processed, marked %v", dedup.marked)
+	}
+
+	// The retry sees the cancellation.
+	repo.byTracking["99M-AABBCCDD"].Status = domain.StatusCancelled
+	evRepo.updateErr = nil
+	if err := svc.Process(context.Background(), in); !errors.Is(err, domain.ErrShipmentCancelled) {
+		t.Errorf("expected ErrShipmentCancelled on retry, got %v", err)
+	}
+}
+
+func TestEventService_Process_UpdateCarriesExpectedStatuses(t *testing.T) {
+	repo := seededRepo("99M-AABBCCDD", "client_1", domain.StatusInWarehouse)
+	repo.byTracking["99M-AABBCCDD"].Pieces = []domain.Piece{
+		{Barcode: "99M-AABBCCDD-01", Status: domain.StatusInWarehouse},
+		{Barcode: "99M-AABBCCDD-02", Status: domain.StatusInTransit},
+	}
+	evRepo := &stubEventRepo{}
+	svc := newEventSvc(repo, evRepo, &stubDedup{})
+
+	err := svc.Process(context.Background(), ports.TrackingEventInput{
+		TrackingNumber: "99M-AABBCCDD",
+		Piece:          "99M-AABBCCDD-01",
+		Status:         "in_transit",
+		Timestamp:      time.Now().Add(time.Minute),
+		Source:         "driver_app",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	update := evRepo.updates[0]
+	want := []domain.ShipmentStatus{domain.StatusInWarehouse, domain.StatusInTransit}
+	if update.From != domain.StatusInWarehouse || !slices.Equal(update.FromPieces, want) {
+		t.Errorf("expected the statuses read, got %s %v", update.From, update.FromPieces)
+	}
+}
+
 func TestEventService_Process_AuditFailureIsNonFatal(t *testing.T) {
 	repo := seededRepo("99M-AABBCCDD", "client_1", domain.StatusCreated)
 	evRepo := &stubEventRepo{insertErr: errors.New("mongo unavailable")}
@@ -238,3 +350,361 @@ func TestEventService_Process_AuditFailureIsNonFatal(t *testing.T) {
 		t.Error("expected shipment status to be updated")
 	}
 }
+
+func TestEventService_Apply_LateEventRecordedWithoutStatusChange(t *testing.T) {
+	repo := seededRepo("99M-AABBCCDD", "client_1", domain.StatusInTransit)
+	last := repo.byTracking["99M-AABBCCDD"].StatusHistory[0].Timestamp
+	evRepo := &stubEventRepo{}
+	dedup := &stubDedup{}
+
+	svc := newEventSvc(repo, evRepo, dedup)
+	state, err := svc.Apply(context.Background(), ports.TrackingEventInput{
+		TrackingNumber: "99M-AABBCCDD",
+		Status:         "in_warehouse",
+		Timestamp:      last.Add(-time.Minute),
+		Source:         "warehouse_scanner",
+	})
+
+	if err != nil {
+		t.Fatalf("expected no error for late event, got: %v", err)
+	}
+	if state != domain.EventLate {
+		t.Errorf("expected state late, got %q", state)
+	}
+	if len(evRepo.updated) != 0 {
+		t.Errorf("late event must not change the status, got updates: %v", evRepo.updated)
+	}
+	if len(evRepo.late) != 1 {
+		t.Errorf("expected a late history entry, got %v", evRepo.late)
+	}
+	if len(evRepo.inserted) != 1 || !evRepo.inserted[0].Late {
+		t.Errorf("expected a late audit event, got %+v", evRepo.inserted)
+	}
+	if len(dedup.marked) != 1 {
+		t.Errorf("expected dedup key marked")
+	}
+}
+
+func TestEventService_Apply_CreatedEntryDoesNotMakeEventsLate(t *testing.T) {
+	repo := seededRepo("99M-AABBCCDD", "client_1", domain.StatusCreated)
+	created := repo.byTracking["99M-AABBCCDD"].StatusHistory[0].Timestamp
+	evRepo := &stubEventRepo{}
+
+	// Scanner clocks can lag the server that created the shipment.
+	svc := newEventSvc(repo, evRepo, &stubDedup{})
+	state, err := svc.Apply(context.Background(), ports.TrackingEventInput{
+		TrackingNumber: "99M-AABBCCDD",
+		Status:         "picked_up",
+		Timestamp:      created.Add(-time.Minute),
+		Source:         "driver_app",
+	})
+
+	if err != nil || state != domain.EventProcessed {
+		t.Fatalf("expected processed, got state=%q err=%v", state, err)
+	}
+	if len(evRepo.updated) != 1 {
+		t.Errorf("expected shipment status updated, got %v", evRepo.updated)
+	}
+}
+
+func TestEventService_Process_FollowsShipmentLifecycle(t *testing.T) {
+	ls := domain.BuiltinLifecycles()
+	ls.Lifecycles["direct"] = &domain.Lifecycle{
+		Initial:  domain.StatusCreated,
+		States:   []domain.ShipmentStatus{domain.StatusCreated, domain.StatusPickedUp, domain.StatusDelivered},
+		Terminal: []domain.ShipmentStatus{domain.StatusDelivered},
+		Transitions: []domain.Transition{
+			{From: domain.StatusCreated, To: domain.StatusPickedUp},
+			{From: domain.StatusPickedUp, To: domain.StatusDelivered, Roles: []string{domain.RoleAdmin}},
+		},
+	}
+	ls.Clients = map[string]string{"client_1": "direct"}
+
+	deliver := ports.TrackingEventInput{
+		TrackingNumber: "99M-AABBCCDD",
+		Status:         "delivered",
+		Timestamp:      time.Now().Add(time.Minute),
+		Source:         "driver_app",
+		Role:           domain.RoleClient,
+	}
+
+	// picked_up → delivered skips in_transit, allowed only for admins.
+	evRepo := &stubEventRepo{}
+	svc := NewEventService(seededRepo("99M-AABBCCDD", "client_1", domain.StatusPickedUp), evRepo, &stubDedup{}, nil, ls, domain.BuiltinCalendar(), zerolog.Nop())
+	if err := svc.Process(context.Background(), deliver); !errors.Is(err, domain.ErrInvalidTransition) {
+		t.Fatalf("client: expected ErrInvalidTransition, got %v", err)
+	}
+	deliver.Role = domain.RoleAdmin
+	if err := svc.Process(context.Background(), deliver); err != nil {
+		t.Fatalf("admin: unexpected error %v", err)
+	}
+	if len(evRepo.updated) != 1 {
+		t.Errorf("expected one status update, got %v", evRepo.updated)
+	}
+
+	// Other clients keep the default lifecycle.
+	svc = NewEventService(seededRepo("99M-AABBCCDD", "client_2", domain.StatusPickedUp), &stubEventRepo{}, &stubDedup{}, nil, ls, domain.BuiltinCalendar(), zerolog.Nop())
+	if err := svc.Process(context.Background(), deliver); !errors.Is(err, domain.ErrInvalidTransition) {
+		t.Errorf("default lifecycle: expected ErrInvalidTransition, got %v", err)
+	}
+}
+
+func TestEventService_Process_CountsFailedAttemptsAndReturns(t *testing.T) {
+	failed := ports.TrackingEventInput{
+		TrackingNumber: "99M-AABBCCDD",
+		Status:         "delivery_attempt_failed",
+		Timestamp:      time.Now().Add(time.Minute),
+		Source:         "driver_app",
+		ReasonCode:     "recipient_absent",
+	}
+
+	// Second attempt of three: recorded and counted.
+	repo := seededRepo("99M-AABBCCDD", "client_1", domain.StatusOutForDelivery)
+	repo.byTracking["99M-AABBCCDD"].DeliveryAttempts = 1
+	evRepo := &stubEventRepo{}
+	if err := newEventSvc(repo, evRepo, &stubDedup{}).Process(context.Background(), failed); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	update := evRepo.updates[0]
+	if update.Status != domain.StatusDeliveryAttemptFailed || !update.FailedAttempt || len(update.Entries) != 1 {
+		t.Fatalf("unexpected update: %+v", update)
+	}
+	if e := update.Entries[0]; e.Attempt != 2 || e.ReasonCode != "recipient_absent" {
+		t.Errorf("unexpected history entry: %+v", e)
+	}
+	if evRepo.inserted[0].ReasonCode != "recipient_absent" {
+		t.Errorf("expected the reason code in the audit event, got %+v", evRepo.inserted[0])
+	}
+
+	// Third attempt: the shipment goes back to its sender.
+	repo.byTracking["99M-AABBCCDD"].DeliveryAttempts = 2
+	evRepo = &stubEventRepo{}
+	if err := newEventSvc(repo, evRepo, &stubDedup{}).Process(context.Background(), failed); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	update = evRepo.updates[0]
+	if update.Status != domain.StatusReturningToSender || len(update.Entries) != 2 {
+		t.Fatalf("expected an automatic return, got %+v", update)
+	}
+	if e := update.Entries[1]; e.Status != domain.StatusReturningToSender || e.ReasonCode != domain.ReasonMaxAttemptsReached {
+		t.Errorf("unexpected return entry: %+v", e)
+	}
+}
+
+func TestEventService_Process_CancelledShipment(t *testing.T) {
+	repo := seededRepo("99M-AABBCCDD", "client_1", domain.StatusCancelled)
+	svc := newEventSvc(repo, &stubEventRepo{}, &stubDedup{})
+
+	err := svc.Process(context.Background(), ports.TrackingEventInput{
+		TrackingNumber: "99M-AABBCCDD",
+		Status:         "picked_up",
+		Timestamp:      time.Now().Add(time.Minute),
+		Source:         "driver_app",
+	})
+	if !errors.Is(err, domain.ErrShipmentCancelled) || !errors.Is(err, domain.ErrInvalidTransition) {
+		t.Errorf("expected ErrShipmentCancelled wrapping ErrInvalidTransition, got %v", err)
+	}
+}
+
+func TestEventService_Process_PieceScansDeriveShipmentStatus(t *testing.T) {
+	repo := seededRepo("99M-AABBCCDD", "client_1", domain.StatusOutForDelivery)
+	shipment := repo.byTracking["99M-AABBCCDD"]
+	shipment.Pieces = []domain.Piece{
+		{Barcode: "99M-AABBCCDD-01", Status: domain.StatusOutForDelivery},
+		{Barcode: "99M-AABBCCDD-02", Status: domain.StatusOutForDelivery},
+	}
+	dedup := &stubDedup{}
+	at := time.Now().Add(time.Minute)
+	deliver := func(piece string) ports.StatusUpdate {
+		t.Helper()
+		evRepo := &stubEventRepo{}
+		err := newEventSvc(repo, evRepo, dedup).Process(context.Background(), ports.TrackingEventInput{
+			TrackingNumber: "99M-AABBCCDD",
+			Piece:          piece,
+			Status:         "delivered",
+			Timestamp:      at,
+			Source:         "driver_app",
+		})
+		if err != nil {
+			t.Fatalf("deliver %s: %v", piece, err)
+		}
+		update := evRepo.updates[0]
+		shipment.Status, shipment.Pieces = update.Status, update.Pieces
+		return update
+	}
+
+	update := deliver("99M-AABBCCDD-01")
+	if update.Status != domain.StatusPartiallyDelivered {
+		t.Errorf("expected partially_delivered, got %s", update.Status)
+	}
+	if update.Pieces[0].Status != domain.StatusDelivered || update.Pieces[1].Status != domain.StatusOutForDelivery {
+		t.Errorf("unexpected pieces: %+v", update.Pieces)
+	}
+	if update.Entries[0].Piece != "99M-AABBCCDD-01" {
+		t.Errorf("expected the piece in the history entry, got %+v", update.Entries[0])
+	}
+
+	// Same status and timestamp on the other piece is not a duplicate.
+	if update := deliver("99M-AABBCCDD-02"); update.Status != domain.StatusDelivered {
+		t.Errorf("expected delivered, got %s", update.Status)
+	}
+	if want := []string{"99M-AABBCCDD-01:delivered", "99M-AABBCCDD-02:delivered"}; !slices.Equal(dedup.marked, want) {
+		t.Errorf("dedup marked %v, want %v", dedup.marked, want)
+	}
+}
+
+func TestEventService_Process_ShipmentScanMovesPieces(t *testing.T) {
+	repo := seededRepo("99M-AABBCCDD", "client_1", domain.StatusInTransit)
+	repo.byTracking["99M-AABBCCDD"].Pieces = []domain.Piece{
+		{Barcode: "99M-AABBCCDD-01", Status: domain.StatusInTransit},
+		{Barcode: "99M-AABBCCDD-02", Status: domain.StatusLost},
+	}
+	evRepo := &stubEventRepo{}
+	svc := newEventSvc(repo, evRepo, &stubDedup{})
+
+	err := svc.Process(context.Background(), ports.TrackingEventInput{
+		TrackingNumber: "99M-AABBCCDD",
+		Status:         "out_for_delivery",
+		Timestamp:      time.Now().Add(time.Minute),
+		Source:         "driver_app",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	update := evRepo.updates[0]
+	if update.Pieces[0].Status != domain.StatusOutForDelivery || update.Pieces[1].Status != domain.StatusLost {
+		t.Errorf("expected only the moving piece to advance, got %+v", update.Pieces)
+	}
+	if update.Status != domain.StatusOutForDelivery {
+		t.Errorf("expected out_for_delivery, got %s", update.Status)
+	}
+
+	err = svc.Process(context.Background(), ports.TrackingEventInput{
+		TrackingNumber: "99M-AABBCCDD",
+		Piece:          "99M-AABBCCDD-09",
+		Status:         "delivered",
+		Timestamp:      time.Now().Add(time.Minute),
+		Source:         "driver_app",
+	})
+	if !errors.Is(err, domain.ErrPieceNotFound) {
+		t.Errorf("expected ErrPieceNotFound, got %v", err)
+	}
+}
+
+func TestEventService_Process_ShipmentScanLeavesPiecesAhead(t *testing.T) {
+	repo := seededRepo("99M-AABBCCDD", "client_1", domain.StatusInWarehouse)
+	repo.byTracking["99M-AABBCCDD"].Pieces = []domain.Piece{
+		{Barcode: "99M-AABBCCDD-01", Status: domain.StatusInWarehouse},
+		{Barcode: "99M-AABBCCDD-02", Status: domain.StatusOutForDelivery},
+	}
+	evRepo := &stubEventRepo{}
+	svc := newEventSvc(repo, evRepo, &stubDedup{})
+
+	err := svc.Process(context.Background(), ports.TrackingEventInput{
+		TrackingNumber: "99M-AABBCCDD",
+		Status:         "in_transit",
+		Timestamp:      time.Now().Add(time.Minute),
+		Source:         "driver_app",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	update := evRepo.updates[0]
+	if update.Pieces[0].Status != domain.StatusInTransit || update.Pieces[1].Status != domain.StatusOutForDelivery {
+		t.Errorf("expected the piece ahead to stay out_for_delivery, got %+v", update.Pieces)
+	}
+	if update.Status != domain.StatusInTransit {
+		t.Errorf("expected in_transit, got %s", update.Status)
+	}
+
+	// No piece can go back to picked_up.
+	err = svc.Process(context.Background(), ports.TrackingEventInput{
+		TrackingNumber: "99M-AABBCCDD",
+		Status:         "picked_up",
+		Timestamp:      time.Now().Add(2 * time.Minute),
+		Source:         "driver_app",
+	})
+	if !errors.Is(err, domain.ErrInvalidTransition) {
+		t.Errorf("expected ErrInvalidTransition, got %v", err)
+	}
+	if len(evRepo.updates) != 1 {
+		t.Errorf("expected no update for a rejected scan, got %d", len(evRepo.updates))
+	}
+}
+
+func TestEventService_Process_ShipmentScanAfterPartialDelivery(t *testing.T) {
+	repo := seededRepo("99M-AABBCCDD", "client_1", domain.StatusPartiallyDelivered)
+	repo.byTracking["99M-AABBCCDD"].Pieces = []domain.Piece{
+		{Barcode: "99M-AABBCCDD-01", Status: domain.StatusDelivered},
+		{Barcode: "99M-AABBCCDD-02", Status: domain.StatusOutForDelivery},
+	}
+	evRepo := &stubEventRepo{}
+	svc := newEventSvc(repo, evRepo, &stubDedup{})
+
+	err := svc.Process(context.Background(), ports.TrackingEventInput{
+		TrackingNumber: "99M-AABBCCDD",
+		Status:         "delivered",
+		Timestamp:      time.Now().Add(time.Minute),
+		Source:         "driver_app",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	update := evRepo.updates[0]
+	if update.Pieces[1].Status != domain.StatusDelivered {
+		t.Errorf("expected the remaining piece delivered, got %+v", update.Pieces)
+	}
+	if update.Status != domain.StatusDelivered {
+		t.Errorf("expected delivered, got %s", update.Status)
+	}
+}
+
+func TestEventService_Process_RevisesETA(t *testing.T) {
+	// Thursday 2026-02-19, promised by 20:00 Mexico City time.
+	cdmx := time.FixedZone("CST", -6*60*60)
+	eta := time.Date(2026, 2, 19, 20, 0, 0, 0, cdmx)
+	friday := time.Date(2026, 2, 20, 20, 0, 0, 0, cdmx)
+
+	cases := []struct {
+		name   string
+		from   domain.ShipmentStatus
+		status string
+		at     time.Time
+		want   time.Time // zero when the estimate stands
+	}{
+		{"on time", domain.StatusInWarehouse, "in_transit", eta.Add(-8 * time.Hour), time.Time{}},
+		{"scanned after the estimate", domain.StatusInWarehouse, "in_transit", eta.Add(time.Hour), friday},
+		{"failed attempt", domain.StatusOutForDelivery, "delivery_attempt_failed", eta.Add(-2 * time.Hour), friday},
+		{"delivered late", domain.StatusOutForDelivery, "delivered", eta.Add(time.Hour), time.Time{}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := seededRepo("99M-AABBCCDD", "client_1", tc.from)
+			repo.byTracking["99M-AABBCCDD"].EstimatedDelivery = eta
+			repo.byTracking["99M-AABBCCDD"].StatusHistory[0].Timestamp = eta.Add(-24 * time.Hour)
+			evRepo := &stubEventRepo{}
+
+			err := newEventSvc(repo, evRepo, &stubDedup{}).Process(context.Background(), ports.TrackingEventInput{
+				TrackingNumber: "99M-AABBCCDD",
+				Status:         tc.status,
+				Timestamp:      tc.at,
+				Source:         "driver_app",
+				ReasonCode:     reasonFor(tc.status),
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := evRepo.updates[0].EstimatedDelivery; !got.Equal(tc.want) {
+				t.Errorf("estimated delivery = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+// reasonFor returns a valid reason code for a failed delivery attempt.
+func reasonFor(status string) string {
+	if status == string(domain.StatusDeliveryAttemptFailed) {
+		return domain.DeliveryFailureReasons[0]
+	}
+	return ""
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	apimetrics "github.com/99minutos/shipping-system/internal/api/metrics"
+	"github.com/99minutos/shipping-system/internal/core/domain"
+	"github.com/99minutos/shipping-system/internal/core/ports"
+)
+
+type PricingService struct {
+	cards  ports.RateCardProvider
+	logger zerolog.Logger
+}
+
+func NewPricingService(cards ports.RateCardProvider, logger zerolog.Logger) *PricingService {
+	return &PricingService{cards: cards, logger: logger}
+}
+
+// Quote prices a shipment with the rate card in effect now, without
+// creating it.
+func (s *PricingService) Quote(ctx context.Context, input ports.QuoteInput) (*ports.Quote, error) {
+	shipment := &domain.Shipment{
+		ServiceType: input.ServiceType,
+		Origin:      toDomainAddress(input.Origin),
+		Destination: toDomainAddress(input.Destination),
+		Package:     toDomainPackage(input.Package),
+		Pieces:      toDomainPieces(input.Pieces, ""),
+	}
+	price, err := priceShipment(s.cards, shipment, input.Insured, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Debug().
+		Str("service_type", input.ServiceType).
+		Str("rate_card", price.RateCardVersion).
+		Float64("total", price.Total).
+		Msg("shipment quoted")
+	return toQuote(price), nil
+}
+
+// priceShipment prices a shipment with the rate card in effect at the given
+// time. Each piece is billed as a parcel; shipments without pieces are billed
+// on the package.
+func priceShipment(cards ports.RateCardProvider, s *domain.Shipment, insured bool, at time.Time) (*domain.Price, error) {
+	price, err := computePrice(cards, s, insured, at)
+	apimetrics.QuotesTotal.WithLabelValues(s.ServiceType, priceResult(err)).Inc()
+	if err != nil {
+		return nil, err
+	}
+	return price, nil
+}
+
+func computePrice(cards ports.RateCardProvider, s *domain.Shipment, insured bool, at time.Time) (*domain.Price, error) {
+	card, err := cards.RateCard(at)
+	if err != nil {
+		return nil, err
+	}
+
+	parcels := []domain.Parcel{{WeightKg: s.Package.WeightKg, Dimensions: s.Package.Dimensions}}
+	if len(s.Pieces) > 0 {
+		parcels = make([]domain.Parcel, len(s.Pieces))
+		for i, p := range s.Pieces {
+			parcels[i] = domain.Parcel{WeightKg: p.WeightKg, Dimensions: p.Dimensions}
+		}
+	}
+	price, err := card.Price(domain.PriceRequest{
+		ServiceType:    s.ServiceType,
+		Origin:         s.Origin.Coordinates,
+		Destination:    s.Destination.Coordinates,
+		DestinationZip: s.Destination.ZipCode,
+		Parcels:        parcels,
+		DeclaredValue:  s.Package.DeclaredValue,
+		Currency:       s.Package.Currency,
+		Insured:        insured,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &price, nil
+}
+
+// priceResult returns the quotes metric result for a pricing error.
+func priceResult(err error) string {
+	switch {
+	case err == nil:
+		return "priced"
+	case errors.Is(err, domain.ErrNoRateCard):
+		return "no_rate_card"
+	case errors.Is(err, domain.ErrZoneNotServed):
+		return "zone_not_served"
+	case errors.Is(err, domain.ErrServiceNotPriced):
+		return "service_not_priced"
+	case errors.Is(err, domain.ErrCurrencyMismatch):
+		return "currency_mismatch"
+	default:
+		return "error"
+	}
+}
+
+func toQuote(p *domain.Price) *ports.Quote {
+	if p == nil {
+		return nil
+	}
+	return &ports.Quote{
+		RateCardVersion:    p.RateCardVersion,
+		Currency:           p.Currency,
+		ServiceType:        p.ServiceType,
+		Zone:               p.Zone,
+		DistanceKm:         p.DistanceKm,
+		ActualWeightKg:     p.ActualWeightKg,
+		VolumetricWeightKg: p.VolumetricWeightKg,
+		BillableWeightKg:   p.BillableWeightKg,
+		Base:               p.Base,
+		WeightCharge:       p.WeightCharge,
+		DistanceCharge:     p.DistanceCharge,
+		ZoneSurcharge:      p.ZoneSurcharge,
+		MinimumAdjustment:  p.MinimumAdjustment,
+		Insured:            p.Insured,
+		Insurance:          p.Insurance,
+		Total:              p.Total,
+	}
+}
@@ -0,0 +1,201 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/99minutos/shipping-system/internal/core/domain"
+	"github.com/99minutos/shipping-system/internal/core/ports"
+)
+
+// stubRateCards serves a single rate card, or err.
+type stubRateCards struct {
+	card *domain.RateCard
+	err  error
+}
+
+func (s stubRateCards) RateCard(time.Time) (*domain.RateCard, error) {
+	return s.card, s.err
+}
+
+func testRateCard() *domain.RateCard {
+	return &domain.RateCard{
+		Version:           "test-1",
+		EffectiveFrom:     time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		Currency:          "MXN",
+		VolumetricDivisor: 5000,
+		Services: map[string]domain.ServiceRate{
+			"next_day": {Base: 50, PerKg: 10, PerKm: 1},
+			"standard": {Base: 10, PerKg: 1, MinPrice: 100},
+		},
+		Zones: []domain.Zone{
+			{Name: "puebla", ZipPrefixes: []string{"72"}, Surcharge: 15},
+			{Name: "puebla_centro", ZipPrefixes: []string{"720"}, Surcharge: 5},
+			{Name: "national", ZipPrefixes: []string{""}, Surcharge: 30},
+		},
+		Insurance: domain.InsuranceRate{Rate: 0.02, Minimum: 20},
+	}
+}
+
+func TestPricingService_Quote(t *testing.T) {
+	svc := NewPricingService(stubRateCards{card: testRateCard()}, discardLogger)
+	puebla := ports.AddressInput{ZipCode: "72000", Coordinates: ports.CoordinatesInput{Lat: 19.0414, Lng: -98.2063}}
+
+	tests := []struct {
+		name  string
+		input ports.QuoteInput
+		want  ports.Quote
+	}{
+		{
+			name: "volumetric weight and longest zip prefix",
+			input: ports.QuoteInput{
+				ServiceType: "next_day",
+				Origin:      puebla,
+				Destination: puebla,
+				Package:     ports.PackageInput{WeightKg: 2, Dimensions: ports.DimensionsInput{LengthCm: 40, WidthCm: 30, HeightCm: 20}},
+			},
+			want: ports.Quote{
+				Zone: "puebla_centro", ActualWeightKg: 2, VolumetricWeightKg: 4.8, BillableWeightKg: 4.8,
+				Base: 50, WeightCharge: 48, ZoneSurcharge: 5, Total: 103,
+			},
+		},
+		{
+			name: "pieces billed one by one with insurance",
+			input: ports.QuoteInput{
+				ServiceType: "next_day",
+				Origin:      puebla,
+				Destination: puebla,
+				Package:     ports.PackageInput{DeclaredValue: 2000, Currency: "mxn"},
+				Pieces: []ports.PieceInput{
+					{WeightKg: 1, Dimensions: ports.DimensionsInput{LengthCm: 10, WidthCm: 10, HeightCm: 10}},
+					{WeightKg: 3, Dimensions: ports.DimensionsInput{LengthCm: 50, WidthCm: 40, HeightCm: 30}},
+				},
+				Insured: true,
+			},
+			want: ports.Quote{
+				Zone: "puebla_centro", ActualWeightKg: 4, VolumetricWeightKg: 12.2, BillableWeightKg: 13,
+				Base: 50, WeightCharge: 130, ZoneSurcharge: 5, Insured: true, Insurance: 40, Total: 225,
+			},
+		},
+		{
+			name: "service minimum",
+			input: ports.QuoteInput{
+				ServiceType: "standard",
+				Destination: ports.AddressInput{ZipCode: "99000"},
+				Package:     ports.PackageInput{WeightKg: 1},
+			},
+			want: ports.Quote{
+				Zone: "national", ActualWeightKg: 1, BillableWeightKg: 1,
+				Base: 10, WeightCharge: 1, ZoneSurcharge: 30, MinimumAdjustment: 59, Total: 100,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := svc.Quote(context.Background(), tt.input)
+			if err != nil {
+				t.Fatalf("quote: %v", err)
+			}
+			tt.want.RateCardVersion = "test-1"
+			tt.want.Currency = "MXN"
+			tt.want.ServiceType = tt.input.ServiceType
+			if *got != tt.want {
+				t.Errorf("quote = %+v\nwant    %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPricingService_Quote_Errors(t *testing.T) {
+	base := ports.QuoteInput{
+		ServiceType: "next_day",
+		Destination: ports.AddressInput{ZipCode: "72000"},
+		Package:     ports.PackageInput{WeightKg: 1, DeclaredValue: 100, Currency: "MXN"},
+	}
+	tests := []struct {
+		name   string
+		cards  stubRateCards
+		modify func(*ports.QuoteInput)
+		want   error
+	}{
+		{"no rate card", stubRateCards{err: domain.ErrNoRateCard}, func(*ports.QuoteInput) {}, domain.ErrNoRateCard},
+		{"service not priced", stubRateCards{card: testRateCard()}, func(i *ports.QuoteInput) { i.ServiceType = "same_day" }, domain.ErrServiceNotPriced},
+		{"currency mismatch", stubRateCards{card: testRateCard()}, func(i *ports.QuoteInput) { i.Insured, i.Package.Currency = true, "USD" }, domain.ErrCurrencyMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := base
+			tt.modify(&in)
+			_, err := NewPricingService(tt.cards, discardLogger).Quote(context.Background(), in)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestShipmentService_Create_StoresPrice(t *testing.T) {
+	repo := newStubShipmentRepo()
+	svc := NewShipmentService(repo, stubRateCards{card: testRateCard()}, domain.BuiltinLifecycles(), domain.BuiltinCalendar(), discardLogger)
+	created := seedViaService(t, svc, func(i *ports.CreateShipmentInput) {
+		i.Package = ports.PackageInput{WeightKg: 1, DeclaredValue: 500, Currency: "MXN"}
+		i.Insured = true
+	})
+
+	detail, err := svc.GetShipment(context.Background(), ports.GetShipmentInput{TrackingNumber: created.TrackingNumber, Role: domain.RoleAdmin})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	p := detail.Price
+	if p == nil {
+		t.Fatal("expected the shipment to be priced")
+	}
+	if p.RateCardVersion != "test-1" || p.Zone != "puebla_centro" || p.Insurance != 20 {
+		t.Errorf("unexpected price %+v", *p)
+	}
+}
+
+func TestShipmentService_Create_FailsWhenNotPriced(t *testing.T) {
+	repo := newStubShipmentRepo()
+	svc := NewShipmentService(repo, stubRateCards{card: testRateCard()}, domain.BuiltinLifecycles(), domain.BuiltinCalendar(), discardLogger)
+
+	_, err := svc.CreateShipment(context.Background(), ports.CreateShipmentInput{
+		ClientID:    "client_001",
+		ServiceType: "same_day",
+		Destination: ports.AddressInput{ZipCode: "72000"},
+		Package:     ports.PackageInput{WeightKg: 1},
+	})
+	if !errors.Is(err, domain.ErrServiceNotPriced) {
+		t.Fatalf("expected ErrServiceNotPriced, got %v", err)
+	}
+	if len(repo.byTracking) != 0 {
+		t.Error("expected no shipment to be stored")
+	}
+}
+
+func TestAmendShipment_RepricesOnNewDestination(t *testing.T) {
+	repo := newStubShipmentRepo()
+	svc := NewShipmentService(repo, stubRateCards{card: testRateCard()}, domain.BuiltinLifecycles(), domain.BuiltinCalendar(), discardLogger)
+	created := seedViaService(t, svc, nil)
+
+	zip := "99000"
+	detail, err := svc.AmendShipment(context.Background(), ports.AmendShipmentInput{
+		TrackingNumber: created.TrackingNumber,
+		Role:           domain.RoleAdmin,
+		Actor:          "ops",
+		Destination:    ports.AddressPatch{ZipCode: &zip},
+	})
+	if err != nil {
+		t.Fatalf("amend: %v", err)
+	}
+	if detail.Price.Zone != "national" {
+		t.Errorf("expected the national zone after the new zip code, got %s", detail.Price.Zone)
+	}
+	changes := detail.Amendments[0].Changes
+	last := changes[len(changes)-1]
+	if last.Field != "price.total" || last.From != "65.00" || last.To != "90.00" {
+		t.Errorf("unexpected price change %+v", last)
+	}
+}
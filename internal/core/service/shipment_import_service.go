@@ -0,0 +1,235 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	apimetrics "github.com/99minutos/shipping-system/internal/api/metrics"
+	"github.com/99minutos/shipping-system/internal/core/domain"
+	"github.com/99minutos/shipping-system/internal/core/ports"
+)
+
+const (
+	// importChunk is how many rows are created between two progress updates.
+	importChunk = 50
+	// importLease is how long an import stays with the worker running it
+	// after its last progress update.
+	importLease = time.Minute
+)
+
+// ShipmentImportService creates the shipments of uploaded files through
+// ShipmentService, in the background. Imports are stored with their rows, so
+// any replica can run them and an interrupted import resumes where its last
+// progress update left it; each row creates its shipment with an idempotency
+// key of its own, so rows run twice create a single shipment.
+type ShipmentImportService struct {
+	repo      ports.ShipmentImportRepository
+	shipments ports.ShipmentService
+	decoder   ports.ImportRowDecoder
+	poll      time.Duration
+	logger    zerolog.Logger
+}
+
+// NewShipmentImportService returns an import service whose Run looks for
+// queued imports every poll interval.
+func NewShipmentImportService(
+	repo ports.ShipmentImportRepository,
+	shipments ports.ShipmentService,
+	decoder ports.ImportRowDecoder,
+	poll time.Duration,
+	logger zerolog.Logger,
+) *ShipmentImportService {
+	return &ShipmentImportService{repo: repo, shipments: shipments, decoder: decoder, poll: poll, logger: logger}
+}
+
+// StartImport queues an import of the given rows. A file the client already
+// uploaded is not imported again: its import is returned instead.
+func (s *ShipmentImportService) StartImport(ctx context.Context, input ports.StartImportInput) (*ports.ShipmentImportView, error) {
+	now := time.Now().UTC()
+	imp := &domain.ShipmentImport{
+		ID:        newID("imp_"),
+		ClientID:  input.ClientID,
+		FileName:  input.FileName,
+		FileHash:  input.FileHash,
+		Status:    domain.ImportQueued,
+		TotalRows: len(input.Rows),
+		Results:   []domain.ImportRowResult{},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	err := s.repo.Create(ctx, imp, input.Rows)
+	if errors.Is(err, domain.ErrImportExists) {
+		existing, err := s.repo.FindByFileHash(ctx, input.ClientID, input.FileHash)
+		if err != nil {
+			return nil, err
+		}
+		s.logger.Info().Str("import_id", existing.ID).Str("client_id", input.ClientID).Msg("shipment import already uploaded")
+		view := toImportView(existing)
+		view.AlreadyExisted = true
+		return view, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info().
+		Str("import_id", imp.ID).
+		Str("client_id", imp.ClientID).
+		Str("file", imp.FileName).
+		Int("rows", imp.TotalRows).
+		Msg("shipment import queued")
+	return toImportView(imp), nil
+}
+
+// GetImport returns the progress of an import. Clients only see their own.
+func (s *ShipmentImportService) GetImport(ctx context.Context, input ports.GetImportInput) (*ports.ShipmentImportView, error) {
+	filterClientID := ""
+	if input.Role == domain.RoleClient {
+		filterClientID = input.ClientID
+	}
+	imp, err := s.repo.FindByID(ctx, input.ID, filterClientID)
+	if err != nil {
+		return nil, err
+	}
+	return toImportView(imp), nil
+}
+
+// Run imports queued files until ctx is cancelled.
+func (s *ShipmentImportService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.poll)
+	defer ticker.Stop()
+	for {
+		for s.RunNext(ctx) {
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunNext claims the next queued import and runs it to completion. It
+// reports whether an import was completed.
+func (s *ShipmentImportService) RunNext(ctx context.Context) bool {
+	now := time.Now().UTC()
+	imp, err := s.repo.Claim(ctx, now, now.Add(importLease))
+	if errors.Is(err, domain.ErrImportNotFound) {
+		return false
+	}
+	if err != nil {
+		s.logger.Error().Err(err).Msg("failed to claim shipment import")
+		return false
+	}
+
+	if err := s.run(ctx, imp); err != nil {
+		s.logger.Warn().Err(err).Str("import_id", imp.ID).Int("processed", imp.Processed).
+			Msg("shipment import interrupted; it resumes when its lease expires")
+		return false
+	}
+	s.logger.Info().
+		Str("import_id", imp.ID).
+		Int("created", imp.Created).
+		Int("failed", imp.Failed).
+		Msg("shipment import completed")
+	return true
+}
+
+func (s *ShipmentImportService) run(ctx context.Context, imp *domain.ShipmentImport) error {
+	for imp.Processed < imp.TotalRows {
+		rows, err := s.repo.Rows(ctx, imp.ID, imp.Processed, importChunk)
+		if err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return fmt.Errorf("rows from %d of %d are missing", imp.Processed, imp.TotalRows)
+		}
+
+		var p ports.ImportProgress
+		for _, row := range rows {
+			result, err := s.importRow(ctx, imp, row)
+			if err != nil {
+				return err
+			}
+			p.Results = append(p.Results, result)
+			if result.Error == "" {
+				p.Created++
+			} else {
+				p.Failed++
+			}
+		}
+
+		now := time.Now().UTC()
+		p.Processed = imp.Processed + len(rows)
+		p.LeaseUntil = now.Add(importLease)
+		p.At = now
+		if err := s.repo.SaveProgress(ctx, imp.ID, p); err != nil {
+			return err
+		}
+		imp.Processed = p.Processed
+		imp.Created += p.Created
+		imp.Failed += p.Failed
+		apimetrics.ShipmentImportRowsTotal.WithLabelValues("created").Add(float64(p.Created))
+		apimetrics.ShipmentImportRowsTotal.WithLabelValues("failed").Add(float64(p.Failed))
+	}
+	return s.repo.Complete(ctx, imp.ID, time.Now().UTC())
+}
+
+// importRow creates the shipment of a row. Rows that are invalid or cannot
+// be priced are reported in the result; any other error interrupts the
+// import, which is retried later.
+func (s *ShipmentImportService) importRow(ctx context.Context, imp *domain.ShipmentImport, row domain.ImportRow) (domain.ImportRowResult, error) {
+	result := domain.ImportRowResult{Line: row.Line}
+	input, err := s.decoder.Decode(row.Fields)
+	if err != nil {
+		result.Error = err.Error()
+		return result, nil
+	}
+	input.ClientID = imp.ClientID
+	input.IdempotencyKey = fmt.Sprintf("import:%s:%d", imp.ID, row.Line)
+
+	created, err := s.shipments.CreateShipment(ctx, input)
+	switch {
+	case err == nil:
+		result.TrackingNumber = created.TrackingNumber
+	case errors.Is(err, domain.ErrZoneNotServed),
+		errors.Is(err, domain.ErrServiceNotPriced),
+		errors.Is(err, domain.ErrCurrencyMismatch),
+		errors.Is(err, domain.ErrNoRateCard):
+		result.Error = err.Error()
+	default:
+		return result, fmt.Errorf("row %d: %w", row.Line, err)
+	}
+	return result, nil
+}
+
+func toImportView(imp *domain.ShipmentImport) *ports.ShipmentImportView {
+	view := &ports.ShipmentImportView{
+		ID:         imp.ID,
+		FileName:   imp.FileName,
+		Status:     string(imp.Status),
+		TotalRows:  imp.TotalRows,
+		Processed:  imp.Processed,
+		Created:    imp.Created,
+		Failed:     imp.Failed,
+		Shipments:  []ports.ImportRowItem{},
+		Errors:     []ports.ImportRowItem{},
+		CreatedAt:  imp.CreatedAt,
+		UpdatedAt:  imp.UpdatedAt,
+		FinishedAt: imp.FinishedAt,
+	}
+	for _, r := range imp.Results {
+		item := ports.ImportRowItem{Line: r.Line, TrackingNumber: r.TrackingNumber, Error: r.Error}
+		if r.Error == "" {
+			view.Shipments = append(view.Shipments, item)
+		} else {
+			view.Errors = append(view.Errors, item)
+		}
+	}
+	return view
+}
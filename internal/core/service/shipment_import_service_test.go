@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/99minutos/shipping-system/internal/core/domain"
+	"github.com/99minutos/shipping-system/internal/core/ports"
+)
+
+// stubImportRepo keeps imports and their rows in memory.
+type stubImportRepo struct {
+	imports map[string]*domain.ShipmentImport
+	rows    map[string][]domain.ImportRow
+}
+
+func newStubImportRepo() *stubImportRepo {
+	return &stubImportRepo{
+		imports: make(map[string]*domain.ShipmentImport),
+		rows:    make(map[string][]domain.ImportRow),
+	}
+}
+
+func (r *stubImportRepo) Create(_ context.Context, imp *domain.ShipmentImport, rows []domain.ImportRow) error {
+	for _, existing := range r.imports {
+		if existing.ClientID == imp.ClientID && existing.FileHash == imp.FileHash {
+			return domain.ErrImportExists
+		}
+	}
+	clone := *imp
+	r.imports[imp.ID] = &clone
+	r.rows[imp.ID] = rows
+	return nil
+}
+
+func (r *stubImportRepo) FindByID(_ context.Context, id, clientID string) (*domain.ShipmentImport, error) {
+	imp, ok := r.imports[id]
+	if !ok || (clientID != "" && imp.ClientID != clientID) {
+		return nil, domain.ErrImportNotFound
+	}
+	clone := *imp
+	return &clone, nil
+}
+
+func (r *stubImportRepo) FindByFileHash(_ context.Context, clientID, fileHash string) (*domain.ShipmentImport, error) {
+	for _, imp := range r.imports {
+		if imp.ClientID == clientID && imp.FileHash == fileHash {
+			clone := *imp
+			return &clone, nil
+		}
+	}
+	return nil, domain.ErrImportNotFound
+}
+
+func (r *stubImportRepo) Claim(_ context.Context, now, leaseUntil time.Time) (*domain.ShipmentImport, error) {
+	for _, imp := range r.imports {
+		if imp.Status == domain.ImportQueued || (imp.Status == domain.ImportRunning && imp.LeaseUntil.Before(now)) {
+			imp.Status = domain.ImportRunning
+			imp.LeaseUntil = leaseUntil
+			clone := *imp
+			return &clone, nil
+		}
+	}
+	return nil, domain.ErrImportNotFound
+}
+
+func (r *stubImportRepo) Rows(_ context.Context, id string, from, limit int) ([]domain.ImportRow, error) {
+	rows := r.rows[id]
+	if from >= len(rows) {
+		return nil, nil
+	}
+	return rows[from:min(from+limit, len(rows))], nil
+}
+
+func (r *stubImportRepo) SaveProgress(_ context.Context, id string, p ports.ImportProgress) error {
+	imp := r.imports[id]
+	if imp.Processed != p.Processed-len(p.Results) {
+		return domain.ErrConcurrentUpdate
+	}
+	imp.Processed = p.Processed
+	imp.Created += p.Created
+	imp.Failed += p.Failed
+	imp.Results = append(imp.Results, p.Results...)
+	imp.LeaseUntil = p.LeaseUntil
+	return nil
+}
+
+func (r *stubImportRepo) Complete(_ context.Context, id string, at time.Time) error {
+	r.imports[id].Status = domain.ImportCompleted
+	r.imports[id].FinishedAt = at
+	return nil
+}
+
+// stubRowDecoder reads a shipment from a service_type and zip cell, and
+// rejects rows without a service type.
+type stubRowDecoder struct{}
+
+func (stubRowDecoder) Decode(fields map[string]string) (ports.CreateShipmentInput, error) {
+	if fields["service_type"] == "" {
+		return ports.CreateShipmentInput{}, errors.New("service_type is required")
+	}
+	in := minimalInput("", fields["service_type"])
+	in.Destination.ZipCode = fields["zip"]
+	return in, nil
+}
+
+func importInput(clientID, hash string) ports.StartImportInput {
+	return ports.StartImportInput{
+		ClientID: clientID,
+		FileName: "orders.csv",
+		FileHash: hash,
+		Rows: []domain.ImportRow{
+			{Line: 2, Fields: map[string]string{"service_type": "next_day", "zip": "72000"}},
+			{Line: 3, Fields: map[string]string{"zip": "72000"}},
+			{Line: 5, Fields: map[string]string{"service_type": "standard", "zip": "06600"}},
+		},
+	}
+}
+
+func TestShipmentImportService_ImportsRows(t *testing.T) {
+	shipments := newStubShipmentRepo()
+	repo := newStubImportRepo()
+	svc := NewShipmentImportService(repo, NewShipmentService(shipments, nil, domain.BuiltinLifecycles(), domain.BuiltinCalendar(), discardLogger), stubRowDecoder{}, time.Second, discardLogger)
+
+	view, err := svc.StartImport(context.Background(), importInput("client_1", "abc"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if view.Status != string(domain.ImportQueued) || view.TotalRows != 3 || view.AlreadyExisted {
+		t.Fatalf("unexpected queued import: %+v", view)
+	}
+
+	if !svc.RunNext(context.Background()) {
+		t.Fatal("expected the queued import to run")
+	}
+	if svc.RunNext(context.Background()) {
+		t.Fatal("expected no import left to run")
+	}
+
+	view, err = svc.GetImport(context.Background(), ports.GetImportInput{ID: view.ID, Role: domain.RoleClient, ClientID: "client_1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if view.Status != string(domain.ImportCompleted) || view.Processed != 3 || view.Created != 2 || view.Failed != 1 {
+		t.Fatalf("unexpected completed import: %+v", view)
+	}
+	if len(view.Shipments) != 2 || view.Shipments[0].Line != 2 || view.Shipments[1].Line != 5 {
+		t.Fatalf("expected shipments of rows 2 and 5, got %+v", view.Shipments)
+	}
+	if len(view.Errors) != 1 || view.Errors[0].Line != 3 || view.Errors[0].Error != "service_type is required" {
+		t.Fatalf("expected the error of row 3, got %+v", view.Errors)
+	}
+	for _, s := range view.Shipments {
+		stored := shipments.byTracking[s.TrackingNumber]
+		if stored == nil || stored.ClientID != "client_1" {
+			t.Errorf("row %d: shipment %s not stored for client_1", s.Line, s.TrackingNumber)
+		}
+	}
+
+	// Other clients do not see the import.
+	if _, err := svc.GetImport(context.Background(), ports.GetImportInput{ID: view.ID, Role: domain.RoleClient, ClientID: "client_2"}); !errors.Is(err, domain.ErrImportNotFound) {
+		t.Fatalf("expected ErrImportNotFound for another client, got %v", err)
+	}
+}
+
+func TestShipmentImportService_SameFileIsImportedOnce(t *testing.T) {
+	shipments := newStubShipmentRepo()
+	repo := newStubImportRepo()
+	svc := NewShipmentImportService(repo, NewShipmentService(shipments, nil, domain.BuiltinLifecycles(), domain.BuiltinCalendar(), discardLogger), stubRowDecoder{}, time.Second, discardLogger)
+
+	first, _ := svc.StartImport(context.Background(), importInput("client_1", "abc"))
+	svc.RunNext(context.Background())
+
+	again, err := svc.StartImport(context.Background(), importInput("client_1", "abc"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !again.AlreadyExisted || again.ID != first.ID || again.Created != 2 {
+		t.Fatalf("expected the existing import, got %+v", again)
+	}
+	if len(repo.imports) != 1 || len(shipments.byTracking) != 2 {
+		t.Fatalf("re-upload must not import again: %d imports, %d shipments", len(repo.imports), len(shipments.byTracking))
+	}
+
+	// The same file from another client is another import.
+	other, _ := svc.StartImport(context.Background(), importInput("client_2", "abc"))
+	if other.AlreadyExisted || other.ID == first.ID {
+		t.Fatalf("expected a new import for client_2, got %+v", other)
+	}
+}
+
+func TestShipmentImportService_ResumedRowsAreNotDuplicated(t *testing.T) {
+	shipments := newStubShipmentRepo()
+	repo := newStubImportRepo()
+	svc := NewShipmentImportService(repo, NewShipmentService(shipments, nil, domain.BuiltinLifecycles(), domain.BuiltinCalendar(), discardLogger), stubRowDecoder{}, time.Second, discardLogger)
+
+	view, _ := svc.StartImport(context.Background(), importInput("client_1", "abc"))
+	svc.RunNext(context.Background())
+	created := repo.imports[view.ID].Results
+
+	// A worker that stopped before saving its progress leaves the rows to
+	// be run again once its lease expires.
+	imp := repo.imports[view.ID]
+	imp.Status, imp.Processed, imp.Created, imp.Failed, imp.Results = domain.ImportRunning, 0, 0, 0, nil
+	imp.LeaseUntil = time.Now().Add(-time.Second)
+
+	if !svc.RunNext(context.Background()) {
+		t.Fatal("expected the expired import to be resumed")
+	}
+	if len(shipments.byTracking) != 2 {
+		t.Fatalf("resumed rows must replay their shipments, got %d shipments", len(shipments.byTracking))
+	}
+	for i, r := range repo.imports[view.ID].Results {
+		if r.TrackingNumber != created[i].TrackingNumber {
+			t.Errorf("row %d: expected %q, got %q", r.Line, created[i].TrackingNumber, r.TrackingNumber)
+		}
+	}
+}
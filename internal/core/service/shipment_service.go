@@ -2,8 +2,9 @@ package service
 
 import (
 	"context"
-	"crypto/rand"
+	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/rs/zerolog"
@@ -14,93 +15,199 @@ import (
 )
 
 type ShipmentService struct {
-	repo   ports.ShipmentRepository
-	logger zerolog.Logger
+	repo       ports.ShipmentRepository
+	cards      ports.RateCardProvider // optional; nil creates shipments without a price
+	lifecycles *domain.Lifecycles
+	calendar   *domain.DeliveryCalendar
+	logger     zerolog.Logger
 }
 
-func NewShipmentService(repo ports.ShipmentRepository, logger zerolog.Logger) *ShipmentService {
-	return &ShipmentService{repo: repo, logger: logger}
+func NewShipmentService(repo ports.ShipmentRepository, cards ports.RateCardProvider, lifecycles *domain.Lifecycles, calendar *domain.DeliveryCalendar, logger zerolog.Logger) *ShipmentService {
+	return &ShipmentService{repo: repo, cards: cards, lifecycles: lifecycles, calendar: calendar, logger: logger}
 }
 
 // CreateShipment creates a new shipment. If an idempotency key is provided and
-// already seen, the previously created shipment is returned without side effects.
+// the client already used it, the previously created shipment is returned
+// without side effects.
 func (s *ShipmentService) CreateShipment(ctx context.Context, input ports.CreateShipmentInput) (*ports.ShipmentResult, error) {
 	if input.IdempotencyKey != "" {
-		existing, err := s.repo.FindByIdempotencyKey(ctx, input.IdempotencyKey)
+		existing, err := s.repo.FindByIdempotencyKey(ctx, input.ClientID, input.IdempotencyKey)
 		if err == nil && existing != nil {
 			s.logger.Info().Str("idempotency_key", input.IdempotencyKey).Str("tracking_number", existing.TrackingNumber).Msg("idempotent replay")
-			return &ports.ShipmentResult{
-				TrackingNumber:    existing.TrackingNumber,
-				Status:            string(existing.Status),
-				CreatedAt:         existing.CreatedAt,
-				EstimatedDelivery: existing.EstimatedDelivery,
-				AlreadyExisted:    true,
-			}, nil
+			return replayResult(existing), nil
+		}
+	}
+
+	shipment, err := s.newShipment(input, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Create(ctx, shipment); err != nil {
+		if errors.Is(err, domain.ErrDuplicateShipment) {
+			// A concurrent request with the same key created it first.
+			if existing, err := s.repo.FindByIdempotencyKey(ctx, input.ClientID, input.IdempotencyKey); err == nil {
+				return replayResult(existing), nil
+			}
+		}
+		s.logger.Error().Err(err).Msg("failed to create shipment")
+		return nil, err
+	}
+
+	s.logger.Info().Str("tracking_number", shipment.TrackingNumber).Str("client_id", input.ClientID).Msg("shipment created")
+	apimetrics.ShipmentsCreatedTotal.WithLabelValues(input.ServiceType).Inc()
+
+	return createdResult(shipment), nil
+}
+
+// CreateShipments creates a batch of shipments with a single insert. Each
+// input is handled as CreateShipment would: a known idempotency key replays
+// its shipment, and so does a key repeated within the batch. A shipment that
+// cannot be priced or inserted does not stop the others.
+func (s *ShipmentService) CreateShipments(ctx context.Context, inputs []ports.CreateShipmentInput) []ports.BatchShipmentResult {
+	results := make([]ports.BatchShipmentResult, len(inputs))
+
+	keys := make(map[string][]string) // client ID -> idempotency keys
+	for _, in := range inputs {
+		if in.IdempotencyKey != "" {
+			keys[in.ClientID] = append(keys[in.ClientID], in.IdempotencyKey)
+		}
+	}
+	existing := make(map[idempotencyKey]*domain.Shipment)
+	for clientID, clientKeys := range keys {
+		found, err := s.repo.FindByIdempotencyKeys(ctx, clientID, clientKeys)
+		if err != nil {
+			s.logger.Error().Err(err).Msg("failed to look up batch idempotency keys")
+			for i := range results {
+				results[i].Err = err
+			}
+			return results
+		}
+		for key, shipment := range found {
+			existing[idempotencyKey{clientID, key}] = shipment
 		}
 	}
 
 	now := time.Now().UTC()
+	var (
+		shipments []*domain.Shipment
+		indexes   []int
+		firstOf   = make(map[idempotencyKey]int) // index of the first new shipment with a key
+		repeats   = make(map[int]int)            // index of an input -> index of the first with its key
+	)
+	for i, in := range inputs {
+		key := idempotencyKey{in.ClientID, in.IdempotencyKey}
+		if e, ok := existing[key]; ok && key.key != "" {
+			results[i].Shipment = replayResult(e)
+			continue
+		}
+		if first, ok := firstOf[key]; ok && key.key != "" {
+			repeats[i] = first
+			continue
+		}
+		shipment, err := s.newShipment(in, now)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		if key.key != "" {
+			firstOf[key] = i
+		}
+		shipments = append(shipments, shipment)
+		indexes = append(indexes, i)
+	}
+
+	created := 0
+	if len(shipments) > 0 {
+		for j, err := range s.repo.CreateMany(ctx, shipments) {
+			i := indexes[j]
+			if errors.Is(err, domain.ErrDuplicateShipment) {
+				// A concurrent request with the same key created it first.
+				in := inputs[i]
+				if e, ferr := s.repo.FindByIdempotencyKey(ctx, in.ClientID, in.IdempotencyKey); ferr == nil {
+					results[i].Shipment = replayResult(e)
+					continue
+				}
+			}
+			if err != nil {
+				s.logger.Error().Err(err).Int("index", i).Msg("failed to create batch shipment")
+				results[i].Err = err
+				continue
+			}
+			results[i].Shipment = createdResult(shipments[j])
+			apimetrics.ShipmentsCreatedTotal.WithLabelValues(shipments[j].ServiceType).Inc()
+			created++
+		}
+	}
+	for i, first := range repeats {
+		results[i] = results[first]
+		if r := results[first].Shipment; r != nil {
+			replay := *r
+			replay.AlreadyExisted = true
+			results[i].Shipment = &replay
+		}
+	}
+
+	s.logger.Info().Int("total", len(inputs)).Int("created", created).Msg("shipment batch created")
+	return results
+}
+
+// newShipment builds a shipment in its initial status from input, priced
+// when rate cards are configured. The repository assigns its tracking number.
+func (s *ShipmentService) newShipment(input ports.CreateShipmentInput, now time.Time) (*domain.Shipment, error) {
+	initial := s.lifecycles.For(input.ServiceType, input.ClientID).Initial
 	shipment := &domain.Shipment{
-		TrackingNumber:    generateTrackingNumber(),
 		ClientID:          input.ClientID,
-		Status:            domain.StatusCreated,
+		Status:            initial,
 		ServiceType:       input.ServiceType,
 		CreatedAt:         now,
-		EstimatedDelivery: estimatedDelivery(input.ServiceType, now),
+		EstimatedDelivery: s.calendar.EstimateDelivery(input.ServiceType, input.Destination.ZipCode, now),
 		IdempotencyKey:    input.IdempotencyKey,
 		StatusHistory: []domain.StatusHistoryEntry{
-			{Status: domain.StatusCreated, Timestamp: now},
+			{Status: initial, Timestamp: now},
 		},
 		Sender: domain.Person{
 			Name:  input.Sender.Name,
 			Email: input.Sender.Email,
 			Phone: input.Sender.Phone,
 		},
-		Origin: domain.Address{
-			Address: input.Origin.Address,
-			City:    input.Origin.City,
-			ZipCode: input.Origin.ZipCode,
-			Coordinates: domain.Coordinates{
-				Lat: input.Origin.Coordinates.Lat,
-				Lng: input.Origin.Coordinates.Lng,
-			},
-		},
-		Destination: domain.Address{
-			Address: input.Destination.Address,
-			City:    input.Destination.City,
-			ZipCode: input.Destination.ZipCode,
-			Coordinates: domain.Coordinates{
-				Lat: input.Destination.Coordinates.Lat,
-				Lng: input.Destination.Coordinates.Lng,
-			},
-		},
-		Package: domain.Package{
-			WeightKg: input.Package.WeightKg,
-			Dimensions: domain.Dimensions{
-				LengthCm: input.Package.Dimensions.LengthCm,
-				WidthCm:  input.Package.Dimensions.WidthCm,
-				HeightCm: input.Package.Dimensions.HeightCm,
-			},
-			Description:   input.Package.Description,
-			DeclaredValue: input.Package.DeclaredValue,
-			Currency:      input.Package.Currency,
-		},
+		Recipient:   toDomainRecipient(input.Recipient),
+		Origin:      toDomainAddress(input.Origin),
+		Destination: toDomainAddress(input.Destination),
+		Package:     toDomainPackage(input.Package),
 	}
+	shipment.Pieces = toDomainPieces(input.Pieces, initial)
 
-	if err := s.repo.Create(ctx, shipment); err != nil {
-		s.logger.Error().Err(err).Msg("failed to create shipment")
-		return nil, err
+	if s.cards != nil {
+		price, err := priceShipment(s.cards, shipment, input.Insured, now)
+		if err != nil {
+			return nil, fmt.Errorf("price shipment: %w", err)
+		}
+		shipment.Price = price
 	}
+	return shipment, nil
+}
 
-	s.logger.Info().Str("tracking_number", shipment.TrackingNumber).Str("client_id", input.ClientID).Msg("shipment created")
-	apimetrics.ShipmentsCreatedTotal.WithLabelValues(input.ServiceType).Inc()
-
+func createdResult(shipment *domain.Shipment) *ports.ShipmentResult {
 	return &ports.ShipmentResult{
 		TrackingNumber:    shipment.TrackingNumber,
 		Status:            string(shipment.Status),
 		CreatedAt:         shipment.CreatedAt,
 		EstimatedDelivery: shipment.EstimatedDelivery,
-	}, nil
+	}
+}
+
+// idempotencyKey is an idempotency key with the client that used it; keys
+// of different clients never match.
+type idempotencyKey struct {
+	clientID, key string
+}
+
+// replayResult answers a create whose idempotency key matched existing.
+func replayResult(existing *domain.Shipment) *ports.ShipmentResult {
+	result := createdResult(existing)
+	result.AlreadyExisted = true
+	return result
 }
 
 // GetShipment retrieves a shipment with its full status history.
@@ -117,15 +224,36 @@ func (s *ShipmentService) GetShipment(ctx context.Context, input ports.GetShipme
 		return nil, err // ErrShipmentNotFound is returned as-is
 	}
 
+	return toShipmentDetail(shipment), nil
+}
+
+// toShipmentDetail maps a shipment to the full view returned by GetShipment
+// and AmendShipment.
+func toShipmentDetail(shipment *domain.Shipment) *ports.ShipmentDetail {
 	history := make([]ports.StatusHistoryItem, len(shipment.StatusHistory))
 	for i, h := range shipment.StatusHistory {
 		history[i] = ports.StatusHistoryItem{
-			Status:    string(h.Status),
-			Timestamp: h.Timestamp,
-			Notes:     h.Notes,
+			Status:     string(h.Status),
+			Timestamp:  h.Timestamp,
+			Notes:      h.Notes,
+			ReasonCode: h.ReasonCode,
+			Actor:      h.Actor,
+			Reason:     h.Reason,
+			Piece:      h.Piece,
+			Attempt:    h.Attempt,
+			Late:       h.Late,
 		}
 	}
 
+	amendments := make([]ports.AmendmentItem, len(shipment.Amendments))
+	for i, a := range shipment.Amendments {
+		changes := make([]ports.FieldChangeItem, len(a.Changes))
+		for j, c := range a.Changes {
+			changes[j] = ports.FieldChangeItem{Field: c.Field, From: c.From, To: c.To}
+		}
+		amendments[i] = ports.AmendmentItem{Version: a.Version, Actor: a.Actor, At: a.At, Changes: changes}
+	}
+
 	return &ports.ShipmentDetail{
 		TrackingNumber:    shipment.TrackingNumber,
 		Status:            string(shipment.Status),
@@ -137,6 +265,7 @@ func (s *ShipmentService) GetShipment(ctx context.Context, input ports.GetShipme
 			Email: shipment.Sender.Email,
 			Phone: shipment.Sender.Phone,
 		},
+		Recipient: toRecipientInput(shipment.Recipient),
 		Origin: ports.AddressInput{
 			Address: shipment.Origin.Address,
 			City:    shipment.Origin.City,
@@ -166,10 +295,304 @@ func (s *ShipmentService) GetShipment(ctx context.Context, input ports.GetShipme
 			DeclaredValue: shipment.Package.DeclaredValue,
 			Currency:      shipment.Package.Currency,
 		},
-		StatusHistory: history,
+		Pieces:           toPieceItems(shipment.Pieces),
+		Price:            toQuote(shipment.Price),
+		DeliveryAttempts: shipment.DeliveryAttempts,
+		Version:          shipment.Version,
+		Amendments:       amendments,
+		StatusHistory:    history,
+	}
+}
+
+func toPieceItems(pieces []domain.Piece) []ports.PieceItem {
+	if len(pieces) == 0 {
+		return nil
+	}
+	items := make([]ports.PieceItem, len(pieces))
+	for i, p := range pieces {
+		items[i] = ports.PieceItem{
+			Barcode:  p.Barcode,
+			WeightKg: p.WeightKg,
+			Dimensions: ports.DimensionsInput{
+				LengthCm: p.Dimensions.LengthCm,
+				WidthCm:  p.Dimensions.WidthCm,
+				HeightCm: p.Dimensions.HeightCm,
+			},
+			Status:           string(p.Status),
+			DeliveryAttempts: p.DeliveryAttempts,
+		}
+	}
+	return items
+}
+
+func toDomainAddress(a ports.AddressInput) domain.Address {
+	return domain.Address{
+		Address: a.Address,
+		City:    a.City,
+		ZipCode: a.ZipCode,
+		Coordinates: domain.Coordinates{
+			Lat: a.Coordinates.Lat,
+			Lng: a.Coordinates.Lng,
+		},
+	}
+}
+
+func toDomainPackage(p ports.PackageInput) domain.Package {
+	return domain.Package{
+		WeightKg: p.WeightKg,
+		Dimensions: domain.Dimensions{
+			LengthCm: p.Dimensions.LengthCm,
+			WidthCm:  p.Dimensions.WidthCm,
+			HeightCm: p.Dimensions.HeightCm,
+		},
+		Description:   p.Description,
+		DeclaredValue: p.DeclaredValue,
+		Currency:      p.Currency,
+	}
+}
+
+// toDomainPieces builds the pieces of a new shipment. They are barcoded once
+// the shipment has a tracking number.
+func toDomainPieces(pieces []ports.PieceInput, status domain.ShipmentStatus) []domain.Piece {
+	var out []domain.Piece
+	for _, p := range pieces {
+		out = append(out, domain.Piece{
+			WeightKg: p.WeightKg,
+			Dimensions: domain.Dimensions{
+				LengthCm: p.Dimensions.LengthCm,
+				WidthCm:  p.Dimensions.WidthCm,
+				HeightCm: p.Dimensions.HeightCm,
+			},
+			Status: status,
+		})
+	}
+	return out
+}
+
+func toDomainRecipient(r ports.RecipientInput) domain.Recipient {
+	out := domain.Recipient{
+		Name:         r.Name,
+		Phone:        r.Phone,
+		Email:        r.Email,
+		Instructions: r.Instructions,
+	}
+	if c := r.AlternativeContact; c != nil {
+		out.AlternativeContact = &domain.Person{Name: c.Name, Email: c.Email, Phone: c.Phone}
+	}
+	return out
+}
+
+func toRecipientInput(r domain.Recipient) ports.RecipientInput {
+	out := ports.RecipientInput{
+		Name:         r.Name,
+		Phone:        r.Phone,
+		Email:        r.Email,
+		Instructions: r.Instructions,
+	}
+	if c := r.AlternativeContact; c != nil {
+		out.AlternativeContact = &ports.SenderInput{Name: c.Name, Email: c.Email, Phone: c.Phone}
+	}
+	return out
+}
+
+// CancelShipment cancels a shipment on behalf of its client or an admin.
+// Clients can only cancel their own shipments, and only from states their
+// lifecycle allows cancelling.
+func (s *ShipmentService) CancelShipment(ctx context.Context, input ports.CancelShipmentInput) (*ports.CancelShipmentResult, error) {
+	filterClientID := ""
+	if input.Role == domain.RoleClient {
+		filterClientID = input.ClientID
+	}
+
+	shipment, err := s.repo.FindByTrackingNumber(ctx, input.TrackingNumber, filterClientID)
+	if err != nil {
+		return nil, err
+	}
+	if shipment.Status == domain.StatusCancelled {
+		return nil, domain.ErrShipmentCancelled
+	}
+	lifecycle := s.lifecycles.Of(shipment)
+	if err := lifecycle.Check(shipment.Status, domain.StatusCancelled, input.Role, cancelSource); err != nil {
+		return nil, fmt.Errorf("cancel shipment: %w", err)
+	}
+
+	entry := domain.StatusHistoryEntry{
+		Status:    domain.StatusCancelled,
+		Timestamp: time.Now().UTC(),
+		Notes:     cancelSource,
+		Actor:     input.Actor,
+		Reason:    input.Reason,
+	}
+	// Every piece still moving is cancelled along with the shipment.
+	for i := range shipment.Pieces {
+		if p := &shipment.Pieces[i]; !lifecycle.IsTerminal(p.Status) {
+			p.Status, p.LastEventAt = domain.StatusCancelled, entry.Timestamp
+		}
+	}
+	if err := s.repo.TransitionStatus(ctx, shipment.TrackingNumber, shipment.Status, entry, shipment.Pieces); err != nil {
+		return nil, fmt.Errorf("cancel shipment: %w", err)
+	}
+
+	s.logger.Info().
+		Str("tracking_number", shipment.TrackingNumber).
+		Str("from", string(shipment.Status)).
+		Str("actor", input.Actor).
+		Msg("shipment cancelled")
+	apimetrics.ShipmentsCancelledTotal.WithLabelValues(input.Role).Inc()
+
+	return &ports.CancelShipmentResult{
+		TrackingNumber: shipment.TrackingNumber,
+		Status:         string(domain.StatusCancelled),
+		CancelledAt:    entry.Timestamp,
+		Reason:         entry.Reason,
 	}, nil
 }
 
+// AmendShipment corrects the destination, sender or recipient contact, package
+// description or service type of a shipment that has not been picked up yet. Each call
+// that changes something is recorded as a new version in the amendment log;
+// changing the service type or destination zip code recalculates the
+// estimated delivery from now, and changing where or how it ships prices it
+// again.
+func (s *ShipmentService) AmendShipment(ctx context.Context, input ports.AmendShipmentInput) (*ports.ShipmentDetail, error) {
+	filterClientID := ""
+	if input.Role == domain.RoleClient {
+		filterClientID = input.ClientID
+	}
+
+	shipment, err := s.repo.FindByTrackingNumber(ctx, input.TrackingNumber, filterClientID)
+	if err != nil {
+		return nil, err
+	}
+	if !shipment.Amendable(s.lifecycles.Of(shipment)) {
+		return nil, domain.ErrShipmentLocked
+	}
+
+	now := time.Now().UTC()
+	var changes []domain.FieldChange
+	set := func(field string, dst, v *string) {
+		if v != nil && *v != *dst {
+			changes = append(changes, domain.FieldChange{Field: field, From: *dst, To: *v})
+			*dst = *v
+		}
+	}
+	set("sender.name", &shipment.Sender.Name, input.Sender.Name)
+	set("sender.email", &shipment.Sender.Email, input.Sender.Email)
+	set("sender.phone", &shipment.Sender.Phone, input.Sender.Phone)
+	set("recipient.name", &shipment.Recipient.Name, input.Recipient.Name)
+	set("recipient.phone", &shipment.Recipient.Phone, input.Recipient.Phone)
+	set("recipient.email", &shipment.Recipient.Email, input.Recipient.Email)
+	set("recipient.instructions", &shipment.Recipient.Instructions, input.Recipient.Instructions)
+	set("destination.address", &shipment.Destination.Address, input.Destination.Address)
+	set("destination.city", &shipment.Destination.City, input.Destination.City)
+	set("destination.zip_code", &shipment.Destination.ZipCode, input.Destination.ZipCode)
+	if c := input.Destination.Coordinates; c != nil {
+		next := domain.Coordinates{Lat: c.Lat, Lng: c.Lng}
+		if next != shipment.Destination.Coordinates {
+			changes = append(changes, domain.FieldChange{
+				Field: "destination.coordinates",
+				From:  formatCoordinates(shipment.Destination.Coordinates),
+				To:    formatCoordinates(next),
+			})
+			shipment.Destination.Coordinates = next
+		}
+	}
+	set("package.description", &shipment.Package.Description, input.Description)
+	if input.ServiceType != nil && *input.ServiceType != shipment.ServiceType {
+		// A service type with its own lifecycle must start where the
+		// shipment is now.
+		next := s.lifecycles.For(*input.ServiceType, shipment.ClientID)
+		if next.Initial != shipment.Status {
+			return nil, fmt.Errorf("amend shipment: %w: service type %s starts at %s",
+				domain.ErrInvalidTransition, *input.ServiceType, next.Initial)
+		}
+		set("service_type", &shipment.ServiceType, input.ServiceType)
+	}
+	if changesETA(changes) {
+		eta := s.calendar.EstimateDelivery(shipment.ServiceType, shipment.Destination.ZipCode, now)
+		if !eta.Equal(shipment.EstimatedDelivery) {
+			changes = append(changes, domain.FieldChange{
+				Field: "estimated_delivery",
+				From:  shipment.EstimatedDelivery.UTC().Format(time.RFC3339),
+				To:    eta.Format(time.RFC3339),
+			})
+			shipment.EstimatedDelivery = eta
+		}
+	}
+	if s.cards != nil && shipment.Price != nil && changesPrice(changes) {
+		price, err := priceShipment(s.cards, shipment, shipment.Price.Insured, now)
+		if err != nil {
+			return nil, fmt.Errorf("amend shipment: %w", err)
+		}
+		if price.Total != shipment.Price.Total {
+			changes = append(changes, domain.FieldChange{
+				Field: "price.total",
+				From:  strconv.FormatFloat(shipment.Price.Total, 'f', 2, 64),
+				To:    strconv.FormatFloat(price.Total, 'f', 2, 64),
+			})
+		}
+		shipment.Price = price
+	}
+	if len(changes) == 0 {
+		return toShipmentDetail(shipment), nil
+	}
+
+	from := shipment.Version
+	shipment.Version++
+	shipment.Amendments = append(shipment.Amendments, domain.Amendment{
+		Version: shipment.Version,
+		Actor:   input.Actor,
+		At:      now,
+		Changes: changes,
+	})
+	if err := s.repo.Amend(ctx, shipment, from); err != nil {
+		return nil, fmt.Errorf("amend shipment: %w", err)
+	}
+
+	s.logger.Info().
+		Str("tracking_number", shipment.TrackingNumber).
+		Int("version", shipment.Version).
+		Int("changes", len(changes)).
+		Str("actor", input.Actor).
+		Msg("shipment amended")
+	apimetrics.ShipmentsAmendedTotal.Inc()
+
+	return toShipmentDetail(shipment), nil
+}
+
+// changesETA reports whether an amendment touches a field the estimated
+// delivery depends on.
+func changesETA(changes []domain.FieldChange) bool {
+	for _, c := range changes {
+		switch c.Field {
+		case "destination.zip_code", "service_type":
+			return true
+		}
+	}
+	return false
+}
+
+// changesPrice reports whether an amendment touches a field the shipment is
+// priced on, so that it has to be priced again with the current rate card.
+func changesPrice(changes []domain.FieldChange) bool {
+	for _, c := range changes {
+		switch c.Field {
+		case "destination.zip_code", "destination.coordinates", "service_type":
+			return true
+		}
+	}
+	return false
+}
+
+// formatCoordinates renders coordinates as "lat,lng" for the amendment log.
+func formatCoordinates(c domain.Coordinates) string {
+	return strconv.FormatFloat(c.Lat, 'f', -1, 64) + "," + strconv.FormatFloat(c.Lng, 'f', -1, 64)
+}
+
+// cancelSource is the event source checked against lifecycle restrictions
+// and recorded for cancellations made through the API.
+const cancelSource = "api"
+
 const (
 	defaultLimit = 20
 	maxLimit     = 100
@@ -199,7 +622,8 @@ func (s *ShipmentService) ListShipments(ctx context.Context, input ports.ListShi
 
 	filter := ports.ListShipmentsFilter{
 		ClientID:    clientIDFilter,
-		Status:      input.Status,
+		Statuses:    input.Statuses,
+		MinAttempts: input.MinAttempts,
 		ServiceType: input.ServiceType,
 		Search:      input.Search,
 		DateFrom:    input.DateFrom,
@@ -223,11 +647,14 @@ func (s *ShipmentService) ListShipments(ctx context.Context, input ports.ListShi
 			ClientID:          sh.ClientID,
 			CreatedAt:         sh.CreatedAt,
 			EstimatedDelivery: sh.EstimatedDelivery,
+			DeliveryAttempts:  sh.DeliveryAttempts,
+			Pieces:            toPieceItems(sh.Pieces),
 			Sender: ports.SenderInput{
 				Name:  sh.Sender.Name,
 				Email: sh.Sender.Email,
 				Phone: sh.Sender.Phone,
 			},
+			Recipient: toRecipientInput(sh.Recipient),
 			Origin: ports.AddressInput{
 				Address: sh.Origin.Address,
 				City:    sh.Origin.City,
@@ -262,24 +689,3 @@ func (s *ShipmentService) ListShipments(ctx context.Context, input ports.ListShi
 		TotalPages: totalPages,
 	}, nil
 }
-func generateTrackingNumber() string {
-	b := make([]byte, 4)
-	if _, err := rand.Read(b); err != nil {
-		// fallback: use current nanoseconds
-		return fmt.Sprintf("99M-%08X", time.Now().UnixNano()&0xFFFFFFFF)
-	}
-	return fmt.Sprintf("99M-%08X", b)
-}
-
-// estimatedDelivery calculates the estimated delivery time based on service type.
-func estimatedDelivery(serviceType string, from time.Time) time.Time {
-	base := time.Date(from.Year(), from.Month(), from.Day(), 18, 0, 0, 0, time.UTC)
-	switch serviceType {
-	case "same_day":
-		return base
-	case "next_day":
-		return base.AddDate(0, 0, 1)
-	default: // "standard" or unknown → 3 days
-		return base.AddDate(0, 0, 3)
-	}
-}
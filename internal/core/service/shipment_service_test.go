@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"errors"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -18,16 +19,19 @@ import (
 // ---------------------------------------------------------------------------
 
 type stubShipmentRepo struct {
-	byTracking     map[string]*domain.Shipment
-	byIdempotency  map[string]*domain.Shipment
-	lastFindFilter string // clientID passed to the last FindByTrackingNumber call
-	createErr      error  // if set, Create returns this error
+	byTracking      map[string]*domain.Shipment
+	byIdempotency   map[string]*domain.Shipment
+	lastFindFilter  string // clientID passed to the last FindByTrackingNumber call
+	createErr       error  // if set, Create returns this error
+	trackingNumbers ports.TrackingNumberGenerator
 }
 
 func newStubShipmentRepo() *stubShipmentRepo {
+	trackingNumbers, _ := domain.NewTrackingNumbers(domain.DefaultTrackingPrefix, nil)
 	return &stubShipmentRepo{
-		byTracking:    make(map[string]*domain.Shipment),
-		byIdempotency: make(map[string]*domain.Shipment),
+		byTracking:      make(map[string]*domain.Shipment),
+		byIdempotency:   make(map[string]*domain.Shipment),
+		trackingNumbers: trackingNumbers,
 	}
 }
 
@@ -35,14 +39,32 @@ func (r *stubShipmentRepo) Create(_ context.Context, s *domain.Shipment) error {
 	if r.createErr != nil {
 		return r.createErr
 	}
+	for {
+		s.AssignTrackingNumber(r.trackingNumbers.Generate(s.ClientID))
+		if _, taken := r.byTracking[s.TrackingNumber]; !taken {
+			break
+		}
+	}
+	// Mirrors the unique (client_id, idempotency_key) index.
+	if _, used := r.byIdempotency[s.ClientID+"/"+s.IdempotencyKey]; used && s.IdempotencyKey != "" {
+		return domain.ErrDuplicateShipment
+	}
 	clone := *s
 	r.byTracking[s.TrackingNumber] = &clone
 	if s.IdempotencyKey != "" {
-		r.byIdempotency[s.IdempotencyKey] = &clone
+		r.byIdempotency[s.ClientID+"/"+s.IdempotencyKey] = &clone
 	}
 	return nil
 }
 
+func (r *stubShipmentRepo) CreateMany(ctx context.Context, shipments []*domain.Shipment) []error {
+	errs := make([]error, len(shipments))
+	for i, s := range shipments {
+		errs[i] = r.Create(ctx, s)
+	}
+	return errs
+}
+
 func (r *stubShipmentRepo) FindByTrackingNumber(_ context.Context, trackingNumber, clientID string) (*domain.Shipment, error) {
 	r.lastFindFilter = clientID
 	s, ok := r.byTracking[trackingNumber]
@@ -57,8 +79,8 @@ func (r *stubShipmentRepo) FindByTrackingNumber(_ context.Context, trackingNumbe
 	return &clone, nil
 }
 
-func (r *stubShipmentRepo) FindByIdempotencyKey(_ context.Context, key string) (*domain.Shipment, error) {
-	s, ok := r.byIdempotency[key]
+func (r *stubShipmentRepo) FindByIdempotencyKey(_ context.Context, clientID, key string) (*domain.Shipment, error) {
+	s, ok := r.byIdempotency[clientID+"/"+key]
 	if !ok {
 		return nil, domain.ErrShipmentNotFound
 	}
@@ -66,6 +88,42 @@ func (r *stubShipmentRepo) FindByIdempotencyKey(_ context.Context, key string) (
 	return &clone, nil
 }
 
+func (r *stubShipmentRepo) FindByIdempotencyKeys(_ context.Context, clientID string, keys []string) (map[string]*domain.Shipment, error) {
+	byKey := make(map[string]*domain.Shipment)
+	for _, key := range keys {
+		if s, ok := r.byIdempotency[clientID+"/"+key]; ok {
+			clone := *s
+			byKey[key] = &clone
+		}
+	}
+	return byKey, nil
+}
+
+// TransitionStatus mirrors the real repo's conditional update.
+func (r *stubShipmentRepo) TransitionStatus(_ context.Context, trackingNumber string, from domain.ShipmentStatus, entry domain.StatusHistoryEntry, pieces []domain.Piece) error {
+	s, ok := r.byTracking[trackingNumber]
+	if !ok || s.Status != from {
+		return domain.ErrConcurrentUpdate
+	}
+	s.Status = entry.Status
+	if pieces != nil {
+		s.Pieces = pieces
+	}
+	s.StatusHistory = append(s.StatusHistory, entry)
+	return nil
+}
+
+// Amend mirrors the real repo's conditional update.
+func (r *stubShipmentRepo) Amend(_ context.Context, s *domain.Shipment, from int) error {
+	stored, ok := r.byTracking[s.TrackingNumber]
+	if !ok || stored.Status != s.Status || stored.Version != from {
+		return domain.ErrConcurrentUpdate
+	}
+	clone := *s
+	r.byTracking[s.TrackingNumber] = &clone
+	return nil
+}
+
 // List applies the same filters the real Mongo repo would use.
 func (r *stubShipmentRepo) List(_ context.Context, f ports.ListShipmentsFilter) ([]*domain.Shipment, int64, error) {
 	if r.createErr != nil {
@@ -77,7 +135,10 @@ func (r *stubShipmentRepo) List(_ context.Context, f ports.ListShipmentsFilter)
 		if f.ClientID != "" && s.ClientID != f.ClientID {
 			continue
 		}
-		if f.Status != "" && string(s.Status) != f.Status {
+		if len(f.Statuses) > 0 && !slices.Contains(f.Statuses, string(s.Status)) {
+			continue
+		}
+		if s.DeliveryAttempts < f.MinAttempts {
 			continue
 		}
 		if f.ServiceType != "" && s.ServiceType != f.ServiceType {
@@ -91,7 +152,8 @@ func (r *stubShipmentRepo) List(_ context.Context, f ports.ListShipmentsFilter)
 		}
 		if f.Search != "" {
 			trackingMatch := strings.Contains(strings.ToLower(s.TrackingNumber), strings.ToLower(f.Search))
-			nameMatch := strings.Contains(strings.ToLower(s.Sender.Name), strings.ToLower(f.Search))
+			nameMatch := strings.Contains(strings.ToLower(s.Sender.Name), strings.ToLower(f.Search)) ||
+				strings.Contains(strings.ToLower(s.Recipient.Name), strings.ToLower(f.Search))
 			if !trackingMatch && !nameMatch {
 				continue
 			}
@@ -149,14 +211,14 @@ func minimalInput(clientID, serviceType string) ports.CreateShipmentInput {
 
 func TestShipmentService_Create_Success(t *testing.T) {
 	repo := newStubShipmentRepo()
-	svc := NewShipmentService(repo, discardLogger)
+	svc := NewShipmentService(repo, nil, domain.BuiltinLifecycles(), domain.BuiltinCalendar(), discardLogger)
 
 	result, err := svc.CreateShipment(context.Background(), minimalInput("client_1", "next_day"))
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
-	if !strings.HasPrefix(result.TrackingNumber, "99M-") {
+	if !strings.HasPrefix(result.TrackingNumber, "99M-") || !domain.ValidTrackingNumber(result.TrackingNumber) {
 		t.Errorf("tracking number format wrong: %s", result.TrackingNumber)
 	}
 	if result.Status != string(domain.StatusCreated) {
@@ -173,9 +235,61 @@ func TestShipmentService_Create_Success(t *testing.T) {
 	}
 }
 
+func TestShipmentService_Create_TrackingNumber(t *testing.T) {
+	repo := newStubShipmentRepo()
+	gen, err := domain.NewTrackingNumbers("99M", map[string]string{"client_acme": "ACME"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	repo.trackingNumbers = gen
+	svc := NewShipmentService(repo, nil, domain.BuiltinLifecycles(), domain.BuiltinCalendar(), discardLogger)
+	const alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+	for _, tc := range []struct{ clientID, prefix string }{
+		{"client_1", "99M-"},
+		{"client_acme", "ACME-"},
+	} {
+		result, err := svc.CreateShipment(context.Background(), minimalInput(tc.clientID, "next_day"))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		tn := result.TrackingNumber
+		if !strings.HasPrefix(tn, tc.prefix) || len(tn) != len(tc.prefix)+11 {
+			t.Fatalf("%s: expected %sXXXXXXXXXXC, got %s", tc.clientID, tc.prefix, tn)
+		}
+		if !domain.ValidTrackingNumber(tn) {
+			t.Fatalf("%s: check character rejected: %s", tc.clientID, tn)
+		}
+
+		for i := len(tc.prefix); i < len(tn); i++ {
+			typo := []byte(tn)
+			typo[i] = alphabet[(strings.IndexByte(alphabet, tn[i])+7)%len(alphabet)]
+			if domain.ValidTrackingNumber(string(typo)) {
+				t.Errorf("mistyped character %d accepted: %s", i, typo)
+			}
+			if i+1 < len(tn) && tn[i] != tn[i+1] {
+				swap := []byte(tn)
+				swap[i], swap[i+1] = swap[i+1], swap[i]
+				if domain.ValidTrackingNumber(string(swap)) {
+					t.Errorf("swapped characters %d and %d accepted: %s", i, i+1, swap)
+				}
+			}
+		}
+	}
+
+	for _, tn := range []string{"99M-AABBCCDD", "99m-aabbccdd", "99M-AABBCCD", "99M", "X-AABBCCDD", ""} {
+		if got, want := domain.ValidTrackingNumber(tn), tn == "99M-AABBCCDD"; got != want {
+			t.Errorf("ValidTrackingNumber(%q) = %v, want %v", tn, got, want)
+		}
+	}
+	if _, err := domain.NewTrackingNumbers("99M", map[string]string{"client_acme": "acme"}); err == nil {
+		t.Error("expected lowercase client prefix to be rejected")
+	}
+}
+
 func TestShipmentService_Create_SetsInitialStatusHistory(t *testing.T) {
 	repo := newStubShipmentRepo()
-	svc := NewShipmentService(repo, discardLogger)
+	svc := NewShipmentService(repo, nil, domain.BuiltinLifecycles(), domain.BuiltinCalendar(), discardLogger)
 
 	result, _ := svc.CreateShipment(context.Background(), minimalInput("client_1", "standard"))
 
@@ -193,7 +307,7 @@ func TestShipmentService_Create_SetsInitialStatusHistory(t *testing.T) {
 
 func TestShipmentService_Create_StoresClientID(t *testing.T) {
 	repo := newStubShipmentRepo()
-	svc := NewShipmentService(repo, discardLogger)
+	svc := NewShipmentService(repo, nil, domain.BuiltinLifecycles(), domain.BuiltinCalendar(), discardLogger)
 
 	result, _ := svc.CreateShipment(context.Background(), minimalInput("client_42", "standard"))
 
@@ -206,7 +320,7 @@ func TestShipmentService_Create_StoresClientID(t *testing.T) {
 func TestShipmentService_Create_RepoError(t *testing.T) {
 	repo := newStubShipmentRepo()
 	repo.createErr = errors.New("db unavailable")
-	svc := NewShipmentService(repo, discardLogger)
+	svc := NewShipmentService(repo, nil, domain.BuiltinLifecycles(), domain.BuiltinCalendar(), discardLogger)
 
 	_, err := svc.CreateShipment(context.Background(), minimalInput("client_1", "standard"))
 	if err == nil {
@@ -220,7 +334,7 @@ func TestShipmentService_Create_RepoError(t *testing.T) {
 
 func TestShipmentService_Create_IdempotencyReplay(t *testing.T) {
 	repo := newStubShipmentRepo()
-	svc := NewShipmentService(repo, discardLogger)
+	svc := NewShipmentService(repo, nil, domain.BuiltinLifecycles(), domain.BuiltinCalendar(), discardLogger)
 
 	input := minimalInput("client_1", "next_day")
 	input.IdempotencyKey = "key-abc-123"
@@ -247,9 +361,119 @@ func TestShipmentService_Create_IdempotencyReplay(t *testing.T) {
 	}
 }
 
+func TestShipmentService_CreateShipments(t *testing.T) {
+	repo := newStubShipmentRepo()
+	svc := NewShipmentService(repo, stubRateCards{card: testRateCard()}, domain.BuiltinLifecycles(), domain.BuiltinCalendar(), discardLogger)
+
+	seeded := minimalInput("client_1", "next_day")
+	seeded.IdempotencyKey = "order-1"
+	first, err := svc.CreateShipment(context.Background(), seeded)
+	if err != nil {
+		t.Fatalf("seed create failed: %v", err)
+	}
+
+	input := func(key, serviceType string) ports.CreateShipmentInput {
+		in := minimalInput("client_1", serviceType)
+		in.IdempotencyKey = key
+		return in
+	}
+	results := svc.CreateShipments(context.Background(), []ports.CreateShipmentInput{
+		input("order-1", "next_day"), // already created
+		input("order-2", "standard"),
+		input("order-3", "same_day"), // not in the rate card
+		input("order-2", "standard"), // repeated within the batch
+		input("order-4", "next_day"),
+	})
+
+	if len(results) != 5 {
+		t.Fatalf("expected 5 results, got %d", len(results))
+	}
+	if r := results[0]; r.Err != nil || !r.Shipment.AlreadyExisted || r.Shipment.TrackingNumber != first.TrackingNumber {
+		t.Errorf("known key must replay the seeded shipment: %+v", r)
+	}
+	for _, i := range []int{1, 4} {
+		if r := results[i]; r.Err != nil || r.Shipment.AlreadyExisted || !domain.ValidTrackingNumber(r.Shipment.TrackingNumber) {
+			t.Errorf("item %d must be created: %+v", i, r)
+		}
+	}
+	if r := results[2]; !errors.Is(r.Err, domain.ErrServiceNotPriced) || r.Shipment != nil {
+		t.Errorf("unpriced item must fail with ErrServiceNotPriced: %+v", r)
+	}
+	if r := results[3]; r.Err != nil || !r.Shipment.AlreadyExisted || r.Shipment.TrackingNumber != results[1].Shipment.TrackingNumber {
+		t.Errorf("repeated key must replay the first shipment of the batch: %+v", r)
+	}
+	if len(repo.byTracking) != 3 {
+		t.Errorf("expected 3 stored shipments, got %d", len(repo.byTracking))
+	}
+	if stored := repo.byTracking[results[4].Shipment.TrackingNumber]; stored == nil || stored.ClientID != "client_1" || stored.Price == nil {
+		t.Errorf("created shipment must be stored priced for its client: %+v", stored)
+	}
+}
+
+func TestShipmentService_IdempotencyKeysScopedToClient(t *testing.T) {
+	repo := newStubShipmentRepo()
+	svc := NewShipmentService(repo, nil, domain.BuiltinLifecycles(), domain.BuiltinCalendar(), discardLogger)
+
+	mine := minimalInput("client_1", "next_day")
+	mine.IdempotencyKey = "order-1"
+	first, err := svc.CreateShipment(context.Background(), mine)
+	if err != nil {
+		t.Fatalf("first create failed: %v", err)
+	}
+
+	theirs := minimalInput("client_2", "next_day")
+	theirs.IdempotencyKey = "order-1"
+	second, err := svc.CreateShipment(context.Background(), theirs)
+	if err != nil {
+		t.Fatalf("other client's create failed: %v", err)
+	}
+	if second.AlreadyExisted || second.TrackingNumber == first.TrackingNumber {
+		t.Errorf("another client's key must not replay: %+v", second)
+	}
+
+	theirs.IdempotencyKey = "order-2"
+	mine.IdempotencyKey = "order-2"
+	results := svc.CreateShipments(context.Background(), []ports.CreateShipmentInput{theirs, mine})
+	for i, r := range results {
+		if r.Err != nil || r.Shipment.AlreadyExisted {
+			t.Errorf("item %d must be created for its own client: %+v", i, r)
+		}
+	}
+	if len(repo.byTracking) != 4 {
+		t.Errorf("expected 4 stored shipments, got %d", len(repo.byTracking))
+	}
+}
+
+// racingShipmentRepo misses every batch key lookup, as if a concurrent retry
+// of the batch inserted its shipments right after the lookup.
+type racingShipmentRepo struct {
+	*stubShipmentRepo
+}
+
+func (r racingShipmentRepo) FindByIdempotencyKeys(context.Context, string, []string) (map[string]*domain.Shipment, error) {
+	return map[string]*domain.Shipment{}, nil
+}
+
+func TestShipmentService_CreateShipments_ConcurrentRetryReplays(t *testing.T) {
+	repo := newStubShipmentRepo()
+	svc := NewShipmentService(racingShipmentRepo{repo}, nil, domain.BuiltinLifecycles(), domain.BuiltinCalendar(), discardLogger)
+
+	in := minimalInput("client_1", "next_day")
+	in.IdempotencyKey = "order-1"
+	first := svc.CreateShipments(context.Background(), []ports.CreateShipmentInput{in})
+	retry := svc.CreateShipments(context.Background(), []ports.CreateShipmentInput{in})
+
+	if r := retry[0]; r.Err != nil || !r.Shipment.AlreadyExisted || r.Shipment.TrackingNumber != first[0].Shipment.TrackingNumber {
+		t.Errorf("retry must replay the shipment the first request created: %+v", r)
+	}
+	if len(repo.byTracking) != 1 {
+		t.Errorf("expected 1 stored shipment, got %d", len(repo.byTracking))
+	}
+}
+
 func TestShipmentService_Create_NoIdempotencyKey_AlwaysCreates(t *testing.T) {
 	repo := newStubShipmentRepo()
-	svc := NewShipmentService(repo, discardLogger)
+	svc := NewShipmentService(repo, nil, domain.BuiltinLifecycles(), domain.BuiltinCalendar(), discardLogger)
 
 	_, _ = svc.CreateShipment(context.Background(), minimalInput("client_1", "standard"))
 	_, _ = svc.CreateShipment(context.Background(), minimalInput("client_1", "standard"))
@@ -264,23 +488,40 @@ func TestShipmentService_Create_NoIdempotencyKey_AlwaysCreates(t *testing.T) {
 // ---------------------------------------------------------------------------
 
 func TestShipmentService_Create_EstimatedDelivery(t *testing.T) {
-	// Fix a reference time: 2026-02-19 10:00:00 UTC
-	ref := time.Date(2026, 2, 19, 10, 0, 0, 0, time.UTC)
+	// The built-in calendar runs on Mexico City time (UTC-6), Monday to
+	// Friday, promising deliveries by 20:00.
+	cdmx := time.FixedZone("CST", -6*60*60)
+	thursday := time.Date(2026, 2, 19, 10, 0, 0, 0, cdmx)
 
 	cases := []struct {
+		name        string
 		serviceType string
+		placedAt    time.Time
 		wantDate    time.Time
 	}{
-		{"same_day", time.Date(2026, 2, 19, 18, 0, 0, 0, time.UTC)},
-		{"next_day", time.Date(2026, 2, 20, 18, 0, 0, 0, time.UTC)},
-		{"standard", time.Date(2026, 2, 22, 18, 0, 0, 0, time.UTC)},
-		{"unknown", time.Date(2026, 2, 22, 18, 0, 0, 0, time.UTC)}, // defaults to +3 days
+		{"same_day before cutoff", "same_day", thursday, time.Date(2026, 2, 19, 20, 0, 0, 0, cdmx)},
+		{"same_day after cutoff", "same_day", time.Date(2026, 2, 19, 17, 55, 0, 0, cdmx), time.Date(2026, 2, 20, 20, 0, 0, 0, cdmx)},
+		{"next_day", "next_day", thursday, time.Date(2026, 2, 20, 20, 0, 0, 0, cdmx)},
+		{"next_day after Friday cutoff", "next_day", time.Date(2026, 2, 20, 19, 0, 0, 0, cdmx), time.Date(2026, 2, 24, 20, 0, 0, 0, cdmx)},
+		{"standard skips the weekend", "standard", thursday, time.Date(2026, 2, 24, 20, 0, 0, 0, cdmx)},
+		{"unknown defaults to standard", "unknown", thursday, time.Date(2026, 2, 24, 20, 0, 0, 0, cdmx)},
+		{"placed on Saturday", "same_day", time.Date(2026, 2, 21, 9, 0, 0, 0, cdmx), time.Date(2026, 2, 23, 20, 0, 0, 0, cdmx)},
 	}
 
 	for _, tc := range cases {
-		got := estimatedDelivery(tc.serviceType, ref)
+		repo := newStubShipmentRepo()
+		svc := NewShipmentService(repo, nil, domain.BuiltinLifecycles(), domain.BuiltinCalendar(), discardLogger)
+		created := seedViaService(t, svc, func(i *ports.CreateShipmentInput) { i.ServiceType = tc.serviceType })
+
+		// Creation uses the current time; check it against the calendar
+		// and the calendar against the fixed cases.
+		stored := repo.byTracking[created.TrackingNumber]
+		if want := domain.BuiltinCalendar().EstimateDelivery(tc.serviceType, "72000", stored.CreatedAt); !created.EstimatedDelivery.Equal(want) {
+			t.Errorf("%s: created with %v, calendar gives %v", tc.name, created.EstimatedDelivery, want)
+		}
+		got := domain.BuiltinCalendar().EstimateDelivery(tc.serviceType, "72000", tc.placedAt)
 		if !got.Equal(tc.wantDate) {
-			t.Errorf("serviceType=%q: expected %v, got %v", tc.serviceType, tc.wantDate, got)
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.wantDate, got)
 		}
 	}
 }
@@ -309,7 +550,7 @@ func seedShipment(repo *stubShipmentRepo, trackingNumber, clientID string) *doma
 
 func TestShipmentService_Get_AdminSeesAll(t *testing.T) {
 	repo := newStubShipmentRepo()
-	svc := NewShipmentService(repo, discardLogger)
+	svc := NewShipmentService(repo, nil, domain.BuiltinLifecycles(), domain.BuiltinCalendar(), discardLogger)
 	seedShipment(repo, "99M-AAAABBBB", "client_1")
 
 	_, err := svc.GetShipment(context.Background(), ports.GetShipmentInput{
@@ -328,7 +569,7 @@ func TestShipmentService_Get_AdminSeesAll(t *testing.T) {
 
 func TestShipmentService_Get_ClientFiltersById(t *testing.T) {
 	repo := newStubShipmentRepo()
-	svc := NewShipmentService(repo, discardLogger)
+	svc := NewShipmentService(repo, nil, domain.BuiltinLifecycles(), domain.BuiltinCalendar(), discardLogger)
 	seedShipment(repo, "99M-AAAABBBB", "client_1")
 
 	_, err := svc.GetShipment(context.Background(), ports.GetShipmentInput{
@@ -347,7 +588,7 @@ func TestShipmentService_Get_ClientFiltersById(t *testing.T) {
 
 func TestShipmentService_Get_ClientCannotSeeOtherClientShipment(t *testing.T) {
 	repo := newStubShipmentRepo()
-	svc := NewShipmentService(repo, discardLogger)
+	svc := NewShipmentService(repo, nil, domain.BuiltinLifecycles(), domain.BuiltinCalendar(), discardLogger)
 	seedShipment(repo, "99M-AAAABBBB", "client_1")
 
 	_, err := svc.GetShipment(context.Background(), ports.GetShipmentInput{
@@ -362,7 +603,7 @@ func TestShipmentService_Get_ClientCannotSeeOtherClientShipment(t *testing.T) {
 
 func TestShipmentService_Get_NotFound(t *testing.T) {
 	repo := newStubShipmentRepo()
-	svc := NewShipmentService(repo, discardLogger)
+	svc := NewShipmentService(repo, nil, domain.BuiltinLifecycles(), domain.BuiltinCalendar(), discardLogger)
 
 	_, err := svc.GetShipment(context.Background(), ports.GetShipmentInput{
 		TrackingNumber: "99M-NOTEXIST",
@@ -375,7 +616,7 @@ func TestShipmentService_Get_NotFound(t *testing.T) {
 
 func TestShipmentService_Get_MapsDetailCorrectly(t *testing.T) {
 	repo := newStubShipmentRepo()
-	svc := NewShipmentService(repo, discardLogger)
+	svc := NewShipmentService(repo, nil, domain.BuiltinLifecycles(), domain.BuiltinCalendar(), discardLogger)
 	seeded := seedShipment(repo, "99M-DETAIL01", "client_1")
 
 	detail, err := svc.GetShipment(context.Background(), ports.GetShipmentInput{
@@ -411,7 +652,7 @@ func TestShipmentService_Get_MapsDetailCorrectly(t *testing.T) {
 
 func TestShipmentService_Get_MapsFullStatusHistory(t *testing.T) {
 	repo := newStubShipmentRepo()
-	svc := NewShipmentService(repo, discardLogger)
+	svc := NewShipmentService(repo, nil, domain.BuiltinLifecycles(), domain.BuiltinCalendar(), discardLogger)
 
 	now := time.Now().UTC()
 	repo.byTracking["99M-HIST0001"] = &domain.Shipment{
@@ -468,7 +709,7 @@ return result
 
 func TestListShipments_AdminSeesAll(t *testing.T) {
 repo := newStubShipmentRepo()
-svc := NewShipmentService(repo, zerolog.Nop())
+svc := NewShipmentService(repo, nil, domain.BuiltinLifecycles(), domain.BuiltinCalendar(), zerolog.Nop())
 
 seedViaService(t, svc, func(i *ports.CreateShipmentInput) { i.ClientID = "client_001" })
 seedViaService(t, svc, func(i *ports.CreateShipmentInput) { i.ClientID = "client_002" })
@@ -486,7 +727,7 @@ t.Errorf("admin: expected 2 total, got %d", res.Total)
 
 func TestListShipments_ClientSeesOwn(t *testing.T) {
 repo := newStubShipmentRepo()
-svc := NewShipmentService(repo, zerolog.Nop())
+svc := NewShipmentService(repo, nil, domain.BuiltinLifecycles(), domain.BuiltinCalendar(), zerolog.Nop())
 
 seedViaService(t, svc, func(i *ports.CreateShipmentInput) { i.ClientID = "client_001" })
 seedViaService(t, svc, func(i *ports.CreateShipmentInput) { i.ClientID = "client_002" })
@@ -507,7 +748,7 @@ t.Error("expected a tracking number in result")
 
 func TestListShipments_LimitCappedAt100(t *testing.T) {
 repo := newStubShipmentRepo()
-svc := NewShipmentService(repo, zerolog.Nop())
+svc := NewShipmentService(repo, nil, domain.BuiltinLifecycles(), domain.BuiltinCalendar(), zerolog.Nop())
 
 res, err := svc.ListShipments(context.Background(), ports.ListShipmentsInput{
 Role: "admin", Limit: 999, Page: 1,
@@ -522,7 +763,7 @@ t.Errorf("expected limit 100, got %d", res.Limit)
 
 func TestListShipments_DefaultLimit(t *testing.T) {
 repo := newStubShipmentRepo()
-svc := NewShipmentService(repo, zerolog.Nop())
+svc := NewShipmentService(repo, nil, domain.BuiltinLifecycles(), domain.BuiltinCalendar(), zerolog.Nop())
 
 res, err := svc.ListShipments(context.Background(), ports.ListShipmentsInput{
 Role: "admin", Limit: 0, Page: 0,
@@ -537,7 +778,7 @@ t.Errorf("expected default limit 20, got %d", res.Limit)
 
 func TestListShipments_PaginationMath(t *testing.T) {
 repo := newStubShipmentRepo()
-svc := NewShipmentService(repo, zerolog.Nop())
+svc := NewShipmentService(repo, nil, domain.BuiltinLifecycles(), domain.BuiltinCalendar(), zerolog.Nop())
 
 for i := 0; i < 5; i++ {
 seedViaService(t, svc, nil)
@@ -565,12 +806,12 @@ t.Errorf("items: expected 2, got %d", len(res.Items))
 
 func TestListShipments_FilterByStatus(t *testing.T) {
 repo := newStubShipmentRepo()
-svc := NewShipmentService(repo, zerolog.Nop())
+svc := NewShipmentService(repo, nil, domain.BuiltinLifecycles(), domain.BuiltinCalendar(), zerolog.Nop())
 
 seedViaService(t, svc, nil) // status=created
 
 res, err := svc.ListShipments(context.Background(), ports.ListShipmentsInput{
-Role: "admin", Status: "created", Page: 1, Limit: 10,
+Role: "admin", Statuses: []string{"created"}, Page: 1, Limit: 10,
 })
 if err != nil {
 t.Fatal(err)
@@ -580,7 +821,7 @@ t.Errorf("filter by created: expected 1, got %d", res.Total)
 }
 
 res2, _ := svc.ListShipments(context.Background(), ports.ListShipmentsInput{
-Role: "admin", Status: "delivered", Page: 1, Limit: 10,
+Role: "admin", Statuses: []string{"delivered"}, Page: 1, Limit: 10,
 })
 if int(res2.Total) != 0 {
 t.Errorf("filter by delivered: expected 0, got %d", res2.Total)
@@ -589,7 +830,7 @@ t.Errorf("filter by delivered: expected 0, got %d", res2.Total)
 
 func TestListShipments_FilterByServiceType(t *testing.T) {
 repo := newStubShipmentRepo()
-svc := NewShipmentService(repo, zerolog.Nop())
+svc := NewShipmentService(repo, nil, domain.BuiltinLifecycles(), domain.BuiltinCalendar(), zerolog.Nop())
 
 seedViaService(t, svc, func(i *ports.CreateShipmentInput) { i.ServiceType = "next_day" })
 seedViaService(t, svc, func(i *ports.CreateShipmentInput) { i.ServiceType = "same_day" })
@@ -607,7 +848,7 @@ t.Errorf("filter by same_day: expected 1, got %d", res.Total)
 
 func TestListShipments_SearchBySenderName(t *testing.T) {
 repo := newStubShipmentRepo()
-svc := NewShipmentService(repo, zerolog.Nop())
+svc := NewShipmentService(repo, nil, domain.BuiltinLifecycles(), domain.BuiltinCalendar(), zerolog.Nop())
 
 seedViaService(t, svc, func(i *ports.CreateShipmentInput) { i.Sender.Name = "Pedro García" })
 seedViaService(t, svc, func(i *ports.CreateShipmentInput) { i.Sender.Name = "Ana Torres" })
@@ -625,7 +866,7 @@ t.Errorf("search: expected 1, got %d", res.Total)
 
 func TestListShipments_DateRangeFilter(t *testing.T) {
 repo := newStubShipmentRepo()
-svc := NewShipmentService(repo, zerolog.Nop())
+svc := NewShipmentService(repo, nil, domain.BuiltinLifecycles(), domain.BuiltinCalendar(), zerolog.Nop())
 
 seedViaService(t, svc, nil)
 
@@ -642,3 +883,242 @@ if int(res.Total) != 1 {
 t.Errorf("date range: expected 1, got %d", res.Total)
 }
 }
+
+func TestCancelShipment_RecordsActorAndReason(t *testing.T) {
+	repo := newStubShipmentRepo()
+	svc := NewShipmentService(repo, nil, domain.BuiltinLifecycles(), domain.BuiltinCalendar(), zerolog.Nop())
+	created := seedViaService(t, svc, nil)
+
+	result, err := svc.CancelShipment(context.Background(), ports.CancelShipmentInput{
+		TrackingNumber: created.TrackingNumber,
+		Role:           domain.RoleClient,
+		ClientID:       "client_001",
+		Actor:          "merchant@example.com",
+		Reason:         "customer changed their mind",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Status != string(domain.StatusCancelled) || repo.lastFindFilter != "client_001" {
+		t.Errorf("unexpected result %+v (filter %q)", result, repo.lastFindFilter)
+	}
+
+	stored := repo.byTracking[created.TrackingNumber]
+	last := stored.StatusHistory[len(stored.StatusHistory)-1]
+	if stored.Status != domain.StatusCancelled || last.Actor != "merchant@example.com" || last.Reason != "customer changed their mind" {
+		t.Errorf("unexpected stored shipment: status=%s last=%+v", stored.Status, last)
+	}
+
+	_, err = svc.CancelShipment(context.Background(), ports.CancelShipmentInput{
+		TrackingNumber: created.TrackingNumber, Role: domain.RoleAdmin, Reason: "again",
+	})
+	if !errors.Is(err, domain.ErrShipmentCancelled) {
+		t.Errorf("second cancel: expected ErrShipmentCancelled, got %v", err)
+	}
+}
+
+func TestCancelShipment_CancelsPieces(t *testing.T) {
+	repo := newStubShipmentRepo()
+	svc := NewShipmentService(repo, nil, domain.BuiltinLifecycles(), domain.BuiltinCalendar(), zerolog.Nop())
+	created := seedViaService(t, svc, func(i *ports.CreateShipmentInput) {
+		i.Pieces = []ports.PieceInput{{WeightKg: 2}, {WeightKg: 3.5}}
+	})
+
+	_, err := svc.CancelShipment(context.Background(), ports.CancelShipmentInput{
+		TrackingNumber: created.TrackingNumber, Role: domain.RoleAdmin, Reason: "duplicate order",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, p := range repo.byTracking[created.TrackingNumber].Pieces {
+		if p.Status != domain.StatusCancelled || p.LastEventAt.IsZero() {
+			t.Errorf("expected piece %s cancelled, got %+v", p.Barcode, p)
+		}
+	}
+}
+
+func TestCancelShipment_OtherClientGetsNotFound(t *testing.T) {
+	repo := newStubShipmentRepo()
+	svc := NewShipmentService(repo, nil, domain.BuiltinLifecycles(), domain.BuiltinCalendar(), zerolog.Nop())
+	created := seedViaService(t, svc, nil)
+
+	_, err := svc.CancelShipment(context.Background(), ports.CancelShipmentInput{
+		TrackingNumber: created.TrackingNumber, Role: domain.RoleClient, ClientID: "client_002", Reason: "mine?",
+	})
+	if !errors.Is(err, domain.ErrShipmentNotFound) {
+		t.Errorf("expected ErrShipmentNotFound, got %v", err)
+	}
+	if repo.byTracking[created.TrackingNumber].Status != domain.StatusCreated {
+		t.Error("shipment must not change")
+	}
+}
+
+func TestCancelShipment_OnlyFromCancellableStates(t *testing.T) {
+	repo := newStubShipmentRepo()
+	svc := NewShipmentService(repo, nil, domain.BuiltinLifecycles(), domain.BuiltinCalendar(), zerolog.Nop())
+	created := seedViaService(t, svc, nil)
+	repo.byTracking[created.TrackingNumber].Status = domain.StatusInTransit
+
+	_, err := svc.CancelShipment(context.Background(), ports.CancelShipmentInput{
+		TrackingNumber: created.TrackingNumber, Role: domain.RoleAdmin, Reason: "too late",
+	})
+	if !errors.Is(err, domain.ErrInvalidTransition) {
+		t.Errorf("expected ErrInvalidTransition, got %v", err)
+	}
+}
+
+func TestAmendShipment_RecordsVersionedChanges(t *testing.T) {
+	repo := newStubShipmentRepo()
+	svc := NewShipmentService(repo, nil, domain.BuiltinLifecycles(), domain.BuiltinCalendar(), zerolog.Nop())
+	created := seedViaService(t, svc, nil)
+
+	zip, phone := "06600", "+525598765432"
+	detail, err := svc.AmendShipment(context.Background(), ports.AmendShipmentInput{
+		TrackingNumber: created.TrackingNumber,
+		Role:           domain.RoleClient,
+		ClientID:       "client_001",
+		Actor:          "ana",
+		Destination:    ports.AddressPatch{ZipCode: &zip},
+		Sender:         ports.SenderPatch{Phone: &phone},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if detail.Destination.ZipCode != zip || detail.Sender.Phone != phone {
+		t.Errorf("fields not amended: %+v %+v", detail.Destination, detail.Sender)
+	}
+	if detail.Version != 1 || len(detail.Amendments) != 1 {
+		t.Fatalf("expected version 1 with one amendment, got %d %+v", detail.Version, detail.Amendments)
+	}
+	a := detail.Amendments[0]
+	if a.Actor != "ana" || len(a.Changes) != 2 {
+		t.Fatalf("unexpected amendment: %+v", a)
+	}
+	if c := a.Changes[0]; c.Field != "sender.phone" || c.To != phone {
+		t.Errorf("unexpected change: %+v", c)
+	}
+	if !detail.EstimatedDelivery.Equal(created.EstimatedDelivery) {
+		t.Errorf("ETA must not change without a service type change")
+	}
+
+	// Repeating the same values is a no-op.
+	detail, err = svc.AmendShipment(context.Background(), ports.AmendShipmentInput{
+		TrackingNumber: created.TrackingNumber, Role: domain.RoleAdmin, Destination: ports.AddressPatch{ZipCode: &zip},
+	})
+	if err != nil || detail.Version != 1 {
+		t.Errorf("expected unchanged version 1, got %d (err %v)", detail.Version, err)
+	}
+}
+
+func TestAmendShipment_ServiceTypeRecalculatesETA(t *testing.T) {
+	repo := newStubShipmentRepo()
+	svc := NewShipmentService(repo, nil, domain.BuiltinLifecycles(), domain.BuiltinCalendar(), zerolog.Nop())
+	created := seedViaService(t, svc, func(in *ports.CreateShipmentInput) { in.ServiceType = "standard" })
+
+	serviceType := "same_day"
+	detail, err := svc.AmendShipment(context.Background(), ports.AmendShipmentInput{
+		TrackingNumber: created.TrackingNumber, Role: domain.RoleAdmin, ServiceType: &serviceType,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := domain.BuiltinCalendar().EstimateDelivery("same_day", "72000", time.Now()); !detail.EstimatedDelivery.Equal(want) {
+		t.Errorf("estimated delivery = %v, want %v", detail.EstimatedDelivery, want)
+	}
+	fields := []string{}
+	for _, c := range detail.Amendments[0].Changes {
+		fields = append(fields, c.Field)
+	}
+	if !slices.Equal(fields, []string{"service_type", "estimated_delivery"}) {
+		t.Errorf("unexpected changed fields %v", fields)
+	}
+}
+
+func TestAmendShipment_LockedAfterPickup(t *testing.T) {
+	repo := newStubShipmentRepo()
+	svc := NewShipmentService(repo, nil, domain.BuiltinLifecycles(), domain.BuiltinCalendar(), zerolog.Nop())
+	created := seedViaService(t, svc, nil)
+	repo.byTracking[created.TrackingNumber].Status = domain.StatusPickedUp
+
+	zip := "06600"
+	_, err := svc.AmendShipment(context.Background(), ports.AmendShipmentInput{
+		TrackingNumber: created.TrackingNumber, Role: domain.RoleAdmin, Destination: ports.AddressPatch{ZipCode: &zip},
+	})
+	if !errors.Is(err, domain.ErrShipmentLocked) {
+		t.Errorf("expected ErrShipmentLocked, got %v", err)
+	}
+}
+
+func TestShipmentService_Get_MapsRecipient(t *testing.T) {
+	repo := newStubShipmentRepo()
+	svc := NewShipmentService(repo, nil, domain.BuiltinLifecycles(), domain.BuiltinCalendar(), zerolog.Nop())
+	created := seedViaService(t, svc, func(i *ports.CreateShipmentInput) {
+		i.Recipient = ports.RecipientInput{
+			Name:               "Lucía Ramos",
+			Phone:              "+522221234567",
+			Instructions:       "Dejar en recepción",
+			AlternativeContact: &ports.SenderInput{Name: "Jorge Ramos", Phone: "+522227654321"},
+		}
+	})
+
+	detail, err := svc.GetShipment(context.Background(), ports.GetShipmentInput{TrackingNumber: created.TrackingNumber, Role: domain.RoleAdmin})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r := detail.Recipient
+	if r.Name != "Lucía Ramos" || r.Phone != "+522221234567" || r.Instructions != "Dejar en recepción" {
+		t.Errorf("unexpected recipient: %+v", r)
+	}
+	if r.AlternativeContact == nil || r.AlternativeContact.Name != "Jorge Ramos" {
+		t.Errorf("unexpected alternative contact: %+v", r.AlternativeContact)
+	}
+
+	// Shipments stored before recipients existed map to an empty recipient.
+	seedShipment(repo, "99M-OLD00001", "client_001")
+	detail, err = svc.GetShipment(context.Background(), ports.GetShipmentInput{TrackingNumber: "99M-OLD00001", Role: domain.RoleAdmin})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if detail.Recipient != (ports.RecipientInput{}) {
+		t.Errorf("expected empty recipient, got %+v", detail.Recipient)
+	}
+}
+
+func TestListShipments_SearchByRecipientName(t *testing.T) {
+	repo := newStubShipmentRepo()
+	svc := NewShipmentService(repo, nil, domain.BuiltinLifecycles(), domain.BuiltinCalendar(), zerolog.Nop())
+	seedViaService(t, svc, func(i *ports.CreateShipmentInput) { i.Recipient.Name = "Lucía Ramos" })
+	seedViaService(t, svc, func(i *ports.CreateShipmentInput) { i.Recipient.Name = "Ana Torres" })
+
+	res, err := svc.ListShipments(context.Background(), ports.ListShipmentsInput{Role: domain.RoleAdmin, Search: "ramos"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total != 1 || res.Items[0].Recipient.Name != "Lucía Ramos" {
+		t.Errorf("search: expected Lucía Ramos only, got %+v", res.Items)
+	}
+}
+
+func TestShipmentService_Create_AssignsPieceBarcodes(t *testing.T) {
+	repo := newStubShipmentRepo()
+	svc := NewShipmentService(repo, nil, domain.BuiltinLifecycles(), domain.BuiltinCalendar(), zerolog.Nop())
+	created := seedViaService(t, svc, func(i *ports.CreateShipmentInput) {
+		i.Pieces = []ports.PieceInput{{WeightKg: 2}, {WeightKg: 3.5}}
+	})
+
+	detail, err := svc.GetShipment(context.Background(), ports.GetShipmentInput{TrackingNumber: created.TrackingNumber, Role: domain.RoleAdmin})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(detail.Pieces) != 2 {
+		t.Fatalf("expected 2 pieces, got %+v", detail.Pieces)
+	}
+	for i, p := range detail.Pieces {
+		if want := domain.PieceBarcode(created.TrackingNumber, i+1); p.Barcode != want || p.Status != "created" {
+			t.Errorf("piece %d = %+v, want barcode %s in created", i, p, want)
+		}
+	}
+	if detail.Pieces[1].WeightKg != 3.5 {
+		t.Errorf("unexpected weight %v", detail.Pieces[1].WeightKg)
+	}
+}
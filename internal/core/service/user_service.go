@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/99minutos/shipping-system/internal/core/domain"
+	"github.com/99minutos/shipping-system/internal/core/ports"
+)
+
+// UserService implements ports.UserService.
+type UserService struct {
+	repo          ports.AuthRepository
+	tokens        ports.RefreshTokenRepository
+	invitations   ports.InvitationRepository
+	invitationTTL time.Duration
+	logger        zerolog.Logger
+}
+
+// NewUserService creates a UserService. A non-positive invitationTTL uses 7
+// days.
+func NewUserService(
+	repo ports.AuthRepository,
+	tokens ports.RefreshTokenRepository,
+	invitations ports.InvitationRepository,
+	invitationTTL time.Duration,
+	logger zerolog.Logger,
+) *UserService {
+	if invitationTTL <= 0 {
+		invitationTTL = 7 * 24 * time.Hour
+	}
+	return &UserService{
+		repo:          repo,
+		tokens:        tokens,
+		invitations:   invitations,
+		invitationTTL: invitationTTL,
+		logger:        logger,
+	}
+}
+
+// Create creates a user of any role, including admins.
+func (s *UserService) Create(ctx context.Context, input ports.CreateUserInput) (*domain.User, error) {
+	if input.Username == "" || input.Password == "" || input.Email == "" {
+		return nil, domain.ErrInvalidCredentials
+	}
+	if err := domain.CheckRole(input.Role, input.ClientID); err != nil {
+		return nil, err
+	}
+	return createUser(ctx, s.repo, input)
+}
+
+func (s *UserService) List(ctx context.Context, input ports.ListUsersInput) (*ports.ListUsersResult, error) {
+	limit := input.Limit
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	if limit > maxLimit {
+		limit = maxLimit
+	}
+	page := input.Page
+	if page <= 0 {
+		page = 1
+	}
+
+	users, total, err := s.repo.List(ctx, ports.ListUsersFilter{
+		Role:     input.Role,
+		ClientID: input.ClientID,
+		Page:     page,
+		Limit:    limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	totalPages := int((total + int64(limit) - 1) / int64(limit))
+	if totalPages == 0 {
+		totalPages = 1
+	}
+	return &ports.ListUsersResult{
+		Items:      users,
+		Total:      total,
+		Page:       page,
+		Limit:      limit,
+		TotalPages: totalPages,
+	}, nil
+}
+
+func (s *UserService) Get(ctx context.Context, id string) (*domain.User, error) {
+	return s.repo.FindByID(ctx, id)
+}
+
+// Update changes the role or client of a user. The new values apply to the
+// user's next login or token refresh.
+func (s *UserService) Update(ctx context.Context, input ports.UpdateUserInput) (*domain.User, error) {
+	user, err := s.repo.FindByID(ctx, input.ID)
+	if err != nil {
+		return nil, err
+	}
+	if input.Role != nil {
+		if user.ID == input.ActorID && *input.Role != user.Role {
+			return nil, domain.ErrCannotModifySelf
+		}
+		user.Role = *input.Role
+	}
+	if input.ClientID != nil {
+		user.ClientID = *input.ClientID
+	}
+	if user.Role == domain.RoleAdmin {
+		user.ClientID = "" // admins are not bound to a client
+	}
+	if err := domain.CheckRole(user.Role, user.ClientID); err != nil {
+		return nil, err
+	}
+
+	user.UpdatedAt = time.Now().UTC()
+	if err := s.repo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+	s.logger.Info().Str("user_id", user.ID).Str("role", user.Role).Str("client_id", user.ClientID).Str("by", input.ActorID).Msg("user updated")
+	return user, nil
+}
+
+// SetDisabled disables or enables a user. Disabling revokes its refresh
+// tokens, so it is signed out once its access token expires.
+func (s *UserService) SetDisabled(ctx context.Context, id string, disabled bool, actorID string) (*domain.User, error) {
+	if disabled && id == actorID {
+		return nil, domain.ErrCannotModifySelf
+	}
+	user, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if user.Disabled() == disabled {
+		return user, nil
+	}
+
+	now := time.Now().UTC()
+	user.DisabledAt = time.Time{}
+	if disabled {
+		user.DisabledAt = now
+	}
+	user.UpdatedAt = now
+	if err := s.repo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+	if disabled {
+		if err := s.tokens.RevokeUser(ctx, user.ID, now); err != nil {
+			return nil, err
+		}
+	}
+	s.logger.Info().Str("user_id", user.ID).Bool("disabled", disabled).Str("by", actorID).Msg("user disabled state changed")
+	return user, nil
+}
+
+// Delete deletes a user and revokes its refresh tokens.
+func (s *UserService) Delete(ctx context.Context, id, actorID string) error {
+	if id == actorID {
+		return domain.ErrCannotModifySelf
+	}
+	if _, err := s.repo.FindByID(ctx, id); err != nil {
+		return err
+	}
+	if err := s.tokens.RevokeUser(ctx, id, time.Now().UTC()); err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.logger.Info().Str("user_id", id).Str("by", actorID).Msg("user deleted")
+	return nil
+}
+
+// Invite issues an invitation for one person to register, by email, as a
+// user of a client or carrier account. Admins are created with Create.
+func (s *UserService) Invite(ctx context.Context, input ports.InviteInput) (*ports.IssuedInvitation, error) {
+	if input.Role == domain.RoleAdmin {
+		return nil, domain.ErrInvalidUser
+	}
+	if err := domain.CheckRole(input.Role, input.ClientID); err != nil {
+		return nil, err
+	}
+
+	secret := make([]byte, 32)
+	_, _ = rand.Read(secret) // never fails since Go 1.24
+	token := base64.RawURLEncoding.EncodeToString(secret)
+	now := time.Now().UTC()
+	invitation := &domain.Invitation{
+		ID:        newID("inv_"),
+		TokenHash: hashToken(token),
+		Email:     input.Email,
+		Role:      input.Role,
+		ClientID:  input.ClientID,
+		CreatedBy: input.CreatedBy,
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.invitationTTL),
+	}
+	if err := s.invitations.Create(ctx, invitation); err != nil {
+		return nil, err
+	}
+	return &ports.IssuedInvitation{Invitation: invitation, Token: token}, nil
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/99minutos/shipping-system/internal/core/domain"
+	"github.com/99minutos/shipping-system/internal/core/ports"
+	"github.com/99minutos/shipping-system/internal/pkg/jwtkeys"
+)
+
+// newTestUserServices returns a UserService and an AuthService sharing
+// their repositories, with the admin "root" already created.
+func newTestUserServices(t *testing.T) (*UserService, *AuthService, *stubAuthRepo) {
+	t.Helper()
+	repo := newStubAuthRepo()
+	tokens := &stubRefreshTokenRepo{byHash: make(map[string]*domain.RefreshToken)}
+	invitations := newStubInvitationRepo()
+	users := NewUserService(repo, tokens, invitations, 0, zerolog.Nop())
+	auth := NewAuthService(repo, tokens, invitations, stubDenylist{}, jwtkeys.NewHMAC("secret"), time.Hour, 24*time.Hour)
+	addUser(t, repo, "root", "s3cret", domain.RoleAdmin, "")
+	return users, auth, repo
+}
+
+func TestUserService_Create(t *testing.T) {
+	users, auth, _ := newTestUserServices(t)
+	ctx := context.Background()
+
+	admin, err := users.Create(ctx, ports.CreateUserInput{
+		Username: "ops",
+		Password: "s3cret",
+		Email:    "ops@example.com",
+		Role:     domain.RoleAdmin,
+	})
+	if err != nil || admin.Role != domain.RoleAdmin {
+		t.Fatalf("create admin: %+v %v", admin, err)
+	}
+	if _, _, err := auth.Login(ctx, "ops@example.com", "s3cret"); err != nil {
+		t.Fatalf("expected the new admin to log in, got %v", err)
+	}
+
+	for _, input := range []ports.CreateUserInput{
+		{Username: "a", Password: "p", Email: "a@example.com", Role: "superuser"},
+		{Username: "b", Password: "p", Email: "b@example.com", Role: domain.RoleCarrier},
+	} {
+		if _, err := users.Create(ctx, input); err != domain.ErrInvalidUser {
+			t.Errorf("expected ErrInvalidUser for %+v, got %v", input, err)
+		}
+	}
+}
+
+func TestUserService_Invite(t *testing.T) {
+	users, auth, _ := newTestUserServices(t)
+	ctx := context.Background()
+
+	if _, err := users.Invite(ctx, ports.InviteInput{Email: "x@example.com", Role: domain.RoleAdmin}); err != domain.ErrInvalidUser {
+		t.Fatalf("expected admins not to be invited, got %v", err)
+	}
+
+	issued, err := users.Invite(ctx, ports.InviteInput{
+		Email:     "hana@example.com",
+		Role:      domain.RoleCarrier,
+		ClientID:  "carrier_1",
+		CreatedBy: "root",
+	})
+	if err != nil {
+		t.Fatalf("invite: %v", err)
+	}
+	if issued.Token == "" || issued.Invitation.TokenHash != hashToken(issued.Token) {
+		t.Fatalf("expected only the hash of the token to be stored, got %+v", issued.Invitation)
+	}
+	if ttl := issued.Invitation.ExpiresAt.Sub(issued.Invitation.CreatedAt); ttl != 7*24*time.Hour {
+		t.Fatalf("expected invitations to last 7 days by default, got %v", ttl)
+	}
+
+	user, err := auth.Register(ctx, ports.RegisterInput{
+		Username:        "hana",
+		Password:        "s3cret",
+		Email:           "hana@example.com",
+		InvitationToken: issued.Token,
+	})
+	if err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	if user.Role != domain.RoleCarrier || user.ClientID != "carrier_1" {
+		t.Fatalf("expected the role and client of the invitation, got %+v", user)
+	}
+}
+
+func TestUserService_Update(t *testing.T) {
+	users, _, repo := newTestUserServices(t)
+	ctx := context.Background()
+	addUser(t, repo, "ines", "s3cret", domain.RoleClient, "client_1")
+
+	carrier, client := domain.RoleCarrier, "carrier_9"
+	user, err := users.Update(ctx, ports.UpdateUserInput{ID: "ines", Role: &carrier, ClientID: &client, ActorID: "root"})
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if stored := repo.users["ines"]; stored.Role != domain.RoleCarrier || stored.ClientID != "carrier_9" || user.UpdatedAt.IsZero() {
+		t.Fatalf("expected the new role and client to be stored, got %+v", stored)
+	}
+
+	empty := ""
+	if _, err := users.Update(ctx, ports.UpdateUserInput{ID: "ines", ClientID: &empty, ActorID: "root"}); err != domain.ErrInvalidUser {
+		t.Fatalf("expected a carrier without client to be rejected, got %v", err)
+	}
+
+	admin := domain.RoleAdmin
+	user, err = users.Update(ctx, ports.UpdateUserInput{ID: "ines", Role: &admin, ActorID: "root"})
+	if err != nil || user.ClientID != "" {
+		t.Fatalf("expected a promoted admin to lose its client, got %+v %v", user, err)
+	}
+
+	demoted := domain.RoleClient
+	if _, err := users.Update(ctx, ports.UpdateUserInput{ID: "root", Role: &demoted, ActorID: "root"}); err != domain.ErrCannotModifySelf {
+		t.Fatalf("expected admins not to change their own role, got %v", err)
+	}
+	if _, err := users.Update(ctx, ports.UpdateUserInput{ID: "ghost", Role: &demoted, ActorID: "root"}); err != domain.ErrUserNotFound {
+		t.Fatalf("expected ErrUserNotFound, got %v", err)
+	}
+}
+
+func TestUserService_SetDisabled(t *testing.T) {
+	users, auth, repo := newTestUserServices(t)
+	ctx := context.Background()
+	addUser(t, repo, "juan", "s3cret", domain.RoleClient, "client_1")
+	login, _, _ := auth.Login(ctx, "juan@example.com", "s3cret")
+
+	if _, err := users.SetDisabled(ctx, "root", true, "root"); err != domain.ErrCannotModifySelf {
+		t.Fatalf("expected admins not to disable themselves, got %v", err)
+	}
+
+	user, err := users.SetDisabled(ctx, "juan", true, "root")
+	if err != nil || !user.Disabled() {
+		t.Fatalf("disable: %+v %v", user, err)
+	}
+	if _, _, err := auth.Refresh(ctx, login.RefreshToken); err != domain.ErrInvalidRefreshToken {
+		t.Fatalf("expected the refresh tokens to be revoked, got %v", err)
+	}
+	if _, _, err := auth.Login(ctx, "juan@example.com", "s3cret"); err != domain.ErrUserDisabled {
+		t.Fatalf("expected the login to be refused, got %v", err)
+	}
+
+	if user, err = users.SetDisabled(ctx, "juan", false, "root"); err != nil || user.Disabled() {
+		t.Fatalf("enable: %+v %v", user, err)
+	}
+	if _, _, err := auth.Login(ctx, "juan@example.com", "s3cret"); err != nil {
+		t.Fatalf("expected an enabled user to log in, got %v", err)
+	}
+}
+
+func TestUserService_Delete(t *testing.T) {
+	users, auth, repo := newTestUserServices(t)
+	ctx := context.Background()
+	addUser(t, repo, "kim", "s3cret", domain.RoleClient, "client_1")
+	login, _, _ := auth.Login(ctx, "kim@example.com", "s3cret")
+
+	if err := users.Delete(ctx, "root", "root"); err != domain.ErrCannotModifySelf {
+		t.Fatalf("expected admins not to delete themselves, got %v", err)
+	}
+	if err := users.Delete(ctx, "kim", "root"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := users.Get(ctx, "kim"); err != domain.ErrUserNotFound {
+		t.Fatalf("expected the user to be gone, got %v", err)
+	}
+	for _, token := range auth.tokens.(*stubRefreshTokenRepo).byHash {
+		if token.UserID == "kim" && token.RevokedAt.IsZero() {
+			t.Fatalf("expected the refresh tokens to be revoked")
+		}
+	}
+	if _, _, err := auth.Refresh(ctx, login.RefreshToken); err != domain.ErrInvalidRefreshToken {
+		t.Fatalf("expected the refresh token to be rejected, got %v", err)
+	}
+	if err := users.Delete(ctx, "kim", "root"); err != domain.ErrUserNotFound {
+		t.Fatalf("expected ErrUserNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,123 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/99minutos/shipping-system/internal/core/domain"
+)
+
+const collectionAPIKeys = "api_keys"
+
+// APIKeyRepository implements ports.APIKeyRepository using MongoDB.
+// Revoked and expired keys are kept so that they can still be listed.
+type APIKeyRepository struct {
+	col *mongo.Collection
+}
+
+func NewAPIKeyRepository(db *mongo.Database) *APIKeyRepository {
+	return &APIKeyRepository{col: db.Collection(collectionAPIKeys)}
+}
+
+func (r *APIKeyRepository) Create(ctx context.Context, key *domain.APIKey) error {
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	if _, err := r.col.InsertOne(ctx, key); err != nil {
+		return fmt.Errorf("insert api key: %w", err)
+	}
+	return nil
+}
+
+func (r *APIKeyRepository) FindByHash(ctx context.Context, keyHash string) (*domain.APIKey, error) {
+	return r.findOne(ctx, bson.M{"key_hash": keyHash})
+}
+
+func (r *APIKeyRepository) FindByID(ctx context.Context, id, clientID string) (*domain.APIKey, error) {
+	filter := bson.M{"_id": id}
+	if clientID != "" {
+		filter["client_id"] = clientID
+	}
+	return r.findOne(ctx, filter)
+}
+
+func (r *APIKeyRepository) ListByClient(ctx context.Context, clientID string) ([]domain.APIKey, error) {
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	cur, err := r.col.Find(ctx,
+		bson.M{"client_id": clientID},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list api keys: %w", err)
+	}
+	keys := []domain.APIKey{}
+	if err := cur.All(ctx, &keys); err != nil {
+		return nil, fmt.Errorf("decode api keys: %w", err)
+	}
+	return keys, nil
+}
+
+func (r *APIKeyRepository) Revoke(ctx context.Context, id string, at time.Time) error {
+	return r.update(ctx, id, bson.M{"revoked_at": at})
+}
+
+func (r *APIKeyRepository) Replace(ctx context.Context, id, replacedBy string, expiresAt time.Time) error {
+	return r.update(ctx, id, bson.M{"replaced_by": replacedBy, "expires_at": expiresAt})
+}
+
+func (r *APIKeyRepository) Touch(ctx context.Context, id string, at time.Time) error {
+	return r.update(ctx, id, bson.M{"last_used_at": at})
+}
+
+// EnsureIndexes creates the index keys are authenticated with and the one
+// they are listed with.
+func (r *APIKeyRepository) EnsureIndexes(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	_, err := r.col.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "key_hash", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "client_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	})
+	return err
+}
+
+func (r *APIKeyRepository) findOne(ctx context.Context, filter bson.M) (*domain.APIKey, error) {
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	var key domain.APIKey
+	err := r.col.FindOne(ctx, filter).Decode(&key)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, domain.ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("find api key: %w", err)
+	}
+	return &key, nil
+}
+
+func (r *APIKeyRepository) update(ctx context.Context, id string, set bson.M) error {
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	res, err := r.col.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": set})
+	if err != nil {
+		return fmt.Errorf("update api key: %w", err)
+	}
+	if res.MatchedCount == 0 {
+		return domain.ErrAPIKeyNotFound
+	}
+	return nil
+}
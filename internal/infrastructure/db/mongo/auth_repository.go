@@ -6,9 +6,11 @@ import (
 	"time"
 
 	"github.com/99minutos/shipping-system/internal/core/domain"
+	"github.com/99minutos/shipping-system/internal/core/ports"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 const authCollection = "auth_users"
@@ -28,6 +30,7 @@ type mongoUser struct {
 	PasswordHash string             `bson:"password_hash"`
 	Role         string             `bson:"role"`
 	ClientID     string             `bson:"client_id,omitempty"`
+	DisabledAt   int64              `bson:"disabled_at,omitempty"`
 	CreatedAt    int64              `bson:"created_at"`
 	UpdatedAt    int64              `bson:"updated_at"`
 }
@@ -60,14 +63,114 @@ func (r *MongoAuthRepository) Create(ctx context.Context, user *domain.User) (*d
 }
 
 func (r *MongoAuthRepository) FindByEmail(ctx context.Context, email string) (*domain.User, error) {
+	return r.findOne(ctx, bson.M{"email": email})
+}
+
+func (r *MongoAuthRepository) FindByID(ctx context.Context, id string) (*domain.User, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, domain.ErrUserNotFound
+	}
+	return r.findOne(ctx, bson.M{"_id": oid})
+}
+
+func (r *MongoAuthRepository) List(ctx context.Context, filter ports.ListUsersFilter) ([]*domain.User, int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	q := bson.M{}
+	if filter.Role != "" {
+		q["role"] = filter.Role
+	}
+	if filter.ClientID != "" {
+		q["client_id"] = filter.ClientID
+	}
+
+	total, err := r.coll.CountDocuments(ctx, q)
+	if err != nil {
+		return nil, 0, fmt.Errorf("count users: %w", err)
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(int64((filter.Page - 1) * filter.Limit)).
+		SetLimit(int64(filter.Limit))
+	cursor, err := r.coll.Find(ctx, q, opts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("list users: %w", err)
+	}
+	var docs []mongoUser
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, 0, fmt.Errorf("decode users: %w", err)
+	}
+
+	users := make([]*domain.User, len(docs))
+	for i := range docs {
+		users[i] = docs[i].toDomain()
+	}
+	return users, total, nil
+}
+
+func (r *MongoAuthRepository) Update(ctx context.Context, user *domain.User) error {
+	oid, err := primitive.ObjectIDFromHex(user.ID)
+	if err != nil {
+		return domain.ErrUserNotFound
+	}
+
+	set := bson.M{"role": user.Role, "updated_at": user.UpdatedAt.Unix()}
+	unset := bson.M{}
+	if user.ClientID != "" {
+		set["client_id"] = user.ClientID
+	} else {
+		unset["client_id"] = ""
+	}
+	if user.Disabled() {
+		set["disabled_at"] = user.DisabledAt.Unix()
+	} else {
+		unset["disabled_at"] = ""
+	}
+	update := bson.M{"$set": set}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+
+	res, err := r.coll.UpdateByID(ctx, oid, update)
+	if err != nil {
+		return fmt.Errorf("update user: %w", err)
+	}
+	if res.MatchedCount == 0 {
+		return domain.ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *MongoAuthRepository) Delete(ctx context.Context, id string) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.ErrUserNotFound
+	}
+	res, err := r.coll.DeleteOne(ctx, bson.M{"_id": oid})
+	if err != nil {
+		return fmt.Errorf("delete user: %w", err)
+	}
+	if res.DeletedCount == 0 {
+		return domain.ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *MongoAuthRepository) findOne(ctx context.Context, filter bson.M) (*domain.User, error) {
 	var mu mongoUser
-	if err := r.coll.FindOne(ctx, bson.M{"email": email}).Decode(&mu); err != nil {
+	if err := r.coll.FindOne(ctx, filter).Decode(&mu); err != nil {
 		if err == mongo.ErrNoDocuments {
 			return nil, domain.ErrUserNotFound
 		}
 		return nil, fmt.Errorf("find user: %w", err)
 	}
+	return mu.toDomain(), nil
+}
 
+func (mu *mongoUser) toDomain() *domain.User {
 	return &domain.User{
 		ID:           mu.ID.Hex(),
 		Username:     mu.Username,
@@ -75,9 +178,10 @@ func (r *MongoAuthRepository) FindByEmail(ctx context.Context, email string) (*d
 		PasswordHash: mu.PasswordHash,
 		Role:         mu.Role,
 		ClientID:     mu.ClientID,
+		DisabledAt:   unixToTime(mu.DisabledAt),
 		CreatedAt:    unixToTime(mu.CreatedAt),
 		UpdatedAt:    unixToTime(mu.UpdatedAt),
-	}, nil
+	}
 }
 
 func unixToTime(ts int64) time.Time {
@@ -0,0 +1,248 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/99minutos/shipping-system/internal/core/domain"
+	"github.com/99minutos/shipping-system/internal/core/ports"
+)
+
+const collectionDeadLetters = "dead_letter_events"
+
+// DeadLetterRepository implements ports.DeadLetterRepository using MongoDB.
+type DeadLetterRepository struct {
+	col *mongo.Collection
+}
+
+func NewDeadLetterRepository(db *mongo.Database) *DeadLetterRepository {
+	return &DeadLetterRepository{col: db.Collection(collectionDeadLetters)}
+}
+
+type mongoLocation struct {
+	Lat float64 `bson:"lat"`
+	Lng float64 `bson:"lng"`
+}
+
+type mongoDeadLetterEvent struct {
+	EventID        string         `bson:"event_id,omitempty"`
+	TrackingNumber string         `bson:"tracking_number"`
+	Status         string         `bson:"status"`
+	Timestamp      time.Time      `bson:"timestamp"`
+	Source         string         `bson:"source"`
+	Role           string         `bson:"role,omitempty"`
+	ReasonCode     string         `bson:"reason_code,omitempty"`
+	Piece          string         `bson:"piece,omitempty"`
+	Location       *mongoLocation `bson:"location,omitempty"`
+}
+
+type mongoDeadLetter struct {
+	ID             primitive.ObjectID   `bson:"_id,omitempty"`
+	TrackingNumber string               `bson:"tracking_number"`
+	Event          mongoDeadLetterEvent `bson:"event"`
+	Reason         string               `bson:"reason"`
+	Attempts       int                  `bson:"attempts"`
+	FirstFailedAt  time.Time            `bson:"first_failed_at"`
+	LastFailedAt   time.Time            `bson:"last_failed_at"`
+}
+
+// Save inserts a new dead letter and sets dl.ID.
+func (r *DeadLetterRepository) Save(ctx context.Context, dl *domain.DeadLetterEvent) error {
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	doc := mongoDeadLetter{
+		TrackingNumber: dl.Event.TrackingNumber,
+		Event: mongoDeadLetterEvent{
+			EventID:        dl.Event.EventID,
+			TrackingNumber: dl.Event.TrackingNumber,
+			Status:         string(dl.Event.Status),
+			Timestamp:      dl.Event.Timestamp.UTC(),
+			Source:         dl.Event.Source,
+			Role:           dl.Event.Role,
+			ReasonCode:     dl.Event.ReasonCode,
+			Piece:          dl.Event.Piece,
+		},
+		Reason:        dl.Reason,
+		Attempts:      dl.Attempts,
+		FirstFailedAt: dl.FirstFailedAt.UTC(),
+		LastFailedAt:  dl.LastFailedAt.UTC(),
+	}
+	if dl.Event.Location != nil {
+		doc.Event.Location = &mongoLocation{Lat: dl.Event.Location.Lat, Lng: dl.Event.Location.Lng}
+	}
+
+	res, err := r.col.InsertOne(ctx, doc)
+	if err != nil {
+		return fmt.Errorf("insert dead letter: %w", err)
+	}
+	if oid, ok := res.InsertedID.(primitive.ObjectID); ok {
+		dl.ID = oid.Hex()
+	}
+	return nil
+}
+
+// FindByID retrieves a dead letter by its hex ObjectID.
+func (r *DeadLetterRepository) FindByID(ctx context.Context, id string) (*domain.DeadLetterEvent, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, domain.ErrDeadLetterNotFound
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	var doc mongoDeadLetter
+	if err := r.col.FindOne(ctx, bson.M{"_id": oid}).Decode(&doc); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, domain.ErrDeadLetterNotFound
+		}
+		return nil, fmt.Errorf("find dead letter: %w", err)
+	}
+	return doc.toDomain(), nil
+}
+
+// List returns a page of dead letters ordered by most recent failure.
+func (r *DeadLetterRepository) List(ctx context.Context, filter ports.ListDeadLettersFilter) ([]*domain.DeadLetterEvent, int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	q := bson.M{}
+	if filter.TrackingNumber != "" {
+		q["tracking_number"] = filter.TrackingNumber
+	}
+
+	total, err := r.col.CountDocuments(ctx, q)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	skip := int64((filter.Page - 1) * filter.Limit)
+	opts := options.Find().
+		SetSort(bson.D{{Key: "last_failed_at", Value: -1}}).
+		SetSkip(skip).
+		SetLimit(int64(filter.Limit))
+
+	cursor, err := r.col.Find(ctx, q, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var docs []mongoDeadLetter
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, 0, err
+	}
+
+	out := make([]*domain.DeadLetterEvent, len(docs))
+	for i := range docs {
+		out[i] = docs[i].toDomain()
+	}
+	return out, total, nil
+}
+
+// MarkFailed increments the attempt counter and stores the latest failure reason.
+func (r *DeadLetterRepository) MarkFailed(ctx context.Context, id, reason string, at time.Time) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.ErrDeadLetterNotFound
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	res, err := r.col.UpdateOne(ctx, bson.M{"_id": oid}, bson.M{
+		"$set": bson.M{"reason": reason, "last_failed_at": at.UTC()},
+		"$inc": bson.M{"attempts": 1},
+	})
+	if err != nil {
+		return fmt.Errorf("update dead letter: %w", err)
+	}
+	if res.MatchedCount == 0 {
+		return domain.ErrDeadLetterNotFound
+	}
+	return nil
+}
+
+// Delete removes a single dead letter.
+func (r *DeadLetterRepository) Delete(ctx context.Context, id string) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.ErrDeadLetterNotFound
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	res, err := r.col.DeleteOne(ctx, bson.M{"_id": oid})
+	if err != nil {
+		return fmt.Errorf("delete dead letter: %w", err)
+	}
+	if res.DeletedCount == 0 {
+		return domain.ErrDeadLetterNotFound
+	}
+	return nil
+}
+
+// Purge deletes all dead letters matching the filter.
+func (r *DeadLetterRepository) Purge(ctx context.Context, filter ports.PurgeDeadLettersFilter) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	q := bson.M{}
+	if filter.TrackingNumber != "" {
+		q["tracking_number"] = filter.TrackingNumber
+	}
+	if !filter.Before.IsZero() {
+		q["last_failed_at"] = bson.M{"$lt": filter.Before.UTC()}
+	}
+
+	res, err := r.col.DeleteMany(ctx, q)
+	if err != nil {
+		return 0, fmt.Errorf("purge dead letters: %w", err)
+	}
+	return res.DeletedCount, nil
+}
+
+// EnsureIndexes creates the indexes used by the admin list and purge queries.
+func (r *DeadLetterRepository) EnsureIndexes(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	_, err := r.col.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "tracking_number", Value: 1}, {Key: "last_failed_at", Value: -1}}},
+		{Keys: bson.D{{Key: "last_failed_at", Value: -1}}},
+	})
+	return err
+}
+
+func (d mongoDeadLetter) toDomain() *domain.DeadLetterEvent {
+	dl := &domain.DeadLetterEvent{
+		ID: d.ID.Hex(),
+		Event: domain.TrackingEvent{
+			EventID:        d.Event.EventID,
+			TrackingNumber: d.Event.TrackingNumber,
+			Status:         domain.ShipmentStatus(d.Event.Status),
+			Timestamp:      d.Event.Timestamp,
+			Source:         d.Event.Source,
+			Role:           d.Event.Role,
+			ReasonCode:     d.Event.ReasonCode,
+			Piece:          d.Event.Piece,
+		},
+		Reason:        d.Reason,
+		Attempts:      d.Attempts,
+		FirstFailedAt: d.FirstFailedAt,
+		LastFailedAt:  d.LastFailedAt,
+	}
+	if d.Event.Location != nil {
+		dl.Event.Location = &domain.Coordinates{Lat: d.Event.Location.Lat, Lng: d.Event.Location.Lng}
+	}
+	return dl
+}
@@ -2,6 +2,7 @@ package mongo
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -21,25 +22,72 @@ func NewEventRepository(db *mongo.Database) ports.EventRepository {
 	return &EventRepository{db: db}
 }
 
-// UpdateShipmentStatus atomically sets the shipment status and appends a history entry.
+// UpdateShipmentStatus atomically sets the shipment status and pieces, appends
+// the history entries and increments the attempt counter, provided the
+// shipment and its pieces are still in the statuses the update was decided
+// on, so that a concurrent cancellation or event is not overwritten.
 func (r *EventRepository) UpdateShipmentStatus(
 	ctx context.Context,
 	trackingNumber string,
-	status domain.ShipmentStatus,
-	ts time.Time,
-	source string,
-	location *domain.Coordinates,
+	su ports.StatusUpdate,
 ) error {
-	historyEntry := bson.M{
-		"status":    string(status),
-		"timestamp": ts.UTC(),
-		"notes":     source,
+	entries := make(bson.A, len(su.Entries))
+	for i, e := range su.Entries {
+		e.Timestamp = e.Timestamp.UTC()
+		entries[i] = e
 	}
 
+	set := bson.M{"status": string(su.Status)}
+	if su.Pieces != nil {
+		pieces := make([]domain.Piece, len(su.Pieces))
+		for i, p := range su.Pieces {
+			p.LastEventAt = p.LastEventAt.UTC()
+			pieces[i] = p
+		}
+		set["pieces"] = pieces
+	}
+	if !su.EstimatedDelivery.IsZero() {
+		set["estimated_delivery"] = su.EstimatedDelivery.UTC()
+	}
+
+	filter := bson.M{"tracking_number": trackingNumber, "status": string(su.From)}
+	for i, st := range su.FromPieces {
+		filter[fmt.Sprintf("pieces.%d.status", i)] = string(st)
+	}
+	update := bson.M{
+		"$set":  set,
+		"$push": bson.M{"status_history": bson.M{"$each": entries}},
+	}
+	if su.FailedAttempt {
+		update["$inc"] = bson.M{"delivery_attempts": 1}
+	}
+
+	res, err := r.db.Collection("shipments").UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return domain.ErrConcurrentUpdate
+	}
+	return nil
+}
+
+// AppendLateEvent inserts a late history entry in timestamp order and leaves
+// the status untouched.
+func (r *EventRepository) AppendLateEvent(
+	ctx context.Context,
+	trackingNumber string,
+	entry domain.StatusHistoryEntry,
+) error {
+	entry.Timestamp = entry.Timestamp.UTC()
+	entry.Late = true
+
 	filter := bson.M{"tracking_number": trackingNumber}
 	update := bson.M{
-		"$set":  bson.M{"status": string(status)},
-		"$push": bson.M{"status_history": historyEntry},
+		"$push": bson.M{"status_history": bson.M{
+			"$each": bson.A{entry},
+			"$sort": bson.M{"timestamp": 1},
+		}},
 	}
 
 	_, err := r.db.Collection("shipments").UpdateOne(ctx, filter, update)
@@ -55,6 +103,21 @@ func (r *EventRepository) InsertEvent(ctx context.Context, event *domain.Trackin
 		"source":          event.Source,
 		"processed_at":    time.Now().UTC(),
 	}
+	if event.Late {
+		doc["late"] = true
+	}
+	if event.EventID != "" {
+		doc["event_id"] = event.EventID
+	}
+	if event.Role != "" {
+		doc["role"] = event.Role
+	}
+	if event.ReasonCode != "" {
+		doc["reason_code"] = event.ReasonCode
+	}
+	if event.Piece != "" {
+		doc["piece"] = event.Piece
+	}
 	if event.Location != nil {
 		doc["location"] = bson.M{
 			"lat": event.Location.Lat,
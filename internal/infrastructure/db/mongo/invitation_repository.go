@@ -0,0 +1,99 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/99minutos/shipping-system/internal/core/domain"
+)
+
+const collectionInvitations = "invitations"
+
+// InvitationRepository implements ports.InvitationRepository using MongoDB.
+// Invitations are deleted by a TTL index once they expire.
+type InvitationRepository struct {
+	col *mongo.Collection
+}
+
+func NewInvitationRepository(db *mongo.Database) *InvitationRepository {
+	return &InvitationRepository{col: db.Collection(collectionInvitations)}
+}
+
+func (r *InvitationRepository) Create(ctx context.Context, invitation *domain.Invitation) error {
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	if _, err := r.col.InsertOne(ctx, invitation); err != nil {
+		return fmt.Errorf("insert invitation: %w", err)
+	}
+	return nil
+}
+
+func (r *InvitationRepository) FindByHash(ctx context.Context, tokenHash string) (*domain.Invitation, error) {
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	var invitation domain.Invitation
+	err := r.col.FindOne(ctx, bson.M{"token_hash": tokenHash}).Decode(&invitation)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, domain.ErrInvalidInvitation
+	}
+	if err != nil {
+		return nil, fmt.Errorf("find invitation: %w", err)
+	}
+	return &invitation, nil
+}
+
+// Accept uses a conditional update, so that of two registrations with the
+// same invitation only one succeeds.
+func (r *InvitationRepository) Accept(ctx context.Context, id string, at time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	res, err := r.col.UpdateOne(ctx,
+		bson.M{"_id": id, "accepted_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"accepted_at": at}},
+	)
+	if err != nil {
+		return fmt.Errorf("accept invitation: %w", err)
+	}
+	if res.MatchedCount == 0 {
+		return domain.ErrInvalidInvitation
+	}
+	return nil
+}
+
+func (r *InvitationRepository) Release(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	if _, err := r.col.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$unset": bson.M{"accepted_at": ""}}); err != nil {
+		return fmt.Errorf("release invitation: %w", err)
+	}
+	return nil
+}
+
+// EnsureIndexes creates the lookup index and the TTL index that removes
+// expired invitations.
+func (r *InvitationRepository) EnsureIndexes(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	_, err := r.col.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "token_hash", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	})
+	return err
+}
@@ -0,0 +1,218 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/99minutos/shipping-system/internal/core/domain"
+	"github.com/99minutos/shipping-system/internal/core/ports"
+)
+
+const (
+	collectionShipmentImports    = "shipment_imports"
+	collectionShipmentImportRows = "shipment_import_rows"
+)
+
+// ShipmentImportRepository implements ports.ShipmentImportRepository using
+// MongoDB. Rows are kept in their own collection so that large files do not
+// approach the document size limit.
+type ShipmentImportRepository struct {
+	col  *mongo.Collection
+	rows *mongo.Collection
+}
+
+func NewShipmentImportRepository(db *mongo.Database) *ShipmentImportRepository {
+	return &ShipmentImportRepository{
+		col:  db.Collection(collectionShipmentImports),
+		rows: db.Collection(collectionShipmentImportRows),
+	}
+}
+
+type mongoImportRow struct {
+	ImportID         string `bson:"import_id"`
+	Seq              int    `bson:"seq"`
+	domain.ImportRow `bson:",inline"`
+}
+
+// Create inserts the rows, then the import. The unique client_id/file_hash
+// index rejects a file uploaded twice, in which case the rows just inserted
+// are removed again.
+func (r *ShipmentImportRepository) Create(ctx context.Context, imp *domain.ShipmentImport, rows []domain.ImportRow) error {
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	if len(rows) > 0 {
+		docs := make([]interface{}, len(rows))
+		for i, row := range rows {
+			docs[i] = mongoImportRow{ImportID: imp.ID, Seq: i, ImportRow: row}
+		}
+		if _, err := r.rows.InsertMany(ctx, docs); err != nil {
+			return fmt.Errorf("insert import rows: %w", err)
+		}
+	}
+
+	_, err := r.col.InsertOne(ctx, imp)
+	if err == nil {
+		return nil
+	}
+	if _, delErr := r.rows.DeleteMany(ctx, bson.M{"import_id": imp.ID}); delErr != nil {
+		return errors.Join(err, fmt.Errorf("delete import rows: %w", delErr))
+	}
+	if mongo.IsDuplicateKeyError(err) {
+		return domain.ErrImportExists
+	}
+	return err
+}
+
+// FindByID retrieves an import, filtered by client_id when clientID is set.
+func (r *ShipmentImportRepository) FindByID(ctx context.Context, id, clientID string) (*domain.ShipmentImport, error) {
+	q := bson.M{"_id": id}
+	if clientID != "" {
+		q["client_id"] = clientID
+	}
+	return r.findOne(ctx, q)
+}
+
+// FindByFileHash retrieves the import of a file the client already uploaded.
+func (r *ShipmentImportRepository) FindByFileHash(ctx context.Context, clientID, fileHash string) (*domain.ShipmentImport, error) {
+	return r.findOne(ctx, bson.M{"client_id": clientID, "file_hash": fileHash})
+}
+
+func (r *ShipmentImportRepository) findOne(ctx context.Context, q bson.M) (*domain.ShipmentImport, error) {
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	var imp domain.ShipmentImport
+	if err := r.col.FindOne(ctx, q).Decode(&imp); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, domain.ErrImportNotFound
+		}
+		return nil, err
+	}
+	return &imp, nil
+}
+
+// Claim leases the oldest runnable import with a single findAndModify, so
+// that two replicas never claim the same one.
+func (r *ShipmentImportRepository) Claim(ctx context.Context, now, leaseUntil time.Time) (*domain.ShipmentImport, error) {
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	filter := bson.M{"$or": bson.A{
+		bson.M{"status": domain.ImportQueued},
+		bson.M{"status": domain.ImportRunning, "lease_until": bson.M{"$lt": now.UTC()}},
+	}}
+	update := bson.M{"$set": bson.M{
+		"status":      domain.ImportRunning,
+		"lease_until": leaseUntil.UTC(),
+		"updated_at":  now.UTC(),
+	}}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "created_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var imp domain.ShipmentImport
+	if err := r.col.FindOneAndUpdate(ctx, filter, update, opts).Decode(&imp); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, domain.ErrImportNotFound
+		}
+		return nil, err
+	}
+	return &imp, nil
+}
+
+// Rows returns up to limit rows of an import from position from.
+func (r *ShipmentImportRepository) Rows(ctx context.Context, id string, from, limit int) ([]domain.ImportRow, error) {
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "seq", Value: 1}}).
+		SetLimit(int64(limit))
+	cursor, err := r.rows.Find(ctx, bson.M{"import_id": id, "seq": bson.M{"$gte": from}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var docs []mongoImportRow
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	rows := make([]domain.ImportRow, len(docs))
+	for i, d := range docs {
+		rows[i] = d.ImportRow
+	}
+	return rows, nil
+}
+
+// SaveProgress appends the results and moves the processed count forward,
+// only if no other worker recorded these rows first.
+func (r *ShipmentImportRepository) SaveProgress(ctx context.Context, id string, p ports.ImportProgress) error {
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	res, err := r.col.UpdateOne(ctx,
+		bson.M{"_id": id, "processed": p.Processed - len(p.Results)},
+		bson.M{
+			"$set":  bson.M{"processed": p.Processed, "lease_until": p.LeaseUntil.UTC(), "updated_at": p.At.UTC()},
+			"$inc":  bson.M{"created": p.Created, "failed": p.Failed},
+			"$push": bson.M{"results": bson.M{"$each": p.Results}},
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("save import progress: %w", err)
+	}
+	if res.MatchedCount == 0 {
+		return domain.ErrConcurrentUpdate
+	}
+	return nil
+}
+
+// Complete marks an import completed and drops its rows, which are no longer
+// needed.
+func (r *ShipmentImportRepository) Complete(ctx context.Context, id string, at time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	_, err := r.col.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$set":   bson.M{"status": domain.ImportCompleted, "finished_at": at.UTC(), "updated_at": at.UTC()},
+		"$unset": bson.M{"lease_until": ""},
+	})
+	if err != nil {
+		return fmt.Errorf("complete import: %w", err)
+	}
+	if _, err := r.rows.DeleteMany(ctx, bson.M{"import_id": id}); err != nil {
+		return fmt.Errorf("delete import rows: %w", err)
+	}
+	return nil
+}
+
+// EnsureIndexes creates the unique index that makes uploads idempotent and
+// the indexes used to claim imports and page through their rows.
+func (r *ShipmentImportRepository) EnsureIndexes(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	_, err := r.col.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "client_id", Value: 1}, {Key: "file_hash", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: 1}}},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = r.rows.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "import_id", Value: 1}, {Key: "seq", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
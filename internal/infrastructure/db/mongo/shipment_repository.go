@@ -3,6 +3,7 @@ package mongo
 import (
 	"context"
 	"errors"
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -15,24 +16,97 @@ import (
 
 const collectionShipments = "shipments"
 
+// idempotencyIndex is the unique index that scopes idempotency keys to their
+// client; a duplicate key error naming it means the key was already used.
+const idempotencyIndex = "client_id_1_idempotency_key_1"
+
+// maxTrackingNumberAttempts bounds the tracking numbers Create draws for one
+// shipment. With 51 random bits a second draw is already exceptional.
+const maxTrackingNumberAttempts = 5
+
 type ShipmentRepository struct {
-	col *mongo.Collection
+	col             *mongo.Collection
+	trackingNumbers ports.TrackingNumberGenerator
 }
 
-func NewShipmentRepository(db *mongo.Database) *ShipmentRepository {
-	return &ShipmentRepository{col: db.Collection(collectionShipments)}
+// NewShipmentRepository returns a shipment repository. trackingNumbers issues
+// the numbers of created shipments; it may be nil when the repository is
+// only used to ensure indexes.
+func NewShipmentRepository(db *mongo.Database, trackingNumbers ports.TrackingNumberGenerator) *ShipmentRepository {
+	return &ShipmentRepository{col: db.Collection(collectionShipments), trackingNumbers: trackingNumbers}
 }
 
-// Create inserts a new shipment document.
+// Create assigns s a tracking number and inserts it. The unique
+// tracking_number index rejects a number already in use, in which case
+// another one is drawn; the idempotency index rejects a key the client
+// already used, reported as domain.ErrDuplicateShipment.
 func (r *ShipmentRepository) Create(ctx context.Context, s *domain.Shipment) error {
 	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
 	defer cancel()
 
-	_, err := r.col.InsertOne(ctx, s)
-	if err != nil {
-		return err
+	for attempt := 1; ; attempt++ {
+		s.AssignTrackingNumber(r.trackingNumbers.Generate(s.ClientID))
+		_, err := r.col.InsertOne(ctx, s)
+		if err == nil {
+			return nil
+		}
+		if isIdempotencyConflict(err) {
+			return domain.ErrDuplicateShipment
+		}
+		if !mongo.IsDuplicateKeyError(err) || attempt == maxTrackingNumberAttempts {
+			return err
+		}
 	}
-	return nil
+}
+
+// CreateMany assigns each shipment a tracking number and inserts them in one
+// unordered InsertMany, so a failing shipment does not stop the rest. The
+// shipments whose number was already taken are drawn a new one and inserted
+// again.
+func (r *ShipmentRepository) CreateMany(ctx context.Context, shipments []*domain.Shipment) []error {
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	errs := make([]error, len(shipments))
+	pending := make([]int, len(shipments))
+	for i := range pending {
+		pending[i] = i
+	}
+	for attempt := 1; len(pending) > 0; attempt++ {
+		docs := make([]interface{}, len(pending))
+		for j, i := range pending {
+			shipments[i].AssignTrackingNumber(r.trackingNumbers.Generate(shipments[i].ClientID))
+			docs[j] = shipments[i]
+		}
+
+		_, err := r.col.InsertMany(ctx, docs, options.InsertMany().SetOrdered(false))
+		if err == nil {
+			return errs
+		}
+		var bwe mongo.BulkWriteException
+		if !errors.As(err, &bwe) || bwe.WriteConcernError != nil {
+			for _, i := range pending {
+				errs[i] = err
+			}
+			return errs
+		}
+
+		var retry []int
+		for _, we := range bwe.WriteErrors {
+			i := pending[we.Index]
+			if isIdempotencyConflict(we.WriteError) {
+				errs[i] = domain.ErrDuplicateShipment
+				continue
+			}
+			if mongo.IsDuplicateKeyError(we.WriteError) && attempt < maxTrackingNumberAttempts {
+				retry = append(retry, i)
+				continue
+			}
+			errs[i] = we.WriteError
+		}
+		pending = retry
+	}
+	return errs
 }
 
 // FindByTrackingNumber retrieves a shipment by tracking number.
@@ -57,13 +131,20 @@ func (r *ShipmentRepository) FindByTrackingNumber(ctx context.Context, trackingN
 	return &s, nil
 }
 
-// FindByIdempotencyKey retrieves an existing shipment that was created with the given key.
-func (r *ShipmentRepository) FindByIdempotencyKey(ctx context.Context, key string) (*domain.Shipment, error) {
+// isIdempotencyConflict reports whether err is the idempotency index
+// rejecting a key its client already used.
+func isIdempotencyConflict(err error) bool {
+	return mongo.IsDuplicateKeyError(err) && strings.Contains(err.Error(), idempotencyIndex)
+}
+
+// FindByIdempotencyKey retrieves an existing shipment that the client created
+// with the given key.
+func (r *ShipmentRepository) FindByIdempotencyKey(ctx context.Context, clientID, key string) (*domain.Shipment, error) {
 	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
 	defer cancel()
 
 	var s domain.Shipment
-	err := r.col.FindOne(ctx, bson.M{"idempotency_key": key}).Decode(&s)
+	err := r.col.FindOne(ctx, bson.M{"client_id": clientID, "idempotency_key": key}).Decode(&s)
 	if err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
 			return nil, domain.ErrShipmentNotFound
@@ -73,6 +154,29 @@ func (r *ShipmentRepository) FindByIdempotencyKey(ctx context.Context, key strin
 	return &s, nil
 }
 
+// FindByIdempotencyKeys retrieves the shipments the client created with any
+// of the given keys, indexed by key.
+func (r *ShipmentRepository) FindByIdempotencyKeys(ctx context.Context, clientID string, keys []string) (map[string]*domain.Shipment, error) {
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	cursor, err := r.col.Find(ctx, bson.M{"client_id": clientID, "idempotency_key": bson.M{"$in": keys}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var shipments []*domain.Shipment
+	if err := cursor.All(ctx, &shipments); err != nil {
+		return nil, err
+	}
+	byKey := make(map[string]*domain.Shipment, len(shipments))
+	for _, s := range shipments {
+		byKey[s.IdempotencyKey] = s
+	}
+	return byKey, nil
+}
+
 // List returns a page of shipments matching the filter and the total document count.
 func (r *ShipmentRepository) List(ctx context.Context, filter ports.ListShipmentsFilter) ([]*domain.Shipment, int64, error) {
 	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
@@ -104,6 +208,84 @@ func (r *ShipmentRepository) List(ctx context.Context, filter ports.ListShipment
 	return shipments, total, nil
 }
 
+// TransitionStatus sets the new status, and the pieces if given, and appends
+// entry only if the shipment is still in status from, so that a concurrent
+// tracking event is not overwritten.
+func (r *ShipmentRepository) TransitionStatus(
+	ctx context.Context,
+	trackingNumber string,
+	from domain.ShipmentStatus,
+	entry domain.StatusHistoryEntry,
+	pieces []domain.Piece,
+) error {
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	entry.Timestamp = entry.Timestamp.UTC()
+	set := bson.M{"status": string(entry.Status)}
+	if pieces != nil {
+		utc := make([]domain.Piece, len(pieces))
+		for i, p := range pieces {
+			p.LastEventAt = p.LastEventAt.UTC()
+			utc[i] = p
+		}
+		set["pieces"] = utc
+	}
+	res, err := r.col.UpdateOne(ctx,
+		bson.M{"tracking_number": trackingNumber, "status": string(from)},
+		bson.M{
+			"$set":  set,
+			"$push": bson.M{"status_history": entry},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return domain.ErrConcurrentUpdate
+	}
+	return nil
+}
+
+// Amend saves the corrected fields of s and appends its latest amendment. The
+// update only applies while the stored shipment keeps its status and version,
+// so an amendment cannot race a pickup scan or another amendment.
+func (r *ShipmentRepository) Amend(ctx context.Context, s *domain.Shipment, from int) error {
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	var version any = from
+	if from == 0 {
+		// Shipments created before amendments existed have no version field.
+		version = bson.M{"$in": bson.A{0, nil}}
+	}
+	amendment := s.Amendments[len(s.Amendments)-1]
+	amendment.At = amendment.At.UTC()
+	set := bson.M{
+		"sender":              s.Sender,
+		"recipient":           s.Recipient,
+		"destination":         s.Destination,
+		"package.description": s.Package.Description,
+		"service_type":        s.ServiceType,
+		"estimated_delivery":  s.EstimatedDelivery.UTC(),
+		"version":             s.Version,
+	}
+	if s.Price != nil {
+		set["price"] = s.Price
+	}
+	res, err := r.col.UpdateOne(ctx,
+		bson.M{"tracking_number": s.TrackingNumber, "status": string(s.Status), "version": version},
+		bson.M{"$set": set, "$push": bson.M{"amendments": amendment}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return domain.ErrConcurrentUpdate
+	}
+	return nil
+}
+
 // buildListFilter constructs a dynamic MongoDB filter from the given parameters.
 func buildListFilter(f ports.ListShipmentsFilter) bson.M {
 	q := bson.M{}
@@ -111,8 +293,15 @@ func buildListFilter(f ports.ListShipmentsFilter) bson.M {
 	if f.ClientID != "" {
 		q["client_id"] = f.ClientID
 	}
-	if f.Status != "" {
-		q["status"] = f.Status
+	switch len(f.Statuses) {
+	case 0:
+	case 1:
+		q["status"] = f.Statuses[0]
+	default:
+		q["status"] = bson.M{"$in": f.Statuses}
+	}
+	if f.MinAttempts > 0 {
+		q["delivery_attempts"] = bson.M{"$gte": f.MinAttempts}
 	}
 	if f.ServiceType != "" {
 		q["service_type"] = f.ServiceType
@@ -131,6 +320,7 @@ func buildListFilter(f ports.ListShipmentsFilter) bson.M {
 		q["$or"] = bson.A{
 			bson.M{"tracking_number": bson.M{"$regex": f.Search, "$options": "i"}},
 			bson.M{"sender.name": bson.M{"$regex": f.Search, "$options": "i"}},
+			bson.M{"recipient.name": bson.M{"$regex": f.Search, "$options": "i"}},
 		}
 	}
 
@@ -140,9 +330,21 @@ func (r *ShipmentRepository) EnsureIndexes(ctx context.Context) error {
 	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
+	if err := r.dropLegacyIndexes(ctx); err != nil {
+		return err
+	}
+
 	indexes := []mongo.IndexModel{
-		{Keys: bson.D{{Key: "tracking_number", Value: 1}}},
-		{Keys: bson.D{{Key: "idempotency_key", Value: 1}}},
+		{Keys: bson.D{{Key: "tracking_number", Value: 1}}, Options: options.Index().SetUnique(true)},
+		// Idempotency keys are unique per client, so concurrent retries of a
+		// request create one shipment. Shipments without a key are left out.
+		{
+			Keys: bson.D{{Key: "client_id", Value: 1}, {Key: "idempotency_key", Value: 1}},
+			Options: options.Index().
+				SetName(idempotencyIndex).
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"idempotency_key": bson.M{"$type": "string"}}),
+		},
 		// Compound indexes for list queries: sorted by created_at desc, filtered by client+status.
 		{Keys: bson.D{{Key: "client_id", Value: 1}, {Key: "created_at", Value: -1}}},
 		{Keys: bson.D{{Key: "client_id", Value: 1}, {Key: "status", Value: 1}}},
@@ -151,3 +353,23 @@ func (r *ShipmentRepository) EnsureIndexes(ctx context.Context) error {
 	_, err := r.col.Indexes().CreateMany(ctx, indexes)
 	return err
 }
+
+// dropLegacyIndexes drops the indexes EnsureIndexes replaces: the
+// tracking_number index of databases created before it was unique, and the
+// idempotency_key index of those created before keys were scoped to their
+// client. Recreating them fails if duplicates remain.
+func (r *ShipmentRepository) dropLegacyIndexes(ctx context.Context) error {
+	specs, err := r.col.Indexes().ListSpecifications(ctx)
+	if err != nil {
+		return err
+	}
+	for _, spec := range specs {
+		nonUniqueTracking := spec.Name == "tracking_number_1" && (spec.Unique == nil || !*spec.Unique)
+		if nonUniqueTracking || spec.Name == "idempotency_key_1" {
+			if _, err := r.col.Indexes().DropOne(ctx, spec.Name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package redis
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/99minutos/shipping-system/internal/core/ports"
+)
+
+// eventLocation and eventDoc are the JSON form of a tracking event stored in
+// Redis by the spill lists and the event streams.
+type eventLocation struct {
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+}
+
+type eventDoc struct {
+	ID             string         `json:"id,omitempty"`
+	TrackingNumber string         `json:"tracking_number"`
+	Status         string         `json:"status"`
+	Timestamp      time.Time      `json:"timestamp"`
+	Source         string         `json:"source"`
+	Role           string         `json:"role,omitempty"`
+	ReasonCode     string         `json:"reason_code,omitempty"`
+	Piece          string         `json:"piece,omitempty"`
+	Location       *eventLocation `json:"location,omitempty"`
+}
+
+func encodeEvent(event ports.TrackingEventInput) ([]byte, error) {
+	doc := eventDoc{
+		ID:             event.ID,
+		TrackingNumber: event.TrackingNumber,
+		Status:         event.Status,
+		Timestamp:      event.Timestamp,
+		Source:         event.Source,
+		Role:           event.Role,
+		ReasonCode:     event.ReasonCode,
+		Piece:          event.Piece,
+	}
+	if event.Location != nil {
+		doc.Location = &eventLocation{Lat: event.Location.Lat, Lng: event.Location.Lng}
+	}
+
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("encode event: %w", err)
+	}
+	return b, nil
+}
+
+func decodeEvent(b []byte) (ports.TrackingEventInput, error) {
+	var doc eventDoc
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return ports.TrackingEventInput{}, fmt.Errorf("decode event: %w", err)
+	}
+
+	event := ports.TrackingEventInput{
+		ID:             doc.ID,
+		TrackingNumber: doc.TrackingNumber,
+		Status:         doc.Status,
+		Timestamp:      doc.Timestamp,
+		Source:         doc.Source,
+		Role:           doc.Role,
+		ReasonCode:     doc.ReasonCode,
+		Piece:          doc.Piece,
+	}
+	if doc.Location != nil {
+		event.Location = &ports.LocationInput{Lat: doc.Location.Lat, Lng: doc.Location.Lng}
+	}
+	return event, nil
+}
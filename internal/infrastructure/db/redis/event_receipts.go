@@ -0,0 +1,159 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/99minutos/shipping-system/internal/core/domain"
+)
+
+const defaultReceiptTTL = 72 * time.Hour
+
+// setStateScript updates a receipt unless it would turn processed into duplicate.
+// KEYS[1] = receipt key, ARGV = state, reason, updated_at, ttl in milliseconds.
+var setStateScript = redis.NewScript(`
+if ARGV[1] == "duplicate" and redis.call("HGET", KEYS[1], "state") == "processed" then
+	return 0
+end
+redis.call("HSET", KEYS[1], "state", ARGV[1], "reason", ARGV[2], "updated_at", ARGV[3])
+redis.call("PEXPIRE", KEYS[1], ARGV[4])
+return 1
+`)
+
+// EventReceiptStore implements ports.EventReceiptRepository with Redis hashes
+// that expire after a TTL; ingestion status is only useful for a while after
+// an event is sent.
+// Key format:
+//
+//	events:receipt:<event_id>  hash with the receipt fields
+//	events:batch:<batch_id>    list of the batch's event IDs in submission order
+type EventReceiptStore struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+// NewEventReceiptStore creates an EventReceiptStore. A ttl <= 0 uses 72h.
+func NewEventReceiptStore(client *redis.Client, ttl time.Duration) *EventReceiptStore {
+	if ttl <= 0 {
+		ttl = defaultReceiptTTL
+	}
+	return &EventReceiptStore{client: client, ttl: ttl}
+}
+
+// Create stores the receipts as queued without overwriting a state a worker
+// may already have set.
+func (s *EventReceiptStore) Create(ctx context.Context, batchID string, receipts []domain.EventReceipt) error {
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		ids := make([]any, len(receipts))
+		for i, r := range receipts {
+			key := s.key(r.ID)
+			pipe.HSet(ctx, key,
+				"batch_id", r.BatchID,
+				"tracking_number", r.TrackingNumber,
+				"status", string(r.Status),
+				"client_id", r.ClientID,
+				"accepted_at", formatTime(r.AcceptedAt),
+			)
+			pipe.HSetNX(ctx, key, "state", string(r.State))
+			pipe.HSetNX(ctx, key, "updated_at", formatTime(r.UpdatedAt))
+			pipe.Expire(ctx, key, s.ttl)
+			ids[i] = r.ID
+		}
+		if batchID != "" && len(ids) > 0 {
+			pipe.RPush(ctx, s.batchKey(batchID), ids...)
+			pipe.Expire(ctx, s.batchKey(batchID), s.ttl)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("create event receipts: %w", err)
+	}
+	return nil
+}
+
+// SetState records the outcome of an event.
+func (s *EventReceiptStore) SetState(ctx context.Context, id string, state domain.EventState, reason string, at time.Time) error {
+	err := setStateScript.Run(ctx, s.client, []string{s.key(id)},
+		string(state), reason, formatTime(at), s.ttl.Milliseconds()).Err()
+	if err != nil {
+		return fmt.Errorf("set event receipt state: %w", err)
+	}
+	return nil
+}
+
+// FindByID returns the receipt of an event, or domain.ErrEventNotFound.
+func (s *EventReceiptStore) FindByID(ctx context.Context, id string) (*domain.EventReceipt, error) {
+	fields, err := s.client.HGetAll(ctx, s.key(id)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("find event receipt: %w", err)
+	}
+	if len(fields) == 0 {
+		return nil, domain.ErrEventNotFound
+	}
+	return toReceipt(id, fields), nil
+}
+
+// FindBatch returns the receipts of a batch that have not expired.
+func (s *EventReceiptStore) FindBatch(ctx context.Context, batchID string) ([]domain.EventReceipt, error) {
+	ids, err := s.client.LRange(ctx, s.batchKey(batchID), 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("find event batch: %w", err)
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	cmds := make([]*redis.MapStringStringCmd, len(ids))
+	_, err = s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, id := range ids {
+			cmds[i] = pipe.HGetAll(ctx, s.key(id))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("find event batch: %w", err)
+	}
+
+	receipts := make([]domain.EventReceipt, 0, len(ids))
+	for i, cmd := range cmds {
+		if fields := cmd.Val(); len(fields) > 0 {
+			receipts = append(receipts, *toReceipt(ids[i], fields))
+		}
+	}
+	return receipts, nil
+}
+
+func (s *EventReceiptStore) key(id string) string {
+	return "events:receipt:" + id
+}
+
+func (s *EventReceiptStore) batchKey(batchID string) string {
+	return "events:batch:" + batchID
+}
+
+func toReceipt(id string, f map[string]string) *domain.EventReceipt {
+	return &domain.EventReceipt{
+		ID:             id,
+		BatchID:        f["batch_id"],
+		TrackingNumber: f["tracking_number"],
+		Status:         domain.ShipmentStatus(f["status"]),
+		ClientID:       f["client_id"],
+		State:          domain.EventState(f["state"]),
+		Reason:         f["reason"],
+		AcceptedAt:     parseTime(f["accepted_at"]),
+		UpdatedAt:      parseTime(f["updated_at"]),
+	}
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// parseTime returns the zero time for a missing or malformed value.
+func parseTime(s string) time.Time {
+	t, _ := time.Parse(time.RFC3339Nano, s)
+	return t
+}
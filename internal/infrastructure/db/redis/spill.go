@@ -0,0 +1,73 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/99minutos/shipping-system/internal/core/ports"
+)
+
+// SpillStore keeps dispatcher overflow in one Redis list per shard.
+// Key format: events:spill:<instance>:<shard>
+//
+// The instance segment keeps each API replica's backlog separate, so a
+// replica only drains what it spilled and per-shard FIFO order holds.
+type SpillStore struct {
+	client   *redis.Client
+	instance string
+}
+
+// NewSpillStore creates a SpillStore for the given replica identity
+// (typically the pod hostname, which must be stable across restarts for a
+// restarted replica to pick up its own backlog).
+func NewSpillStore(client *redis.Client, instance string) *SpillStore {
+	return &SpillStore{client: client, instance: instance}
+}
+
+// Push appends an event to the tail of the shard list.
+func (s *SpillStore) Push(ctx context.Context, shard int, event ports.TrackingEventInput) error {
+	b, err := encodeEvent(event)
+	if err != nil {
+		return fmt.Errorf("spill push: %w", err)
+	}
+	if err := s.client.RPush(ctx, s.key(shard), b).Err(); err != nil {
+		return fmt.Errorf("spill push: %w", err)
+	}
+	return nil
+}
+
+// Pop removes the head of the shard list, blocking up to wait.
+// It returns nil, nil when the list stayed empty.
+func (s *SpillStore) Pop(ctx context.Context, shard int, wait time.Duration) (*ports.TrackingEventInput, error) {
+	res, err := s.client.BLPop(ctx, wait, s.key(shard)).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("spill pop: %w", err)
+	}
+
+	// BLPOP returns [key, value].
+	event, err := decodeEvent([]byte(res[1]))
+	if err != nil {
+		return nil, fmt.Errorf("spill pop: %w", err)
+	}
+	return &event, nil
+}
+
+// Len returns the number of events waiting for the shard.
+func (s *SpillStore) Len(ctx context.Context, shard int) (int64, error) {
+	n, err := s.client.LLen(ctx, s.key(shard)).Result()
+	if err != nil {
+		return 0, fmt.Errorf("spill len: %w", err)
+	}
+	return n, nil
+}
+
+func (s *SpillStore) key(shard int) string {
+	return fmt.Sprintf("events:spill:%s:%d", s.instance, shard)
+}
@@ -0,0 +1,188 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/99minutos/shipping-system/internal/core/ports"
+	"github.com/99minutos/shipping-system/internal/infrastructure/queue"
+)
+
+const (
+	streamGroup = "event-workers"
+	streamField = "event"
+)
+
+// leaseScript takes the lease when it is free and renews it when this
+// consumer already holds it.
+// KEYS[1] = lease key, ARGV[1] = consumer, ARGV[2] = ttl in milliseconds.
+var leaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+if redis.call("SET", KEYS[1], ARGV[1], "NX", "PX", ARGV[2]) then
+	return 1
+end
+return 0
+`)
+
+// releaseScript deletes the lease only if this consumer still holds it.
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// EventStream implements queue.StreamBroker on Redis Streams, with one stream
+// per partition read through the "event-workers" consumer group.
+// Key format:
+//
+//	events:stream:<partition>        stream of JSON-encoded events
+//	events:stream:<partition>:lease  consumer currently owning the partition
+//
+// Acknowledged entries are deleted from the stream, so its length is the
+// partition backlog.
+type EventStream struct {
+	client   *redis.Client
+	consumer string
+}
+
+// NewEventStream creates an EventStream that reads as the given consumer
+// (typically the pod hostname; a replica restarted under the same name
+// recovers its own unacknowledged entries).
+func NewEventStream(client *redis.Client, consumer string) *EventStream {
+	return &EventStream{client: client, consumer: consumer}
+}
+
+var _ queue.StreamBroker = (*EventStream)(nil)
+
+// EnsureGroup creates the consumer group, and the stream with it, if needed.
+func (s *EventStream) EnsureGroup(ctx context.Context, partition int) error {
+	err := s.client.XGroupCreateMkStream(ctx, s.key(partition), streamGroup, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return fmt.Errorf("stream create group: %w", err)
+	}
+	return nil
+}
+
+// Publish appends the event to the partition stream.
+func (s *EventStream) Publish(ctx context.Context, partition int, event ports.TrackingEventInput) error {
+	b, err := encodeEvent(event)
+	if err != nil {
+		return fmt.Errorf("stream publish: %w", err)
+	}
+	err = s.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: s.key(partition),
+		Values: map[string]any{streamField: b},
+	}).Err()
+	if err != nil {
+		return fmt.Errorf("stream publish: %w", err)
+	}
+	return nil
+}
+
+// Lease acquires or renews this consumer's ownership of the partition.
+func (s *EventStream) Lease(ctx context.Context, partition int, ttl time.Duration) (bool, error) {
+	n, err := leaseScript.Run(ctx, s.client, []string{s.leaseKey(partition)}, s.consumer, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, fmt.Errorf("stream lease: %w", err)
+	}
+	return n == 1, nil
+}
+
+// Release drops the partition lease if this consumer holds it.
+func (s *EventStream) Release(ctx context.Context, partition int) error {
+	if err := releaseScript.Run(ctx, s.client, []string{s.leaseKey(partition)}, s.consumer).Err(); err != nil {
+		return fmt.Errorf("stream release: %w", err)
+	}
+	return nil
+}
+
+// Claim transfers entries pending for at least minIdle to this consumer.
+func (s *EventStream) Claim(ctx context.Context, partition int, minIdle time.Duration, count int) ([]queue.StreamMessage, error) {
+	msgs, _, err := s.client.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+		Stream:   s.key(partition),
+		Group:    streamGroup,
+		Consumer: s.consumer,
+		MinIdle:  minIdle,
+		Start:    "0-0",
+		Count:    int64(count),
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("stream claim: %w", err)
+	}
+	return toStreamMessages(msgs), nil
+}
+
+// Pending returns the number of entries delivered but not acknowledged.
+func (s *EventStream) Pending(ctx context.Context, partition int) (int64, error) {
+	res, err := s.client.XPending(ctx, s.key(partition), streamGroup).Result()
+	if err != nil {
+		return 0, fmt.Errorf("stream pending: %w", err)
+	}
+	return res.Count, nil
+}
+
+// Read returns entries never delivered to the group, blocking up to block.
+func (s *EventStream) Read(ctx context.Context, partition int, count int, block time.Duration) ([]queue.StreamMessage, error) {
+	streams, err := s.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    streamGroup,
+		Consumer: s.consumer,
+		Streams:  []string{s.key(partition), ">"},
+		Count:    int64(count),
+		Block:    block,
+	}).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("stream read: %w", err)
+	}
+	if len(streams) == 0 {
+		return nil, nil
+	}
+	return toStreamMessages(streams[0].Messages), nil
+}
+
+// Ack acknowledges the entry and deletes it from the stream.
+func (s *EventStream) Ack(ctx context.Context, partition int, id string) error {
+	key := s.key(partition)
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.XAck(ctx, key, streamGroup, id)
+		pipe.XDel(ctx, key, id)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("stream ack: %w", err)
+	}
+	return nil
+}
+
+func (s *EventStream) key(partition int) string {
+	return fmt.Sprintf("events:stream:%d", partition)
+}
+
+func (s *EventStream) leaseKey(partition int) string {
+	return s.key(partition) + ":lease"
+}
+
+func toStreamMessages(msgs []redis.XMessage) []queue.StreamMessage {
+	out := make([]queue.StreamMessage, 0, len(msgs))
+	for _, m := range msgs {
+		msg := queue.StreamMessage{ID: m.ID}
+		raw, ok := m.Values[streamField].(string)
+		if !ok {
+			msg.Err = fmt.Errorf("stream entry %s: missing %q field", m.ID, streamField)
+		} else if msg.Event, msg.Err = decodeEvent([]byte(raw)); msg.Err != nil {
+			msg.Err = fmt.Errorf("stream entry %s: %w", m.ID, msg.Err)
+		}
+		out = append(out, msg)
+	}
+	return out
+}
@@ -0,0 +1,46 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// TokenDenylist implements ports.TokenDenylist with keys that expire together
+// with the token they deny.
+// Key format: auth:denylist:<jti>
+type TokenDenylist struct {
+	client *redis.Client
+}
+
+// NewTokenDenylist creates a TokenDenylist wrapping the given Redis client.
+func NewTokenDenylist(client *redis.Client) *TokenDenylist {
+	return &TokenDenylist{client: client}
+}
+
+// Revoke denies the token until it expires; tokens already expired are
+// rejected anyway and are not stored.
+func (d *TokenDenylist) Revoke(ctx context.Context, jti string, until time.Time) error {
+	ttl := time.Until(until)
+	if ttl <= 0 {
+		return nil
+	}
+	if err := d.client.Set(ctx, d.key(jti), "1", ttl).Err(); err != nil {
+		return fmt.Errorf("revoke token: %w", err)
+	}
+	return nil
+}
+
+func (d *TokenDenylist) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	n, err := d.client.Exists(ctx, d.key(jti)).Result()
+	if err != nil {
+		return false, fmt.Errorf("check token denylist: %w", err)
+	}
+	return n > 0, nil
+}
+
+func (d *TokenDenylist) key(jti string) string {
+	return "auth:denylist:" + jti
+}
@@ -0,0 +1,158 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	apimetrics "github.com/99minutos/shipping-system/internal/api/metrics"
+	"github.com/99minutos/shipping-system/internal/core/domain"
+	"github.com/99minutos/shipping-system/internal/core/ports"
+)
+
+// AdmissionPolicy decides what Enqueue does when a shard's buffer is full.
+type AdmissionPolicy string
+
+const (
+	// AdmissionBlock waits up to Admission.Timeout for room, then rejects.
+	AdmissionBlock AdmissionPolicy = "block"
+	// AdmissionReject fails immediately.
+	AdmissionReject AdmissionPolicy = "reject"
+	// AdmissionSpill writes the overflow to a SpillStore; a per-shard drainer
+	// feeds it back to the worker as room frees up.
+	AdmissionSpill AdmissionPolicy = "spill"
+)
+
+const (
+	defaultEnqueueTimeout = 2 * time.Second
+	spillPollInterval     = time.Second
+)
+
+// ParseAdmissionPolicy converts a configuration string into an AdmissionPolicy.
+func ParseAdmissionPolicy(s string) (AdmissionPolicy, error) {
+	switch p := AdmissionPolicy(strings.ToLower(strings.TrimSpace(s))); p {
+	case AdmissionBlock, AdmissionReject, AdmissionSpill:
+		return p, nil
+	case "":
+		return AdmissionBlock, nil
+	default:
+		return "", fmt.Errorf("unknown admission policy %q (want block, reject or spill)", s)
+	}
+}
+
+// Admission configures the per-shard buffers and what happens when they fill up.
+type Admission struct {
+	// BufferSize is the capacity of each shard channel. Defaults to channelBuffer.
+	BufferSize int
+	Policy     AdmissionPolicy
+	// Timeout bounds how long AdmissionBlock waits for room.
+	Timeout time.Duration
+	// Spill receives overflow under AdmissionSpill. Required for that policy.
+	Spill SpillStore
+}
+
+// SpillStore is durable overflow storage for events that did not fit in a
+// shard buffer. Implementations must preserve FIFO order per shard.
+type SpillStore interface {
+	Push(ctx context.Context, shard int, event ports.TrackingEventInput) error
+	// Pop removes the oldest event of the shard, waiting up to wait for one.
+	// It returns nil without error when none arrived in time.
+	Pop(ctx context.Context, shard int, wait time.Duration) (*ports.TrackingEventInput, error)
+	Len(ctx context.Context, shard int) (int64, error)
+}
+
+// admit places event on shard idx according to the admission policy.
+func (d *Dispatcher) admit(ctx context.Context, idx int, event ports.TrackingEventInput) error {
+	ch := d.workers[idx]
+
+	// Once a shard has spilled, later events must queue behind the spilled
+	// ones or they would overtake them.
+	if d.admission.Policy == AdmissionSpill && d.spilled[idx].Load() > 0 {
+		return d.spill(ctx, idx, event)
+	}
+
+	select {
+	case ch <- event:
+		d.pending.Add(1)
+		return nil
+	default:
+	}
+
+	switch d.admission.Policy {
+	case AdmissionReject:
+		apimetrics.EventsRejectedTotal.WithLabelValues(string(AdmissionReject)).Inc()
+		return domain.ErrEventQueueFull
+	case AdmissionSpill:
+		return d.spill(ctx, idx, event)
+	}
+
+	timeout := d.admission.Timeout
+	if timeout <= 0 {
+		timeout = defaultEnqueueTimeout
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case ch <- event:
+		d.pending.Add(1)
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		apimetrics.EventsRejectedTotal.WithLabelValues(string(AdmissionBlock)).Inc()
+		return domain.ErrEventQueueFull
+	}
+}
+
+func (d *Dispatcher) spill(ctx context.Context, idx int, event ports.TrackingEventInput) error {
+	d.spilled[idx].Add(1)
+	if err := d.admission.Spill.Push(ctx, idx, event); err != nil {
+		d.spilled[idx].Add(-1)
+		d.log.Error().Err(err).Int("worker_id", idx).Msg("failed to spill event")
+		apimetrics.EventsRejectedTotal.WithLabelValues(string(AdmissionSpill)).Inc()
+		return fmt.Errorf("spill event: %w", domain.ErrEventQueueUnavailable)
+	}
+	apimetrics.EventsSpilledTotal.Inc()
+	return nil
+}
+
+// drainSpill moves spilled events for shard idx back into its channel,
+// blocking while the channel is full so the spill order is preserved.
+func (d *Dispatcher) drainSpill(ctx context.Context, idx int) {
+	ch := d.workers[idx]
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+
+		event, err := d.admission.Spill.Pop(ctx, idx, spillPollInterval)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			d.log.Error().Err(err).Int("worker_id", idx).Msg("failed to read spilled events")
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(spillPollInterval):
+			}
+			continue
+		}
+		if event == nil {
+			continue
+		}
+
+		select {
+		case ch <- *event:
+			d.pending.Add(1)
+			d.spilled[idx].Add(-1)
+		case <-ctx.Done():
+			// Pushing it back would put it behind newer spilled events;
+			// dead-letter it instead so it is not lost.
+			d.deadLetter(ctx, *event, fmt.Errorf("dispatcher stopped before spilled event was processed: %w", ctx.Err()), 0)
+			return
+		}
+	}
+}
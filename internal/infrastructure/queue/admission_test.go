@@ -0,0 +1,204 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/99minutos/shipping-system/internal/core/domain"
+	"github.com/99minutos/shipping-system/internal/core/ports"
+)
+
+// memorySpill is an in-memory SpillStore.
+type memorySpill struct {
+	mu      sync.Mutex
+	lists   map[int][]ports.TrackingEventInput
+	pushErr error
+}
+
+func newMemorySpill() *memorySpill {
+	return &memorySpill{lists: make(map[int][]ports.TrackingEventInput)}
+}
+
+func (m *memorySpill) Push(_ context.Context, shard int, event ports.TrackingEventInput) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.pushErr != nil {
+		return m.pushErr
+	}
+	m.lists[shard] = append(m.lists[shard], event)
+	return nil
+}
+
+func (m *memorySpill) Pop(ctx context.Context, shard int, wait time.Duration) (*ports.TrackingEventInput, error) {
+	deadline := time.Now().Add(wait)
+	for {
+		m.mu.Lock()
+		if l := m.lists[shard]; len(l) > 0 {
+			m.lists[shard] = l[1:]
+			m.mu.Unlock()
+			return &l[0], nil
+		}
+		m.mu.Unlock()
+		if time.Now().After(deadline) || ctx.Err() != nil {
+			return nil, nil
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func (m *memorySpill) Len(_ context.Context, shard int) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return int64(len(m.lists[shard])), nil
+}
+
+// blockingService holds every event until release is closed.
+type blockingService struct {
+	mu      sync.Mutex
+	release chan struct{}
+	seen    []string
+}
+
+func (s *blockingService) Process(ctx context.Context, in ports.TrackingEventInput) error {
+	select {
+	case <-s.release:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	s.mu.Lock()
+	s.seen = append(s.seen, in.Status)
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *blockingService) Apply(ctx context.Context, in ports.TrackingEventInput) (domain.EventState, error) {
+	return domain.EventProcessed, s.Process(ctx, in)
+}
+
+func (s *blockingService) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.seen)
+}
+
+func event(status string) ports.TrackingEventInput {
+	return ports.TrackingEventInput{TrackingNumber: "99M-AAAA0001", Status: status}
+}
+
+func TestParseAdmissionPolicy(t *testing.T) {
+	if p, err := ParseAdmissionPolicy(""); err != nil || p != AdmissionBlock {
+		t.Errorf("empty policy: got %q, %v", p, err)
+	}
+	if p, err := ParseAdmissionPolicy("Reject"); err != nil || p != AdmissionReject {
+		t.Errorf("Reject: got %q, %v", p, err)
+	}
+	if _, err := ParseAdmissionPolicy("drop"); err == nil {
+		t.Error("expected error for unknown policy")
+	}
+}
+
+func TestNewDispatcher_SpillRequiresStore(t *testing.T) {
+	if _, err := NewDispatcher(1, &blockingService{}, &stubRecorder{}, fastPolicy(1), 0, Admission{Policy: AdmissionSpill}, zerolog.Nop()); err == nil {
+		t.Fatal("expected error for spill policy without store")
+	}
+}
+
+func TestEnqueue_RejectWhenFull(t *testing.T) {
+	d := newTestDispatcher(t, &blockingService{}, &stubRecorder{}, fastPolicy(1),
+		Admission{BufferSize: 1, Policy: AdmissionReject})
+
+	// Workers are not started, so the single buffer slot fills up.
+	if err := d.Enqueue(context.Background(), event("picked_up")); err != nil {
+		t.Fatalf("first enqueue: %v", err)
+	}
+	err := d.Enqueue(context.Background(), event("in_warehouse"))
+	if !errors.Is(err, domain.ErrEventQueueFull) {
+		t.Fatalf("expected ErrEventQueueFull, got %v", err)
+	}
+}
+
+func TestEnqueue_BlockTimesOut(t *testing.T) {
+	d := newTestDispatcher(t, &blockingService{}, &stubRecorder{}, fastPolicy(1),
+		Admission{BufferSize: 1, Policy: AdmissionBlock, Timeout: 10 * time.Millisecond})
+
+	_ = d.Enqueue(context.Background(), event("picked_up"))
+
+	start := time.Now()
+	err := d.Enqueue(context.Background(), event("in_warehouse"))
+	if !errors.Is(err, domain.ErrEventQueueFull) {
+		t.Fatalf("expected ErrEventQueueFull, got %v", err)
+	}
+	if time.Since(start) < 10*time.Millisecond {
+		t.Error("expected enqueue to wait for the configured timeout")
+	}
+}
+
+func TestEnqueueBatch_ReportsFailingIndex(t *testing.T) {
+	d := newTestDispatcher(t, &blockingService{}, &stubRecorder{}, fastPolicy(1),
+		Admission{BufferSize: 1, Policy: AdmissionReject})
+
+	err := d.EnqueueBatch(context.Background(), []ports.TrackingEventInput{event("picked_up"), event("in_warehouse")})
+	if !errors.Is(err, domain.ErrEventQueueFull) {
+		t.Fatalf("expected ErrEventQueueFull, got %v", err)
+	}
+	if err.Error() != "event[1]: event queue is full" {
+		t.Errorf("unexpected error message: %q", err.Error())
+	}
+}
+
+func TestEnqueue_SpillPreservesOrder(t *testing.T) {
+	svc := &blockingService{release: make(chan struct{})}
+	spill := newMemorySpill()
+	d := newTestDispatcher(t, svc, &stubRecorder{}, fastPolicy(1),
+		Admission{BufferSize: 1, Policy: AdmissionSpill, Spill: spill})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	statuses := []string{"picked_up", "in_warehouse", "in_transit", "delivered"}
+	for _, s := range statuses {
+		if err := d.Enqueue(ctx, event(s)); err != nil {
+			t.Fatalf("enqueue %s: %v", s, err)
+		}
+	}
+	if n, _ := spill.Len(ctx, 0); n == 0 {
+		t.Fatal("expected overflow to be spilled")
+	}
+
+	d.Start(ctx)
+	close(svc.release)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for svc.count() < len(statuses) {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out: processed %v", svc.seen)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+	for i, s := range statuses {
+		if svc.seen[i] != s {
+			t.Fatalf("order broken: got %v, want %v", svc.seen, statuses)
+		}
+	}
+}
+
+func TestEnqueue_SpillFailureIsUnavailable(t *testing.T) {
+	spill := newMemorySpill()
+	spill.pushErr = errors.New("redis down")
+	d := newTestDispatcher(t, &blockingService{}, &stubRecorder{}, fastPolicy(1),
+		Admission{BufferSize: 1, Policy: AdmissionSpill, Spill: spill})
+
+	_ = d.Enqueue(context.Background(), event("picked_up"))
+	err := d.Enqueue(context.Background(), event("in_warehouse"))
+	if !errors.Is(err, domain.ErrEventQueueUnavailable) {
+		t.Fatalf("expected ErrEventQueueUnavailable, got %v", err)
+	}
+}
@@ -2,66 +2,237 @@ package queue
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"hash/fnv"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/rs/zerolog"
 
 	apimetrics "github.com/99minutos/shipping-system/internal/api/metrics"
+	"github.com/99minutos/shipping-system/internal/core/domain"
 	"github.com/99minutos/shipping-system/internal/core/ports"
 )
 
 const (
 	defaultWorkers = 8
 	channelBuffer  = 256
+
+	// deadLetterTimeout bounds the write of a failed event to the dead-letter
+	// store; it is detached from the worker context so shutdown does not lose it.
+	deadLetterTimeout = 5 * time.Second
 )
 
+// DeadLetterRecorder stores events that could not be processed.
+type DeadLetterRecorder interface {
+	Record(ctx context.Context, event ports.TrackingEventInput, reason error, attempts int) error
+}
+
 // Dispatcher routes tracking events to a fixed set of workers using consistent
 // hashing on the tracking number, guaranteeing per-shipment event ordering.
+// Each worker holds events in a reorder window before applying them, so the
+// events of a shipment are applied in timestamp order.
 type Dispatcher struct {
-	workers []chan ports.TrackingEventInput
-	service ports.EventService
-	log     zerolog.Logger
+	processor
+	workers   []chan ports.TrackingEventInput
+	held      []*reorderBuffer[ports.TrackingEventInput] // per worker, owned by it while running
+	spilled   []atomic.Int64                             // per-shard events waiting in the spill store
+	pending   atomic.Int64                               // events in the shard buffers or reorder windows
+	admission Admission
+	wg        sync.WaitGroup // workers
+	spillWg   sync.WaitGroup // spill drainers
+
+	// mu guards closed: Enqueue holds it for reading while it sends, so
+	// Shutdown can close the channels once it holds it for writing.
+	mu          sync.RWMutex
+	closed      bool
+	cancelWork  context.CancelFunc
+	cancelSpill context.CancelFunc
 }
 
 // NewDispatcher creates a Dispatcher with numWorkers sharded workers.
-// If numWorkers <= 0, defaultWorkers is used.
-func NewDispatcher(numWorkers int, service ports.EventService, log zerolog.Logger) *Dispatcher {
+// If numWorkers <= 0, defaultWorkers is used. Failed events are retried
+// according to retry and handed to dlq once they cannot be applied. Events
+// are held for reorder before being applied; zero applies them on arrival.
+// admission sizes the shard buffers and decides what happens when they are full.
+func NewDispatcher(
+	numWorkers int,
+	service ports.EventService,
+	dlq DeadLetterRecorder,
+	retry RetryPolicy,
+	reorder time.Duration,
+	admission Admission,
+	log zerolog.Logger,
+) (*Dispatcher, error) {
 	if numWorkers <= 0 {
 		numWorkers = defaultWorkers
 	}
+	if admission.BufferSize <= 0 {
+		admission.BufferSize = channelBuffer
+	}
+	if admission.Policy == "" {
+		admission.Policy = AdmissionBlock
+	}
+	if admission.Policy == AdmissionSpill && admission.Spill == nil {
+		return nil, fmt.Errorf("new dispatcher: %s admission requires a spill store", AdmissionSpill)
+	}
+
 	d := &Dispatcher{
-		workers: make([]chan ports.TrackingEventInput, numWorkers),
-		service: service,
-		log:     log,
+		processor: processor{service: service, dlq: dlq, retry: retry, log: log},
+		workers:   make([]chan ports.TrackingEventInput, numWorkers),
+		held:      make([]*reorderBuffer[ports.TrackingEventInput], numWorkers),
+		spilled:   make([]atomic.Int64, numWorkers),
+		admission: admission,
 	}
 	for i := range d.workers {
-		d.workers[i] = make(chan ports.TrackingEventInput, channelBuffer)
+		d.workers[i] = make(chan ports.TrackingEventInput, admission.BufferSize)
+		d.held[i] = newReorderBuffer[ports.TrackingEventInput](reorder)
 	}
-	return d
+	return d, nil
 }
 
-// Start launches all worker goroutines. Workers stop when ctx is cancelled.
+// Start launches all worker goroutines, plus one spill drainer per shard
+// under AdmissionSpill. Workers stop when ctx is cancelled, abandoning the
+// events still buffered; use Shutdown to drain them first.
 func (d *Dispatcher) Start(ctx context.Context) {
+	workCtx, cancelWork := context.WithCancel(ctx)
+	spillCtx, cancelSpill := context.WithCancel(ctx)
+	d.cancelWork, d.cancelSpill = cancelWork, cancelSpill
+
 	for i, ch := range d.workers {
-		go d.runWorker(ctx, i, ch)
+		d.wg.Add(1)
+		go func(id int, ch <-chan ports.TrackingEventInput) {
+			defer d.wg.Done()
+			d.runWorker(workCtx, id, ch)
+		}(i, ch)
+	}
+
+	if d.admission.Policy != AdmissionSpill {
+		return
+	}
+	ctx = spillCtx
+	for i := range d.workers {
+		// Events spilled before a restart are still in the store.
+		if n, err := d.admission.Spill.Len(ctx, i); err != nil {
+			d.log.Error().Err(err).Int("worker_id", i).Msg("failed to read spill backlog")
+		} else {
+			d.spilled[i].Store(n)
+		}
+
+		d.spillWg.Add(1)
+		go func(id int) {
+			defer d.spillWg.Done()
+			d.drainSpill(ctx, id)
+		}(i)
 	}
 }
 
+// Wait blocks until every worker and spill drainer has returned. Call it after
+// cancelling the context passed to Start so the event being processed by each
+// worker can finish before its dependencies are torn down.
+func (d *Dispatcher) Wait() {
+	d.wg.Wait()
+	d.spillWg.Wait()
+}
+
+// Shutdown stops admission and drains the shard buffers. New events are
+// rejected with domain.ErrEventQueueUnavailable, the spill drainers stop
+// (spilled events stay in the store for the next start) and the workers keep
+// processing until their buffers are empty, applying the events held in their
+// reorder windows without waiting for the window. If ctx ends first, the
+// workers are stopped and whatever is still buffered or held is written to
+// the dead-letter store.
+// It returns ctx's error when the deadline cut the drain short.
+func (d *Dispatcher) Shutdown(ctx context.Context) (ShutdownResult, error) {
+	d.mu.Lock()
+	if d.closed {
+		d.mu.Unlock()
+		return ShutdownResult{}, nil
+	}
+	d.closed = true
+	d.mu.Unlock()
+
+	// The drainers are the only other senders; stop them before closing.
+	if d.cancelSpill != nil {
+		d.cancelSpill()
+	}
+	d.spillWg.Wait()
+
+	// Every sender has stopped, so this counts each accepted event not yet
+	// taken by a worker exactly once.
+	result := ShutdownResult{Buffered: int(d.pending.Load())}
+	for _, ch := range d.workers {
+		close(ch)
+	}
+
+	drained := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(drained)
+	}()
+
+	var err error
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		err = ctx.Err()
+		if d.cancelWork != nil {
+			d.cancelWork()
+		}
+		<-drained
+	}
+
+	// Anything left was never picked up by a worker.
+	reason := errors.New("dispatcher shut down before event was processed")
+	for i, ch := range d.workers {
+		leftovers := d.held[i].drain()
+		for event := range ch {
+			leftovers = append(leftovers, event)
+		}
+		for _, event := range leftovers {
+			if d.deadLetter(ctx, event, reason, 0) != nil {
+				result.Lost++
+			} else {
+				result.Persisted++
+			}
+		}
+	}
+	result.Flushed = result.Buffered - result.Persisted - result.Lost
+	return result, err
+}
+
 // Enqueue sends an event to the worker responsible for its tracking number.
-// The call is non-blocking up to channelBuffer capacity.
-func (d *Dispatcher) Enqueue(event ports.TrackingEventInput) {
+// When the shard buffer is full the admission policy applies; a saturated
+// queue is reported as domain.ErrEventQueueFull and an unreachable spill
+// store as domain.ErrEventQueueUnavailable.
+func (d *Dispatcher) Enqueue(ctx context.Context, event ports.TrackingEventInput) error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.closed {
+		return fmt.Errorf("dispatcher shut down: %w", domain.ErrEventQueueUnavailable)
+	}
+
 	idx := d.shardIndex(event.TrackingNumber)
-	d.workers[idx] <- event
+	if err := d.admit(ctx, idx, event); err != nil {
+		return err
+	}
 	apimetrics.EventsQueueDepth.WithLabelValues(fmt.Sprintf("%d", idx)).Set(float64(len(d.workers[idx])))
+	return nil
 }
 
 // EnqueueBatch enqueues multiple events preserving per-shipment ordering.
-func (d *Dispatcher) EnqueueBatch(events []ports.TrackingEventInput) {
-	for _, e := range events {
-		d.Enqueue(e)
+// It stops at the first event that is not admitted; events admitted before it
+// stay queued, and resending the whole batch is safe because duplicates are
+// discarded by the event service.
+func (d *Dispatcher) EnqueueBatch(ctx context.Context, events []ports.TrackingEventInput) error {
+	for i, e := range events {
+		if err := d.Enqueue(ctx, e); err != nil {
+			return &ports.EnqueueBatchError{Index: i, Err: err}
+		}
 	}
+	return nil
 }
 
 // shardIndex maps a tracking number deterministically to a worker index.
@@ -71,32 +242,60 @@ func (d *Dispatcher) shardIndex(trackingNumber string) int {
 	return int(h.Sum32()) % len(d.workers)
 }
 
+// runWorker moves events from the shard channel into the worker's reorder
+// window and applies them as they fall due. Once the channel is closed it
+// applies everything still held and returns.
 func (d *Dispatcher) runWorker(ctx context.Context, id int, ch <-chan ports.TrackingEventInput) {
 	workerLabel := fmt.Sprintf("%d", id)
+	held := d.held[id]
 	for {
+		d.applyDue(ctx, id, held, time.Now())
+
+		// Checked first: select picks at random when an event is also ready,
+		// and a stopped worker must leave the rest of its buffer to Shutdown.
+		if ctx.Err() != nil {
+			return
+		}
+
+		var (
+			wake  <-chan time.Time
+			timer *time.Timer
+		)
+		if due, ok := held.next(); ok {
+			timer = time.NewTimer(time.Until(due))
+			wake = timer.C
+		}
+
 		select {
 		case <-ctx.Done():
 			return
+		case <-wake:
 		case event, ok := <-ch:
 			if !ok {
+				d.applyDue(ctx, id, held, maxTime)
 				return
 			}
 			// Update queue depth after dequeue
 			apimetrics.EventsQueueDepth.WithLabelValues(workerLabel).Set(float64(len(ch)))
+			held.add(event.TrackingNumber, event.Timestamp, event, time.Now())
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
 
-			start := time.Now()
-			err := d.service.Process(ctx, event)
-			elapsed := time.Since(start).Seconds()
-
-			statusLabel := event.Status
-			if err != nil {
-				statusLabel = "error"
-				d.log.Error().Err(err).
-					Str("tracking_number", event.TrackingNumber).
-					Int("worker_id", id).
-					Msg("event processing failed")
-			}
-			apimetrics.EventProcessingDuration.WithLabelValues(statusLabel).Observe(elapsed)
+// applyDue processes the held events that are due at now, stopping early if
+// ctx ends so the rest stay held for Shutdown.
+func (d *Dispatcher) applyDue(ctx context.Context, id int, held *reorderBuffer[ports.TrackingEventInput], now time.Time) {
+	for ctx.Err() == nil {
+		event, ok := held.pop(now)
+		if !ok {
+			return
+		}
+		d.pending.Add(-1)
+		if attempts, err := d.handle(ctx, id, event); err != nil {
+			d.deadLetter(ctx, event, err, attempts)
 		}
 	}
 }
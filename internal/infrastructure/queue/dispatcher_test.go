@@ -0,0 +1,109 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/99minutos/shipping-system/internal/core/domain"
+)
+
+// enqueueAndWaitPickup enqueues the statuses and waits until the worker has
+// taken the first one, so the rest stay buffered behind it.
+func enqueueAndWaitPickup(t *testing.T, d *Dispatcher, statuses ...string) {
+	t.Helper()
+	for _, s := range statuses {
+		if err := d.Enqueue(context.Background(), event(s)); err != nil {
+			t.Fatalf("enqueue %s: %v", s, err)
+		}
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for len(d.workers[0]) != len(statuses)-1 {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the worker to pick up an event")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestDispatcher_ShutdownFlushesBufferedEvents(t *testing.T) {
+	svc := &blockingService{release: make(chan struct{})}
+	dlq := &stubRecorder{}
+	d := newTestDispatcher(t, svc, dlq, fastPolicy(1), Admission{})
+	d.Start(context.Background())
+	enqueueAndWaitPickup(t, d, "picked_up", "in_warehouse", "in_transit")
+
+	time.AfterFunc(10*time.Millisecond, func() { close(svc.release) })
+	result, err := d.Shutdown(context.Background())
+	if err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+
+	want := ShutdownResult{Buffered: 2, Flushed: 2}
+	if result != want {
+		t.Errorf("result = %+v, want %+v", result, want)
+	}
+	if svc.count() != 3 {
+		t.Errorf("expected all 3 events processed, got %d", svc.count())
+	}
+	if len(dlq.records) != 0 {
+		t.Errorf("expected no dead letters, got %v", dlq.records)
+	}
+}
+
+func TestDispatcher_ShutdownDeadlinePersistsLeftovers(t *testing.T) {
+	svc := &blockingService{release: make(chan struct{})}
+	dlq := &stubRecorder{}
+	d := newTestDispatcher(t, svc, dlq, fastPolicy(1), Admission{})
+	d.Start(context.Background())
+	enqueueAndWaitPickup(t, d, "picked_up", "in_warehouse", "in_transit")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	result, err := d.Shutdown(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected DeadlineExceeded, got %v", err)
+	}
+
+	want := ShutdownResult{Buffered: 2, Persisted: 2}
+	if result != want {
+		t.Errorf("result = %+v, want %+v", result, want)
+	}
+	// The interrupted in-flight event is dead-lettered by its worker as well.
+	if len(dlq.records) != 3 {
+		t.Errorf("expected 3 dead letters, got %v", dlq.records)
+	}
+}
+
+func TestDispatcher_ShutdownCountsLostEvents(t *testing.T) {
+	svc := &blockingService{release: make(chan struct{})}
+	dlq := &stubRecorder{err: errors.New("mongo down")}
+	d := newTestDispatcher(t, svc, dlq, fastPolicy(1), Admission{})
+	d.Start(context.Background())
+	enqueueAndWaitPickup(t, d, "picked_up", "in_warehouse")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	result, _ := d.Shutdown(ctx)
+
+	if want := (ShutdownResult{Buffered: 1, Lost: 1}); result != want {
+		t.Errorf("result = %+v, want %+v", result, want)
+	}
+}
+
+func TestDispatcher_EnqueueAfterShutdownIsUnavailable(t *testing.T) {
+	d := newTestDispatcher(t, &blockingService{}, &stubRecorder{}, fastPolicy(1), Admission{})
+	d.Start(context.Background())
+	if _, err := d.Shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+
+	err := d.Enqueue(context.Background(), event("picked_up"))
+	if !errors.Is(err, domain.ErrEventQueueUnavailable) {
+		t.Fatalf("expected ErrEventQueueUnavailable, got %v", err)
+	}
+	if _, err := d.Shutdown(context.Background()); err != nil {
+		t.Errorf("second shutdown: %v", err)
+	}
+}
@@ -0,0 +1,105 @@
+package queue
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	apimetrics "github.com/99minutos/shipping-system/internal/api/metrics"
+	"github.com/99minutos/shipping-system/internal/core/ports"
+)
+
+// processor applies events to the event service with retries and hands the
+// ones that cannot be applied to the dead-letter store. It is shared by every
+// EventQueue backend so they retry and dead-letter identically.
+type processor struct {
+	service ports.EventService
+	dlq     DeadLetterRecorder
+	retry   RetryPolicy
+	log     zerolog.Logger
+}
+
+// handle processes one event and records its outcome in logs and metrics.
+// It returns the number of attempts made and the final error; what to do with
+// a failed event is left to the caller.
+func (p *processor) handle(ctx context.Context, workerID int, event ports.TrackingEventInput) (int, error) {
+	start := time.Now()
+	attempts, err := p.process(ctx, workerID, event)
+	elapsed := time.Since(start).Seconds()
+
+	statusLabel := event.Status
+	if err != nil {
+		statusLabel = "error"
+		p.log.Error().Err(err).
+			Str("tracking_number", event.TrackingNumber).
+			Int("worker_id", workerID).
+			Int("attempts", attempts).
+			Msg("event processing failed")
+	}
+	apimetrics.EventProcessingDuration.WithLabelValues(statusLabel).Observe(elapsed)
+	return attempts, err
+}
+
+// process runs the event through the service, retrying transient failures
+// with backoff. It blocks the worker while retrying, which is what keeps
+// later events for the same tracking number behind this one.
+// It returns the number of attempts made and the last error, if any.
+func (p *processor) process(ctx context.Context, workerID int, event ports.TrackingEventInput) (int, error) {
+	maxAttempts := p.retry.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = p.service.Process(ctx, event)
+		if err == nil {
+			return attempt, nil
+		}
+
+		kind := errorKind(err)
+		switch {
+		case !p.retry.retryable(err):
+			apimetrics.EventGiveUpsTotal.WithLabelValues(kind, "permanent").Inc()
+			return attempt, err
+		case attempt >= maxAttempts:
+			apimetrics.EventGiveUpsTotal.WithLabelValues(kind, "exhausted").Inc()
+			return attempt, err
+		}
+
+		wait := p.retry.Backoff(attempt + 1)
+		p.log.Warn().Err(err).
+			Str("tracking_number", event.TrackingNumber).
+			Int("worker_id", workerID).
+			Int("attempt", attempt).
+			Dur("backoff", wait).
+			Msg("event processing failed, retrying")
+		apimetrics.EventRetriesTotal.WithLabelValues(kind).Inc()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			apimetrics.EventGiveUpsTotal.WithLabelValues(kind, "shutdown").Inc()
+			return attempt, err
+		case <-timer.C:
+		}
+	}
+}
+
+// deadLetter persists a failed event. A failure here is logged and returned,
+// but there is nowhere left to hand the event to: it is lost.
+func (p *processor) deadLetter(ctx context.Context, event ports.TrackingEventInput, reason error, attempts int) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), deadLetterTimeout)
+	defer cancel()
+
+	if err := p.dlq.Record(ctx, event, reason, attempts); err != nil {
+		p.log.Error().Err(err).
+			Str("tracking_number", event.TrackingNumber).
+			Str("status", event.Status).
+			Msg("event lost: dead-letter write failed")
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/99minutos/shipping-system/internal/core/ports"
+)
+
+// Backend selects the EventQueue implementation.
+type Backend string
+
+const (
+	// BackendMemory dispatches events to in-process workers over channels.
+	BackendMemory Backend = "memory"
+	// BackendRedis publishes events to Redis Streams, shared by every replica.
+	BackendRedis Backend = "redis"
+)
+
+// ParseBackend converts a configuration string into a Backend.
+func ParseBackend(s string) (Backend, error) {
+	switch b := Backend(strings.ToLower(strings.TrimSpace(s))); b {
+	case BackendMemory, BackendRedis:
+		return b, nil
+	case "":
+		return BackendMemory, nil
+	default:
+		return "", fmt.Errorf("unknown event queue backend %q (want memory or redis)", s)
+	}
+}
+
+// Runner is an EventQueue whose consumers run in the background.
+type Runner interface {
+	ports.EventQueue
+	// Start launches the consumers; they stop when ctx is cancelled.
+	Start(ctx context.Context)
+	// Shutdown stops accepting events and winds the consumers down, giving
+	// them until ctx ends to finish the events already accepted.
+	Shutdown(ctx context.Context) (ShutdownResult, error)
+}
+
+// ShutdownResult reports what Shutdown did with the events held in local
+// buffers when it was called. Backends that keep nothing locally report zeros.
+type ShutdownResult struct {
+	// Buffered is the number of accepted events not yet picked up by a worker.
+	Buffered int
+	// Flushed were processed by the workers: applied, or dead-lettered after failing.
+	Flushed int
+	// Persisted were written to the dead-letter store unprocessed because the
+	// deadline passed.
+	Persisted int
+	// Lost could not be written to the dead-letter store either.
+	Lost int
+}
+
+var (
+	_ Runner = (*Dispatcher)(nil)
+	_ Runner = (*StreamQueue)(nil)
+)
@@ -0,0 +1,125 @@
+package queue
+
+import (
+	"sort"
+	"time"
+
+	apimetrics "github.com/99minutos/shipping-system/internal/api/metrics"
+)
+
+// reorderBuffer holds events for a grace window after they arrive so that
+// events of the same tracking number can be applied in timestamp order even
+// when their scans arrive out of order. An event becomes due once it has been
+// held for the window; popping a due event first releases every held event of
+// its tracking number with an earlier timestamp. A window <= 0 releases events
+// as soon as they arrive, in arrival order.
+//
+// It is not safe for concurrent use: each consumer owns its own buffer.
+type reorderBuffer[T any] struct {
+	window  time.Duration
+	arrival []*heldEvent[T]            // every held event, oldest arrival first
+	byKey   map[string][]*heldEvent[T] // held events per tracking number, by timestamp
+	size    int
+}
+
+type heldEvent[T any] struct {
+	key      string
+	ts       time.Time
+	due      time.Time
+	item     T
+	released bool
+}
+
+func newReorderBuffer[T any](window time.Duration) *reorderBuffer[T] {
+	return &reorderBuffer[T]{window: window, byKey: make(map[string][]*heldEvent[T])}
+}
+
+// add holds item, which belongs to tracking number key and carries
+// timestamp ts, until now plus the window.
+func (b *reorderBuffer[T]) add(key string, ts time.Time, item T, now time.Time) {
+	h := &heldEvent[T]{key: key, ts: ts, due: now.Add(b.window), item: item}
+	b.arrival = append(b.arrival, h)
+
+	// Insert after every event with the same or an earlier timestamp, so
+	// events sharing a timestamp keep their arrival order.
+	held := b.byKey[key]
+	i := sort.Search(len(held), func(i int) bool { return held[i].ts.After(ts) })
+	held = append(held, nil)
+	copy(held[i+1:], held[i:])
+	held[i] = h
+	b.byKey[key] = held
+	b.size++
+}
+
+// pop returns the next event to apply at now, if any is due.
+func (b *reorderBuffer[T]) pop(now time.Time) (T, bool) {
+	var zero T
+	front := b.front()
+	if front == nil || front.due.After(now) {
+		return zero, false
+	}
+
+	held := b.byKey[front.key]
+	h := held[0]
+	if len(held) == 1 {
+		delete(b.byKey, front.key)
+	} else {
+		b.byKey[front.key] = held[1:]
+	}
+	if h != front {
+		apimetrics.EventsReorderedTotal.Inc()
+	}
+	h.released = true
+	b.size--
+	return h.item, true
+}
+
+// drain releases every held event regardless of the window, per tracking
+// number in timestamp order.
+func (b *reorderBuffer[T]) drain() []T {
+	items := make([]T, 0, b.size)
+	for {
+		item, ok := b.pop(maxTime)
+		if !ok {
+			return items
+		}
+		items = append(items, item)
+	}
+}
+
+// next reports when the earliest held event becomes due.
+func (b *reorderBuffer[T]) next() (time.Time, bool) {
+	front := b.front()
+	if front == nil {
+		return time.Time{}, false
+	}
+	return front.due, true
+}
+
+// len returns the number of events held.
+func (b *reorderBuffer[T]) len() int {
+	return b.size
+}
+
+// reset drops every held event.
+func (b *reorderBuffer[T]) reset() {
+	b.arrival = nil
+	b.byKey = make(map[string][]*heldEvent[T])
+	b.size = 0
+}
+
+// front returns the earliest-arrived event still held, discarding arrival
+// entries already released ahead of their turn.
+func (b *reorderBuffer[T]) front() *heldEvent[T] {
+	for len(b.arrival) > 0 && b.arrival[0].released {
+		b.arrival[0] = nil
+		b.arrival = b.arrival[1:]
+	}
+	if len(b.arrival) == 0 {
+		return nil
+	}
+	return b.arrival[0]
+}
+
+// maxTime is later than any due time.
+var maxTime = time.Unix(1<<62, 0)
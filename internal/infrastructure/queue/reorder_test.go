@@ -0,0 +1,154 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/99minutos/shipping-system/internal/core/ports"
+)
+
+func scan(tracking, status string, minute int) ports.TrackingEventInput {
+	return ports.TrackingEventInput{
+		TrackingNumber: tracking,
+		Status:         status,
+		Timestamp:      time.Date(2026, 2, 19, 10, minute, 0, 0, time.UTC),
+	}
+}
+
+func popAll(b *reorderBuffer[ports.TrackingEventInput], now time.Time) []string {
+	var out []string
+	for {
+		e, ok := b.pop(now)
+		if !ok {
+			return out
+		}
+		out = append(out, e.TrackingNumber+":"+e.Status)
+	}
+}
+
+func TestReorderBuffer_ReleasesInTimestampOrderAfterWindow(t *testing.T) {
+	b := newReorderBuffer[ports.TrackingEventInput](time.Second)
+	start := time.Now()
+	for i, e := range []ports.TrackingEventInput{
+		scan("A", "in_transit", 30),
+		scan("B", "picked_up", 5),
+		scan("A", "in_warehouse", 20),
+		scan("A", "picked_up", 10),
+	} {
+		b.add(e.TrackingNumber, e.Timestamp, e, start.Add(time.Duration(i)*100*time.Millisecond))
+	}
+
+	if got := popAll(b, start.Add(500*time.Millisecond)); len(got) != 0 {
+		t.Fatalf("nothing should be due inside the window, got %v", got)
+	}
+	if due, _ := b.next(); !due.Equal(start.Add(time.Second)) {
+		t.Errorf("next due = %v, want %v", due, start.Add(time.Second))
+	}
+
+	// The first arrival is due: every earlier scan of A goes out with it.
+	got := fmt.Sprint(popAll(b, start.Add(time.Second)))
+	if want := "[A:picked_up A:in_warehouse A:in_transit]"; got != want {
+		t.Errorf("released %v, want %v", got, want)
+	}
+	if b.len() != 1 {
+		t.Errorf("expected B still held, %d held", b.len())
+	}
+	if got := fmt.Sprint(popAll(b, start.Add(2*time.Second))); got != "[B:picked_up]" {
+		t.Errorf("released %v, want [B:picked_up]", got)
+	}
+}
+
+func TestReorderBuffer_ZeroWindowKeepsArrivalOrder(t *testing.T) {
+	b := newReorderBuffer[ports.TrackingEventInput](0)
+	now := time.Now()
+	b.add("A", scan("A", "in_transit", 30).Timestamp, scan("A", "in_transit", 30), now)
+	if got := fmt.Sprint(popAll(b, now)); got != "[A:in_transit]" {
+		t.Fatalf("released %v, want [A:in_transit]", got)
+	}
+	b.add("A", scan("A", "in_warehouse", 20).Timestamp, scan("A", "in_warehouse", 20), now)
+	if got := fmt.Sprint(popAll(b, now)); got != "[A:in_warehouse]" {
+		t.Fatalf("released %v, want [A:in_warehouse]", got)
+	}
+}
+
+func TestDispatcher_AppliesOutOfOrderScansByTimestamp(t *testing.T) {
+	svc := &scriptedService{done: make(chan struct{}), expected: 3}
+	d, err := NewDispatcher(1, svc, &stubRecorder{}, fastPolicy(1), 50*time.Millisecond, Admission{}, zerolog.Nop())
+	if err != nil {
+		t.Fatalf("new dispatcher: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	d.Start(ctx)
+
+	err = d.EnqueueBatch(ctx, []ports.TrackingEventInput{
+		scan("99M-AAAA0001", "in_transit", 30),
+		scan("99M-AAAA0001", "picked_up", 10),
+		scan("99M-AAAA0001", "in_warehouse", 20),
+	})
+	if err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	waitDone(t, svc.done)
+
+	want := "[99M-AAAA0001:picked_up 99M-AAAA0001:in_warehouse 99M-AAAA0001:in_transit]"
+	if got := fmt.Sprint(svc.calls); got != want {
+		t.Errorf("calls = %v, want %v", got, want)
+	}
+}
+
+func TestDispatcher_ShutdownAppliesHeldEventsWithoutWaiting(t *testing.T) {
+	svc := &scriptedService{done: make(chan struct{}), expected: 2}
+	d, err := NewDispatcher(1, svc, &stubRecorder{}, fastPolicy(1), time.Hour, Admission{}, zerolog.Nop())
+	if err != nil {
+		t.Fatalf("new dispatcher: %v", err)
+	}
+	d.Start(context.Background())
+	_ = d.Enqueue(context.Background(), scan("99M-AAAA0001", "in_warehouse", 20))
+	_ = d.Enqueue(context.Background(), scan("99M-AAAA0001", "picked_up", 10))
+
+	result, err := d.Shutdown(context.Background())
+	if err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+	if want := (ShutdownResult{Buffered: 2, Flushed: 2}); result != want {
+		t.Errorf("result = %+v, want %+v", result, want)
+	}
+	want := "[99M-AAAA0001:picked_up 99M-AAAA0001:in_warehouse]"
+	if got := fmt.Sprint(svc.calls); got != want {
+		t.Errorf("calls = %v, want %v", got, want)
+	}
+}
+
+func TestStreamQueue_AppliesOutOfOrderScansByTimestamp(t *testing.T) {
+	svc := &scriptedService{done: make(chan struct{}), expected: 2}
+	broker := newMemoryBroker("api-1")
+	opts := testStreamOptions()
+	opts.ReorderWindow = 30 * time.Millisecond
+	q := NewStreamQueue(broker, opts, svc, &stubRecorder{}, fastPolicy(1), zerolog.Nop())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	q.Start(ctx)
+	err := q.EnqueueBatch(ctx, []ports.TrackingEventInput{
+		scan("99M-AAAA0001", "in_warehouse", 20),
+		scan("99M-AAAA0001", "picked_up", 10),
+	})
+	if err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	waitDone(t, svc.done)
+	cancel()
+	q.Wait()
+
+	want := "[99M-AAAA0001:picked_up 99M-AAAA0001:in_warehouse]"
+	if got := fmt.Sprint(svc.calls); got != want {
+		t.Errorf("calls = %v, want %v", got, want)
+	}
+	if n := broker.size(); n != 0 {
+		t.Errorf("expected every entry acknowledged, %d left", n)
+	}
+}
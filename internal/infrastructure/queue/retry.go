@@ -0,0 +1,120 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"github.com/99minutos/shipping-system/internal/core/domain"
+)
+
+const (
+	defaultMaxAttempts    = 5
+	defaultInitialBackoff = 100 * time.Millisecond
+	defaultMaxBackoff     = 5 * time.Second
+	defaultMultiplier     = 2.0
+	defaultJitter         = 0.2
+)
+
+// RetryPolicy controls how a worker retries an event whose processing failed.
+// Retries happen inline on the worker, so later events for the same shard —
+// and therefore for the same tracking number — wait behind a retrying one.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of Process calls, including the first.
+	// Values <= 1 disable retries.
+	MaxAttempts int
+	// InitialBackoff is the wait before the second attempt.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the wait between attempts.
+	MaxBackoff time.Duration
+	// Multiplier grows the backoff after each attempt.
+	Multiplier float64
+	// Jitter is the fraction (0–1) of each backoff that is randomised, so
+	// workers hitting the same outage do not retry in lockstep.
+	Jitter float64
+	// Retryable classifies errors; nil means DefaultRetryable.
+	Retryable func(error) bool
+}
+
+// DefaultRetryPolicy returns the policy used when none is configured.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    defaultMaxAttempts,
+		InitialBackoff: defaultInitialBackoff,
+		MaxBackoff:     defaultMaxBackoff,
+		Multiplier:     defaultMultiplier,
+		Jitter:         defaultJitter,
+		Retryable:      DefaultRetryable,
+	}
+}
+
+// DefaultRetryable reports whether err is worth retrying.
+// Business-rule rejections are permanent: retrying cannot change their outcome.
+// Cancellation means the dispatcher is stopping. Anything else (Mongo write
+// failures, timeouts, network errors) is treated as transient.
+func DefaultRetryable(err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, domain.ErrInvalidTransition),
+		errors.Is(err, domain.ErrShipmentNotFound),
+		errors.Is(err, domain.ErrPieceNotFound):
+		return false
+	case errors.Is(err, context.Canceled):
+		return false
+	default:
+		return true
+	}
+}
+
+// Backoff returns the wait before the given attempt (2 = first retry),
+// including jitter.
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	if attempt < 2 || p.InitialBackoff <= 0 {
+		return 0
+	}
+	mult := p.Multiplier
+	if mult < 1 {
+		mult = 1
+	}
+
+	d := float64(p.InitialBackoff) * math.Pow(mult, float64(attempt-2))
+	if p.MaxBackoff > 0 && d > float64(p.MaxBackoff) {
+		d = float64(p.MaxBackoff)
+	}
+
+	if p.Jitter > 0 {
+		j := math.Min(p.Jitter, 1)
+		d -= d * j * rand.Float64()
+	}
+	return time.Duration(d)
+}
+
+func (p RetryPolicy) retryable(err error) bool {
+	if p.Retryable != nil {
+		return p.Retryable(err)
+	}
+	return DefaultRetryable(err)
+}
+
+// errorKind maps an error to a low-cardinality metric label.
+func errorKind(err error) string {
+	switch {
+	case errors.Is(err, domain.ErrInvalidTransition):
+		return "invalid_transition"
+	case errors.Is(err, domain.ErrShipmentNotFound):
+		return "shipment_not_found"
+	case errors.Is(err, domain.ErrPieceNotFound):
+		return "piece_not_found"
+	case errors.Is(err, domain.ErrConcurrentUpdate):
+		return "concurrent_update"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, context.Canceled):
+		return "cancelled"
+	default:
+		return "other"
+	}
+}
@@ -0,0 +1,251 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/99minutos/shipping-system/internal/core/domain"
+	"github.com/99minutos/shipping-system/internal/core/ports"
+)
+
+// ---------------------------------------------------------------------------
+// Stubs
+// ---------------------------------------------------------------------------
+
+// scriptedService fails each tracking number with the queued errors before
+// succeeding, and records the order in which events were attempted.
+type scriptedService struct {
+	mu       sync.Mutex
+	failures map[string][]error
+	calls    []string
+	done     chan struct{}
+	expected int
+	applied  int
+}
+
+func (s *scriptedService) Process(_ context.Context, in ports.TrackingEventInput) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls = append(s.calls, in.TrackingNumber+":"+in.Status)
+	if errs := s.failures[in.TrackingNumber]; len(errs) > 0 {
+		s.failures[in.TrackingNumber] = errs[1:]
+		if errs[0] != nil {
+			if !DefaultRetryable(errs[0]) {
+				s.finish()
+			}
+			return errs[0]
+		}
+	}
+	s.finish()
+	return nil
+}
+
+func (s *scriptedService) Apply(ctx context.Context, in ports.TrackingEventInput) (domain.EventState, error) {
+	return domain.EventProcessed, s.Process(ctx, in)
+}
+
+func (s *scriptedService) finish() {
+	s.applied++
+	if s.applied == s.expected {
+		close(s.done)
+	}
+}
+
+type stubRecorder struct {
+	mu      sync.Mutex
+	records []int // attempts per dead letter
+	err     error
+}
+
+func (r *stubRecorder) Record(_ context.Context, _ ports.TrackingEventInput, _ error, attempts int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return r.err
+	}
+	r.records = append(r.records, attempts)
+	return nil
+}
+
+func newTestDispatcher(t *testing.T, svc ports.EventService, dlq DeadLetterRecorder, retry RetryPolicy, admission Admission) *Dispatcher {
+	t.Helper()
+	d, err := NewDispatcher(1, svc, dlq, retry, 0, admission, zerolog.Nop())
+	if err != nil {
+		t.Fatalf("new dispatcher: %v", err)
+	}
+	return d
+}
+
+func fastPolicy(maxAttempts int) RetryPolicy {
+	p := DefaultRetryPolicy()
+	p.MaxAttempts = maxAttempts
+	p.InitialBackoff = time.Millisecond
+	p.MaxBackoff = 5 * time.Millisecond
+	return p
+}
+
+// ---------------------------------------------------------------------------
+// Tests
+// ---------------------------------------------------------------------------
+
+func TestDefaultRetryable(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{fmt.Errorf("process event: %w", domain.ErrInvalidTransition), false},
+		{fmt.Errorf("process event: %w", domain.ErrShipmentNotFound), false},
+		{context.Canceled, false},
+		{fmt.Errorf("process event: update status: %w", context.DeadlineExceeded), true},
+		{errors.New("connection reset"), true},
+		// Retried, the event is checked against the shipment's new status.
+		{fmt.Errorf("process event: %w", domain.ErrConcurrentUpdate), true},
+	}
+	for _, tc := range cases {
+		if got := DefaultRetryable(tc.err); got != tc.want {
+			t.Errorf("DefaultRetryable(%v) = %v, want %v", tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestRetryPolicy_Backoff_GrowsAndCaps(t *testing.T) {
+	p := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second, Multiplier: 2}
+
+	want := map[int]time.Duration{
+		1: 0,
+		2: 100 * time.Millisecond,
+		3: 200 * time.Millisecond,
+		4: 400 * time.Millisecond,
+		6: time.Second, // 1.6s capped
+	}
+	for attempt, w := range want {
+		if got := p.Backoff(attempt); got != w {
+			t.Errorf("Backoff(%d) = %v, want %v", attempt, got, w)
+		}
+	}
+}
+
+func TestRetryPolicy_Backoff_JitterStaysInRange(t *testing.T) {
+	p := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second, Multiplier: 2, Jitter: 0.5}
+
+	for i := 0; i < 100; i++ {
+		got := p.Backoff(2)
+		if got < 50*time.Millisecond || got > 100*time.Millisecond {
+			t.Fatalf("Backoff with jitter out of range: %v", got)
+		}
+	}
+}
+
+func TestDispatcher_RetriesTransientErrorsInOrder(t *testing.T) {
+	svc := &scriptedService{
+		failures: map[string][]error{
+			"99M-AAAA0001": {errors.New("mongo timeout"), errors.New("mongo timeout")},
+		},
+		done:     make(chan struct{}),
+		expected: 2,
+	}
+	dlq := &stubRecorder{}
+	d := newTestDispatcher(t, svc, dlq, fastPolicy(5), Admission{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	d.Start(ctx)
+	_ = d.Enqueue(ctx, ports.TrackingEventInput{TrackingNumber: "99M-AAAA0001", Status: "picked_up"})
+	_ = d.Enqueue(ctx, ports.TrackingEventInput{TrackingNumber: "99M-AAAA0001", Status: "in_warehouse"})
+
+	select {
+	case <-svc.done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for events")
+	}
+
+	want := []string{
+		"99M-AAAA0001:picked_up",
+		"99M-AAAA0001:picked_up",
+		"99M-AAAA0001:picked_up",
+		"99M-AAAA0001:in_warehouse",
+	}
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+	if fmt.Sprint(svc.calls) != fmt.Sprint(want) {
+		t.Errorf("calls = %v, want %v", svc.calls, want)
+	}
+	if len(dlq.records) != 0 {
+		t.Errorf("expected no dead letters, got %v", dlq.records)
+	}
+}
+
+func TestDispatcher_PermanentErrorIsNotRetried(t *testing.T) {
+	svc := &scriptedService{
+		failures: map[string][]error{
+			"99M-AAAA0001": {fmt.Errorf("process event: %w", domain.ErrInvalidTransition)},
+		},
+		done:     make(chan struct{}),
+		expected: 1,
+	}
+	dlq := &stubRecorder{}
+	d := newTestDispatcher(t, svc, dlq, fastPolicy(5), Admission{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	d.Start(ctx)
+	_ = d.Enqueue(ctx, ports.TrackingEventInput{TrackingNumber: "99M-AAAA0001", Status: "delivered"})
+
+	select {
+	case <-svc.done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for event")
+	}
+	cancel()
+	d.Wait()
+
+	if len(svc.calls) != 1 {
+		t.Errorf("expected a single attempt, got %d", len(svc.calls))
+	}
+	if len(dlq.records) != 1 || dlq.records[0] != 1 {
+		t.Errorf("expected one dead letter with 1 attempt, got %v", dlq.records)
+	}
+}
+
+func TestDispatcher_GivesUpAfterMaxAttempts(t *testing.T) {
+	transient := errors.New("mongo timeout")
+	svc := &scriptedService{
+		failures: map[string][]error{
+			"99M-AAAA0001": {transient, transient, transient},
+		},
+		done: make(chan struct{}),
+	}
+	dlq := &stubRecorder{}
+	d := newTestDispatcher(t, svc, dlq, fastPolicy(3), Admission{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	d.Start(ctx)
+	_ = d.Enqueue(ctx, ports.TrackingEventInput{TrackingNumber: "99M-AAAA0001", Status: "picked_up"})
+
+	deadline := time.After(2 * time.Second)
+	for {
+		dlq.mu.Lock()
+		n := len(dlq.records)
+		dlq.mu.Unlock()
+		if n > 0 {
+			break
+		}
+		select {
+		case <-deadline:
+			t.Fatal("timed out waiting for dead letter")
+		case <-time.After(time.Millisecond):
+		}
+	}
+	cancel()
+	d.Wait()
+
+	if dlq.records[0] != 3 {
+		t.Errorf("expected dead letter after 3 attempts, got %d", dlq.records[0])
+	}
+}
@@ -0,0 +1,402 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	apimetrics "github.com/99minutos/shipping-system/internal/api/metrics"
+	"github.com/99minutos/shipping-system/internal/core/domain"
+	"github.com/99minutos/shipping-system/internal/core/ports"
+)
+
+const (
+	defaultPartitions = 16
+	defaultClaimIdle  = 30 * time.Second
+	defaultLeaseTTL   = 15 * time.Second
+
+	streamReadCount    = 64
+	streamPollInterval = time.Second
+)
+
+// StreamMessage is an entry read from a broker partition. Err is set when the
+// entry could not be decoded; such entries are acknowledged and dropped.
+type StreamMessage struct {
+	ID    string
+	Event ports.TrackingEventInput
+	Err   error
+}
+
+// StreamBroker is a durable, partitioned log with consumer-group semantics,
+// such as Redis Streams. Entries stay pending for the consumer that read them
+// until they are acknowledged, so a crashed consumer's work can be claimed by
+// another one.
+type StreamBroker interface {
+	// EnsureGroup creates the consumer group for the partition if needed.
+	EnsureGroup(ctx context.Context, partition int) error
+	Publish(ctx context.Context, partition int, event ports.TrackingEventInput) error
+	// Lease acquires or renews this consumer's exclusive ownership of the
+	// partition for ttl. It reports false when another consumer owns it.
+	Lease(ctx context.Context, partition int, ttl time.Duration) (bool, error)
+	// Release gives up ownership of the partition if this consumer holds it.
+	Release(ctx context.Context, partition int) error
+	// Claim takes over pending entries idle for at least minIdle, oldest first.
+	Claim(ctx context.Context, partition int, minIdle time.Duration, count int) ([]StreamMessage, error)
+	// Pending returns the number of entries read but not yet acknowledged.
+	Pending(ctx context.Context, partition int) (int64, error)
+	// Read returns new entries, waiting up to block for at least one.
+	Read(ctx context.Context, partition int, count int, block time.Duration) ([]StreamMessage, error)
+	// Ack marks the entry as processed and removes it from the partition.
+	Ack(ctx context.Context, partition int, id string) error
+}
+
+// StreamOptions configures a StreamQueue.
+type StreamOptions struct {
+	// Partitions is the number of broker partitions events are hashed into.
+	// It must be the same on every replica sharing the broker.
+	Partitions int
+	// MaxPartitions caps how many partitions this replica consumes at once so
+	// several replicas share the work. Zero means no limit.
+	MaxPartitions int
+	// LeaseTTL is how long a partition stays owned without renewal; a crashed
+	// replica's partitions are taken over after at most this long.
+	LeaseTTL time.Duration
+	// ClaimIdle is how long an unacknowledged entry must sit before a new
+	// owner re-processes it.
+	ClaimIdle time.Duration
+	// ReorderWindow is how long entries are held before being applied so the
+	// events of a shipment are applied in timestamp order. Zero applies them
+	// as they are read.
+	ReorderWindow time.Duration
+}
+
+// StreamQueue is an EventQueue backed by a StreamBroker. Events are hashed by
+// tracking number onto partitions and each partition is consumed by a single
+// replica at a time, which holds a lease on it; that keeps per-shipment
+// ordering while letting several replicas split the partitions between them.
+// Events survive a restart: anything not acknowledged is re-processed by the
+// next owner of the partition before newer entries.
+type StreamQueue struct {
+	processor
+	broker StreamBroker
+	opts   StreamOptions
+	leases atomic.Int64
+	wg     sync.WaitGroup
+	cancel context.CancelFunc
+}
+
+// NewStreamQueue creates a StreamQueue. Failed events are retried according
+// to retry and handed to dlq once they cannot be applied.
+func NewStreamQueue(
+	broker StreamBroker,
+	opts StreamOptions,
+	service ports.EventService,
+	dlq DeadLetterRecorder,
+	retry RetryPolicy,
+	log zerolog.Logger,
+) *StreamQueue {
+	if opts.Partitions <= 0 {
+		opts.Partitions = defaultPartitions
+	}
+	if opts.LeaseTTL <= 0 {
+		opts.LeaseTTL = defaultLeaseTTL
+	}
+	if opts.ClaimIdle <= 0 {
+		opts.ClaimIdle = defaultClaimIdle
+	}
+	return &StreamQueue{
+		processor: processor{service: service, dlq: dlq, retry: retry, log: log},
+		broker:    broker,
+		opts:      opts,
+	}
+}
+
+// Start launches one consumer per partition. Each one competes for the
+// partition lease and only reads while it holds it. Consumers stop when ctx
+// is cancelled.
+func (q *StreamQueue) Start(ctx context.Context) {
+	ctx, q.cancel = context.WithCancel(ctx)
+	for i := 0; i < q.opts.Partitions; i++ {
+		q.wg.Add(1)
+		go func(partition int) {
+			defer q.wg.Done()
+			q.runPartition(ctx, partition)
+		}(i)
+	}
+}
+
+// Wait blocks until every partition consumer has returned.
+func (q *StreamQueue) Wait() {
+	q.wg.Wait()
+}
+
+// Shutdown stops the partition consumers and releases their leases. Events
+// are durable in the broker, so nothing is drained locally: an event
+// interrupted mid-processing, like those held in a reorder window, stays
+// pending and is re-processed by the next owner of its partition. Enqueue
+// keeps publishing after Shutdown.
+func (q *StreamQueue) Shutdown(ctx context.Context) (ShutdownResult, error) {
+	if q.cancel != nil {
+		q.cancel()
+	}
+	stopped := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return ShutdownResult{}, nil
+	case <-ctx.Done():
+		return ShutdownResult{}, ctx.Err()
+	}
+}
+
+// Enqueue publishes the event to the partition of its tracking number.
+// Broker failures are reported as domain.ErrEventQueueUnavailable.
+func (q *StreamQueue) Enqueue(ctx context.Context, event ports.TrackingEventInput) error {
+	if err := q.broker.Publish(ctx, q.partition(event.TrackingNumber), event); err != nil {
+		q.log.Error().Err(err).Str("tracking_number", event.TrackingNumber).Msg("failed to publish event")
+		return fmt.Errorf("publish event: %w", domain.ErrEventQueueUnavailable)
+	}
+	return nil
+}
+
+// EnqueueBatch publishes the events in order, stopping at the first failure.
+func (q *StreamQueue) EnqueueBatch(ctx context.Context, events []ports.TrackingEventInput) error {
+	for i, e := range events {
+		if err := q.Enqueue(ctx, e); err != nil {
+			return &ports.EnqueueBatchError{Index: i, Err: err}
+		}
+	}
+	return nil
+}
+
+func (q *StreamQueue) partition(trackingNumber string) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(trackingNumber))
+	return int(h.Sum32() % uint32(q.opts.Partitions))
+}
+
+// runPartition alternates between waiting for the partition lease and
+// consuming the partition while the lease holds.
+func (q *StreamQueue) runPartition(ctx context.Context, partition int) {
+	retry := q.opts.LeaseTTL / 3
+	for {
+		if q.acquire(ctx, partition) {
+			q.own(ctx, partition)
+			q.leases.Add(-1)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(retry):
+		}
+	}
+}
+
+// acquire reserves a lease slot and tries to take the partition lease.
+func (q *StreamQueue) acquire(ctx context.Context, partition int) bool {
+	if n := q.leases.Add(1); q.opts.MaxPartitions > 0 && n > int64(q.opts.MaxPartitions) {
+		q.leases.Add(-1)
+		return false
+	}
+	ok, err := q.broker.Lease(ctx, partition, q.opts.LeaseTTL)
+	if err != nil && ctx.Err() == nil {
+		q.log.Error().Err(err).Int("partition", partition).Msg("failed to acquire partition lease")
+	}
+	if !ok || err != nil {
+		q.leases.Add(-1)
+		return false
+	}
+	return true
+}
+
+// own consumes the partition until ctx ends or the lease is lost, renewing
+// the lease in the background. The lease is released on the way out.
+func (q *StreamQueue) own(ctx context.Context, partition int) {
+	q.log.Info().Int("partition", partition).Msg("partition lease acquired")
+
+	leaseCtx, lost := context.WithCancel(ctx)
+	defer lost()
+
+	renewed := make(chan struct{})
+	go func() {
+		defer close(renewed)
+		q.renew(leaseCtx, partition, lost)
+	}()
+
+	q.consume(leaseCtx, partition)
+	lost()
+	<-renewed
+
+	releaseCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), deadLetterTimeout)
+	defer cancel()
+	if err := q.broker.Release(releaseCtx, partition); err != nil {
+		q.log.Error().Err(err).Int("partition", partition).Msg("failed to release partition lease")
+	}
+	q.log.Info().Int("partition", partition).Msg("partition lease released")
+}
+
+// renew keeps the lease alive, calling lost once it can no longer be held.
+func (q *StreamQueue) renew(ctx context.Context, partition int, lost context.CancelFunc) {
+	ticker := time.NewTicker(q.opts.LeaseTTL / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		ok, err := q.broker.Lease(ctx, partition, q.opts.LeaseTTL)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil || !ok {
+			q.log.Warn().Err(err).Int("partition", partition).Msg("partition lease lost")
+			lost()
+			return
+		}
+	}
+}
+
+// consume processes the partition. Entries read are held in a reorder window
+// and delivered per tracking number in timestamp order; they stay pending in
+// the broker while held, so a crash or a lost lease leaves them to the next
+// owner. Entries left pending by a previous owner (or by this replica before
+// a restart) are recovered first; until they are, no new entries are read,
+// since those would overtake them.
+func (q *StreamQueue) consume(ctx context.Context, partition int) {
+	if err := q.broker.EnsureGroup(ctx, partition); err != nil {
+		if ctx.Err() == nil {
+			q.log.Error().Err(err).Int("partition", partition).Msg("failed to create consumer group")
+		}
+		return
+	}
+
+	held := newReorderBuffer[StreamMessage](q.opts.ReorderWindow)
+	heldIDs := make(map[string]struct{})
+	recovering := true
+	for ctx.Err() == nil {
+		if !q.deliverDue(ctx, partition, held, heldIDs) {
+			// The entry is still pending, and so are the ones held behind
+			// it; recover them in entry order before reading on.
+			held.reset()
+			clear(heldIDs)
+			recovering = true
+			continue
+		}
+
+		wait := streamPollInterval
+		if due, ok := held.next(); ok {
+			wait = min(wait, max(time.Until(due), time.Millisecond))
+		}
+
+		var (
+			msgs []StreamMessage
+			err  error
+		)
+		if recovering {
+			msgs, recovering, err = q.recover(ctx, partition, held.len())
+		} else {
+			msgs, err = q.broker.Read(ctx, partition, streamReadCount, wait)
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			q.log.Error().Err(err).Int("partition", partition).Msg("failed to read partition")
+			q.pause(ctx, streamPollInterval)
+			continue
+		}
+		if recovering && len(msgs) == 0 {
+			q.pause(ctx, wait)
+			continue
+		}
+
+		now := time.Now()
+		for _, msg := range msgs {
+			// A held entry idle for longer than ClaimIdle is claimed again.
+			if _, ok := heldIDs[msg.ID]; ok {
+				continue
+			}
+			heldIDs[msg.ID] = struct{}{}
+			held.add(msg.Event.TrackingNumber, msg.Event.Timestamp, msg, now)
+		}
+	}
+}
+
+// deliverDue delivers the held entries whose window has passed. It reports
+// false when one of them was left pending.
+func (q *StreamQueue) deliverDue(ctx context.Context, partition int, held *reorderBuffer[StreamMessage], heldIDs map[string]struct{}) bool {
+	now := time.Now()
+	for ctx.Err() == nil {
+		msg, ok := held.pop(now)
+		if !ok {
+			return true
+		}
+		delete(heldIDs, msg.ID)
+		if !q.deliver(ctx, partition, msg) {
+			return false
+		}
+	}
+	return true
+}
+
+// recover claims entries abandoned by an earlier consumer. It reports whether
+// recovery must continue: either entries were claimed, or some are pending
+// but have not been idle long enough to be claimed yet. The held entries in
+// this consumer's reorder window are pending as well and do not count.
+func (q *StreamQueue) recover(ctx context.Context, partition int, held int) ([]StreamMessage, bool, error) {
+	msgs, err := q.broker.Claim(ctx, partition, q.opts.ClaimIdle, streamReadCount)
+	if err != nil {
+		return nil, true, err
+	}
+	if len(msgs) > 0 {
+		apimetrics.EventsRedeliveredTotal.Add(float64(len(msgs)))
+		return msgs, true, nil
+	}
+	pending, err := q.broker.Pending(ctx, partition)
+	if err != nil {
+		return nil, true, err
+	}
+	return nil, pending > int64(held), nil
+}
+
+// deliver processes one entry and acknowledges it once it was applied or
+// dead-lettered. It reports false when the entry was left pending because
+// the consumer is stopping or the acknowledgement failed.
+func (q *StreamQueue) deliver(ctx context.Context, partition int, msg StreamMessage) bool {
+	if msg.Err != nil {
+		q.log.Error().Err(msg.Err).Int("partition", partition).Str("entry_id", msg.ID).Msg("dropping undecodable event")
+	} else if attempts, err := q.handle(ctx, partition, msg.Event); err != nil {
+		if ctx.Err() != nil {
+			// Leave it pending: the next owner of the partition re-processes it.
+			return false
+		}
+		q.deadLetter(ctx, msg.Event, err, attempts)
+	}
+
+	// The event has been handled: acknowledge it even if the consumer is
+	// stopping, or it would be re-processed by the next owner.
+	ackCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), deadLetterTimeout)
+	defer cancel()
+	if err := q.broker.Ack(ackCtx, partition, msg.ID); err != nil {
+		q.log.Error().Err(err).Int("partition", partition).Str("entry_id", msg.ID).Msg("failed to acknowledge event")
+		return false
+	}
+	return true
+}
+
+func (q *StreamQueue) pause(ctx context.Context, d time.Duration) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(d):
+	}
+}
@@ -0,0 +1,328 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/99minutos/shipping-system/internal/core/domain"
+	"github.com/99minutos/shipping-system/internal/core/ports"
+)
+
+// memoryEntry is a stream entry of memoryBroker; delivered entries are pending
+// until acknowledged.
+type memoryEntry struct {
+	id        string
+	event     ports.TrackingEventInput
+	delivered bool
+	consumer  string
+	at        time.Time
+}
+
+// memoryBroker is an in-memory StreamBroker with consumer-group semantics.
+type memoryBroker struct {
+	mu         sync.Mutex
+	consumer   string
+	seq        int
+	parts      map[int][]*memoryEntry
+	leases     map[int]string
+	publishErr error
+}
+
+func newMemoryBroker(consumer string) *memoryBroker {
+	return &memoryBroker{consumer: consumer, parts: make(map[int][]*memoryEntry), leases: make(map[int]string)}
+}
+
+func (b *memoryBroker) EnsureGroup(context.Context, int) error { return nil }
+
+func (b *memoryBroker) Publish(_ context.Context, partition int, event ports.TrackingEventInput) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.publishErr != nil {
+		return b.publishErr
+	}
+	b.seq++
+	b.parts[partition] = append(b.parts[partition], &memoryEntry{id: strconv.Itoa(b.seq), event: event})
+	return nil
+}
+
+// seedPending adds an entry already delivered to another consumer.
+func (b *memoryBroker) seedPending(partition int, event ports.TrackingEventInput, consumer string, at time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.seq++
+	b.parts[partition] = append(b.parts[partition], &memoryEntry{
+		id: strconv.Itoa(b.seq), event: event, delivered: true, consumer: consumer, at: at,
+	})
+}
+
+func (b *memoryBroker) Lease(_ context.Context, partition int, _ time.Duration) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if owner := b.leases[partition]; owner != "" && owner != b.consumer {
+		return false, nil
+	}
+	b.leases[partition] = b.consumer
+	return true, nil
+}
+
+func (b *memoryBroker) Release(_ context.Context, partition int) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.leases[partition] == b.consumer {
+		delete(b.leases, partition)
+	}
+	return nil
+}
+
+func (b *memoryBroker) Claim(_ context.Context, partition int, minIdle time.Duration, count int) ([]StreamMessage, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var out []StreamMessage
+	for _, e := range b.parts[partition] {
+		if len(out) == count {
+			break
+		}
+		if e.delivered && time.Since(e.at) >= minIdle {
+			e.consumer, e.at = b.consumer, time.Now()
+			out = append(out, StreamMessage{ID: e.id, Event: e.event})
+		}
+	}
+	return out, nil
+}
+
+func (b *memoryBroker) Pending(_ context.Context, partition int) (int64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var n int64
+	for _, e := range b.parts[partition] {
+		if e.delivered {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (b *memoryBroker) Read(ctx context.Context, partition int, count int, _ time.Duration) ([]StreamMessage, error) {
+	b.mu.Lock()
+	var out []StreamMessage
+	for _, e := range b.parts[partition] {
+		if len(out) == count {
+			break
+		}
+		if !e.delivered {
+			e.delivered, e.consumer, e.at = true, b.consumer, time.Now()
+			out = append(out, StreamMessage{ID: e.id, Event: e.event})
+		}
+	}
+	b.mu.Unlock()
+
+	if len(out) == 0 {
+		select {
+		case <-ctx.Done():
+		case <-time.After(time.Millisecond):
+		}
+	}
+	return out, nil
+}
+
+func (b *memoryBroker) Ack(_ context.Context, partition int, id string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	entries := b.parts[partition]
+	for i, e := range entries {
+		if e.id == id {
+			b.parts[partition] = append(entries[:i], entries[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+func (b *memoryBroker) size() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	n := 0
+	for _, entries := range b.parts {
+		n += len(entries)
+	}
+	return n
+}
+
+func testStreamOptions() StreamOptions {
+	return StreamOptions{Partitions: 1, LeaseTTL: 30 * time.Millisecond, ClaimIdle: 10 * time.Millisecond}
+}
+
+func waitDone(t *testing.T, done <-chan struct{}) {
+	t.Helper()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for events")
+	}
+}
+
+func TestStreamQueue_ProcessesAndAcknowledgesInOrder(t *testing.T) {
+	svc := &scriptedService{
+		failures: map[string][]error{"99M-AAAA0001": {errors.New("mongo timeout")}},
+		done:     make(chan struct{}),
+		expected: 3,
+	}
+	broker := newMemoryBroker("api-1")
+	q := NewStreamQueue(broker, testStreamOptions(), svc, &stubRecorder{}, fastPolicy(3), zerolog.Nop())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	q.Start(ctx)
+	err := q.EnqueueBatch(ctx, []ports.TrackingEventInput{
+		{TrackingNumber: "99M-AAAA0001", Status: "picked_up"},
+		{TrackingNumber: "99M-AAAA0001", Status: "in_warehouse"},
+		{TrackingNumber: "99M-AAAA0001", Status: "in_transit"},
+	})
+	if err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	waitDone(t, svc.done)
+	cancel()
+	q.Wait()
+
+	want := "[99M-AAAA0001:picked_up 99M-AAAA0001:picked_up 99M-AAAA0001:in_warehouse 99M-AAAA0001:in_transit]"
+	if got := fmt.Sprint(svc.calls); got != want {
+		t.Errorf("calls = %v, want %v", got, want)
+	}
+	if n := broker.size(); n != 0 {
+		t.Errorf("expected every entry acknowledged, %d left", n)
+	}
+}
+
+func TestStreamQueue_RecoversPendingEntriesFirst(t *testing.T) {
+	svc := &scriptedService{done: make(chan struct{}), expected: 2}
+	broker := newMemoryBroker("api-2")
+	// api-1 crashed while processing picked_up; in_warehouse arrived later.
+	broker.seedPending(0, ports.TrackingEventInput{TrackingNumber: "99M-AAAA0001", Status: "picked_up"}, "api-1", time.Now().Add(-time.Minute))
+	_ = broker.Publish(context.Background(), 0, ports.TrackingEventInput{TrackingNumber: "99M-AAAA0001", Status: "in_warehouse"})
+
+	q := NewStreamQueue(broker, testStreamOptions(), svc, &stubRecorder{}, fastPolicy(1), zerolog.Nop())
+	ctx, cancel := context.WithCancel(context.Background())
+	q.Start(ctx)
+	waitDone(t, svc.done)
+	cancel()
+	q.Wait()
+
+	want := "[99M-AAAA0001:picked_up 99M-AAAA0001:in_warehouse]"
+	if got := fmt.Sprint(svc.calls); got != want {
+		t.Errorf("calls = %v, want %v", got, want)
+	}
+}
+
+func TestStreamQueue_SkipsPartitionLeasedByAnotherReplica(t *testing.T) {
+	svc := &scriptedService{done: make(chan struct{}), expected: 1}
+	broker := newMemoryBroker("api-2")
+	broker.leases[0] = "api-1"
+
+	q := NewStreamQueue(broker, testStreamOptions(), svc, &stubRecorder{}, fastPolicy(1), zerolog.Nop())
+	ctx, cancel := context.WithCancel(context.Background())
+	q.Start(ctx)
+	_ = q.Enqueue(ctx, ports.TrackingEventInput{TrackingNumber: "99M-AAAA0001", Status: "picked_up"})
+
+	time.Sleep(50 * time.Millisecond)
+	svc.mu.Lock()
+	calls := len(svc.calls)
+	svc.mu.Unlock()
+	if calls != 0 {
+		t.Fatalf("expected no processing while another replica holds the lease, got %d calls", calls)
+	}
+
+	// The other replica goes away: its lease expires and this one takes over.
+	broker.mu.Lock()
+	delete(broker.leases, 0)
+	broker.mu.Unlock()
+	waitDone(t, svc.done)
+	cancel()
+	q.Wait()
+}
+
+func TestStreamQueue_ShutdownLeavesEventPending(t *testing.T) {
+	svc := &blockingService{release: make(chan struct{})}
+	broker := newMemoryBroker("api-1")
+	dlq := &stubRecorder{}
+	q := NewStreamQueue(broker, testStreamOptions(), svc, dlq, fastPolicy(1), zerolog.Nop())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	q.Start(ctx)
+	_ = q.Enqueue(ctx, ports.TrackingEventInput{TrackingNumber: "99M-AAAA0001", Status: "picked_up"})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if n, _ := broker.Pending(ctx, 0); n == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for delivery")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	q.Wait()
+
+	if n, _ := broker.Pending(context.Background(), 0); n != 1 {
+		t.Errorf("expected the event to stay pending, got %d pending", n)
+	}
+	if len(dlq.records) != 0 {
+		t.Errorf("expected no dead letters on shutdown, got %v", dlq.records)
+	}
+}
+
+func TestStreamQueue_EnqueueBatch_PublishFailure(t *testing.T) {
+	broker := newMemoryBroker("api-1")
+	broker.publishErr = errors.New("connection refused")
+	q := NewStreamQueue(broker, testStreamOptions(), &scriptedService{}, &stubRecorder{}, fastPolicy(1), zerolog.Nop())
+
+	err := q.EnqueueBatch(context.Background(), []ports.TrackingEventInput{{TrackingNumber: "99M-AAAA0001", Status: "picked_up"}})
+	if !errors.Is(err, domain.ErrEventQueueUnavailable) {
+		t.Fatalf("expected ErrEventQueueUnavailable, got %v", err)
+	}
+	if !strings.HasPrefix(err.Error(), "event[0]: ") {
+		t.Errorf("expected error to carry the batch index, got %q", err)
+	}
+}
+
+func TestStreamQueue_MaxPartitionsLimitsLeases(t *testing.T) {
+	broker := newMemoryBroker("api-1")
+	opts := testStreamOptions()
+	opts.Partitions = 4
+	opts.MaxPartitions = 2
+	q := NewStreamQueue(broker, opts, &scriptedService{}, &stubRecorder{}, fastPolicy(1), zerolog.Nop())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	q.Start(ctx)
+	time.Sleep(50 * time.Millisecond)
+
+	broker.mu.Lock()
+	held := len(broker.leases)
+	broker.mu.Unlock()
+	cancel()
+	q.Wait()
+
+	if held != 2 {
+		t.Errorf("expected 2 leased partitions, got %d", held)
+	}
+}
+
+func TestParseBackend(t *testing.T) {
+	for in, want := range map[string]Backend{"": BackendMemory, "memory": BackendMemory, " Redis ": BackendRedis} {
+		got, err := ParseBackend(in)
+		if err != nil || got != want {
+			t.Errorf("ParseBackend(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseBackend("kafka"); err == nil {
+		t.Error("expected an error for an unknown backend")
+	}
+}
@@ -0,0 +1,35 @@
+// Package calendar loads the delivery calendar shipments are promised with
+// from a JSON file.
+//
+// The file format is domain.DeliveryCalendar; configs/calendar.json documents
+// it with the Mexico City operation, its holidays and transit zones.
+package calendar
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/99minutos/shipping-system/internal/core/domain"
+)
+
+// Load reads and validates the calendar at path. Unknown fields are rejected
+// so that a misspelt cutoff does not silently fall back to midnight.
+func Load(path string) (*domain.DeliveryCalendar, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("calendar: %w", err)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	var c domain.DeliveryCalendar
+	if err := dec.Decode(&c); err != nil {
+		return nil, fmt.Errorf("calendar: parse %s: %w", path, err)
+	}
+	if err := c.Validate(); err != nil {
+		return nil, fmt.Errorf("calendar: invalid %s: %w", path, err)
+	}
+	return &c, nil
+}
@@ -0,0 +1,118 @@
+package calendar
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	_ "time/tzdata" // the calendars' timezones, even where the OS has no zoneinfo
+)
+
+func writeCalendar(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "calendar.json")
+	if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+		t.Fatalf("write calendar: %v", err)
+	}
+	return path
+}
+
+func TestLoad_ReferenceCalendar(t *testing.T) {
+	c, err := Load("../../../configs/calendar.json")
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	cdmx, err := time.LoadLocation("America/Mexico_City")
+	if err != nil {
+		t.Fatalf("load location: %v", err)
+	}
+	tests := []struct {
+		name        string
+		serviceType string
+		zipCode     string
+		placedAt    time.Time
+		want        time.Time
+	}{
+		{
+			name:        "same day in the metro area",
+			serviceType: "same_day", zipCode: "06600",
+			placedAt: time.Date(2026, 3, 4, 11, 30, 0, 0, cdmx),
+			want:     time.Date(2026, 3, 4, 20, 0, 0, 0, cdmx),
+		},
+		{
+			name:        "next day skips a holiday Monday",
+			serviceType: "next_day", zipCode: "72000",
+			placedAt: time.Date(2026, 11, 13, 10, 0, 0, 0, cdmx),
+			want:     time.Date(2026, 11, 17, 20, 0, 0, 0, cdmx),
+		},
+		{
+			name:        "standard to an extended zone",
+			serviceType: "standard", zipCode: "22000",
+			placedAt: time.Date(2026, 3, 2, 10, 0, 0, 0, cdmx),
+			want:     time.Date(2026, 3, 9, 20, 0, 0, 0, cdmx),
+		},
+		{
+			name:        "next day to the rest of the country",
+			serviceType: "next_day", zipCode: "97000",
+			placedAt: time.Date(2026, 3, 2, 10, 0, 0, 0, cdmx),
+			want:     time.Date(2026, 3, 4, 20, 0, 0, 0, cdmx),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := c.EstimateDelivery(tt.serviceType, tt.zipCode, tt.placedAt.UTC())
+			if !got.Equal(tt.want) {
+				t.Errorf("got %v, want %v", got.In(cdmx), tt.want)
+			}
+		})
+	}
+
+	// A failed attempt on the eve of a holiday is promised for the day after.
+	if got, want := c.NextDelivery(time.Date(2026, 9, 15, 15, 0, 0, 0, cdmx)), time.Date(2026, 9, 17, 20, 0, 0, 0, cdmx); !got.Equal(want) {
+		t.Errorf("next delivery = %v, want %v", got.In(cdmx), want)
+	}
+}
+
+func TestLoad_RejectsInvalidCalendars(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{
+			name: "unknown timezone and bad clock",
+			body: `{"timezone": "Mars/Olympus", "delivery_by": "8pm", "working_days": ["monday"], "default": {"cutoff": "18:00", "transit_days": 3}}`,
+			want: []string{"timezone:", `delivery_by: "8pm" must be HH:MM`},
+		},
+		{
+			name: "bad weekday and holiday",
+			body: `{"timezone": "UTC", "delivery_by": "20:00", "working_days": ["funday"], "holidays": ["16/09/2026"], "default": {"cutoff": "18:00", "transit_days": 3}}`,
+			want: []string{`working day "funday" is not a weekday`, "at least one working day is required", `holiday "16/09/2026" must be YYYY-MM-DD`},
+		},
+		{
+			name: "same day cut off after delivery",
+			body: `{"timezone": "UTC", "delivery_by": "18:00", "working_days": ["monday"], "services": {"same_day": {"cutoff": "19:00", "transit_days": 0}}, "default": {"cutoff": "18:00", "transit_days": 3}}`,
+			want: []string{`service "same_day": cutoff of a same-day service cannot be after delivery_by`},
+		},
+		{
+			name: "unknown field",
+			body: `{"timezone": "UTC", "delivery_by": "18:00", "working_days": ["monday"], "default": {"cutof": "18:00"}}`,
+			want: []string{"unknown field"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(writeCalendar(t, tt.body))
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q does not mention %q", err, want)
+				}
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/sethvargo/go-envconfig"
 )
@@ -13,8 +14,53 @@ type Config struct {
 	JWTSecret string `env:"JWT_SECRET"`
 	LogLevel  string `env:"LOG_LEVEL, default=info"`
 
+	// JWTKeys are PEM key files, or directories of them, access tokens are
+	// signed with RS256 or ES256; JWTSigningKey is the kid of the one new
+	// tokens are signed with. Empty signs with JWTSecret (HS256).
+	JWTKeys       []string `env:"JWT_KEYS"`
+	JWTSigningKey string   `env:"JWT_SIGNING_KEY"`
+
+	// AccessTokenTTL is the lifetime of the JWTs issued on login and refresh;
+	// RefreshTokenTTL that of the refresh tokens that renew them.
+	AccessTokenTTL  time.Duration `env:"ACCESS_TOKEN_TTL,  default=15m"`
+	RefreshTokenTTL time.Duration `env:"REFRESH_TOKEN_TTL, default=720h"`
+	// InvitationTTL is how long an invitation to register can be used.
+	InvitationTTL time.Duration `env:"INVITATION_TTL, default=168h"`
+	// TrustedProxies are the CIDRs of the proxies trusted to report the
+	// client address in X-Forwarded-For. Empty uses the peer address.
+	TrustedProxies []string `env:"TRUSTED_PROXIES"`
+
+	// ShutdownTimeout bounds the whole graceful shutdown sequence.
+	ShutdownTimeout time.Duration `env:"SHUTDOWN_TIMEOUT, default=15s"`
+	// LifecycleFile is a JSON shipment lifecycle definition. Empty uses the
+	// built-in lifecycle.
+	LifecycleFile string `env:"LIFECYCLE_FILE"`
+	// CalendarFile is a JSON delivery calendar: timezone, cutoffs, holidays
+	// and transit days. Empty uses the built-in calendar.
+	CalendarFile string `env:"CALENDAR_FILE"`
+	// RateCardsDir holds the JSON rate cards shipments are priced with,
+	// reloaded every RateCardsReloadInterval (zero or less never reloads).
+	// Empty disables pricing.
+	RateCardsDir            string        `env:"RATE_CARDS_DIR"`
+	RateCardsReloadInterval time.Duration `env:"RATE_CARDS_RELOAD_INTERVAL, default=1m"`
+
+	// TrackingPrefix starts tracking numbers; TrackingClientPrefixes gives
+	// clients their own, as client_id:PREFIX pairs separated by commas.
+	TrackingPrefix         string            `env:"TRACKING_PREFIX, default=99M"`
+	TrackingClientPrefixes map[string]string `env:"TRACKING_CLIENT_PREFIXES"`
+	// ShipmentBatchMaxSize caps the shipments of POST /v1/shipments/batch.
+	ShipmentBatchMaxSize int `env:"SHIPMENT_BATCH_MAX_SIZE, default=500"`
+	// EventSyncBatchMaxSize caps the events of POST /v1/events/batch?mode=sync,
+	// which are applied while the request waits.
+	EventSyncBatchMaxSize int `env:"EVENT_SYNC_BATCH_MAX_SIZE, default=500"`
+	// ImportMaxRows caps the rows of a shipment import file;
+	// ImportPollInterval is how often queued imports are looked for.
+	ImportMaxRows      int           `env:"IMPORT_MAX_ROWS,      default=5000"`
+	ImportPollInterval time.Duration `env:"IMPORT_POLL_INTERVAL, default=2s"`
+
 	Mongo MongoConfig
 	Redis RedisConfig
+	Queue QueueConfig
 }
 
 type MongoConfig struct {
@@ -27,6 +73,38 @@ type RedisConfig struct {
 	DB   int    `env:"REDIS_DB,   default=0"`
 }
 
+// QueueConfig tunes the asynchronous event queue.
+type QueueConfig struct {
+	// Backend is memory (in-process workers) or redis (Redis Streams).
+	Backend string `env:"EVENT_QUEUE_BACKEND, default=memory"`
+	// Instance identifies this replica in Redis (spill lists, stream consumer). Defaults to the hostname.
+	Instance string `env:"EVENT_QUEUE_INSTANCE"`
+
+	Workers             int           `env:"EVENT_WORKERS,               default=8"`
+	RetryMaxAttempts    int           `env:"EVENT_RETRY_MAX_ATTEMPTS,    default=5"`
+	RetryInitialBackoff time.Duration `env:"EVENT_RETRY_INITIAL_BACKOFF, default=100ms"`
+	RetryMaxBackoff     time.Duration `env:"EVENT_RETRY_MAX_BACKOFF,     default=5s"`
+
+	// BufferSize is the capacity of each worker channel.
+	BufferSize int `env:"EVENT_QUEUE_BUFFER, default=256"`
+	// AdmissionPolicy applies when a worker channel is full: block, reject or spill.
+	AdmissionPolicy string        `env:"EVENT_ADMISSION_POLICY, default=block"`
+	EnqueueTimeout  time.Duration `env:"EVENT_ENQUEUE_TIMEOUT,  default=2s"`
+	// RetryAfter is sent to clients in the Retry-After header when load is shed.
+	RetryAfter time.Duration `env:"EVENT_QUEUE_RETRY_AFTER, default=1s"`
+	// ReorderWindow is how long events are held so that late scans of the same
+	// shipment can be applied in timestamp order. Zero applies them on arrival.
+	ReorderWindow time.Duration `env:"EVENT_REORDER_WINDOW, default=2s"`
+	// StatusTTL is how long GET /v1/events/{id} can report on an event.
+	StatusTTL time.Duration `env:"EVENT_STATUS_TTL, default=72h"`
+
+	// Redis Streams backend. StreamPartitions must match on every replica.
+	StreamPartitions    int           `env:"EVENT_STREAM_PARTITIONS,     default=16"`
+	StreamMaxPartitions int           `env:"EVENT_STREAM_MAX_PARTITIONS, default=0"`
+	StreamLeaseTTL      time.Duration `env:"EVENT_STREAM_LEASE_TTL,      default=15s"`
+	StreamClaimIdle     time.Duration `env:"EVENT_STREAM_CLAIM_IDLE,     default=30s"`
+}
+
 // Load reads configuration from environment variables using go-envconfig.
 func Load() *Config {
 	var cfg Config
@@ -0,0 +1,278 @@
+// Package jwtkeys holds the keys access tokens are signed and verified with,
+// and publishes the public ones as a JSON Web Key Set.
+//
+// Keys are PEM files named <kid>.pem: RSA keys of at least 2048 bits sign
+// with RS256 and P-256 EC keys with ES256. A private key can sign and verify;
+// a public key only verifies. One private key signs; the others keep
+// verifying the tokens they signed, so keys rotate without downtime:
+//
+//  1. add the new key on every replica, still signing with the old one;
+//  2. sign with the new key;
+//  3. remove the old key once the tokens it signed have expired.
+//
+// Without key files, tokens are signed and verified with a shared HS256
+// secret and the key set publishes no keys.
+package jwtkeys
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// minRSABits is the smallest RSA key accepted.
+const minRSABits = 2048
+
+// Key is a key tokens are verified with, and signed with when Private is set.
+type Key struct {
+	ID      string
+	Method  jwt.SigningMethod
+	Private crypto.Signer // nil for keys that only verify
+	Public  crypto.PublicKey
+}
+
+// KeySet is the set of keys of the service. It is safe for concurrent use.
+type KeySet struct {
+	signing *Key
+	keys    map[string]*Key
+	secret  []byte // HS256 secret when there are no keys
+}
+
+// NewHMAC returns a key set that signs and verifies with an HS256 secret.
+func NewHMAC(secret string) *KeySet {
+	return &KeySet{secret: []byte(secret)}
+}
+
+// Load reads the *.pem keys of paths, each a file or a directory, and signs
+// with the private key named signingKeyID. signingKeyID may be empty when
+// there is a single private key.
+func Load(paths []string, signingKeyID string) (*KeySet, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("jwtkeys: %w", err)
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		matches, err := filepath.Glob(filepath.Join(path, "*.pem"))
+		if err != nil {
+			return nil, fmt.Errorf("jwtkeys: %w", err)
+		}
+		files = append(files, matches...)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("jwtkeys: no keys in %s", strings.Join(paths, ", "))
+	}
+
+	s := &KeySet{keys: make(map[string]*Key, len(files))}
+	var signers []string
+	for _, file := range files {
+		key, err := loadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := s.keys[key.ID]; ok {
+			return nil, fmt.Errorf("jwtkeys: key %q is loaded twice", key.ID)
+		}
+		s.keys[key.ID] = key
+		if key.Private != nil {
+			signers = append(signers, key.ID)
+		}
+	}
+
+	switch {
+	case signingKeyID != "":
+		key, ok := s.keys[signingKeyID]
+		if !ok {
+			return nil, fmt.Errorf("jwtkeys: signing key %q not found", signingKeyID)
+		}
+		if key.Private == nil {
+			return nil, fmt.Errorf("jwtkeys: signing key %q is a public key", signingKeyID)
+		}
+		s.signing = key
+	case len(signers) == 1:
+		s.signing = s.keys[signers[0]]
+	case len(signers) == 0:
+		return nil, errors.New("jwtkeys: no private key to sign with")
+	default:
+		sort.Strings(signers)
+		return nil, fmt.Errorf("jwtkeys: several private keys (%s), choose the signing key", strings.Join(signers, ", "))
+	}
+	return s, nil
+}
+
+// SigningKeyID is the kid of the key new tokens are signed with, empty for
+// HS256.
+func (s *KeySet) SigningKeyID() string {
+	if s.signing == nil {
+		return ""
+	}
+	return s.signing.ID
+}
+
+// Sign signs claims with the signing key, naming it in the kid header.
+func (s *KeySet) Sign(claims map[string]any) (string, error) {
+	if s.signing == nil {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims(claims)).SignedString(s.secret)
+	}
+	t := jwt.NewWithClaims(s.signing.Method, jwt.MapClaims(claims))
+	t.Header["kid"] = s.signing.ID
+	return t.SignedString(s.signing.Private)
+}
+
+// Keyfunc returns the key a token is verified with: the one named by its kid
+// header, provided the token uses that key's algorithm.
+func (s *KeySet) Keyfunc(t *jwt.Token) (any, error) {
+	if s.keys == nil {
+		if t.Method.Alg() != jwt.SigningMethodHS256.Alg() {
+			return nil, jwt.ErrTokenSignatureInvalid
+		}
+		return s.secret, nil
+	}
+
+	kid, _ := t.Header["kid"].(string)
+	key, ok := s.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown key %q", jwt.ErrTokenUnverifiable, kid)
+	}
+	if t.Method.Alg() != key.Method.Alg() {
+		return nil, jwt.ErrTokenSignatureInvalid
+	}
+	return key.Public, nil
+}
+
+// Methods lists the algorithms of the keys, for jwt.WithValidMethods.
+func (s *KeySet) Methods() []string {
+	if s.keys == nil {
+		return []string{jwt.SigningMethodHS256.Alg()}
+	}
+	seen := make(map[string]bool)
+	var methods []string
+	for _, key := range s.keys {
+		if alg := key.Method.Alg(); !seen[alg] {
+			seen[alg] = true
+			methods = append(methods, alg)
+		}
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+// JWK is a public key in JSON Web Key format (RFC 7517).
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// EC
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKS is a JSON Web Key Set.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public keys of the set, sorted by kid.
+func (s *KeySet) JWKS() JWKS {
+	set := JWKS{Keys: make([]JWK, 0, len(s.keys))}
+	for _, key := range s.keys {
+		jwk := JWK{Use: "sig", Alg: key.Method.Alg(), Kid: key.ID}
+		switch pub := key.Public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = encode(pub.N.Bytes())
+			jwk.E = encode(big.NewInt(int64(pub.E)).Bytes())
+		case *ecdsa.PublicKey:
+			point, _ := pub.ECDH() // curve checked on load
+			b := point.Bytes()     // 0x04 || X || Y
+			jwk.Kty = "EC"
+			jwk.Crv = "P-256"
+			jwk.X = encode(b[1:33])
+			jwk.Y = encode(b[33:])
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].Kid < set.Keys[j].Kid })
+	return set
+}
+
+func encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// loadFile reads one PEM key; its kid is the file name without extension.
+func loadFile(path string) (*Key, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("jwtkeys: %w", err)
+	}
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, fmt.Errorf("jwtkeys: %s is not a PEM file", path)
+	}
+
+	var parsed any
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		parsed, err = x509.ParseECPrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		parsed, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("jwtkeys: %s holds an unsupported %q block", path, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("jwtkeys: parse %s: %w", path, err)
+	}
+
+	key := &Key{ID: strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))}
+	if signer, ok := parsed.(crypto.Signer); ok {
+		key.Private = signer
+		key.Public = signer.Public()
+	} else {
+		key.Public = parsed
+	}
+
+	switch pub := key.Public.(type) {
+	case *rsa.PublicKey:
+		if pub.N.BitLen() < minRSABits {
+			return nil, fmt.Errorf("jwtkeys: %s: RSA keys must have at least %d bits", path, minRSABits)
+		}
+		key.Method = jwt.SigningMethodRS256
+	case *ecdsa.PublicKey:
+		if pub.Curve != elliptic.P256() {
+			return nil, fmt.Errorf("jwtkeys: %s: EC keys must use the P-256 curve", path)
+		}
+		key.Method = jwt.SigningMethodES256
+	default:
+		return nil, fmt.Errorf("jwtkeys: %s: only RSA and P-256 EC keys are supported", path)
+	}
+	return key, nil
+}
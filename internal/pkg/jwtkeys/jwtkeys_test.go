@@ -0,0 +1,214 @@
+package jwtkeys
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// writeKey writes key as <kid>.pem: a private key, or only its public half.
+func writeKey(t *testing.T, dir, kid string, key any, publicOnly bool) {
+	t.Helper()
+	var block *pem.Block
+	if publicOnly {
+		der, err := x509.MarshalPKIXPublicKey(key.(crypto.Signer).Public())
+		if err != nil {
+			t.Fatal(err)
+		}
+		block = &pem.Block{Type: "PUBLIC KEY", Bytes: der}
+	} else {
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		block = &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+	}
+	if err := os.WriteFile(filepath.Join(dir, kid+".pem"), pem.EncodeToMemory(block), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func verify(s *KeySet, token string) error {
+	_, err := jwt.Parse(token, s.Keyfunc, jwt.WithValidMethods(s.Methods()))
+	return err
+}
+
+func TestKeySet_Rotation(t *testing.T) {
+	dir := t.TempDir()
+	oldKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	newKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	writeKey(t, dir, "2026-01", oldKey, false)
+	writeKey(t, dir, "2026-07", newKey, false)
+
+	if _, err := Load([]string{dir}, ""); err == nil || !strings.Contains(err.Error(), "choose the signing key") {
+		t.Fatalf("expected an error for several private keys, got %v", err)
+	}
+
+	before, err := Load([]string{dir}, "2026-01")
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	oldToken, err := before.Sign(map[string]any{"sub": "user_1"})
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+
+	after, err := Load([]string{dir}, "2026-07")
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	newToken, _ := after.Sign(map[string]any{"sub": "user_1"})
+
+	parsed, _ := jwt.Parse(newToken, after.Keyfunc)
+	if parsed.Method.Alg() != "ES256" || parsed.Header["kid"] != "2026-07" {
+		t.Fatalf("expected an ES256 token of key 2026-07, got %v", parsed.Header)
+	}
+	// Both keys verify, whichever one signs.
+	for _, token := range []string{oldToken, newToken} {
+		if err := verify(before, token); err != nil {
+			t.Errorf("before rotation: %v", err)
+		}
+		if err := verify(after, token); err != nil {
+			t.Errorf("after rotation: %v", err)
+		}
+	}
+
+	// Once the old key is removed its tokens are rejected.
+	if err := os.Remove(filepath.Join(dir, "2026-01.pem")); err != nil {
+		t.Fatal(err)
+	}
+	removed, err := Load([]string{dir}, "")
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if err := verify(removed, oldToken); err == nil {
+		t.Fatal("expected the token of a removed key to be rejected")
+	}
+	// Even with its algorithm allowed, a token of an unknown key is unverifiable.
+	other, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	unknown := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{"sub": "user_1"})
+	unknown.Header["kid"] = "2025-01"
+	unknownToken, _ := unknown.SignedString(other)
+	if err := verify(removed, unknownToken); !errors.Is(err, jwt.ErrTokenUnverifiable) {
+		t.Fatalf("expected a token of an unknown key to be unverifiable, got %v", err)
+	}
+}
+
+func TestKeySet_PublicKeysOnlyVerify(t *testing.T) {
+	dir := t.TempDir()
+	signer, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	other, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	writeKey(t, dir, "active", signer, false)
+	writeKey(t, dir, "partner", other, true)
+
+	if _, err := Load([]string{dir}, "partner"); err == nil || !strings.Contains(err.Error(), "public key") {
+		t.Fatalf("expected a public key not to sign, got %v", err)
+	}
+	s, err := Load([]string{filepath.Join(dir, "active.pem"), filepath.Join(dir, "partner.pem")}, "")
+	if err != nil {
+		t.Fatalf("load files: %v", err)
+	}
+	if s.SigningKeyID() != "active" {
+		t.Fatalf("expected to sign with the only private key, got %q", s.SigningKeyID())
+	}
+
+	// A token signed with the partner's private key verifies with its public key.
+	t2 := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{"sub": "svc"})
+	t2.Header["kid"] = "partner"
+	token, _ := t2.SignedString(other)
+	if err := verify(s, token); err != nil {
+		t.Fatalf("verify partner token: %v", err)
+	}
+
+	// The kid must match the key that signed.
+	t2.Header["kid"] = "active"
+	forged, _ := t2.SignedString(other)
+	if err := verify(s, forged); err == nil {
+		t.Fatal("expected a token signed with another key to be rejected")
+	}
+}
+
+func TestKeySet_RejectsAlgorithmConfusion(t *testing.T) {
+	dir := t.TempDir()
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	writeKey(t, dir, "main", key, false)
+	s, err := Load([]string{dir}, "")
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	// An HS256 token keyed with the public key must not verify.
+	der, _ := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	hs := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "x"})
+	hs.Header["kid"] = "main"
+	token, _ := hs.SignedString(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	if err := verify(s, token); err == nil {
+		t.Fatal("expected an HS256 token to be rejected")
+	}
+
+	if err := verify(NewHMAC("secret"), token); err == nil {
+		t.Fatal("expected a token with another secret to be rejected")
+	}
+	hmacToken, _ := NewHMAC("secret").Sign(map[string]any{"sub": "x"})
+	if err := verify(NewHMAC("secret"), hmacToken); err != nil {
+		t.Fatalf("verify HS256 token: %v", err)
+	}
+}
+
+func TestKeySet_JWKS(t *testing.T) {
+	dir := t.TempDir()
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	writeKey(t, dir, "a-rsa", rsaKey, false)
+	writeKey(t, dir, "b-ec", ecKey, true)
+
+	s, err := Load([]string{dir}, "")
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	keys := s.JWKS().Keys
+	if len(keys) != 2 {
+		t.Fatalf("expected 2 keys, got %+v", keys)
+	}
+	r, e := keys[0], keys[1]
+	if r.Kid != "a-rsa" || r.Kty != "RSA" || r.Alg != "RS256" || r.Use != "sig" || r.E != "AQAB" || len(r.N) != 342 {
+		t.Errorf("unexpected RSA key: %+v", r)
+	}
+	if e.Kid != "b-ec" || e.Kty != "EC" || e.Alg != "ES256" || e.Crv != "P-256" || len(e.X) != 43 || len(e.Y) != 43 {
+		t.Errorf("unexpected EC key: %+v", e)
+	}
+	if len(NewHMAC("secret").JWKS().Keys) != 0 {
+		t.Error("an HS256 secret must not be published")
+	}
+}
+
+func TestLoad_RejectsWeakKeys(t *testing.T) {
+	dir := t.TempDir()
+	weak, _ := rsa.GenerateKey(rand.Reader, 1024)
+	writeKey(t, dir, "weak", weak, false)
+	if _, err := Load([]string{dir}, ""); err == nil || !strings.Contains(err.Error(), "2048") {
+		t.Fatalf("expected a 1024-bit key to be rejected, got %v", err)
+	}
+
+	p384, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	dir = t.TempDir()
+	writeKey(t, dir, "p384", p384, false)
+	if _, err := Load([]string{dir}, ""); err == nil || !strings.Contains(err.Error(), "P-256") {
+		t.Fatalf("expected a P-384 key to be rejected, got %v", err)
+	}
+
+	if _, err := Load([]string{t.TempDir()}, ""); err == nil {
+		t.Fatal("expected an error for a directory without keys")
+	}
+}
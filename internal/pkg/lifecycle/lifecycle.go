@@ -0,0 +1,35 @@
+// Package lifecycle loads shipment state machine definitions from JSON files.
+//
+// The file format is domain.Lifecycles; configs/lifecycle.json holds the
+// built-in lifecycle and configs/lifecycle.example.json adds an example
+// service-type override.
+package lifecycle
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/99minutos/shipping-system/internal/core/domain"
+)
+
+// Load reads and validates the lifecycle definition at path. Unknown fields
+// are rejected so that typos do not silently drop a restriction.
+func Load(path string) (*domain.Lifecycles, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("lifecycle: %w", err)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	var ls domain.Lifecycles
+	if err := dec.Decode(&ls); err != nil {
+		return nil, fmt.Errorf("lifecycle: parse %s: %w", path, err)
+	}
+	if err := ls.Validate(); err != nil {
+		return nil, fmt.Errorf("lifecycle: invalid %s: %w", path, err)
+	}
+	return &ls, nil
+}
@@ -0,0 +1,126 @@
+package lifecycle
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/99minutos/shipping-system/internal/core/domain"
+)
+
+func writeDefinition(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "lifecycle.json")
+	if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+		t.Fatalf("write definition: %v", err)
+	}
+	return path
+}
+
+func TestLoad_ReferenceDefinition(t *testing.T) {
+	ls, err := Load("../../../configs/lifecycle.json")
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	// The default lifecycle must match the built-in one.
+	builtin := domain.BuiltinLifecycles().Lifecycles[domain.DefaultLifecycle]
+	def := ls.Lifecycles[domain.DefaultLifecycle]
+	if len(def.Transitions) != len(builtin.Transitions) {
+		t.Fatalf("default lifecycle has %d transitions, built-in has %d", len(def.Transitions), len(builtin.Transitions))
+	}
+	for _, tr := range builtin.Transitions {
+		if !def.Allows(tr.From, tr.To) {
+			t.Errorf("default lifecycle misses %s -> %s", tr.From, tr.To)
+		}
+	}
+	if def.MaxDeliveryAttempts != builtin.MaxDeliveryAttempts {
+		t.Errorf("max_delivery_attempts = %d, built-in has %d", def.MaxDeliveryAttempts, builtin.MaxDeliveryAttempts)
+	}
+	// The shipped file changes nothing until overridden.
+	if len(ls.Lifecycles) != 1 || ls.For("same_day", "client_1") != def {
+		t.Errorf("expected only the default lifecycle, got %d lifecycles", len(ls.Lifecycles))
+	}
+}
+
+func TestLoad_ExampleDefinition(t *testing.T) {
+	ls, err := Load("../../../configs/lifecycle.example.json")
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	sameDay := ls.For("same_day", "client_1")
+	if sameDay != ls.Lifecycles["same_day"] {
+		t.Fatal("expected same_day service type to use the same_day lifecycle")
+	}
+	if err := sameDay.Check(domain.StatusOutForDelivery, domain.StatusDelivered, domain.RoleClient, "api"); err == nil {
+		t.Error("expected delivery from a non-driver source to be rejected")
+	}
+}
+
+func TestLoad_RejectsInvalidDefinitions(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{
+			name: "missing default",
+			body: `{"lifecycles": {"other": {"initial": "a", "states": ["a", "b"], "terminal": ["b"], "transitions": [{"from": "a", "to": "b"}]}}}`,
+			want: []string{`lifecycle "default" is required`},
+		},
+		{
+			name: "unknown field",
+			body: `{"lifecycles": {}, "lifecycle": {}}`,
+			want: []string{`unknown field "lifecycle"`},
+		},
+		{
+			name: "unknown lifecycle assignment",
+			body: `{"lifecycles": {"default": {"initial": "a", "states": ["a", "b"], "terminal": ["b"], "transitions": [{"from": "a", "to": "b"}]}},
+			        "service_types": {"express": "fast"}}`,
+			want: []string{`service type "express": unknown lifecycle "fast"`},
+		},
+		{
+			name: "auto return without a return transition",
+			body: `{"lifecycles": {"default": {"initial": "a", "states": ["a", "delivery_attempt_failed", "b"], "terminal": ["b"],
+			        "transitions": [{"from": "a", "to": "delivery_attempt_failed"}, {"from": "delivery_attempt_failed", "to": "b"}],
+			        "max_delivery_attempts": 2}}}`,
+			want: []string{"max_delivery_attempts requires a transition delivery_attempt_failed -> returning_to_sender"},
+		},
+		{
+			name: "malformed graph",
+			body: `{"lifecycles": {"default": {
+				"initial": "a",
+				"states": ["a", "b", "c", "d", "d"],
+				"terminal": ["c", "z"],
+				"transitions": [
+					{"from": "a", "to": "b"}, {"from": "a", "to": "b"}, {"from": "c", "to": "a"}, {"from": "a", "to": "x"}
+				]
+			}}}`,
+			want: []string{
+				`state "d" declared twice`,
+				`terminal state "z" is not declared`,
+				`transition a -> b declared twice`,
+				`transition c -> a leaves terminal state "c"`,
+				`transition a -> x uses an undeclared state`,
+				`state "c" is unreachable from "a"`,
+				`state "b" has no transitions and is not terminal`,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Load(writeDefinition(t, tc.body))
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			for _, want := range tc.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q does not mention %q", err, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+// Package ratecard loads versioned shipment rate cards from a directory of
+// JSON files and keeps them current while the service runs.
+//
+// Each file holds one domain.RateCard; configs/ratecards documents the format.
+// Finance adds a card with a later effective_from to change prices: it is
+// picked up on the next reload and takes effect at that time.
+package ratecard
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/99minutos/shipping-system/internal/core/domain"
+)
+
+// Store holds the rate cards of a directory, oldest effective_from first.
+// It is safe for concurrent use.
+type Store struct {
+	dir   string
+	cards atomic.Pointer[[]*domain.RateCard]
+}
+
+// Load reads and validates every *.json rate card in dir. It fails if the
+// directory holds none.
+func Load(dir string) (*Store, error) {
+	s := &Store{dir: dir}
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Reload reads the directory again. On error the cards already loaded stay
+// in use.
+func (s *Store) Reload() error {
+	paths, err := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	if err != nil {
+		return fmt.Errorf("ratecard: %w", err)
+	}
+	if len(paths) == 0 {
+		return fmt.Errorf("ratecard: no rate cards in %s", s.dir)
+	}
+
+	cards := make([]*domain.RateCard, 0, len(paths))
+	versions := make(map[string]string, len(paths))
+	for _, path := range paths {
+		card, err := loadFile(path)
+		if err != nil {
+			return err
+		}
+		if other, ok := versions[card.Version]; ok {
+			return fmt.Errorf("ratecard: version %q is in both %s and %s", card.Version, other, path)
+		}
+		versions[card.Version] = path
+		cards = append(cards, card)
+	}
+	sort.Slice(cards, func(i, j int) bool { return cards[i].EffectiveFrom.Before(cards[j].EffectiveFrom) })
+	s.cards.Store(&cards)
+	return nil
+}
+
+// Watch reloads the directory every interval until ctx is done, logging
+// reloads that fail. An interval of zero or less disables reloading.
+func (s *Store) Watch(ctx context.Context, interval time.Duration, log zerolog.Logger) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Reload(); err != nil {
+				log.Error().Err(err).Msg("rate card reload failed, keeping current cards")
+			}
+		}
+	}
+}
+
+// RateCard returns the card in effect at the given time.
+func (s *Store) RateCard(at time.Time) (*domain.RateCard, error) {
+	cards := *s.cards.Load()
+	for i := len(cards) - 1; i >= 0; i-- {
+		if !cards[i].EffectiveFrom.After(at) {
+			return cards[i], nil
+		}
+	}
+	return nil, domain.ErrNoRateCard
+}
+
+// loadFile decodes and validates one card. Unknown fields are rejected so
+// that a misspelt rate is not silently priced at zero.
+func loadFile(path string) (*domain.RateCard, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("ratecard: %w", err)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	var card domain.RateCard
+	if err := dec.Decode(&card); err != nil {
+		return nil, fmt.Errorf("ratecard: parse %s: %w", path, err)
+	}
+	if err := card.Validate(); err != nil {
+		return nil, fmt.Errorf("ratecard: invalid %s: %w", path, err)
+	}
+	return &card, nil
+}
@@ -0,0 +1,194 @@
+package ratecard
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/99minutos/shipping-system/internal/core/domain"
+	"github.com/rs/zerolog"
+)
+
+func writeCard(t *testing.T, dir, name, body string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o600); err != nil {
+		t.Fatalf("write card: %v", err)
+	}
+}
+
+// card returns a minimal valid rate card with the given version and
+// effective date.
+func card(version, effectiveFrom string, base int) string {
+	return `{"version": "` + version + `", "effective_from": "` + effectiveFrom + `", "currency": "MXN",
+		"volumetric_divisor": 5000, "services": {"standard": {"base": ` + strconv.Itoa(base) + `, "per_kg": 1, "per_km": 0}},
+		"zones": [{"name": "all", "zip_prefixes": [""], "surcharge": 0}], "insurance": {"rate": 0.01, "minimum": 0}}`
+}
+
+func TestLoad_ReferenceCards(t *testing.T) {
+	store, err := Load("../../../configs/ratecards")
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	c, err := store.RateCard(time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("rate card: %v", err)
+	}
+	for _, service := range []string{"same_day", "next_day", "standard"} {
+		if _, ok := c.Services[service]; !ok {
+			t.Errorf("reference card does not price %s", service)
+		}
+	}
+
+	// Polanco (CDMX) to Puebla centro.
+	price, err := c.Price(domain.PriceRequest{
+		ServiceType:    "next_day",
+		Origin:         domain.Coordinates{Lat: 19.4326, Lng: -99.1332},
+		Destination:    domain.Coordinates{Lat: 19.0414, Lng: -98.2063},
+		DestinationZip: "72000",
+		Parcels:        []domain.Parcel{{WeightKg: 2, Dimensions: domain.Dimensions{LengthCm: 40, WidthCm: 30, HeightCm: 20}}},
+	})
+	if err != nil {
+		t.Fatalf("price: %v", err)
+	}
+	if price.Zone != "major_cities" {
+		t.Errorf("zone = %q, want major_cities", price.Zone)
+	}
+	if price.BillableWeightKg != 4.8 {
+		t.Errorf("billable weight = %v, want the volumetric 4.8", price.BillableWeightKg)
+	}
+	if price.Total <= 0 {
+		t.Errorf("expected a positive total, got %+v", price)
+	}
+}
+
+func TestStore_RateCardInEffect(t *testing.T) {
+	dir := t.TempDir()
+	writeCard(t, dir, "a.json", card("2026-01", "2026-01-01T00:00:00Z", 10))
+	writeCard(t, dir, "b.json", card("2026-07", "2026-07-01T00:00:00Z", 20))
+
+	store, err := Load(dir)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	tests := []struct {
+		at   time.Time
+		want string
+	}{
+		{time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), "2026-01"},
+		{time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC), "2026-07"},
+		{time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC), "2026-07"},
+	}
+	for _, tt := range tests {
+		c, err := store.RateCard(tt.at)
+		if err != nil {
+			t.Fatalf("rate card at %s: %v", tt.at, err)
+		}
+		if c.Version != tt.want {
+			t.Errorf("at %s got %s, want %s", tt.at, c.Version, tt.want)
+		}
+	}
+
+	if _, err := store.RateCard(time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC)); !errors.Is(err, domain.ErrNoRateCard) {
+		t.Errorf("expected ErrNoRateCard before the first card, got %v", err)
+	}
+}
+
+func TestStore_ReloadKeepsCardsOnError(t *testing.T) {
+	dir := t.TempDir()
+	writeCard(t, dir, "a.json", card("2026-01", "2026-01-01T00:00:00Z", 10))
+	store, err := Load(dir)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	writeCard(t, dir, "b.json", `{"version": "2026-07", "currency": "MXN"}`)
+	if err := store.Reload(); err == nil {
+		t.Fatal("expected reload of an invalid card to fail")
+	}
+	if c, err := store.RateCard(time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)); err != nil || c.Version != "2026-01" {
+		t.Fatalf("expected the loaded card to stay in use, got %v, %v", c, err)
+	}
+
+	writeCard(t, dir, "b.json", card("2026-07", "2026-07-01T00:00:00Z", 20))
+	if err := store.Reload(); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if c, _ := store.RateCard(time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)); c.Version != "2026-07" {
+		t.Errorf("expected the new card after reload, got %s", c.Version)
+	}
+}
+
+func TestStore_WatchWithoutIntervalNeverReloads(t *testing.T) {
+	dir := t.TempDir()
+	writeCard(t, dir, "a.json", card("2026-01", "2026-01-01T00:00:00Z", 10))
+	store, err := Load(dir)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	for _, interval := range []time.Duration{0, -time.Second} {
+		// Must return at once rather than panic or block on ctx.
+		store.Watch(context.Background(), interval, zerolog.Nop())
+	}
+}
+
+func TestLoad_RejectsInvalidCards(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{
+			name:  "empty directory",
+			files: nil,
+			want:  "no rate cards",
+		},
+		{
+			name:  "unknown field",
+			files: map[string]string{"a.json": strings.Replace(card("v1", "2026-01-01T00:00:00Z", 10), `"per_kg"`, `"per_kilo"`, 1)},
+			want:  "unknown field",
+		},
+		{
+			name:  "missing rates",
+			files: map[string]string{"a.json": `{"version": "v1", "effective_from": "2026-01-01T00:00:00Z", "currency": "MXN"}`},
+			want:  "volumetric_divisor must be positive",
+		},
+		{
+			name: "prefix in two zones",
+			files: map[string]string{"a.json": strings.Replace(card("v1", "2026-01-01T00:00:00Z", 10),
+				`[{"name": "all", "zip_prefixes": [""], "surcharge": 0}]`,
+				`[{"name": "a", "zip_prefixes": ["06"], "surcharge": 0}, {"name": "b", "zip_prefixes": ["06"], "surcharge": 0}]`, 1)},
+			want: `zip prefix "06" is in zones "a" and "b"`,
+		},
+		{
+			name: "duplicate version",
+			files: map[string]string{
+				"a.json": card("v1", "2026-01-01T00:00:00Z", 10),
+				"b.json": card("v1", "2026-07-01T00:00:00Z", 20),
+			},
+			want: `version "v1" is in both`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, body := range tt.files {
+				writeCard(t, dir, name, body)
+			}
+			_, err := Load(dir)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error %q does not mention %q", err, tt.want)
+			}
+		})
+	}
+}
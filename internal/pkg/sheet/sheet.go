@@ -0,0 +1,237 @@
+// Package sheet reads the first sheet of a CSV or XLSX file into rows of
+// cells, so uploads can be handled the same whatever spreadsheet they come
+// from.
+//
+// XLSX files are read with the standard library only: the workbook is a zip
+// of XML parts, and only the cell values of the first worksheet are used.
+// Formulas yield their cached value; dates yield the serial number Excel
+// stores them as.
+package sheet
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// ErrUnsupported is returned for files that are neither CSV nor XLSX.
+var ErrUnsupported = errors.New("sheet: unsupported file type, expected .csv or .xlsx")
+
+// Read returns the rows of the CSV or XLSX file name holding data. XLSX is
+// recognised by its zip signature, CSV by the .csv extension or plain text.
+// Trailing empty cells are dropped from every row.
+func Read(name string, data []byte) ([][]string, error) {
+	switch ext := strings.ToLower(path.Ext(name)); {
+	case bytes.HasPrefix(data, []byte("PK\x03\x04")):
+		return readXLSX(data)
+	case ext == ".csv" || ext == ".txt" || ext == "":
+		return readCSV(data)
+	default:
+		return nil, ErrUnsupported
+	}
+}
+
+// readCSV reads comma or semicolon separated values, the latter being what
+// spreadsheets export in locales that use the comma as decimal separator.
+func readCSV(data []byte) ([][]string, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")) // UTF-8 BOM
+	header, _, _ := bytes.Cut(data, []byte("\n"))
+
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	if bytes.Count(header, []byte(";")) > bytes.Count(header, []byte(",")) {
+		r.Comma = ';'
+	}
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("sheet: %w", err)
+	}
+	for i := range rows {
+		rows[i] = trimRow(rows[i])
+	}
+	return rows, nil
+}
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		ID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+// xlsxText is a string item or an inline string: plain text, or runs of
+// formatted text.
+type xlsxText struct {
+	T string `xml:"t"`
+	R []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxText) String() string {
+	if len(t.R) == 0 {
+		return t.T
+	}
+	var b strings.Builder
+	for _, r := range t.R {
+		b.WriteString(r.T)
+	}
+	return b.String()
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxText `xml:"si"`
+}
+
+type xlsxWorksheet struct {
+	Rows []struct {
+		R     int `xml:"r,attr"`
+		Cells []struct {
+			Ref    string   `xml:"r,attr"`
+			Type   string   `xml:"t,attr"`
+			Value  string   `xml:"v"`
+			Inline xlsxText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+func readXLSX(data []byte) ([][]string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("sheet: %w", err)
+	}
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	sheetPath, err := firstSheet(files)
+	if err != nil {
+		return nil, err
+	}
+	var shared xlsxSharedStrings
+	if f, ok := files["xl/sharedStrings.xml"]; ok {
+		if err := decodeXML(f, &shared); err != nil {
+			return nil, err
+		}
+	}
+	var ws xlsxWorksheet
+	if err := decodeXML(files[sheetPath], &ws); err != nil {
+		return nil, err
+	}
+
+	var rows [][]string
+	for _, row := range ws.Rows {
+		cells := make([]string, 0, len(row.Cells))
+		for i, c := range row.Cells {
+			col := i
+			if c.Ref != "" {
+				if col, err = columnIndex(c.Ref); err != nil {
+					return nil, err
+				}
+			}
+			for len(cells) <= col {
+				cells = append(cells, "")
+			}
+			switch c.Type {
+			case "s":
+				n, err := strconv.Atoi(c.Value)
+				if err != nil || n < 0 || n >= len(shared.Items) {
+					return nil, fmt.Errorf("sheet: cell %s refers to missing shared string %q", c.Ref, c.Value)
+				}
+				cells[col] = shared.Items[n].String()
+			case "inlineStr":
+				cells[col] = c.Inline.String()
+			default:
+				cells[col] = c.Value
+			}
+		}
+		// Rows may be sparse; keep row numbers aligned with the sheet.
+		for row.R > 0 && len(rows) < row.R-1 {
+			rows = append(rows, nil)
+		}
+		rows = append(rows, trimRow(cells))
+	}
+	return rows, nil
+}
+
+// firstSheet returns the path in the archive of the workbook's first
+// worksheet.
+func firstSheet(files map[string]*zip.File) (string, error) {
+	var wb xlsxWorkbook
+	if err := decodeXML(files["xl/workbook.xml"], &wb); err != nil {
+		return "", err
+	}
+	if len(wb.Sheets) == 0 {
+		return "", errors.New("sheet: workbook has no sheets")
+	}
+	var rels xlsxRelationships
+	if err := decodeXML(files["xl/_rels/workbook.xml.rels"], &rels); err != nil {
+		return "", err
+	}
+	for _, rel := range rels.Relationships {
+		if rel.ID != wb.Sheets[0].ID {
+			continue
+		}
+		if strings.HasPrefix(rel.Target, "/") {
+			return strings.TrimPrefix(rel.Target, "/"), nil
+		}
+		return path.Join("xl", rel.Target), nil
+	}
+	return "", fmt.Errorf("sheet: first sheet %q not found in workbook relationships", wb.Sheets[0].ID)
+}
+
+func decodeXML(f *zip.File, v any) error {
+	if f == nil {
+		return errors.New("sheet: not an xlsx workbook")
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("sheet: %s: %w", f.Name, err)
+	}
+	defer rc.Close()
+	if err := xml.NewDecoder(io.LimitReader(rc, 64<<20)).Decode(v); err != nil {
+		return fmt.Errorf("sheet: %s: %w", f.Name, err)
+	}
+	return nil
+}
+
+// columnIndex returns the zero-based column of a cell reference such as
+// "AB12".
+func columnIndex(ref string) (int, error) {
+	col := 0
+	for i := 0; i < len(ref); i++ {
+		c := ref[i]
+		if c < 'A' || c > 'Z' {
+			if i == 0 {
+				break
+			}
+			return col - 1, nil
+		}
+		col = col*26 + int(c-'A'+1)
+	}
+	return 0, fmt.Errorf("sheet: invalid cell reference %q", ref)
+}
+
+func trimRow(cells []string) []string {
+	for i := range cells {
+		cells[i] = strings.TrimSpace(cells[i])
+	}
+	for len(cells) > 0 && cells[len(cells)-1] == "" {
+		cells = cells[:len(cells)-1]
+	}
+	return cells
+}
@@ -0,0 +1,89 @@
+package sheet
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestRead_CSV(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want [][]string
+	}{
+		{"comma", "a,b,c\n1, 2 ,\n", [][]string{{"a", "b", "c"}, {"1", "2"}}},
+		{"semicolon with BOM", "\xef\xbb\xbfa;b\n\"x;y\";2,5\n", [][]string{{"a", "b"}, {"x;y", "2,5"}}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Read("orders.csv", []byte(tc.data))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestRead_XLSX(t *testing.T) {
+	data := buildXLSX(t, map[string]string{
+		"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"
+			xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+			<sheets><sheet name="Pedidos" sheetId="1" r:id="rId3"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+			<Relationship Id="rId1" Target="styles.xml"/>
+			<Relationship Id="rId3" Target="worksheets/orders.xml"/></Relationships>`,
+		"xl/sharedStrings.xml": `<sst><si><t>service_type</t></si><si><t>weight_kg</t></si>
+			<si><r><t>next</t></r><r><t>_day</t></r></si></sst>`,
+		"xl/worksheets/orders.xml": `<worksheet><sheetData>
+			<row r="1"><c r="A1" t="s"><v>0</v></c><c r="C1" t="s"><v>1</v></c></row>
+			<row r="3"><c r="A3" t="s"><v>2</v></c><c r="B3" t="inlineStr"><is><t>Pedro</t></is></c><c r="C3"><v>2.5</v></c></row>
+			</sheetData></worksheet>`,
+	})
+
+	got, err := Read("orders.xlsx", data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := [][]string{
+		{"service_type", "", "weight_kg"},
+		nil,
+		{"next_day", "Pedro", "2.5"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestRead_Unsupported(t *testing.T) {
+	if _, err := Read("orders.pdf", []byte("%PDF-1.7")); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("expected ErrUnsupported, got %v", err)
+	}
+	if _, err := Read("orders.xlsx", buildXLSX(t, map[string]string{"word/document.xml": "<document/>"})); err == nil {
+		t.Fatal("expected an error for a zip that is not a workbook")
+	}
+}
+
+func buildXLSX(t *testing.T, parts map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range parts {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
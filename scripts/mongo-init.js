@@ -1,5 +1,5 @@
 // mongo-init.js — runs once when the container is first created.
-// Seeds the auth_users collection with two default users.
+// Seeds the auth_users collection with three default users.
 // Passwords are bcrypt hashes of "password123" (cost 12).
 
 db = db.getSiblingDB("shipping_system");
@@ -39,6 +39,15 @@ db.auth_users.insertMany([
     created_at:    NOW,
     updated_at:    NOW,
   },
+  {
+    username:      "carrier_user_001",
+    email:         "carrier001@99minutos.com",
+    password_hash: PASSWORD_HASH,
+    role:          "carrier",
+    client_id:     "carrier_001",
+    created_at:    NOW,
+    updated_at:    NOW,
+  },
 ]);
 
 print("✅  mongo-init: indexes and seed users created");
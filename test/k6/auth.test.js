@@ -2,7 +2,8 @@
  * K6 integration tests — Auth endpoints
  *
  * Covers:
- *   POST /auth/register  — happy path, duplicate, missing fields, bad content-type
+ *   POST /auth/register  — invitation, reused/missing invitation, duplicate, missing fields, bad content-type
+ *   Admin user management — create admin, disable/enable, delete
  *   POST /auth/login     — happy path, wrong password, unknown email, empty body
  *   Protected routes     — valid token, no token, invalid token, malformed header
  *
@@ -11,51 +12,71 @@
 import http from 'k6/http';
 import { check, group } from 'k6';
 import { BASE_URL, options as baseOptions } from './config.js';
-import { register, login } from './helpers/auth.js';
-import { get, parse } from './helpers/http.js';
+import { register, login, adminToken, invite, createUser } from './helpers/auth.js';
+import { get, post, parse } from './helpers/http.js';
 
 export const options = baseOptions;
 
 export function setup() {
   const ts = Date.now();
+  const admin = adminToken();
   // Pre-create a user that will be used for duplicate/login tests
   const email = `auth_test_${ts}@test.com`;
-  const res = register(`auth_user_${ts}`, 'Password123!', email, 'client', `c_${ts}`);
+  const res = createUser(admin, `auth_user_${ts}`, 'Password123!', email, 'client', `c_${ts}`);
   if (res.status !== 201) {
     console.error('setup: failed to pre-create user', res.body);
   }
-  return { email, password: 'Password123!', username: `auth_user_${ts}`, ts };
+  return { email, password: 'Password123!', username: `auth_user_${ts}`, ts, admin };
 }
 
 export default function (data) {
-  const { email, password, username, ts } = data;
+  const { email, password, username, ts, admin } = data;
 
   // ─────────────────────────────────────────────────────────── Register ─────
 
-  group('POST /auth/register — happy path', () => {
-    const ts2 = `${ts}_2`;
-    const res = register(`new_${ts2}`, 'Password123!', `new_${ts2}@test.com`, 'client', `c_${ts2}`);
+  const ts2 = `${ts}_2`;
+  const invRes = invite(admin, `new_${ts2}@test.com`, 'client', `c_${ts2}`);
+  const invitation = parse(invRes)?.token;
+
+  group('POST /v1/admin/invitations — happy path', () => {
+    check(invRes, {
+      'status 201':      r => r.status === 201,
+      'token present':   r => !!invitation,
+      'expires_at set':  r => !!parse(r)?.expires_at,
+    });
+  });
+
+  group('POST /auth/register — with invitation', () => {
+    const res = register(`new_${ts2}`, 'Password123!', `new_${ts2}@test.com`, invitation);
     check(res, {
       'status 201':           r => r.status === 201,
       'message present':      r => !!parse(r)?.message,
       'user object present':  r => !!parse(r)?.user,
       'username correct':     r => parse(r)?.user?.username === `new_${ts2}`,
       'role is client':       r => parse(r)?.user?.role === 'client',
-      'client_id present':    r => !!parse(r)?.user?.client_id,
+      'client_id from invitation': r => parse(r)?.user?.client_id === `c_${ts2}`,
     });
   });
 
-  group('POST /auth/register — admin role', () => {
-    const ts3 = `${ts}_adm`;
-    const res = register(`adm_${ts3}`, 'Password123!', `adm_${ts3}@test.com`, 'admin', '');
-    check(res, {
-      'status 201':     r => r.status === 201,
-      'role is admin':  r => parse(r)?.user?.role === 'admin',
-    });
+  group('POST /auth/register — invitation reused → 403', () => {
+    const res = register(`again_${ts2}`, 'Password123!', `new_${ts2}@test.com`, invitation);
+    check(res, { 'status 403': r => r.status === 403 });
+  });
+
+  group('POST /auth/register — no invitation → 403', () => {
+    const res = register(`adm_${ts}`, 'Password123!', `adm_${ts}@test.com`);
+    check(res, { 'status 403': r => r.status === 403 });
+  });
+
+  group('POST /v1/admin/invitations — admin role → 422', () => {
+    const res = invite(admin, `adm_${ts}@test.com`, 'admin');
+    check(res, { 'status 422': r => r.status === 422 });
   });
 
   group('POST /auth/register — duplicate username → 409', () => {
-    const res = register(username, password, email, 'client', '');
+    const dupEmail = `dup_${ts}@test.com`;
+    const inv = parse(invite(admin, dupEmail, 'client', `c_${ts}`))?.token;
+    const res = register(username, password, dupEmail, inv);
     check(res, {
       'status 409': r => r.status === 409,
       'error field': r => !!parse(r)?.error,
@@ -113,6 +134,40 @@ export default function (data) {
     check(res, { 'status 4xx': r => r.status >= 400 });
   });
 
+  // ──────────────────────────────────────────────── Admin user management ──
+
+  group('POST /v1/admin/users — create admin', () => {
+    const res = createUser(admin, `adm_${ts}`, 'Password123!', `adm_${ts}@test.com`, 'admin');
+    check(res, {
+      'status 201':     r => r.status === 201,
+      'role is admin':  r => parse(r)?.role === 'admin',
+    });
+  });
+
+  group('POST /v1/admin/users/:id/disable — login refused', () => {
+    const created = parse(createUser(admin, `dis_${ts}`, 'Password123!', `dis_${ts}@test.com`, 'client', `c_${ts}`));
+    const disabled = post(`${BASE_URL}/v1/admin/users/${created?.id}/disable`, {}, admin);
+    check(disabled, {
+      'status 200':        r => r.status === 200,
+      'disabled is true':  r => parse(r)?.disabled === true,
+    });
+    check(login(`dis_${ts}@test.com`, 'Password123!'), { 'login 403': r => r.status === 403 });
+
+    post(`${BASE_URL}/v1/admin/users/${created?.id}/enable`, {}, admin);
+    check(login(`dis_${ts}@test.com`, 'Password123!'), { 'login 200 once enabled': r => r.status === 200 });
+
+    const del = http.del(`${BASE_URL}/v1/admin/users/${created?.id}`, null, {
+      headers: { Authorization: `Bearer ${admin}` },
+    });
+    check(del, { 'delete 204': r => r.status === 204 });
+  });
+
+  group('GET /v1/admin/users — client token → 403', () => {
+    const clientToken = parse(login(email, password))?.token;
+    const res = get(`${BASE_URL}/v1/admin/users`, clientToken);
+    check(res, { 'status 403': r => r.status === 403 });
+  });
+
   // ─────────────────────────────────────────── Protected route auth checks ──
 
   const loginRes = login(email, password);
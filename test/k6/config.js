@@ -13,3 +13,7 @@ export const BASE_URL = __ENV.BASE_URL || 'http://localhost:8080';
 
 /** Seconds to wait after sending an event before polling for the new status. */
 export const EVENT_SETTLE_MS = 1.5;
+
+/** Seeded admin (scripts/mongo-init.js) that creates and invites the test users. */
+export const ADMIN_EMAIL = __ENV.ADMIN_EMAIL || 'admin@99minutos.com';
+export const ADMIN_PASSWORD = __ENV.ADMIN_PASSWORD || 'password123';
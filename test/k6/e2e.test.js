@@ -13,7 +13,7 @@
  */
 import { check, group, sleep } from 'k6';
 import { options as baseOptions, EVENT_SETTLE_MS } from './config.js';
-import { setupUser, setupAdmin } from './helpers/auth.js';
+import { setupUser, setupAdmin, setupCarrier } from './helpers/auth.js';
 import { createShipment, getShipment, listShipments } from './helpers/shipment.js';
 import { sendEvent, buildEvent } from './helpers/events.js';
 import { waitForStatus } from './helpers/poll.js';
@@ -32,11 +32,12 @@ export function setup() {
   const ts = Date.now();
   const client = setupUser(`e2e_c_${ts}`);
   const admin  = setupAdmin(`e2e_adm_${ts}`);
-  return { client, admin };
+  const carrier = setupCarrier(`e2e_k_${ts}`);
+  return { client, admin, carrier };
 }
 
 export default function (data) {
-  const { client, admin } = data;
+  const { client, admin, carrier } = data;
 
   // ── Step 1: Create shipment ──────────────────────────────────────────────
 
@@ -88,7 +89,7 @@ export default function (data) {
 
   for (const { status, expectedHistory } of transitions) {
     group(`Step 3: Transition → ${status}`, () => {
-      const res = sendEvent(client.token, buildEvent(trackingNumber, status, {
+      const res = sendEvent(carrier.token, buildEvent(trackingNumber, status, {
         source: 'driver_app',
         location: { lat: 19.4326, lng: -99.1332 },
       }));
@@ -108,7 +109,7 @@ export default function (data) {
   // ── Step 4: Post-delivery — no further transitions allowed ───────────────
 
   group('Step 4: Post-delivery transition is a no-op (invalid transition)', () => {
-    const res = sendEvent(client.token, buildEvent(trackingNumber, 'cancelled'));
+    const res = sendEvent(carrier.token, buildEvent(trackingNumber, 'cancelled'));
     check(res, {
       '202 accepted (async rejection)': r => r.status === 202,
     });
@@ -2,7 +2,7 @@
  * K6 integration tests — Event endpoints
  *
  * Covers:
- *   POST /v1/events         — happy path, invalid status, missing fields, auth
+ *   POST /v1/events         — happy path, invalid status, missing fields, auth, scopes
  *   POST /v1/events/batch   — happy path, empty batch, partial validation
  *   Idempotency             — duplicate event is silently ignored
  *   State machine           — invalid transition is accepted (202) but not applied
@@ -11,7 +11,7 @@
  */
 import { check, group, sleep } from 'k6';
 import { options as baseOptions, EVENT_SETTLE_MS } from './config.js';
-import { setupUser } from './helpers/auth.js';
+import { setupUser, setupCarrier } from './helpers/auth.js';
 import { setupShipment, getShipment } from './helpers/shipment.js';
 import { sendEvent, sendBatch, buildEvent } from './helpers/events.js';
 import { waitForStatus } from './helpers/poll.js';
@@ -22,17 +22,26 @@ export const options = baseOptions;
 export function setup() {
   const ts = Date.now();
   const client = setupUser(`evt_c_${ts}`);
+  const carrier = setupCarrier(`evt_k_${ts}`);
   // Each test group that transitions status needs its own fresh shipment
   const trackingHappy    = setupShipment(client.token);
   const trackingDedup    = setupShipment(client.token);
   const trackingBatch    = setupShipment(client.token);
   const trackingInvalid  = setupShipment(client.token); // for invalid-transition test
-  return { client, trackingHappy, trackingDedup, trackingBatch, trackingInvalid };
+  return { client, carrier, trackingHappy, trackingDedup, trackingBatch, trackingInvalid };
 }
 
 export default function (data) {
-  const { client, trackingHappy, trackingDedup, trackingBatch, trackingInvalid } = data;
-  const token = client.token;
+  const { client, carrier, trackingHappy, trackingDedup, trackingBatch, trackingInvalid } = data;
+  // Events are posted by carriers; carriers can read any shipment.
+  const token = carrier.token;
+
+  group('POST /v1/events — client token → 403 (missing events:write)', () => {
+    const res = sendEvent(client.token, buildEvent(trackingHappy, 'picked_up'));
+    check(res, {
+      'status is 403': (r) => r.status === 403,
+    });
+  });
 
   // ───────────────────────────── POST /v1/events — single event ────────────
 
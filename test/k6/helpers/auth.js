@@ -1,14 +1,13 @@
 import { post, parse } from './http.js';
-import { BASE_URL } from '../config.js';
+import { BASE_URL, ADMIN_EMAIL, ADMIN_PASSWORD } from '../config.js';
 import { fail } from 'k6';
 
-export function register(username, password, email, role = 'client', clientId = '') {
+export function register(username, password, email, invitationToken = '') {
   return post(`${BASE_URL}/auth/register`, {
     username,
     password,
     email,
-    role,
-    client_id: clientId,
+    invitation_token: invitationToken,
   });
 }
 
@@ -17,48 +16,83 @@ export function login(email, password) {
 }
 
 /**
- * Register a new user and login; returns { token, user }.
- * Calls fail() if either step fails — stops the test immediately.
+ * Login as the seeded admin; returns its access token.
+ * Registration is by invitation only, so test users are created through it.
+ */
+export function adminToken() {
+  const res = login(ADMIN_EMAIL, ADMIN_PASSWORD);
+  if (res.status !== 200) {
+    fail(`setup: seeded admin login failed (${res.status}): ${res.body}`);
+  }
+  return parse(res).token;
+}
+
+export function invite(token, email, role = 'client', clientId = '') {
+  return post(`${BASE_URL}/v1/admin/invitations`, { email, role, client_id: clientId }, token);
+}
+
+export function createUser(token, username, password, email, role, clientId = '') {
+  return post(`${BASE_URL}/v1/admin/users`, { username, password, email, role, client_id: clientId }, token);
+}
+
+/**
+ * Invite a new client user, register it with the invitation and login;
+ * returns { token, user }.
+ * Calls fail() if any step fails — stops the test immediately.
  */
 export function setupUser(suffix) {
   const username = `u_${suffix}`;
   const email = `u_${suffix}@test.com`;
   const password = 'Password123!';
-  const role = 'client';
   const clientId = `client_${suffix}`;
 
-  const regRes = register(username, password, email, role, clientId);
+  const invRes = invite(adminToken(), email, 'client', clientId);
+  if (invRes.status !== 201) {
+    fail(`setup: invite failed (${invRes.status}): ${invRes.body}`);
+  }
+  const regRes = register(username, password, email, parse(invRes).token);
   if (regRes.status !== 201 && regRes.status !== 409) {
     fail(`setup: register failed (${regRes.status}): ${regRes.body}`);
   }
 
-  const loginRes = login(email, password);
-  if (loginRes.status !== 200) {
-    fail(`setup: login failed (${loginRes.status}): ${loginRes.body}`);
-  }
-
-  const body = parse(loginRes);
-  return { token: body.token, user: body.user, email, password, username, clientId };
+  return loginAs(username, email, password, clientId);
 }
 
 /**
- * Register an admin user and login; returns { token, user }.
+ * Create an admin user and login; returns { token, user }.
  */
 export function setupAdmin(suffix) {
-  const username = `admin_${suffix}`;
-  const email = `admin_${suffix}@test.com`;
+  return setupCreated(`admin_${suffix}`, 'admin', '');
+}
+
+/**
+ * Create a carrier user and login; returns { token, user }.
+ * Carriers post tracking events; clients cannot.
+ */
+export function setupCarrier(suffix) {
+  return setupCreated(`carrier_${suffix}`, 'carrier', `carrier_${suffix}`);
+}
+
+function setupCreated(username, role, clientId) {
+  const email = `${username}@test.com`;
   const password = 'Password123!';
 
-  const regRes = register(username, password, email, 'admin', '');
-  if (regRes.status !== 201 && regRes.status !== 409) {
-    fail(`setup: admin register failed (${regRes.status}): ${regRes.body}`);
+  const res = createUser(adminToken(), username, password, email, role, clientId);
+  if (res.status !== 201 && res.status !== 409) {
+    fail(`setup: ${role} create failed (${res.status}): ${res.body}`);
   }
 
+  return loginAs(username, email, password, clientId);
+}
+
+function loginAs(username, email, password, clientId) {
   const loginRes = login(email, password);
   if (loginRes.status !== 200) {
-    fail(`setup: admin login failed (${loginRes.status}): ${loginRes.body}`);
+    fail(`setup: login failed (${loginRes.status}): ${loginRes.body}`);
   }
 
   const body = parse(loginRes);
-  return { token: body.token, user: body.user, email, password, username };
+  const result = { token: body.token, user: body.user, email, password, username };
+  if (clientId) result.clientId = clientId;
+  return result;
 }